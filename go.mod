@@ -3,6 +3,7 @@ module github.com/gameap/gameapctl
 go 1.25.8
 
 require (
+	aead.dev/minisign v0.2.0
 	github.com/d-tux/go-fstab v0.0.0-20141204152952-eb4090f26517
 	github.com/go-sql-driver/mysql v1.10.0
 	github.com/goccy/go-yaml v1.19.2
@@ -29,7 +30,6 @@ require (
 )
 
 require (
-	aead.dev/minisign v0.2.0 // indirect
 	cel.dev/expr v0.25.1 // indirect
 	cloud.google.com/go v0.123.0 // indirect
 	cloud.google.com/go/auth v0.18.2 // indirect
@@ -0,0 +1,221 @@
+// Package autoupdate implements the 'autoupdate' commands scheduling the
+// upgrades of the daemon, the panel and gameapctl itself.
+package autoupdate
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gameap/gameapctl/internal/pkg/autoupdate"
+	"github.com/gameap/gameapctl/internal/pkg/gameapctl"
+	"github.com/gameap/gameapctl/internal/pkg/output"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	componentGameapctl = "gameapctl"
+	componentDaemon    = "daemon"
+	componentPanel     = "panel"
+)
+
+// components lists the components in the order they are updated: gameapctl
+// first, so the daemon and the panel are upgraded by the new version.
+var components = []string{componentGameapctl, componentDaemon, componentPanel}
+
+// statusRuns is the number of log entries 'autoupdate status' prints.
+const statusRuns = 20
+
+type Result struct {
+	Settings gameapctl.AutoUpdateSettings `json:"settings"`
+	Runs     []gameapctl.AutoUpdateRun    `json:"runs,omitempty"`
+}
+
+func (r Result) WriteText(w io.Writer) error {
+	var b strings.Builder
+
+	if r.Settings.Enabled {
+		fmt.Fprintf(&b, "Automatic updates are enabled (%s)\n", r.Settings.Scheduler)
+	} else {
+		b.WriteString("Automatic updates are disabled\n")
+	}
+
+	if len(r.Settings.Components) > 0 {
+		fmt.Fprintf(&b, "Components: %s\n", strings.Join(r.Settings.Components, ", "))
+		fmt.Fprintf(&b, "Window:     %s\n", r.Settings.Window)
+		fmt.Fprintf(&b, "Policy:     %s\n", r.Settings.Policy)
+	}
+
+	if len(r.Runs) > 0 {
+		b.WriteString("\nRecent runs:\n")
+	}
+
+	for _, run := range r.Runs {
+		fmt.Fprintf(&b, "  %s  %-10s %-10s", run.StartedAt.Local().Format(time.DateTime), run.Component, run.Result)
+		if run.To != "" && run.To != run.From {
+			fmt.Fprintf(&b, " %s -> %s", run.From, run.To)
+		} else if run.From != "" {
+			fmt.Fprintf(&b, " %s", run.From)
+		}
+		if run.Message != "" {
+			fmt.Fprintf(&b, " %s", run.Message)
+		}
+		b.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return errors.Wrap(err, "failed to write result")
+}
+
+// Enable schedules the update job and saves the settings it follows.
+func Enable(cliCtx *cli.Context) error {
+	ctx := cliCtx.Context
+
+	selected, err := parseComponents(cliCtx.StringSlice("components"))
+	if err != nil {
+		return err
+	}
+
+	window, err := autoupdate.ParseWindow(cliCtx.String("window"))
+	if err != nil {
+		return err
+	}
+
+	policy, err := autoupdate.ParsePolicy(cliCtx.String("policy"))
+	if err != nil {
+		return err
+	}
+
+	executable, err := executablePath()
+	if err != nil {
+		return err
+	}
+
+	settings, err := gameapctl.LoadAutoUpdateSettings()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return errors.WithMessage(err, "failed to load autoupdate settings")
+	}
+
+	// The job of another scheduler would run the updates twice.
+	if settings.Scheduler != "" {
+		if err := autoupdate.Remove(ctx, autoupdate.Scheduler(settings.Scheduler)); err != nil {
+			return errors.WithMessage(err, "failed to remove the scheduled job")
+		}
+	}
+
+	scheduler, err := autoupdate.Install(ctx, executable)
+	if err != nil {
+		return errors.WithMessage(err, "failed to schedule the updates")
+	}
+
+	settings = gameapctl.AutoUpdateSettings{
+		Enabled:    true,
+		Components: selected,
+		Window:     window.String(),
+		Policy:     string(policy),
+		Scheduler:  string(scheduler),
+		UpdatedAt:  time.Now(),
+	}
+
+	if err := gameapctl.SaveAutoUpdateSettings(ctx, settings); err != nil {
+		return errors.WithMessage(err, "failed to save autoupdate settings")
+	}
+
+	return output.Print(cliCtx, Result{Settings: settings})
+}
+
+// Disable removes the scheduled job, the settings and the log are kept.
+func Disable(cliCtx *cli.Context) error {
+	ctx := cliCtx.Context
+
+	settings, err := gameapctl.LoadAutoUpdateSettings()
+	if errors.Is(err, fs.ErrNotExist) {
+		return errors.New("automatic updates are not enabled")
+	}
+	if err != nil {
+		return errors.WithMessage(err, "failed to load autoupdate settings")
+	}
+
+	if settings.Scheduler != "" {
+		if err := autoupdate.Remove(ctx, autoupdate.Scheduler(settings.Scheduler)); err != nil {
+			return errors.WithMessage(err, "failed to remove the scheduled job")
+		}
+	}
+
+	settings.Enabled = false
+	settings.Scheduler = ""
+	settings.UpdatedAt = time.Now()
+
+	if err := gameapctl.SaveAutoUpdateSettings(ctx, settings); err != nil {
+		return errors.WithMessage(err, "failed to save autoupdate settings")
+	}
+
+	return output.Print(cliCtx, Result{Settings: settings})
+}
+
+// Status prints the settings and the recent runs, the latest last.
+func Status(cliCtx *cli.Context) error {
+	settings, err := gameapctl.LoadAutoUpdateSettings()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return errors.WithMessage(err, "failed to load autoupdate settings")
+	}
+
+	runs, err := gameapctl.LoadAutoUpdateRuns()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return errors.WithMessage(err, "failed to load autoupdate log")
+	}
+
+	if len(runs) > statusRuns {
+		runs = runs[len(runs)-statusRuns:]
+	}
+
+	return output.Print(cliCtx, Result{Settings: settings, Runs: runs})
+}
+
+func parseComponents(values []string) ([]string, error) {
+	var selected []string
+
+	for _, value := range values {
+		for _, c := range strings.Split(value, ",") {
+			c = strings.ToLower(strings.TrimSpace(c))
+			if c == "" {
+				continue
+			}
+
+			if !slices.Contains(components, c) {
+				return nil, errors.Errorf("unknown component %q, expected panel, daemon or gameapctl", c)
+			}
+
+			if !slices.Contains(selected, c) {
+				selected = append(selected, c)
+			}
+		}
+	}
+
+	if len(selected) == 0 {
+		return nil, errors.New("no components to update")
+	}
+
+	return selected, nil
+}
+
+func executablePath() (string, error) {
+	ex, err := os.Executable()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get executable path")
+	}
+
+	ex, err = filepath.EvalSymlinks(ex)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to resolve executable path")
+	}
+
+	return ex, nil
+}
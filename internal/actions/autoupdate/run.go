@@ -0,0 +1,264 @@
+package autoupdate
+
+import (
+	"context"
+	"io/fs"
+	"log"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gameap/gameapctl/internal/pkg/autoupdate"
+	"github.com/gameap/gameapctl/internal/pkg/gameapctl"
+	"github.com/gameap/gameapctl/pkg/gameap"
+	"github.com/gameap/gameapctl/pkg/oscore"
+	"github.com/gameap/gameapctl/pkg/releasefinder"
+	"github.com/gameap/gameapctl/pkg/releasesource"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/mod/semver"
+)
+
+var errNotInstalled = errors.New("not installed")
+
+var releaseComponents = map[string]releasesource.Component{
+	componentGameapctl: releasesource.ComponentGameAPCtl,
+	componentDaemon:    releasesource.ComponentDaemon,
+	componentPanel:     releasesource.ComponentPanel,
+}
+
+// upgradeArgs are the gameapctl commands upgrading the components.
+var upgradeArgs = map[string][]string{
+	componentGameapctl: {"self-update"},
+	componentDaemon:    {"daemon", "upgrade"},
+	componentPanel:     {"panel", "upgrade"},
+}
+
+// Run is started by the scheduler. It upgrades the components once per window
+// occurrence, and retries on the next start while the game servers are busy.
+func Run(cliCtx *cli.Context) error {
+	ctx := cliCtx.Context
+	now := time.Now()
+
+	settings, err := gameapctl.LoadAutoUpdateSettings()
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return errors.WithMessage(err, "failed to load autoupdate settings")
+	}
+
+	if !settings.Enabled {
+		return nil
+	}
+
+	window, err := autoupdate.ParseWindow(settings.Window)
+	if err != nil {
+		return err
+	}
+
+	policy, err := autoupdate.ParsePolicy(settings.Policy)
+	if err != nil {
+		return err
+	}
+
+	start, ok := window.Start(now)
+	if !ok {
+		return nil
+	}
+
+	runs, err := gameapctl.LoadAutoUpdateRuns()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return errors.WithMessage(err, "failed to load autoupdate log")
+	}
+
+	reason, err := autoupdate.Busy(ctx)
+	if err != nil {
+		return errors.WithMessage(err, "failed to check whether game servers are busy")
+	}
+
+	if reason != "" {
+		log.Printf("Skipping automatic updates: %s\n", reason)
+
+		return gameapctl.AppendAutoUpdateRuns(ctx, gameapctl.AutoUpdateRun{
+			StartedAt: now,
+			Result:    gameapctl.AutoUpdateBusy,
+			Message:   reason,
+		})
+	}
+
+	executable, err := executablePath()
+	if err != nil {
+		return err
+	}
+
+	for _, component := range components {
+		if !slices.Contains(settings.Components, component) || ranSince(runs, component, start) {
+			continue
+		}
+
+		var run gameapctl.AutoUpdateRun
+		if component == componentDaemon {
+			run = waitForServers(ctx)
+		}
+		if run.Result == "" {
+			run = update(ctx, executable, component, policy)
+		}
+		run.StartedAt = now
+
+		log.Printf("Automatic update of %s: %s %s\n", component, run.Result, run.Message)
+
+		// Each run is logged right away, a later upgrade may take long.
+		if err := gameapctl.AppendAutoUpdateRuns(ctx, run); err != nil {
+			return errors.WithMessage(err, "failed to write autoupdate log")
+		}
+	}
+
+	return nil
+}
+
+// ranSince reports whether the component ran since the start of the window
+// occurrence. Runs waiting for busy game servers are retried.
+func ranSince(runs []gameapctl.AutoUpdateRun, component string, start time.Time) bool {
+	for _, run := range runs {
+		if run.Component == component && !run.StartedAt.Before(start) && run.Result != gameapctl.AutoUpdateBusy {
+			return true
+		}
+	}
+
+	return false
+}
+
+// waitForServers returns a busy run while game servers are running, a daemon
+// upgrade restarts the daemon and stops them. It returns an empty run when the
+// daemon can be upgraded.
+func waitForServers(ctx context.Context) gameapctl.AutoUpdateRun {
+	run := gameapctl.AutoUpdateRun{Component: componentDaemon}
+
+	reason, err := autoupdate.ServersRunning(ctx)
+	if err != nil {
+		run.Result = gameapctl.AutoUpdateBusy
+		run.Message = errors.WithMessage(err, "failed to check for running game servers").Error()
+
+		return run
+	}
+
+	if reason != "" {
+		run.Result = gameapctl.AutoUpdateBusy
+		run.Message = reason
+	}
+
+	return run
+}
+
+func update(
+	ctx context.Context, executable, component string, policy autoupdate.Policy,
+) gameapctl.AutoUpdateRun {
+	run := gameapctl.AutoUpdateRun{Component: component}
+
+	installed, channel, err := installedVersion(ctx, component)
+	if err != nil {
+		run.Result = gameapctl.AutoUpdateSkipped
+		run.Message = err.Error()
+
+		return run
+	}
+	run.From = installed
+
+	args := append([]string{"--non-interactive"}, upgradeArgs[component]...)
+
+	if channel.FromSource() {
+		run.Message = "nightly build from the GitHub source"
+	} else {
+		prefix, err := policy.TagPrefix(installed)
+		if err != nil {
+			run.Result = gameapctl.AutoUpdateFailed
+			run.Message = err.Error()
+
+			return run
+		}
+
+		release, err := releasesource.FindRelease(
+			ctx,
+			releaseComponents[component],
+			runtime.GOOS,
+			runtime.GOARCH,
+			releasefinder.FindOptions{TagPrefix: prefix, AllowPrerelease: channel.AllowPrerelease()},
+		)
+		if err != nil {
+			run.Result = gameapctl.AutoUpdateFailed
+			run.Message = errors.WithMessage(err, "failed to find release").Error()
+
+			return run
+		}
+
+		if !newer(release.Tag, installed) {
+			run.Result = gameapctl.AutoUpdateUpToDate
+
+			return run
+		}
+
+		run.To = release.Tag
+		args = append(args, "--version", release.Tag)
+	}
+
+	if err := oscore.ExecCommand(ctx, executable, args...); err != nil {
+		run.Result = gameapctl.AutoUpdateFailed
+		run.Message = errors.WithMessage(err, "upgrade failed").Error()
+
+		return run
+	}
+
+	run.Result = gameapctl.AutoUpdateUpdated
+
+	return run
+}
+
+func installedVersion(ctx context.Context, component string) (string, releasesource.Channel, error) {
+	switch component {
+	case componentGameapctl:
+		if strings.HasPrefix(gameap.Version, "dev") {
+			return "", "", errors.New("development build of gameapctl")
+		}
+
+		return gameap.Version, gameapctl.ReleaseChannel(""), nil
+	case componentDaemon:
+		state, err := gameapctl.LoadDaemonInstallState(ctx)
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", "", errNotInstalled
+		}
+		if err != nil {
+			return "", "", errors.WithMessage(err, "failed to load daemon install state")
+		}
+
+		return state.Version, state.ReleaseChannel(), nil
+	case componentPanel:
+		state, err := gameapctl.LoadPanelInstallState(ctx)
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", "", errNotInstalled
+		}
+		if err != nil {
+			return "", "", errors.WithMessage(err, "failed to load panel install state")
+		}
+
+		return state.Version, state.ReleaseChannel(), nil
+	default:
+		return "", "", errors.Errorf("unknown component %q", component)
+	}
+}
+
+// newer reports whether the release is newer than the installed version. An
+// unknown installed version is older than any release.
+func newer(tag, installed string) bool {
+	return semver.Compare(withV(tag), withV(installed)) > 0
+}
+
+func withV(version string) string {
+	version = strings.TrimSpace(version)
+	if version == "" || strings.HasPrefix(version, "v") {
+		return version
+	}
+
+	return "v" + version
+}
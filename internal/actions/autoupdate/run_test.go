@@ -0,0 +1,50 @@
+package autoupdate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gameap/gameapctl/internal/pkg/gameapctl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRanSince(t *testing.T) {
+	start := time.Date(2026, 10, 4, 3, 0, 0, 0, time.UTC)
+
+	assert.False(t, ranSince(nil, "daemon", start))
+	assert.False(t, ranSince([]gameapctl.AutoUpdateRun{
+		{StartedAt: start.Add(-time.Hour), Component: "daemon", Result: gameapctl.AutoUpdateUpdated},
+		{StartedAt: start.Add(15 * time.Minute), Result: gameapctl.AutoUpdateBusy},
+		{StartedAt: start.Add(30 * time.Minute), Component: "daemon", Result: gameapctl.AutoUpdateBusy},
+	}, "daemon", start))
+	assert.True(t, ranSince([]gameapctl.AutoUpdateRun{
+		{StartedAt: start, Component: "daemon", Result: gameapctl.AutoUpdateFailed},
+	}, "daemon", start))
+	assert.False(t, ranSince([]gameapctl.AutoUpdateRun{
+		{StartedAt: start, Component: "daemon", Result: gameapctl.AutoUpdateFailed},
+	}, "panel", start))
+	assert.True(t, ranSince([]gameapctl.AutoUpdateRun{
+		{StartedAt: start, Component: "panel", Result: gameapctl.AutoUpdateSkipped},
+	}, "panel", start))
+}
+
+func TestNewer(t *testing.T) {
+	assert.True(t, newer("v4.1.3", "4.1.2"))
+	assert.True(t, newer("v4.1.0", ""))
+	assert.False(t, newer("v4.1.2", "4.1.2"))
+	assert.False(t, newer("v4.1.2", "v4.1.3"))
+	assert.True(t, newer("v4.1.0", "v4.1.0-beta1"))
+}
+
+func TestParseComponents(t *testing.T) {
+	selected, err := parseComponents([]string{"daemon,gameapctl", "Panel", "daemon"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"daemon", "gameapctl", "panel"}, selected)
+
+	_, err = parseComponents([]string{"daemon,web"})
+	require.ErrorContains(t, err, `unknown component "web"`)
+
+	_, err = parseComponents(nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,329 @@
+// Package bundle implements 'bundle create' and the --from-bundle flag of the
+// install and upgrade commands. A bundle carries the component releases and
+// the package archives gameapctl downloads itself, so GameAP can be installed
+// on a host without access to GitHub, the CDNs and the package repositories
+// of GameAP. Packages from the OS repositories are not bundled.
+package bundle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gameap/gameapctl/internal/pkg/bundle"
+	"github.com/gameap/gameapctl/internal/pkg/output"
+	"github.com/gameap/gameapctl/pkg/dryrun"
+	osinfo "github.com/gameap/gameapctl/pkg/os_info"
+	packagemanager "github.com/gameap/gameapctl/pkg/package_manager"
+	"github.com/gameap/gameapctl/pkg/progress"
+	"github.com/gameap/gameapctl/pkg/releasefinder"
+	"github.com/gameap/gameapctl/pkg/releasesource"
+	"github.com/gameap/gameapctl/pkg/utils"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+var components = map[string]releasesource.Component{
+	"panel":     releasesource.ComponentPanel,
+	"daemon":    releasesource.ComponentDaemon,
+	"gameapctl": releasesource.ComponentGameAPCtl,
+}
+
+type CreateResult struct {
+	Path     string           `json:"path"`
+	Size     int64            `json:"size"`
+	Releases []bundle.Release `json:"releases"`
+	Files    []bundle.File    `json:"files"`
+}
+
+func (r CreateResult) WriteText(w io.Writer) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Bundle saved to %s (%d bytes)\n", r.Path, r.Size)
+	for _, release := range r.Releases {
+		fmt.Fprintf(&b, "  %s %s\n", release.Component, release.Tag)
+	}
+	fmt.Fprintf(&b, "  %d package files\n", len(r.Files))
+
+	_, err := io.WriteString(w, b.String())
+
+	return errors.Wrap(err, "failed to write result")
+}
+
+// Create downloads the latest stable releases of the components and the
+// package archives for the target system into a bundle, by default
+// gameap-bundle-<os>-<arch>.tar.gz in the working directory.
+func Create(cliCtx *cli.Context) (err error) {
+	ctx := cliCtx.Context
+
+	selected, err := parseComponents(cliCtx.StringSlice("components"))
+	if err != nil {
+		return err
+	}
+
+	targetOS := strings.ToLower(cliCtx.String("os"))
+	distribution, version, err := bundle.ParseOS(targetOS)
+	if err != nil {
+		return err
+	}
+
+	arch := cliCtx.String("arch")
+
+	archivePath := cliCtx.Args().First()
+	if archivePath == "" {
+		archivePath = "gameap-bundle-" + targetOS + "-" + arch + ".tar.gz"
+	}
+
+	archivePath, err = filepath.Abs(archivePath)
+	if err != nil {
+		return errors.Wrap(err, "failed to resolve bundle path")
+	}
+
+	if dryrun.Record(ctx, dryrun.KindFile, "create bundle %s", archivePath) {
+		return nil
+	}
+
+	staging, err := os.MkdirTemp("", "gameap-bundle")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temporary directory")
+	}
+	defer func() {
+		if err := os.RemoveAll(staging); err != nil {
+			log.Printf("Failed to remove temporary directory: %v\n", err)
+		}
+	}()
+
+	steps := progress.Steps(ctx, 3) //nolint:mnd
+	defer func() { steps.End(err) }()
+
+	manifest := bundle.Manifest{
+		CreatedAt: time.Now().UTC(),
+		OS:        targetOS,
+		Arch:      arch,
+	}
+
+	steps.Next("bundle.releases", "component releases")
+
+	kernel := "linux"
+	if distribution.IsWindows() {
+		kernel = "windows"
+	}
+
+	for _, component := range selected {
+		release, err := addRelease(ctx, staging, component, kernel, arch)
+		if err != nil {
+			return errors.WithMessagef(err, "failed to bundle %s", component)
+		}
+
+		manifest.Releases = append(manifest.Releases, release)
+	}
+
+	steps.Next("bundle.packages", "package archives")
+
+	manifest.Files, err = addPackages(ctx, staging, osinfo.Info{
+		Distribution:        distribution,
+		DistributionVersion: version,
+		Platform:            platform(arch),
+	})
+	if err != nil {
+		return err
+	}
+
+	steps.Next("bundle.archive", "bundle archive")
+
+	log.Printf("Writing %s ...\n", archivePath)
+
+	if err := bundle.WriteManifest(staging, manifest); err != nil {
+		return err
+	}
+
+	if err := bundle.Pack(staging, archivePath); err != nil {
+		if removeErr := os.Remove(archivePath); removeErr != nil && !errors.Is(removeErr, os.ErrNotExist) {
+			log.Printf("Failed to remove incomplete bundle: %v\n", removeErr)
+		}
+
+		return err
+	}
+
+	info, err := os.Stat(archivePath)
+	if err != nil {
+		return errors.Wrap(err, "failed to stat bundle")
+	}
+
+	return output.Print(cliCtx, CreateResult{
+		Path:     archivePath,
+		Size:     info.Size(),
+		Releases: manifest.Releases,
+		Files:    manifest.Files,
+	})
+}
+
+func parseComponents(names []string) ([]releasesource.Component, error) {
+	selected := make([]releasesource.Component, 0, len(names))
+
+	for _, name := range names {
+		component, ok := components[strings.TrimSpace(name)]
+		if !ok {
+			return nil, errors.Errorf("unknown component %q, expected panel, daemon or gameapctl", name)
+		}
+
+		selected = append(selected, component)
+	}
+
+	return selected, nil
+}
+
+// platform maps a Go architecture to the platform of the package managers.
+func platform(arch string) osinfo.Platform {
+	if arch == "386" {
+		return osinfo.PlatformX86
+	}
+
+	return osinfo.Platform(arch)
+}
+
+// addRelease downloads the release asset with its published checksums and
+// writes a release list with the single release, so the releases directory
+// is a release mirror.
+func addRelease(
+	ctx context.Context, staging string, component releasesource.Component, kernel, arch string,
+) (bundle.Release, error) {
+	found, err := releasesource.FindRelease(ctx, component, kernel, arch, releasefinder.FindOptions{})
+	if err != nil {
+		return bundle.Release{}, errors.WithMessage(err, "failed to find release")
+	}
+
+	release := bundle.Release{Component: component, Tag: found.Tag, Asset: found.AssetName}
+	dst := filepath.Join(staging, filepath.FromSlash(release.Path()))
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return release, errors.Wrapf(err, "failed to create %s", filepath.Dir(dst))
+	}
+
+	log.Printf("Downloading %s %s ...\n", component, found.Tag)
+
+	if err := releasesource.DownloadArchive(ctx, found, dst); err != nil {
+		return release, errors.WithMessage(err, "failed to download release")
+	}
+
+	release.SHA256, err = bundle.FileChecksum(dst)
+	if err != nil {
+		return release, err
+	}
+
+	// The signed checksums let self-update verify the bundled gameapctl.
+	for _, name := range []string{releasesource.ChecksumsAsset, releasesource.SignatureAsset} {
+		content, err := releasesource.FetchAsset(ctx, found, name)
+		if err != nil {
+			log.Printf("No %s for %s %s: %v\n", name, component, found.Tag, err)
+
+			continue
+		}
+
+		if err := os.WriteFile(filepath.Join(filepath.Dir(dst), name), content, 0o644); err != nil {
+			return release, errors.Wrapf(err, "failed to write %s", name)
+		}
+	}
+
+	return release, writeReleaseList(staging, release)
+}
+
+//nolint:tagliatelle
+type releaseListEntry struct {
+	TagName string             `json:"tag_name"`
+	Assets  []releaseListAsset `json:"assets"`
+}
+
+type releaseListAsset struct {
+	Name   string `json:"name"`
+	Digest string `json:"digest"`
+}
+
+func writeReleaseList(staging string, release bundle.Release) error {
+	b, err := json.MarshalIndent([]releaseListEntry{{
+		TagName: release.Tag,
+		Assets:  []releaseListAsset{{Name: release.Asset, Digest: "sha256:" + release.SHA256}},
+	}}, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal release list")
+	}
+
+	p := filepath.Join(staging, bundle.ReleasesPrefix, string(release.Component), "releases.json")
+
+	return errors.Wrap(os.WriteFile(p, b, 0o644), "failed to write release list")
+}
+
+func addPackages(ctx context.Context, staging string, info osinfo.Info) ([]bundle.File, error) {
+	downloads, err := packagemanager.PackageDownloads(info)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]bundle.File, 0, len(downloads))
+	seen := make(map[string]struct{}, len(downloads))
+
+	for _, d := range downloads {
+		file, err := downloadPackage(ctx, staging, d, seen)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed to download %s package", d.Package)
+		}
+
+		if file.Path != "" {
+			files = append(files, file)
+		}
+	}
+
+	return files, nil
+}
+
+// downloadPackage downloads the first available location of the package
+// file. A file bundled for another package is not downloaded again.
+func downloadPackage(
+	ctx context.Context, staging string, d packagemanager.PackageDownload, seen map[string]struct{},
+) (bundle.File, error) {
+	var lastErr error
+
+	for _, u := range d.URLs {
+		entry, err := bundle.FilePath(u)
+		if err != nil {
+			lastErr = err
+
+			continue
+		}
+
+		if _, ok := seen[entry]; ok {
+			return bundle.File{}, nil
+		}
+
+		dst := filepath.Join(staging, filepath.FromSlash(entry))
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return bundle.File{}, errors.Wrapf(err, "failed to create %s", filepath.Dir(dst))
+		}
+
+		log.Printf("Downloading %s ...\n", u)
+
+		if err := utils.DownloadFileOrArchive(ctx, u, dst); err != nil {
+			lastErr = err
+			log.Printf("Download from %s failed: %v\n", u, err)
+
+			continue
+		}
+
+		sum, err := bundle.FileChecksum(dst)
+		if err != nil {
+			return bundle.File{}, err
+		}
+
+		seen[entry] = struct{}{}
+
+		return bundle.File{Package: d.Package, URL: u, Path: entry, SHA256: sum}, nil
+	}
+
+	return bundle.File{}, lastErr
+}
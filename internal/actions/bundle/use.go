@@ -0,0 +1,57 @@
+package bundle
+
+import (
+	"log"
+	"runtime"
+
+	contextInternal "github.com/gameap/gameapctl/internal/context"
+	"github.com/gameap/gameapctl/internal/pkg/bundle"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+// FlagName is the flag of the install and upgrade commands taking a bundle.
+const FlagName = "from-bundle"
+
+func Flag() cli.Flag {
+	return &cli.StringFlag{
+		Name: FlagName,
+		Usage: "Take the releases and package archives only from an offline bundle " +
+			"created with 'gameapctl bundle create'",
+	}
+}
+
+// FromBundle runs the action with the releases and package archives coming
+// only from the bundle passed in the FlagName flag.
+func FromBundle(action cli.ActionFunc) cli.ActionFunc {
+	return func(cliCtx *cli.Context) error {
+		archivePath := cliCtx.String(FlagName)
+		if archivePath == "" {
+			return action(cliCtx)
+		}
+
+		b, err := bundle.Open(archivePath)
+		if err != nil {
+			return errors.WithMessage(err, "failed to open bundle")
+		}
+		defer func() {
+			if err := b.Close(); err != nil {
+				log.Println(err)
+			}
+		}()
+
+		manifest := b.Manifest()
+		if err := manifest.CheckTarget(contextInternal.OSInfoFromContext(cliCtx.Context), runtime.GOARCH); err != nil {
+			return err
+		}
+
+		log.Printf("Using offline bundle %s created at %s\n", archivePath, manifest.CreatedAt.Format("2006-01-02 15:04"))
+
+		cliCtx.Context, err = b.Use(cliCtx.Context)
+		if err != nil {
+			return err
+		}
+
+		return action(cliCtx)
+	}
+}
@@ -0,0 +1,107 @@
+// Package cache implements the 'cache' commands managing the download cache.
+package cache
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gameap/gameapctl/internal/pkg/output"
+	"github.com/gameap/gameapctl/pkg/downloadcache"
+	"github.com/gameap/gameapctl/pkg/dryrun"
+	"github.com/gameap/gameapctl/pkg/progress"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+var errDisabled = errors.New("the download cache is disabled in the gameapctl config")
+
+type ListResult struct {
+	Dir     string                `json:"dir"`
+	Size    int64                 `json:"size"`
+	MaxSize int64                 `json:"maxSize"`
+	Entries []downloadcache.Entry `json:"entries"`
+}
+
+func (r ListResult) WriteText(w io.Writer) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Download cache %s: %s of %s\n",
+		r.Dir, progress.FormatBytes(r.Size), progress.FormatBytes(r.MaxSize))
+
+	for _, entry := range r.Entries {
+		state := ""
+		if !entry.Complete {
+			state = " (partial)"
+		}
+
+		fmt.Fprintf(&b, "  %s  %10s  %s%s\n",
+			entry.UsedAt.Local().Format(time.DateTime), progress.FormatBytes(entry.Size), entry.URL, state)
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return errors.Wrap(err, "failed to write result")
+}
+
+// List prints the cached files, the most recently used first.
+func List(cliCtx *cli.Context) error {
+	c := downloadcache.Default()
+	if c == nil {
+		return errDisabled
+	}
+
+	entries, err := c.List()
+	if err != nil {
+		return err
+	}
+
+	result := ListResult{Dir: c.Dir(), MaxSize: c.MaxSize(), Entries: entries}
+	for _, entry := range entries {
+		result.Size += entry.Size
+	}
+
+	return output.Print(cliCtx, result)
+}
+
+type PruneResult struct {
+	Removed int   `json:"removed"`
+	Freed   int64 `json:"freed"`
+}
+
+func (r PruneResult) WriteText(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "Removed %d cached files, %s freed\n", r.Removed, progress.FormatBytes(r.Freed))
+
+	return errors.Wrap(err, "failed to write result")
+}
+
+// Prune removes the cached files not used for --older-than, all of them when
+// it is not set.
+func Prune(cliCtx *cli.Context) error {
+	c := downloadcache.Default()
+	if c == nil {
+		return errDisabled
+	}
+
+	olderThan := cliCtx.Duration("older-than")
+	if olderThan < 0 {
+		return errors.New("--older-than must not be negative")
+	}
+
+	description := "remove all files from " + c.Dir()
+	if olderThan > 0 {
+		description = fmt.Sprintf("remove files not used for %s from %s", olderThan, c.Dir())
+	}
+
+	if dryrun.Record(cliCtx.Context, dryrun.KindFile, "%s", description) {
+		return nil
+	}
+
+	removed, freed, err := c.Prune(olderThan)
+	if err != nil {
+		return err
+	}
+
+	return output.Print(cliCtx, PruneResult{Removed: removed, Freed: freed})
+}
@@ -0,0 +1,135 @@
+// Package channel implements the 'channel' commands switching the release
+// channel panel upgrade, daemon upgrade and self-update follow.
+package channel
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+
+	"github.com/gameap/gameapctl/internal/pkg/gameapctl"
+	"github.com/gameap/gameapctl/internal/pkg/output"
+	"github.com/gameap/gameapctl/pkg/dryrun"
+	"github.com/gameap/gameapctl/pkg/releasesource"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+type Result struct {
+	Gameapctl releasesource.Channel `json:"gameapctl"`
+	Panel     releasesource.Channel `json:"panel,omitempty"`
+	Daemon    releasesource.Channel `json:"daemon,omitempty"`
+}
+
+func (r Result) WriteText(w io.Writer) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "gameapctl: %s\n", r.Gameapctl)
+	if r.Panel != "" {
+		fmt.Fprintf(&b, "panel:     %s\n", r.Panel)
+	}
+	if r.Daemon != "" {
+		fmt.Fprintf(&b, "daemon:    %s\n", r.Daemon)
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return errors.Wrap(err, "failed to write result")
+}
+
+// Show prints the release channels of gameapctl and the installations.
+func Show(cliCtx *cli.Context) error {
+	ctx := cliCtx.Context
+
+	result := Result{Gameapctl: gameapctl.ReleaseChannel("")}
+
+	panelState, err := gameapctl.LoadPanelInstallState(ctx)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return errors.WithMessage(err, "failed to load panel install state")
+	default:
+		result.Panel = panelState.ReleaseChannel()
+	}
+
+	daemonState, err := gameapctl.LoadDaemonInstallState(ctx)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return errors.WithMessage(err, "failed to load daemon install state")
+	default:
+		result.Daemon = daemonState.ReleaseChannel()
+	}
+
+	return output.Print(cliCtx, result)
+}
+
+// Set switches gameapctl and the installed panel and daemon to the channel.
+// The nightly channel builds the --branch of the panel and daemon, or the
+// branch they are built from already.
+func Set(cliCtx *cli.Context) error {
+	ctx := cliCtx.Context
+
+	if cliCtx.NArg() != 1 {
+		return errors.New("expected a channel: stable, beta or nightly")
+	}
+
+	channel, err := releasesource.ParseChannel(cliCtx.Args().First())
+	if err != nil {
+		return err
+	}
+
+	branch := cliCtx.String("branch")
+	if branch != "" && !channel.FromSource() {
+		return errors.New("--branch is only used by the nightly channel")
+	}
+
+	if dryrun.Record(ctx, dryrun.KindFile, "switch gameapctl, panel and daemon to the %s channel", channel) {
+		return nil
+	}
+
+	if err := gameapctl.SetConfigValue("channel", string(channel)); err != nil {
+		return errors.WithMessage(err, "failed to save channel")
+	}
+
+	result := Result{Gameapctl: channel}
+
+	panelState, err := gameapctl.LoadPanelInstallState(ctx)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return errors.WithMessage(err, "failed to load panel install state")
+	default:
+		panelState.Channel = string(channel)
+		panelState.FromGithub = channel.FromSource()
+		if branch != "" {
+			panelState.Branch = branch
+		}
+
+		if err := gameapctl.SavePanelInstallState(ctx, panelState); err != nil {
+			return errors.WithMessage(err, "failed to save panel install state")
+		}
+		result.Panel = channel
+	}
+
+	daemonState, err := gameapctl.LoadDaemonInstallState(ctx)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return errors.WithMessage(err, "failed to load daemon install state")
+	default:
+		daemonState.Channel = string(channel)
+		daemonState.FromGithub = channel.FromSource()
+		if branch != "" {
+			daemonState.Branch = branch
+		}
+
+		if err := gameapctl.SaveDaemonInstallState(ctx, daemonState); err != nil {
+			return errors.WithMessage(err, "failed to save daemon install state")
+		}
+		result.Daemon = channel
+	}
+
+	return output.Print(cliCtx, result)
+}
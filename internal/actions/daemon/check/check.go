@@ -0,0 +1,21 @@
+package check
+
+import (
+	doctoraction "github.com/gameap/gameapctl/internal/actions/doctor"
+	daemonpkg "github.com/gameap/gameapctl/internal/pkg/daemon"
+	"github.com/gameap/gameapctl/internal/pkg/doctor"
+	"github.com/urfave/cli/v2"
+)
+
+func Handle(cliCtx *cli.Context) error {
+	scope, paths, err := daemonpkg.ResolvePaths(cliCtx.Context)
+	if err != nil {
+		return err
+	}
+
+	registry := doctor.NewRegistry()
+	registry.Register(daemonpkg.Checks(scope, paths)...)
+	registry.Register(doctor.SystemChecks(scope)...)
+
+	return doctoraction.Run(cliCtx, registry)
+}
@@ -0,0 +1,214 @@
+// Package config implements the 'daemon config' commands. Edits go through the
+// YAML AST of the daemon config, so the comments and the key order are kept.
+package config
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/gameap/gameapctl/internal/pkg/daemon"
+	"github.com/gameap/gameapctl/internal/pkg/output"
+	daemonsvc "github.com/gameap/gameapctl/pkg/daemon"
+	"github.com/gameap/gameapctl/pkg/dryrun"
+	"github.com/goccy/go-yaml"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+var errInvalidConfig = errors.New("daemon config is invalid")
+
+type Entry struct {
+	Key   string `json:"key"`
+	Value any    `json:"value"`
+}
+
+// WriteText prints scalars as they are and nested values as YAML.
+func (e Entry) WriteText(w io.Writer) error {
+	switch e.Value.(type) {
+	case map[string]any, []any:
+		b, err := yaml.Marshal(e.Value)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal value")
+		}
+
+		_, err = w.Write(b)
+
+		return errors.Wrap(err, "failed to write value")
+	default:
+		_, err := fmt.Fprintln(w, e.Value)
+
+		return errors.Wrap(err, "failed to write value")
+	}
+}
+
+func Get(cliCtx *cli.Context) error {
+	if cliCtx.NArg() != 1 {
+		return errors.New("expected exactly one argument: the key")
+	}
+
+	cfg, err := load(cliCtx)
+	if err != nil {
+		return err
+	}
+
+	key := cliCtx.Args().First()
+
+	value, ok, err := cfg.Get(yamlPath(key))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.Errorf("%s is not set in %s", key, cfg.Path())
+	}
+
+	return output.Print(cliCtx, Entry{Key: key, Value: value})
+}
+
+// Set accepts 'KEY VALUE' or any number of 'KEY=VALUE' arguments. Values are
+// YAML: 31717 is a number, [eth0, eth1] a list, "31717" a string.
+func Set(cliCtx *cli.Context) error {
+	ctx := cliCtx.Context
+
+	args := cliCtx.Args().Slice()
+	if len(args) == 2 && !strings.Contains(args[0], "=") {
+		args = []string{args[0] + "=" + args[1]}
+	}
+	if len(args) == 0 {
+		return errors.New("expected 'KEY VALUE' or 'KEY=VALUE' arguments")
+	}
+
+	cfg, err := load(cliCtx)
+	if err != nil {
+		return err
+	}
+
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			return errors.Errorf("invalid argument %q, expected KEY=VALUE", arg)
+		}
+
+		src, err := yamlSource(value)
+		if err != nil {
+			return err
+		}
+
+		if err := cfg.SetValue(yamlPath(key), src); err != nil {
+			return errors.WithMessagef(err, "failed to set %s", key)
+		}
+	}
+
+	if problems := validate(cfg.Bytes(), cfg.Path()); len(problems) > 0 {
+		if !cliCtx.Bool("force") {
+			return errors.Errorf(
+				"the change makes the daemon config invalid, use --force to write it anyway:\n  %s",
+				strings.Join(problems, "\n  "),
+			)
+		}
+
+		log.Printf("Warning: writing an invalid daemon config:\n  %s\n", strings.Join(problems, "\n  "))
+	}
+
+	if dryrun.Record(ctx, dryrun.KindFile, "back up and update %s", cfg.Path()) {
+		return nil
+	}
+
+	backupPath, err := daemon.Backup(cfg.Path())
+	if err != nil {
+		return errors.WithMessage(err, "failed to back up daemon config")
+	}
+
+	if err := cfg.Save(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Daemon config updated, the previous version is saved to %s\n", backupPath)
+
+	if !cliCtx.Bool("restart") {
+		fmt.Println("Restart the daemon to apply the changes: gameapctl daemon restart")
+
+		return nil
+	}
+
+	return restart(cliCtx)
+}
+
+func Validate(cliCtx *cli.Context) error {
+	cfg, err := load(cliCtx)
+	if err != nil {
+		return err
+	}
+
+	problems := validate(cfg.Bytes(), cfg.Path())
+
+	result := ValidationResult{Path: cfg.Path(), Valid: len(problems) == 0, Problems: problems}
+	if result.Problems == nil {
+		result.Problems = []string{}
+	}
+
+	if err := output.Print(cliCtx, result); err != nil {
+		return err
+	}
+
+	if !result.Valid {
+		return errInvalidConfig
+	}
+
+	return nil
+}
+
+func load(cliCtx *cli.Context) (*daemon.ConfigFile, error) {
+	_, paths, err := daemon.ResolvePaths(cliCtx.Context)
+	if err != nil {
+		return nil, err
+	}
+
+	return daemon.LoadConfig(paths.DaemonConfigFilePath)
+}
+
+func restart(cliCtx *cli.Context) error {
+	scope, _, err := daemon.ResolvePaths(cliCtx.Context)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Restarting daemon ...")
+
+	if err := daemonsvc.Restart(cliCtx.Context, daemonsvc.Options{Scope: scope}); err != nil {
+		return errors.WithMessage(err, "failed to restart daemon")
+	}
+
+	return nil
+}
+
+// yamlPath turns a dotted key (process_manager.name) into a YAMLPath, YAMLPaths
+// are passed as they are.
+func yamlPath(key string) string {
+	if strings.HasPrefix(key, "$") {
+		return key
+	}
+
+	return "$." + key
+}
+
+// yamlSource returns the value as a single-line YAML source. A value that is
+// not valid YAML is written as a string.
+func yamlSource(value string) (string, error) {
+	if trimmed := strings.TrimSpace(value); trimmed == "null" || trimmed == "~" {
+		return "null", nil
+	}
+
+	var v any
+	if err := yaml.Unmarshal([]byte(value), &v); err != nil || v == nil {
+		v = value
+	}
+
+	b, err := yaml.MarshalWithOptions(v, yaml.Flow(true))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to encode value %q", value)
+	}
+
+	return strings.TrimSpace(string(b)), nil
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestYAMLSource(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"31717", "31717"},
+		{"true", "true"},
+		{"/srv/gameap", "/srv/gameap"},
+		{`"31717"`, `"31717"`},
+		{"[eth0, eth1]", "[eth0, eth1]"},
+		{"{name: tmux}", "{name: tmux}"},
+		{"null", "null"},
+		{"", `""`},
+		{"C:\\gameap\\daemon", `"C:\\gameap\\daemon"`},
+		{"a: b: c", `"a: b: c"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			got, err := yamlSource(test.value)
+			require.NoError(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestYAMLPath(t *testing.T) {
+	assert.Equal(t, "$.listen_port", yamlPath("listen_port"))
+	assert.Equal(t, "$.process_manager.name", yamlPath("process_manager.name"))
+	assert.Equal(t, "$.users.gameap", yamlPath("$.users.gameap"))
+}
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "gameap-daemon.yaml")
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "certs"), 0o700))
+	for _, name := range []string{"ca.crt", "server.crt", "server.key"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "certs", name), []byte("cert"), 0o600))
+	}
+
+	valid := `listen_port: 31717
+work_path: /srv/gameap
+process_manager:
+  name: tmux
+ca_certificate_file: certs/ca.crt
+certificate_chain_file: certs/server.crt
+private_key_file: ` + filepath.Join(dir, "certs", "server.key") + `
+`
+
+	assert.Empty(t, validate([]byte(valid), configPath))
+
+	problems := validate([]byte(`listen_port: 70000
+work_path: srv/gameap
+process_manager:
+  name: screen
+ca_certificate_file: certs/missing.crt
+certificate_chain_file: certs/server.crt
+`), configPath)
+
+	assert.Equal(t, []string{
+		"listen_port: must be a port number between 1 and 65535",
+		"work_path: must be an absolute path",
+		"process_manager.name: unsupported value \"screen\", expected one of: " +
+			"tmux, systemd, docker, podman, simple, shawl",
+		"ca_certificate_file: file " + filepath.Join(dir, "certs", "missing.crt") + " does not exist",
+		"private_key_file: is required",
+	}, problems)
+}
+
+func TestValidate_WrongType(t *testing.T) {
+	problems := validate([]byte("listen_port: abc\nwork_path: /srv/gameap\n"), "gameap-daemon.yaml")
+
+	require.Len(t, problems, 1)
+	assert.Contains(t, problems[0], "listen_port")
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	daemoninstall "github.com/gameap/gameapctl/internal/actions/daemon/install"
+	"github.com/gameap/gameapctl/pkg/utils"
+	"github.com/goccy/go-yaml"
+	"github.com/pkg/errors"
+)
+
+type ValidationResult struct {
+	Path     string   `json:"path"`
+	Valid    bool     `json:"valid"`
+	Problems []string `json:"problems"`
+}
+
+func (r ValidationResult) WriteText(w io.Writer) error {
+	if r.Valid {
+		_, err := fmt.Fprintf(w, "%s is valid\n", r.Path)
+
+		return errors.Wrap(err, "failed to write result")
+	}
+
+	if _, err := fmt.Fprintf(w, "%s is invalid:\n", r.Path); err != nil {
+		return errors.Wrap(err, "failed to write result")
+	}
+
+	for _, problem := range r.Problems {
+		if _, err := fmt.Fprintf(w, "  %s\n", problem); err != nil {
+			return errors.Wrap(err, "failed to write result")
+		}
+	}
+
+	return nil
+}
+
+// validate decodes the config into the DaemonConfig written by the installer
+// and checks the settings the daemon does not start without. Relative
+// certificate paths are resolved against the config directory.
+func validate(data []byte, configPath string) []string {
+	var cfg daemoninstall.DaemonConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return []string{yaml.FormatError(err, false, true)}
+	}
+
+	var problems []string
+
+	if cfg.ListenPort < 0 || cfg.ListenPort > 65535 {
+		problems = append(problems, "listen_port: must be a port number between 1 and 65535")
+	}
+
+	switch {
+	case strings.TrimSpace(cfg.WorkPath) == "":
+		problems = append(problems, "work_path: is required")
+	case !filepath.IsAbs(cfg.WorkPath):
+		problems = append(problems, "work_path: must be an absolute path")
+	}
+
+	if name := cfg.ProcessManager.Name; name != "" && !slices.Contains(daemoninstall.ProcessManagers, name) {
+		problems = append(problems, fmt.Sprintf(
+			"process_manager.name: unsupported value %q, expected one of: %s",
+			name, strings.Join(daemoninstall.ProcessManagers, ", "),
+		))
+	}
+
+	certificates := []struct {
+		key      string
+		path     string
+		required bool
+	}{
+		{"ca_certificate_file", cfg.CACertificateFile, true},
+		{"certificate_chain_file", cfg.CertificateChainFile, true},
+		{"private_key_file", cfg.PrivateKeyFile, true},
+		{"dh_file", cfg.DHFile, false},
+	}
+
+	for _, cert := range certificates {
+		if cert.path == "" {
+			if cert.required {
+				problems = append(problems, cert.key+": is required")
+			}
+
+			continue
+		}
+
+		path := cert.path
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(configPath), path)
+		}
+
+		if !utils.IsFileExists(path) {
+			problems = append(problems, fmt.Sprintf("%s: file %s does not exist", cert.key, path))
+		}
+	}
+
+	return problems
+}
@@ -60,3 +60,13 @@ func (c ConnectInfo) Address() string {
 func (c ConnectInfo) URL() string {
 	return "grpc://" + c.Address() + "/" + c.SetupKey
 }
+
+// connectURLHost returns the panel address of a connect URL without the setup key.
+func connectURLHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "<invalid connect URL>"
+	}
+
+	return u.Host
+}
@@ -27,12 +27,16 @@ import (
 	"time"
 
 	contextInternal "github.com/gameap/gameapctl/internal/context"
+	"github.com/gameap/gameapctl/internal/pkg/answers"
 	daemonpkg "github.com/gameap/gameapctl/internal/pkg/daemon"
 	"github.com/gameap/gameapctl/internal/pkg/gameapctl"
 	"github.com/gameap/gameapctl/pkg/daemon"
+	"github.com/gameap/gameapctl/pkg/dryrun"
 	"github.com/gameap/gameapctl/pkg/gameap"
 	osinfo "github.com/gameap/gameapctl/pkg/os_info"
+	"github.com/gameap/gameapctl/pkg/oscore"
 	packagemanager "github.com/gameap/gameapctl/pkg/package_manager"
+	"github.com/gameap/gameapctl/pkg/progress"
 	"github.com/gameap/gameapctl/pkg/releasefinder"
 	"github.com/gameap/gameapctl/pkg/releasesource"
 	"github.com/gameap/gameapctl/pkg/utils"
@@ -52,8 +56,19 @@ const (
 	processManagerPodman  = "podman"
 	processManagerSimple  = "simple"
 	processManagerTmux    = "tmux"
+	processManagerShawl   = "shawl"
 )
 
+// ProcessManagers lists the values of process_manager.name supported by the daemon.
+var ProcessManagers = []string{
+	processManagerTmux,
+	processManagerSystemD,
+	processManagerDocker,
+	processManagerPodman,
+	processManagerSimple,
+	processManagerShawl,
+}
+
 var errEmptyToken = errors.New("empty token")
 
 const (
@@ -61,22 +76,20 @@ const (
 	daemonDirMode       = 0755
 )
 
-func ensureDaemonDirs(state daemonsInstallState) error {
+func ensureDaemonDirs(ctx context.Context, state daemonsInstallState) error {
 	if state.DaemonConfigDir != "" {
-		if err := os.MkdirAll(state.DaemonConfigDir, daemonConfigDirMode); err != nil {
-			return errors.Wrapf(err, "failed to create %s", state.DaemonConfigDir)
+		if err := oscore.MkdirAll(ctx, state.DaemonConfigDir, daemonConfigDirMode); err != nil {
+			return err
 		}
 	}
 	if state.OutputLogPath != "" {
-		logDir := filepath.Dir(state.OutputLogPath)
-		if err := os.MkdirAll(logDir, daemonDirMode); err != nil {
-			return errors.Wrapf(err, "failed to create %s", logDir)
+		if err := oscore.MkdirAll(ctx, filepath.Dir(state.OutputLogPath), daemonDirMode); err != nil {
+			return err
 		}
 	}
 	if state.DaemonFilePath != "" {
-		binDir := filepath.Dir(state.DaemonFilePath)
-		if err := os.MkdirAll(binDir, daemonDirMode); err != nil {
-			return errors.Wrapf(err, "failed to create %s", binDir)
+		if err := oscore.MkdirAll(ctx, filepath.Dir(state.DaemonFilePath), daemonDirMode); err != nil {
+			return err
 		}
 	}
 
@@ -154,20 +167,33 @@ type InstallOptions struct {
 }
 
 func Handle(cliCtx *cli.Context) error {
+	a, err := answers.LoadFromFlag(cliCtx, (*answers.File).ValidateDaemon)
+	if err != nil {
+		return err
+	}
+
+	d := a.DaemonOrEmpty()
+
+	// Connection flags replace the whole daemon section, the panel is either
+	// reached with a connect URL or with a host and a token.
+	if cliCtx.IsSet("connect") || cliCtx.IsSet("host") || cliCtx.IsSet("token") {
+		d = answers.Daemon{}
+	}
+
 	return Install(cliCtx.Context, InstallOptions{
-		Host:       cliCtx.String("host"),
-		Token:      cliCtx.String("token"),
-		ConnectURL: cliCtx.String("connect"),
+		Host:       answers.FlagOr(cliCtx, "host", d.Host),
+		Token:      answers.FlagOr(cliCtx, "token", d.Token),
+		ConnectURL: answers.FlagOr(cliCtx, "connect", d.ConnectURL),
 		Config:     cliCtx.String("config"),
-		Scope:      cliCtx.String("scope"),
+		Scope:      answers.FlagOr(cliCtx, "scope", a.Scope),
 		FromGithub: cliCtx.Bool("github"),
 		Branch:     cliCtx.String("branch"),
-		Version:    cliCtx.String("version"),
+		Version:    answers.FlagOr(cliCtx, "version", a.Version),
 	})
 }
 
 //nolint:gocognit,funlen,gocyclo
-func Install(ctx context.Context, opts InstallOptions) error {
+func Install(ctx context.Context, opts InstallOptions) (err error) {
 	fmt.Println("Install daemon")
 
 	if opts.Branch == "" {
@@ -178,7 +204,13 @@ func Install(ctx context.Context, opts InstallOptions) error {
 		return errors.New("--connect and --host/--token are mutually exclusive")
 	}
 
-	if opts.ConnectURL != "" {
+	switch {
+	case opts.ConnectURL != "" && dryrun.Enabled(ctx):
+		// The panel certificate is probed for enrollment, which is not done in a dry run.
+		if _, err := ParseConnectURL(opts.ConnectURL); err != nil {
+			return errors.WithMessage(err, "invalid connect URL")
+		}
+	case opts.ConnectURL != "":
 		resolvedURL, err := resolveConnectAddress(ctx, defaultResolveDeps(), opts.ConnectURL)
 		if err != nil {
 			return err
@@ -240,7 +272,12 @@ func Install(ctx context.Context, opts InstallOptions) error {
 
 	state.OSInfo = contextInternal.OSInfoFromContext(ctx)
 
-	if err := ensureDaemonDirs(state); err != nil {
+	steps := progress.Steps(ctx, 5) //nolint:mnd
+	defer func() { steps.End(err) }()
+
+	steps.Next("daemon.dependencies", "daemon dependencies")
+
+	if err := ensureDaemonDirs(ctx, state); err != nil {
 		return errors.WithMessage(err, "failed to prepare daemon directories")
 	}
 
@@ -323,13 +360,9 @@ func Install(ctx context.Context, opts InstallOptions) error {
 	if !utils.IsFileExists(filepath.Join(state.WorkPath, "servers")) {
 		fmt.Println("Creating servers directory ...")
 
-		err = os.MkdirAll(filepath.Join(state.WorkPath, "servers"), 0755)
+		err = oscore.MkdirAll(ctx, filepath.Join(state.WorkPath, "servers"), 0755)
 		if err != nil {
-			return errors.Wrapf(
-				err,
-				"failed to create servers directory %s",
-				filepath.Join(state.WorkPath, "servers"),
-			)
+			return errors.WithMessage(err, "failed to create servers directory")
 		}
 	}
 
@@ -356,6 +389,8 @@ func Install(ctx context.Context, opts InstallOptions) error {
 		return errors.WithMessage(err, "failed to set user privileges")
 	}
 
+	steps.Next("daemon.system", "daemon user privileges and firewall")
+
 	fmt.Println("Set user privileges ...")
 	state, err = setUserPrivileges(ctx, state)
 	if err != nil {
@@ -368,6 +403,8 @@ func Install(ctx context.Context, opts InstallOptions) error {
 		return errors.WithMessage(err, "failed to set firewall rules")
 	}
 
+	steps.Next("daemon.binaries", "gameap-daemon binaries")
+
 	if state.FromGithub {
 		fmt.Println("Building gameap-daemon from GitHub source ...")
 		state, err = installDaemonFromGithub(ctx, pm, state)
@@ -379,6 +416,8 @@ func Install(ctx context.Context, opts InstallOptions) error {
 		return errors.WithMessage(err, "failed to install daemon binaries")
 	}
 
+	steps.Next("daemon.register", "daemon registration in the panel")
+
 	if state.ConnectURL != "" {
 		state, err = enrollFlow(ctx, state)
 	} else {
@@ -388,6 +427,8 @@ func Install(ctx context.Context, opts InstallOptions) error {
 		return err
 	}
 
+	steps.Next("daemon.start", "gameap-daemon start")
+
 	fmt.Println("Checking GameAP CDN availability ...")
 	daemonpkg.SetupCDNReplacements(ctx, state.DaemonConfigFilePath)
 
@@ -614,6 +655,16 @@ func installDaemonBinaries(
 	}
 	state.ResolvedTag = release.Tag
 
+	if dryrun.Record(ctx, dryrun.KindDownload, "gameap-daemon %s from %s", release.Tag, release.PrimaryURL()) {
+		dryrun.Record(ctx, dryrun.KindFile, "install gameap-daemon binary to %s", state.DaemonFilePath)
+
+		if state.OSInfo.Distribution == packagemanager.DistributionWindows {
+			return state, pm.Install(ctx, packagemanager.GameAPDaemon)
+		}
+
+		return state, nil
+	}
+
 	err = releasesource.Download(
 		ctx,
 		release,
@@ -853,6 +904,14 @@ func configureDaemon(ctx context.Context, state daemonsInstallState) (daemonsIns
 }
 
 func enrollFlow(ctx context.Context, state daemonsInstallState) (daemonsInstallState, error) {
+	// The connect URL carries the one-time setup key, only the panel address goes to the plan.
+	if dryrun.Record(
+		ctx, dryrun.KindFile, "enroll daemon in the panel at %s and write %s",
+		connectURLHost(state.ConnectURL), state.DaemonConfigFilePath,
+	) {
+		return state, nil
+	}
+
 	if _, statErr := os.Stat(state.CertsPath); os.IsNotExist(statErr) {
 		if mkErr := os.MkdirAll(state.CertsPath, 0700); mkErr != nil { //nolint:mnd
 			return state, errors.WithMessage(mkErr, "failed to create certificates directory")
@@ -995,6 +1054,13 @@ func applySteamCMDPath(configPath, steamCMDPath string) error {
 }
 
 func legacyConfigureFlow(ctx context.Context, state daemonsInstallState) (daemonsInstallState, error) {
+	if dryrun.Record(
+		ctx, dryrun.KindFile, "register daemon in the panel at %s, write certificates to %s and config to %s",
+		state.Host, state.CertsPath, state.DaemonConfigFilePath,
+	) {
+		return state, nil
+	}
+
 	var err error
 
 	fmt.Println("Generating GameAP Daemon certificates ...")
@@ -8,6 +8,7 @@ import (
 	"os/user"
 	"strconv"
 
+	"github.com/gameap/gameapctl/pkg/dryrun"
 	"github.com/gameap/gameapctl/pkg/gameap"
 	"github.com/gameap/gameapctl/pkg/oscore"
 	packagemanager "github.com/gameap/gameapctl/pkg/package_manager"
@@ -57,6 +58,11 @@ func setUserPrivileges(ctx context.Context, state daemonsInstallState) (daemonsI
 		return state, nil
 	}
 
+	// The gameap user is not created in a dry run, so there is no uid to look up.
+	if dryrun.Record(ctx, dryrun.KindFile, "chown -R gameap:gameap %s", state.WorkPath) {
+		return state, nil
+	}
+
 	gameapUser, err := user.Lookup("gameap")
 	if err != nil {
 		return state, errors.WithMessage(err, "failed to lookup user")
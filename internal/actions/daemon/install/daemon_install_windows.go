@@ -35,7 +35,7 @@ func setUserPrivileges(ctx context.Context, state daemonsInstallState) (daemonsI
 
 func setFirewallRules(ctx context.Context, state daemonsInstallState) (daemonsInstallState, error) {
 	// Check if rule already exists to avoid duplicates on re-installation
-	showErr := oscore.ExecCommand(
+	_, showErr := oscore.ExecCommandWithOutput(
 		ctx,
 		"netsh", "advfirewall", "firewall", "show", "rule", "name=GameAP_Daemon",
 	)
@@ -66,7 +66,7 @@ func setFirewallRules(ctx context.Context, state daemonsInstallState) (daemonsIn
 }
 
 const (
-	defaultProcessManager = "shawl"
+	defaultProcessManager = processManagerShawl
 )
 
 func defineProcessManager(_ context.Context, state daemonsInstallState) (daemonsInstallState, error) {
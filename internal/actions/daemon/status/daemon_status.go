@@ -1,16 +1,25 @@
 package status
 
 import (
-	"github.com/gameap/gameapctl/pkg/daemon"
+	"github.com/gameap/gameapctl/internal/pkg/output"
+	statuspkg "github.com/gameap/gameapctl/internal/pkg/status"
 	"github.com/pkg/errors"
 	"github.com/urfave/cli/v2"
 )
 
 func Handle(cliCtx *cli.Context) error {
-	err := daemon.Status(cliCtx.Context)
+	result, err := statuspkg.Daemon(cliCtx.Context)
 	if err != nil {
 		return errors.WithMessage(err, "failed to get daemon status")
 	}
 
+	if err := output.Print(cliCtx, result); err != nil {
+		return err
+	}
+
+	if result.State != statuspkg.StateRunning {
+		return errors.New("daemon process not found")
+	}
+
 	return nil
 }
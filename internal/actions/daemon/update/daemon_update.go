@@ -59,6 +59,14 @@ func Handle(cliCtx *cli.Context) error {
 		}
 	}
 
+	channel := gameapctl.ReleaseChannel("")
+	if stateErr == nil {
+		channel = daemonState.ReleaseChannel()
+	}
+	if rawVersion == "" && channel.FromSource() {
+		fromGithub = true
+	}
+
 	if branch == "" {
 		branch = "master"
 	}
@@ -74,27 +82,16 @@ func Handle(cliCtx *cli.Context) error {
 		return handleFromGithub(ctx, branch, scope)
 	}
 
-	gameapDaemonPath := ""
-	if scope == gameap.ScopeUser {
-		paths, pathsErr := gameap.DaemonPathsForScope(scope)
-		if pathsErr != nil {
-			return errors.WithMessage(pathsErr, "failed to resolve daemon paths")
-		}
-		gameapDaemonPath = paths.DaemonFilePath
-	} else {
-		var lookErr error
-		gameapDaemonPath, lookErr = exec.LookPath("gameap-daemon")
-		if lookErr != nil {
-			fmt.Println("Daemon not found")
-
-			return errors.WithMessage(lookErr, "failed to find gameap-daemon")
-		}
+	gameapDaemonPath, err := daemonBinaryPath(scope)
+	if err != nil {
+		return err
 	}
 
 	fmt.Println("Checking new versions...")
 	release, err := findRelease(ctx, releasefinder.FindOptions{
-		Tag:       tag,
-		TagPrefix: tagPrefix,
+		Tag:             tag,
+		TagPrefix:       tagPrefix,
+		AllowPrerelease: channel.AllowPrerelease(),
 	})
 	if err != nil {
 		return errors.WithMessage(err, "failed to find release")
@@ -142,6 +139,8 @@ func Handle(cliCtx *cli.Context) error {
 		return errors.WithMessage(err, "failed to stop daemon")
 	}
 
+	keepRelease(ctx, scope, gameapDaemonPath)
+
 	backupPath := filepath.Join(os.TempDir(), "gameap-daemon-backup")
 	err = utils.Copy(gameapDaemonPath, backupPath)
 	if err != nil {
@@ -224,6 +223,26 @@ func updateDaemonStateVersion(ctx context.Context, resolvedTag string) {
 	}
 }
 
+func daemonBinaryPath(scope string) (string, error) {
+	if scope == gameap.ScopeUser {
+		paths, err := gameap.DaemonPathsForScope(scope)
+		if err != nil {
+			return "", errors.WithMessage(err, "failed to resolve daemon paths")
+		}
+
+		return paths.DaemonFilePath, nil
+	}
+
+	path, err := exec.LookPath("gameap-daemon")
+	if err != nil {
+		fmt.Println("Daemon not found")
+
+		return "", errors.WithMessage(err, "failed to find gameap-daemon")
+	}
+
+	return path, nil
+}
+
 func stopDaemon(ctx context.Context, scope string) error {
 	err := daemon.Stop(ctx, daemon.Options{Scope: scope})
 	if err != nil {
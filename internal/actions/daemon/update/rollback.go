@@ -0,0 +1,105 @@
+package update
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log"
+
+	"github.com/gameap/gameapctl/internal/pkg/gameapctl"
+	"github.com/gameap/gameapctl/pkg/gameap"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+const releaseHistoryComponent = "daemon"
+
+// keepRelease keeps the installed binary and config for 'daemon rollback'.
+// The upgrade goes on when they cannot be kept.
+func keepRelease(ctx context.Context, scope, binaryPath string) {
+	history, err := gameapctl.ReleaseHistory(releaseHistoryComponent, scope)
+	if err != nil {
+		log.Printf("Warning: failed to keep the installed release for rollback: %v\n", err)
+
+		return
+	}
+
+	err = history.Save(ctx, installedVersion(ctx), binaryPath, daemonConfigPath(scope))
+	if err != nil {
+		log.Printf("Warning: failed to keep the installed release for rollback: %v\n", err)
+	}
+}
+
+func installedVersion(ctx context.Context) string {
+	state, err := gameapctl.LoadDaemonInstallState(ctx)
+	if err != nil {
+		return ""
+	}
+
+	return state.Version
+}
+
+func daemonConfigPath(scope string) string {
+	paths, err := gameap.DaemonPathsForScope(scope)
+	if err != nil {
+		return ""
+	}
+
+	return paths.DaemonConfigFilePath
+}
+
+// HandleRollback restores the daemon binary and config kept by an earlier
+// upgrade: the release given with --to, or the one installed before the last
+// upgrade.
+func HandleRollback(cliCtx *cli.Context) error {
+	ctx := cliCtx.Context
+
+	// Without the install state the daemon is in the system scope and the
+	// installed release is unknown.
+	scope, currentTag := "", ""
+	state, err := gameapctl.LoadDaemonInstallState(ctx)
+	switch {
+	case err == nil:
+		scope, currentTag = state.Scope, state.Version
+	case !errors.Is(err, fs.ErrNotExist):
+		return errors.WithMessage(err, "failed to load daemon install state")
+	}
+
+	history, err := gameapctl.ReleaseHistory(releaseHistoryComponent, scope)
+	if err != nil {
+		return err
+	}
+
+	snapshot, err := history.Find(cliCtx.String("to"))
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Rolling back daemon to %s...\n", snapshot.Name())
+
+	fmt.Println("Stopping daemon...")
+	if err := stopDaemon(ctx, scope); err != nil {
+		return errors.WithMessage(err, "failed to stop daemon")
+	}
+
+	if err := history.Rollback(ctx, snapshot, currentTag, true); err != nil {
+		if startErr := startDaemon(ctx, scope); startErr != nil {
+			log.Printf("Failed to start daemon: %v\n", startErr)
+		}
+
+		return errors.WithMessage(err, "failed to roll back")
+	}
+
+	fmt.Println("Starting daemon...")
+	if err := startDaemon(ctx, scope); err != nil {
+		return errors.WithMessagef(
+			err, "daemon %s failed to start, 'daemon rollback' restores the release installed before", snapshot.Name(),
+		)
+	}
+
+	updateDaemonStateVersion(ctx, snapshot.Tag)
+
+	fmt.Println("Rolled back successfully")
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package doctor
+
+import (
+	"log"
+
+	daemonpkg "github.com/gameap/gameapctl/internal/pkg/daemon"
+	"github.com/gameap/gameapctl/internal/pkg/doctor"
+	"github.com/gameap/gameapctl/internal/pkg/output"
+	panelpkg "github.com/gameap/gameapctl/internal/pkg/panel"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+// Handle diagnoses every GameAP component installed on this host.
+func Handle(cliCtx *cli.Context) error {
+	ctx := cliCtx.Context
+
+	registry := doctor.NewRegistry()
+
+	// SELinux can only be fixed for a system scope installation; the panel scope
+	// takes precedence when both components are installed.
+	scope := ""
+
+	panelPaths, err := panelpkg.ResolveScope(ctx, "")
+	if err != nil {
+		log.Println(errors.WithMessage(err, "failed to resolve panel installation scope"))
+	} else if panelpkg.IsInstalled(panelPaths) {
+		registry.Register(panelpkg.Checks(panelPaths)...)
+		scope = panelPaths.Scope
+	}
+
+	daemonScope, daemonPaths, err := daemonpkg.ResolvePaths(ctx)
+	if err != nil {
+		log.Println(err)
+	} else if daemonpkg.IsInstalled(daemonPaths) {
+		registry.Register(daemonpkg.Checks(daemonScope, daemonPaths)...)
+		if scope == "" {
+			scope = daemonScope
+		}
+	}
+
+	if len(registry.Checks()) == 0 {
+		return errors.New("neither panel nor daemon installation found")
+	}
+
+	registry.Register(doctor.SystemChecks(scope)...)
+
+	return Run(cliCtx, registry)
+}
+
+// Run executes the registered checks, prints the report and fails when any check failed.
+func Run(cliCtx *cli.Context, registry *doctor.Registry) error {
+	report := registry.Run(cliCtx.Context, doctor.Options{Fix: cliCtx.Bool("fix")})
+
+	if err := output.Print(cliCtx, report); err != nil {
+		return err
+	}
+
+	if report.Failed() {
+		return errors.Errorf("%d of %d checks failed", report.Summary.Fail, len(report.Results))
+	}
+
+	return nil
+}
@@ -0,0 +1,346 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Entries of the backup archive.
+const (
+	manifestEntry  = "manifest.json"
+	configEntry    = "config.env"
+	stateEntry     = "state/panel_install_state.json"
+	dataPrefix     = "data"
+	filesPrefix    = "files"
+	databasePrefix = "database"
+	sqliteEntry    = "database/database.sqlite"
+)
+
+// manifestVersion is increased on incompatible changes of the archive layout.
+const manifestVersion = 1
+
+type Manifest struct {
+	Version      int       `json:"version"`
+	CreatedAt    time.Time `json:"createdAt"`
+	Scope        string    `json:"scope"`
+	PanelVersion string    `json:"panelVersion,omitempty"`
+	// DatabaseDriver is the normalized DATABASE_DRIVER of the backed up panel.
+	DatabaseDriver string `json:"databaseDriver"`
+	// Tables lists the tables of a MySQL or PostgreSQL dump in the order they
+	// are restored: referenced tables first.
+	Tables []string `json:"tables,omitempty"`
+	// Files is set when the files directory is outside of the data directory
+	// and is archived on its own.
+	Files bool `json:"files,omitempty"`
+	State bool `json:"state,omitempty"`
+}
+
+type archiveWriter struct {
+	file *os.File
+	gz   *gzip.Writer
+	tw   *tar.Writer
+}
+
+func createArchive(archivePath string) (*archiveWriter, error) {
+	f, err := os.OpenFile(archivePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create archive %s", archivePath)
+	}
+
+	gz := gzip.NewWriter(f)
+
+	return &archiveWriter{file: f, gz: gz, tw: tar.NewWriter(gz)}, nil
+}
+
+func (a *archiveWriter) addBytes(name string, data []byte) error {
+	err := a.tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o600,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+		Format:  tar.FormatPAX,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to write %s header", name)
+	}
+
+	if _, err := a.tw.Write(data); err != nil {
+		return errors.Wrapf(err, "failed to write %s", name)
+	}
+
+	return nil
+}
+
+func (a *archiveWriter) addJSON(name string, v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal %s", name)
+	}
+
+	return a.addBytes(name, b)
+}
+
+func (a *archiveWriter) addFile(name, src string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return errors.Wrapf(err, "failed to stat %s", src)
+	}
+
+	return a.addEntry(name, src, info)
+}
+
+// addDir adds the directory tree under prefix. Paths for which skip returns
+// true are left out.
+func (a *archiveWriter) addDir(prefix, dir string, skip func(path string) bool) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s", p)
+		}
+
+		if skip != nil && skip(p) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return errors.Wrapf(err, "failed to resolve %s", p)
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return errors.Wrapf(err, "failed to stat %s", p)
+		}
+
+		return a.addEntry(path.Join(prefix, filepath.ToSlash(rel)), p, info)
+	})
+}
+
+func (a *archiveWriter) addEntry(name, src string, info fs.FileInfo) error {
+	var link string
+
+	switch {
+	case info.Mode()&fs.ModeSymlink != 0:
+		target, err := os.Readlink(src)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read link %s", src)
+		}
+		link = target
+	case info.IsDir(), info.Mode().IsRegular():
+	default:
+		// Sockets, pipes and devices cannot be restored meaningfully.
+		return nil
+	}
+
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create header for %s", src)
+	}
+	header.Name = name
+	if info.IsDir() {
+		header.Name += "/"
+	}
+	header.Format = tar.FormatPAX
+
+	if err := a.tw.WriteHeader(header); err != nil {
+		return errors.Wrapf(err, "failed to write %s header", name)
+	}
+
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	f, err := os.Open(src)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %s", src)
+	}
+	defer func() { _ = f.Close() }()
+
+	// The size is fixed by the header, a file growing meanwhile is cut.
+	if _, err := io.CopyN(a.tw, f, header.Size); err != nil {
+		return errors.Wrapf(err, "failed to archive %s", src)
+	}
+
+	return nil
+}
+
+func (a *archiveWriter) Close() error {
+	if err := a.tw.Close(); err != nil {
+		_ = a.file.Close()
+
+		return errors.Wrap(err, "failed to finish tar")
+	}
+
+	if err := a.gz.Close(); err != nil {
+		_ = a.file.Close()
+
+		return errors.Wrap(err, "failed to finish gzip")
+	}
+
+	return errors.Wrap(a.file.Close(), "failed to close archive")
+}
+
+// extractArchive unpacks the archive into dir and returns its manifest.
+func extractArchive(archivePath, dir string) (Manifest, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return Manifest{}, errors.Wrapf(err, "failed to open archive %s", archivePath)
+	}
+	defer func() { _ = f.Close() }()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return Manifest{}, errors.Wrap(err, "failed to read archive")
+	}
+	defer func() { _ = gz.Close() }()
+
+	tr := tar.NewReader(gz)
+
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Manifest{}, errors.Wrap(err, "failed to read archive")
+		}
+
+		if err := extractEntry(tr, header, dir); err != nil {
+			return Manifest{}, err
+		}
+	}
+
+	return readManifest(filepath.Join(dir, manifestEntry))
+}
+
+func extractEntry(r io.Reader, header *tar.Header, dir string) error {
+	name := path.Clean(header.Name)
+	if name == "." || path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+		return errors.Errorf("unsafe path %q in archive", header.Name)
+	}
+
+	target := filepath.Join(dir, filepath.FromSlash(name))
+	mode := fs.FileMode(header.Mode) & fs.ModePerm //nolint:gosec
+
+	switch header.Typeflag {
+	case tar.TypeDir:
+		return mkdirInside(dir, target, mode|0o700)
+	case tar.TypeSymlink:
+		// A link leading out of the restore directory would let the
+		// following entries, or the restore itself, write anywhere.
+		if !linkInside(name, header.Linkname) {
+			log.Printf("Skipping link %s to %s outside of the backup\n", name, header.Linkname)
+
+			return nil
+		}
+
+		if err := mkdirInside(dir, filepath.Dir(target), 0o700); err != nil {
+			return err
+		}
+
+		return errors.Wrapf(os.Symlink(header.Linkname, target), "failed to create link %s", target)
+	case tar.TypeReg:
+		if err := mkdirInside(dir, filepath.Dir(target), 0o700); err != nil {
+			return err
+		}
+
+		if info, err := os.Lstat(target); err == nil && info.Mode()&fs.ModeSymlink != 0 {
+			return errors.Errorf("refusing to write %s through a link", target)
+		}
+
+		f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create %s", target)
+		}
+
+		// The entry sizes are not limited: a backup is as large as the
+		// panel data it was made of.
+		if _, err := io.Copy(f, r); err != nil { //nolint:gosec
+			_ = f.Close()
+
+			return errors.Wrapf(err, "failed to extract %s", target)
+		}
+
+		return errors.Wrapf(f.Close(), "failed to close %s", target)
+	default:
+		return nil
+	}
+}
+
+// linkInside reports whether the relative link target of the entry stays in
+// the archive root.
+func linkInside(name, linkname string) bool {
+	if linkname == "" || path.IsAbs(linkname) || filepath.IsAbs(linkname) {
+		return false
+	}
+
+	resolved := path.Join(path.Dir(name), filepath.ToSlash(linkname))
+
+	return resolved != ".." && !strings.HasPrefix(resolved, "../")
+}
+
+// mkdirInside creates the directory p inside dir. An existing path component
+// must be a real directory, a link made by an earlier entry is refused.
+func mkdirInside(dir, p string, mode fs.FileMode) error {
+	rel, err := filepath.Rel(dir, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return errors.Errorf("path %s is outside of %s", p, dir)
+	}
+
+	current := dir
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		if part == "." {
+			continue
+		}
+		current = filepath.Join(current, part)
+
+		info, err := os.Lstat(current)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			if err := os.Mkdir(current, mode); err != nil {
+				return errors.Wrapf(err, "failed to create %s", current)
+			}
+		case err != nil:
+			return errors.Wrapf(err, "failed to stat %s", current)
+		case info.Mode()&fs.ModeSymlink != 0:
+			return errors.Errorf("refusing to write %s through a link", current)
+		case !info.IsDir():
+			return errors.Errorf("%s is not a directory", current)
+		}
+	}
+
+	return nil
+}
+
+func readManifest(manifestPath string) (Manifest, error) {
+	var m Manifest
+
+	b, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return m, errors.Wrap(err, "failed to read manifest, the file is not a panel backup")
+	}
+
+	if err := json.Unmarshal(b, &m); err != nil {
+		return m, errors.Wrap(err, "failed to parse manifest")
+	}
+
+	if m.Version != manifestVersion {
+		return m, errors.Errorf("unsupported backup version %d, expected %d", m.Version, manifestVersion)
+	}
+
+	return m, nil
+}
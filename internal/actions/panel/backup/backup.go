@@ -0,0 +1,258 @@
+// Package backup implements 'panel backup' and 'panel restore'. A backup is a
+// tar.gz archive with config.env, the data and files directories, the install
+// state and a dump of the panel database.
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gameap/gameapctl/internal/pkg/gameapctl"
+	"github.com/gameap/gameapctl/internal/pkg/output"
+	panelpkg "github.com/gameap/gameapctl/internal/pkg/panel"
+	"github.com/gameap/gameapctl/pkg/dryrun"
+	"github.com/gameap/gameapctl/pkg/gameap"
+	"github.com/gameap/gameapctl/pkg/progress"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+type Result struct {
+	Path           string `json:"path"`
+	Size           int64  `json:"size"`
+	DatabaseDriver string `json:"databaseDriver"`
+}
+
+func (r Result) WriteText(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "Backup saved to %s (%d bytes)\n", r.Path, r.Size)
+
+	return errors.Wrap(err, "failed to write result")
+}
+
+// Handle creates the backup archive, by default gameap-backup-<time>.tar.gz in
+// the working directory. The panel keeps running: the database dump is taken
+// in a single transaction, SQLite is copied with VACUUM INTO.
+func Handle(cliCtx *cli.Context) (err error) {
+	ctx := cliCtx.Context
+
+	paths, err := panelpkg.ResolveScope(ctx, cliCtx.String("scope"))
+	if err != nil {
+		return err
+	}
+
+	archivePath := cliCtx.Args().First()
+	if archivePath == "" {
+		archivePath = "gameap-backup-" + time.Now().Format("20060102-150405") + ".tar.gz"
+	}
+
+	archivePath, err = filepath.Abs(archivePath)
+	if err != nil {
+		return errors.Wrap(err, "failed to resolve archive path")
+	}
+
+	_, values, err := panelpkg.ReadConfigEnv(paths.ConfigFilePath)
+	if err != nil {
+		return err
+	}
+
+	driver, err := panelpkg.DatabaseDriver(values["DATABASE_DRIVER"])
+	if err != nil {
+		return err
+	}
+
+	if dryrun.Record(ctx, dryrun.KindFile, "create panel backup %s", archivePath) {
+		return nil
+	}
+
+	staging, err := os.MkdirTemp("", "gameap-backup")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temporary directory")
+	}
+	defer func() {
+		if err := os.RemoveAll(staging); err != nil {
+			log.Printf("Failed to remove temporary directory: %v\n", err)
+		}
+	}()
+
+	steps := progress.Steps(ctx, 2) //nolint:mnd
+	defer func() { steps.End(err) }()
+
+	manifest := Manifest{
+		Version:        manifestVersion,
+		CreatedAt:      time.Now().UTC(),
+		Scope:          gameap.ScopeOrDefault(paths.Scope),
+		DatabaseDriver: driver,
+	}
+
+	steps.Next("backup.database", "database dump")
+
+	log.Printf("Dumping %s database ...\n", driver)
+
+	manifest.Tables, err = dumpDatabaseTo(ctx, driver, values["DATABASE_URL"], filepath.Join(staging, databasePrefix))
+	if err != nil {
+		return errors.WithMessage(err, "failed to dump database")
+	}
+
+	steps.Next("backup.archive", "backup archive")
+
+	log.Printf("Writing %s ...\n", archivePath)
+
+	if err := writeArchive(ctx, archivePath, staging, paths, values, &manifest); err != nil {
+		if removeErr := os.Remove(archivePath); removeErr != nil && !errors.Is(removeErr, os.ErrNotExist) {
+			log.Printf("Failed to remove incomplete archive: %v\n", removeErr)
+		}
+
+		return err
+	}
+
+	info, err := os.Stat(archivePath)
+	if err != nil {
+		return errors.Wrap(err, "failed to stat archive")
+	}
+
+	return output.Print(cliCtx, Result{Path: archivePath, Size: info.Size(), DatabaseDriver: driver})
+}
+
+func dumpDatabaseTo(ctx context.Context, driver, dsn, dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, errors.Wrapf(err, "failed to create %s", dir)
+	}
+
+	if driver == panelpkg.DatabaseSQLite {
+		return nil, snapshotSQLite(ctx, dsn, filepath.Join(dir, filepath.Base(sqliteEntry)))
+	}
+
+	db, err := panelpkg.OpenDatabase(ctx, driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = db.Close() }()
+
+	return dumpDatabase(ctx, db, driver, dir)
+}
+
+func writeArchive(
+	ctx context.Context,
+	archivePath, staging string,
+	paths gameap.PanelPaths,
+	values map[string]string,
+	manifest *Manifest,
+) error {
+	state, stateErr := gameapctl.LoadPanelInstallState(ctx)
+	if stateErr == nil {
+		manifest.State = true
+		manifest.PanelVersion = state.Version
+	}
+
+	filesDir := filesDirectory(paths, values)
+	manifest.Files = filesDir != "" && !isWithin(filesDir, paths.DataDir) && dirExists(filesDir)
+
+	a, err := createArchive(archivePath)
+	if err != nil {
+		return err
+	}
+
+	if err := writeEntries(a, staging, paths, values, manifest, state, filesDir); err != nil {
+		_ = a.Close()
+
+		return err
+	}
+
+	return a.Close()
+}
+
+func writeEntries(
+	a *archiveWriter,
+	staging string,
+	paths gameap.PanelPaths,
+	values map[string]string,
+	manifest *Manifest,
+	state gameapctl.PanelInstallState,
+	filesDir string,
+) error {
+	if err := a.addJSON(manifestEntry, manifest); err != nil {
+		return err
+	}
+
+	if err := a.addFile(configEntry, paths.ConfigFilePath); err != nil {
+		return err
+	}
+
+	// The passwords are not needed to restore: config.env keeps the database
+	// credentials, the admin password is in the database.
+	if manifest.State {
+		if err := a.addJSON(stateEntry, state.WithoutSecrets()); err != nil {
+			return err
+		}
+	}
+
+	if err := a.addDir(databasePrefix, filepath.Join(staging, databasePrefix), nil); err != nil {
+		return err
+	}
+
+	// The live SQLite database is inconsistent while the panel writes to it,
+	// the snapshot in database/ replaces it.
+	var skip func(string) bool
+	if manifest.DatabaseDriver == panelpkg.DatabaseSQLite {
+		dbPath := panelpkg.SQLitePath(values["DATABASE_URL"])
+		skip = func(p string) bool {
+			return p == dbPath || slices.ContainsFunc(sqliteSidecars, func(suffix string) bool {
+				return p == dbPath+suffix
+			})
+		}
+	}
+
+	if dirExists(paths.DataDir) {
+		if err := a.addDir(dataPrefix, paths.DataDir, skip); err != nil {
+			return err
+		}
+	}
+
+	if manifest.Files {
+		if err := a.addDir(filesPrefix, filesDir, skip); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// filesDirectory is where the panel stores the uploaded files when the local
+// files driver is used.
+func filesDirectory(paths gameap.PanelPaths, values map[string]string) string {
+	if driver := values["FILES_DRIVER"]; driver != "" && driver != "local" {
+		return ""
+	}
+
+	if dir := values["FILES_LOCAL_BASE_PATH"]; dir != "" {
+		return dir
+	}
+
+	return paths.FilesBasePath
+}
+
+func isWithin(path, dir string) bool {
+	if dir == "" {
+		return false
+	}
+
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+func dirExists(path string) bool {
+	info, err := os.Stat(path)
+
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,281 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/gameap/gameapctl/internal/pkg/gameapctl"
+	panelpkg "github.com/gameap/gameapctl/internal/pkg/panel"
+	"github.com/gameap/gameapctl/pkg/gameap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchive_RoundTrip(t *testing.T) {
+	src := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "certs"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "certs", "server.crt"), []byte("cert"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "database.sqlite"), []byte("live"), 0o644))
+
+	archivePath := filepath.Join(t.TempDir(), "backup.tar.gz")
+	a, err := createArchive(archivePath)
+	require.NoError(t, err)
+
+	manifest := Manifest{Version: manifestVersion, DatabaseDriver: panelpkg.DatabaseSQLite, Scope: "system"}
+	require.NoError(t, a.addJSON(manifestEntry, manifest))
+	require.NoError(t, a.addBytes(configEntry, []byte("HTTP_PORT=80\n")))
+	require.NoError(t, a.addDir(dataPrefix, src, func(p string) bool {
+		return p == filepath.Join(src, "database.sqlite")
+	}))
+	require.NoError(t, a.Close())
+
+	dst := t.TempDir()
+	got, err := extractArchive(archivePath, dst)
+	require.NoError(t, err)
+
+	assert.Equal(t, manifest, got)
+	assert.FileExists(t, filepath.Join(dst, configEntry))
+	assert.NoFileExists(t, filepath.Join(dst, dataPrefix, "database.sqlite"))
+
+	content, err := os.ReadFile(filepath.Join(dst, dataPrefix, "certs", "server.crt"))
+	require.NoError(t, err)
+	assert.Equal(t, "cert", string(content))
+}
+
+func TestWriteArchive_StateWithoutSecrets(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	ctx := context.Background()
+	require.NoError(t, gameapctl.SavePanelInstallState(ctx, gameapctl.PanelInstallState{
+		Version:        "v4.2.0",
+		Host:           "panel.example.com",
+		DBPassword:     "db-secret",
+		DBRootPassword: "root-secret",
+		AdminPassword:  "admin-secret",
+	}))
+
+	configDir := t.TempDir()
+	paths := gameap.PanelPaths{
+		ConfigFilePath: filepath.Join(configDir, "config.env"),
+		DataDir:        filepath.Join(t.TempDir(), "missing"),
+	}
+	require.NoError(t, os.WriteFile(paths.ConfigFilePath, []byte("HTTP_PORT=80\n"), 0o600))
+
+	staging := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(staging, databasePrefix), 0o700))
+
+	archivePath := filepath.Join(t.TempDir(), "backup.tar.gz")
+	manifest := Manifest{Version: manifestVersion, DatabaseDriver: panelpkg.DatabasePostgres}
+	require.NoError(t, writeArchive(ctx, archivePath, staging, paths, map[string]string{}, &manifest))
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(archivePath)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	}
+
+	dst := t.TempDir()
+	_, err := extractArchive(archivePath, dst)
+	require.NoError(t, err)
+
+	raw, err := os.ReadFile(filepath.Join(dst, filepath.FromSlash(stateEntry)))
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), "panel.example.com")
+	assert.NotContains(t, string(raw), "secret")
+	assert.NotContains(t, string(raw), "enc:v1:")
+}
+
+func TestExtractArchive_UnsafePath(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "evil.tar.gz")
+
+	f, err := os.Create(archivePath)
+	require.NoError(t, err)
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "../escape", Mode: 0o600, Size: 1, Typeflag: tar.TypeReg}))
+	_, err = tw.Write([]byte("x"))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	require.NoError(t, f.Close())
+
+	_, err = extractArchive(archivePath, t.TempDir())
+	assert.EqualError(t, err, `unsafe path "../escape" in archive`)
+}
+
+func writeTestArchive(t *testing.T, headers ...*tar.Header) string {
+	t.Helper()
+
+	archivePath := filepath.Join(t.TempDir(), "evil.tar.gz")
+
+	f, err := os.Create(archivePath)
+	require.NoError(t, err)
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for _, header := range headers {
+		require.NoError(t, tw.WriteHeader(header))
+		if header.Size > 0 {
+			_, err = tw.Write(bytes.Repeat([]byte("x"), int(header.Size)))
+			require.NoError(t, err)
+		}
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	require.NoError(t, f.Close())
+
+	return archivePath
+}
+
+func TestExtractArchive_LinkOutside(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need privileges on Windows")
+	}
+
+	outside := t.TempDir()
+	archivePath := writeTestArchive(t,
+		&tar.Header{Name: "data/evil", Linkname: outside, Typeflag: tar.TypeSymlink},
+		&tar.Header{Name: "data/evil/file", Mode: 0o600, Size: 1, Typeflag: tar.TypeReg},
+	)
+
+	dst := t.TempDir()
+	_, err := extractArchive(archivePath, dst)
+	require.ErrorContains(t, err, "failed to read manifest")
+
+	assert.NoFileExists(t, filepath.Join(outside, "file"))
+	assert.FileExists(t, filepath.Join(dst, "data", "evil", "file"))
+}
+
+func TestExtractArchive_WriteThroughLink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need privileges on Windows")
+	}
+
+	archivePath := writeTestArchive(t,
+		&tar.Header{Name: "files/public", Mode: 0o700, Typeflag: tar.TypeDir},
+		&tar.Header{Name: "data/link", Linkname: "../files/public", Typeflag: tar.TypeSymlink},
+		&tar.Header{Name: "data/link/file", Mode: 0o600, Size: 1, Typeflag: tar.TypeReg},
+	)
+
+	_, err := extractArchive(archivePath, t.TempDir())
+	require.ErrorContains(t, err, "through a link")
+}
+
+func TestEncodeDecodeValue(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 12, 30, 0, 123, time.UTC)
+
+	values := []any{nil, true, int64(42), 1.5, "text", []byte("binary\x00"), ts}
+	binary := []bool{false, false, false, false, false, true, false}
+
+	row := make([]any, len(values))
+	for i, v := range values {
+		row[i] = encodeValue(v, binary[i])
+	}
+
+	b, err := json.Marshal(row)
+	require.NoError(t, err)
+
+	var decoded []any
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	require.NoError(t, dec.Decode(&decoded))
+
+	for i, v := range decoded {
+		got, err := decodeValue(v)
+		require.NoError(t, err)
+
+		if want, ok := values[i].(time.Time); ok {
+			assert.True(t, want.Equal(got.(time.Time)))
+
+			continue
+		}
+
+		assert.Equal(t, values[i], got)
+	}
+
+	assert.Equal(t, "text", encodeValue([]byte("text"), false))
+}
+
+func TestMergeConfig(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "backup.env")
+	dst := filepath.Join(dir, "config.env")
+
+	require.NoError(t, os.WriteFile(src, []byte(
+		"HTTP_PORT=8080\nDATABASE_DRIVER=sqlite\nDATABASE_URL=file:/old/database.sqlite\n"+
+			"ENCRYPTION_KEY=old-key\nAUTH_SECRET=old-secret\n",
+	), 0o600))
+	require.NoError(t, os.WriteFile(dst, []byte(
+		"# Server\nHTTP_PORT=80\nDATABASE_DRIVER=sqlite\nDATABASE_URL=file:/new/database.sqlite\n"+
+			"ENCRYPTION_KEY=new-key\n",
+	), 0o600))
+
+	values, err := mergeConfig(src, dst)
+	require.NoError(t, err)
+
+	assert.Equal(t, "8080", values["HTTP_PORT"])
+	assert.Equal(t, "file:/new/database.sqlite", values["DATABASE_URL"])
+	assert.Equal(t, "old-key", values["ENCRYPTION_KEY"])
+
+	content, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t,
+		"# Server\nHTTP_PORT=8080\nDATABASE_DRIVER=sqlite\nDATABASE_URL=file:/new/database.sqlite\n"+
+			"ENCRYPTION_KEY=old-key\nAUTH_SECRET=old-secret",
+		string(content),
+	)
+}
+
+func TestSQLite_SnapshotAndRestore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	srcDSN := "file:" + filepath.Join(dir, "src.sqlite")
+	db, err := panelpkg.OpenDatabase(ctx, panelpkg.DatabaseSQLite, srcDSN)
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "CREATE TABLE users (id INTEGER PRIMARY KEY, login TEXT)")
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "INSERT INTO users (login) VALUES ('admin')")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	snapshot := filepath.Join(dir, "snapshot.sqlite")
+	require.NoError(t, snapshotSQLite(ctx, srcDSN, snapshot))
+
+	dstPath := filepath.Join(dir, "data", "database.sqlite")
+	require.NoError(t, os.MkdirAll(filepath.Dir(dstPath), 0o755))
+	require.NoError(t, os.WriteFile(dstPath+"-wal", []byte("stale"), 0o600))
+
+	dstDSN := "file:" + dstPath + "?_pragma=busy_timeout(5000)"
+	require.NoError(t, restoreSQLite(snapshot, dstDSN))
+	assert.NoFileExists(t, dstPath+"-wal")
+
+	db, err = panelpkg.OpenDatabase(ctx, panelpkg.DatabaseSQLite, dstDSN)
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	var login string
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT login FROM users WHERE id = 1").Scan(&login))
+	assert.Equal(t, "admin", login)
+}
+
+func TestCheckManifest(t *testing.T) {
+	assert.NoError(t, checkManifest(Manifest{DatabaseDriver: "mysql", Tables: []string{"users"}}, "mysql"))
+	assert.EqualError(t,
+		checkManifest(Manifest{DatabaseDriver: "mysql"}, "sqlite"),
+		"the backup contains a mysql database, the panel uses sqlite: install the panel with the mysql database",
+	)
+	assert.EqualError(t,
+		checkManifest(Manifest{DatabaseDriver: "mysql", Tables: []string{"../users"}}, "mysql"),
+		`invalid table name "../users" in manifest`,
+	)
+}
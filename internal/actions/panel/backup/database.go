@@ -0,0 +1,345 @@
+package backup
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	panelpkg "github.com/gameap/gameapctl/internal/pkg/panel"
+	"github.com/pkg/errors"
+)
+
+// MySQL and PostgreSQL are dumped logically: every table goes to its own
+// database/<table>.jsonl file, a header line with the columns followed by a
+// JSON array per row. Restoring needs the schema created by the panel
+// migrations, so the dump is loaded into a fresh installation of the same or
+// a newer panel version.
+
+type tableHeader struct {
+	Columns []string `json:"columns"`
+}
+
+// Values that JSON cannot carry losslessly are wrapped into objects.
+type taggedValue struct {
+	Bytes *string `json:"b64,omitempty"`
+	Time  *string `json:"time,omitempty"`
+}
+
+// binaryTypes are the column types whose values are written as base64, other
+// []byte values are text in the column charset.
+var binaryTypes = []string{
+	"BINARY", "VARBINARY", "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "BIT", "BYTEA",
+}
+
+// snapshotSQLite writes a consistent copy of the SQLite database to dst.
+// VACUUM INTO works on a live database, unlike copying the file.
+func snapshotSQLite(ctx context.Context, dsn, dst string) error {
+	db, err := panelpkg.OpenDatabase(ctx, panelpkg.DatabaseSQLite, dsn)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
+
+	if _, err := db.ExecContext(ctx, "VACUUM INTO ?", dst); err != nil {
+		return errors.Wrap(err, "failed to snapshot sqlite database")
+	}
+
+	return nil
+}
+
+// restoreSQLite replaces the SQLite database with the snapshot from the backup.
+func restoreSQLite(snapshot, dsn string) error {
+	dbPath := panelpkg.SQLitePath(dsn)
+	if dbPath == "" || dbPath == ":memory:" {
+		return errors.Errorf("invalid sqlite DATABASE_URL %q", dsn)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0o755); err != nil {
+		return errors.Wrapf(err, "failed to create %s", filepath.Dir(dbPath))
+	}
+
+	// The journal of the replaced database would be applied to the snapshot.
+	for _, suffix := range sqliteSidecars {
+		if err := os.Remove(dbPath + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return errors.Wrapf(err, "failed to remove %s", dbPath+suffix)
+		}
+	}
+
+	if err := copyFile(snapshot, dbPath); err != nil {
+		return errors.WithMessage(err, "failed to restore sqlite database")
+	}
+
+	return nil
+}
+
+var sqliteSidecars = []string{"-wal", "-shm", "-journal"}
+
+// dumpDatabase dumps all tables of the current schema into dir and returns the
+// table names in restore order.
+func dumpDatabase(ctx context.Context, db *sql.DB, driver, dir string) ([]string, error) {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to begin transaction")
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	tables, err := panelpkg.ListTables(ctx, tx, driver)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, table := range tables {
+		if err := dumpTable(ctx, tx, driver, table, filepath.Join(dir, table+".jsonl")); err != nil {
+			return nil, errors.WithMessagef(err, "failed to dump table %s", table)
+		}
+	}
+
+	return tables, nil
+}
+
+func dumpTable(ctx context.Context, tx *sql.Tx, driver, table, dst string) error {
+	rows, err := tx.QueryContext(ctx, "SELECT * FROM "+panelpkg.QuoteIdent(driver, table)) //nolint:gosec
+	if err != nil {
+		return errors.Wrap(err, "failed to select rows")
+	}
+	defer func() { _ = rows.Close() }()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return errors.Wrap(err, "failed to get column types")
+	}
+
+	header := tableHeader{Columns: make([]string, 0, len(columnTypes))}
+	binary := make([]bool, 0, len(columnTypes))
+
+	for _, ct := range columnTypes {
+		header.Columns = append(header.Columns, ct.Name())
+		binary = append(binary, slices.Contains(binaryTypes, strings.ToUpper(ct.DatabaseTypeName())))
+	}
+
+	f, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create %s", dst)
+	}
+	defer func() { _ = f.Close() }()
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+
+	if err := enc.Encode(header); err != nil {
+		return errors.Wrap(err, "failed to write header")
+	}
+
+	values := make([]any, len(columnTypes))
+	pointers := make([]any, len(columnTypes))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return errors.Wrap(err, "failed to scan row")
+		}
+
+		row := make([]any, len(values))
+		for i, v := range values {
+			row[i] = encodeValue(v, binary[i])
+		}
+
+		if err := enc.Encode(row); err != nil {
+			return errors.Wrap(err, "failed to write row")
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return errors.Wrap(err, "failed to read rows")
+	}
+
+	if err := w.Flush(); err != nil {
+		return errors.Wrapf(err, "failed to write %s", dst)
+	}
+
+	return errors.Wrapf(f.Close(), "failed to close %s", dst)
+}
+
+// restoreDatabase replaces the content of the tables with the dump in dir. It
+// runs in a single transaction, a failed restore leaves the database as it was.
+func restoreDatabase(ctx context.Context, db *sql.DB, driver, dir string, tables []string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	existing, err := panelpkg.ListTables(ctx, tx, driver)
+	if err != nil {
+		return err
+	}
+
+	for _, table := range tables {
+		if !slices.Contains(existing, table) {
+			return errors.Errorf(
+				"table %s from the backup does not exist in the database, "+
+					"install or upgrade the panel to the version of the backup first", table,
+			)
+		}
+	}
+
+	if err := panelpkg.DeferForeignKeys(ctx, tx, driver); err != nil {
+		return err
+	}
+
+	for _, table := range slices.Backward(tables) {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+panelpkg.QuoteIdent(driver, table)); err != nil { //nolint:gosec
+			return errors.Wrapf(err, "failed to clear table %s", table)
+		}
+	}
+
+	for _, table := range tables {
+		if err := loadTable(ctx, tx, driver, table, filepath.Join(dir, table+".jsonl")); err != nil {
+			return errors.WithMessagef(err, "failed to restore table %s", table)
+		}
+	}
+
+	if err := panelpkg.RestoreForeignKeys(ctx, tx, driver); err != nil {
+		return err
+	}
+
+	if err := panelpkg.ResetSequences(ctx, tx, driver); err != nil {
+		return err
+	}
+
+	return errors.Wrap(tx.Commit(), "failed to commit transaction")
+}
+
+func loadTable(ctx context.Context, tx *sql.Tx, driver, table, src string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %s", src)
+	}
+	defer func() { _ = f.Close() }()
+
+	dec := json.NewDecoder(bufio.NewReader(f))
+	dec.UseNumber()
+
+	var header tableHeader
+	if err := dec.Decode(&header); err != nil {
+		return errors.Wrap(err, "failed to read header")
+	}
+
+	if len(header.Columns) == 0 {
+		return errors.New("no columns in the dump")
+	}
+
+	stmt, err := tx.PrepareContext(ctx, panelpkg.InsertQuery(driver, table, header.Columns))
+	if err != nil {
+		return errors.Wrap(err, "failed to prepare insert")
+	}
+	defer func() { _ = stmt.Close() }()
+
+	for {
+		var row []any
+		err := dec.Decode(&row)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "failed to read row")
+		}
+
+		if len(row) != len(header.Columns) {
+			return errors.Errorf("row has %d values, expected %d", len(row), len(header.Columns))
+		}
+
+		args := make([]any, len(row))
+		for i, v := range row {
+			if args[i], err = decodeValue(v); err != nil {
+				return errors.WithMessagef(err, "invalid value of %s", header.Columns[i])
+			}
+		}
+
+		if _, err := stmt.ExecContext(ctx, args...); err != nil {
+			return errors.Wrap(err, "failed to insert row")
+		}
+	}
+}
+
+func encodeValue(v any, binary bool) any {
+	switch value := v.(type) {
+	case nil, bool, int64, float64, string:
+		return value
+	case []byte:
+		if !binary {
+			return string(value)
+		}
+
+		s := base64.StdEncoding.EncodeToString(value)
+
+		return taggedValue{Bytes: &s}
+	case time.Time:
+		s := value.Format(time.RFC3339Nano)
+
+		return taggedValue{Time: &s}
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+func decodeValue(v any) (any, error) {
+	switch value := v.(type) {
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return i, nil
+		}
+
+		f, err := value.Float64()
+
+		return f, errors.Wrapf(err, "invalid number %s", value)
+	case map[string]any:
+		if s, ok := value["b64"].(string); ok {
+			b, err := base64.StdEncoding.DecodeString(s)
+
+			return b, errors.Wrap(err, "invalid base64 value")
+		}
+
+		if s, ok := value["time"].(string); ok {
+			t, err := time.Parse(time.RFC3339Nano, s)
+
+			return t, errors.Wrap(err, "invalid time value")
+		}
+
+		return nil, errors.New("unknown value type")
+	default:
+		return value, nil
+	}
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %s", src)
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create %s", dst)
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+
+		return errors.Wrapf(err, "failed to copy %s", src)
+	}
+
+	return errors.Wrapf(out.Close(), "failed to close %s", dst)
+}
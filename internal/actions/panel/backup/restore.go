@@ -0,0 +1,293 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gameap/gameapctl/internal/pkg/gameapctl"
+	panelpkg "github.com/gameap/gameapctl/internal/pkg/panel"
+	"github.com/gameap/gameapctl/pkg/dryrun"
+	"github.com/gameap/gameapctl/pkg/gameap"
+	"github.com/gameap/gameapctl/pkg/oscore"
+	"github.com/gameap/gameapctl/pkg/panel"
+	"github.com/gameap/gameapctl/pkg/progress"
+	"github.com/gameap/gameapctl/pkg/utils"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+// keptConfigKeys describe the target installation rather than the panel
+// state, restore keeps their values from the target config.env.
+var keptConfigKeys = []string{"DATABASE_DRIVER", "DATABASE_URL", "FILES_LOCAL_BASE_PATH"}
+
+// Restore loads a backup onto an installed panel, usually a fresh one of the
+// same or a newer version. The database of the installation is replaced, the
+// data and files directories are overwritten with the archived files.
+func Restore(cliCtx *cli.Context) (err error) {
+	ctx := cliCtx.Context
+
+	if cliCtx.NArg() != 1 {
+		return errors.New("expected exactly one argument: the backup archive")
+	}
+
+	archivePath := cliCtx.Args().First()
+
+	paths, err := panelpkg.ResolveScope(ctx, cliCtx.String("scope"))
+	if err != nil {
+		return err
+	}
+
+	if err := panelpkg.CheckBinaryInstalled(paths); err != nil {
+		return errors.WithMessage(err, "install the panel before restoring a backup")
+	}
+
+	_, targetValues, err := panelpkg.ReadConfigEnv(paths.ConfigFilePath)
+	if err != nil {
+		return err
+	}
+
+	driver, err := panelpkg.DatabaseDriver(targetValues["DATABASE_DRIVER"])
+	if err != nil {
+		return err
+	}
+
+	staging, err := os.MkdirTemp("", "gameap-restore")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temporary directory")
+	}
+	defer func() {
+		if err := os.RemoveAll(staging); err != nil {
+			log.Printf("Failed to remove temporary directory: %v\n", err)
+		}
+	}()
+
+	log.Printf("Extracting %s ...\n", archivePath)
+
+	manifest, err := extractArchive(archivePath, staging)
+	if err != nil {
+		return err
+	}
+
+	if err := checkManifest(manifest, driver); err != nil {
+		return err
+	}
+
+	if dryrun.Enabled(ctx) {
+		dryrun.Record(ctx, dryrun.KindService, "stop gameap")
+		dryrun.Record(ctx, dryrun.KindFile, "restore config.env and files of %s to %s", archivePath, paths.DataDir)
+		dryrun.Record(ctx, dryrun.KindDatabase, "replace %s database with the backup", driver)
+		dryrun.Record(ctx, dryrun.KindService, "start gameap")
+
+		return nil
+	}
+
+	steps := progress.Steps(ctx, 5) //nolint:mnd
+	defer func() { steps.End(err) }()
+
+	steps.Next("panel.stop", "panel stop")
+
+	log.Println("Stopping GameAP ...")
+	if err := panel.Stop(ctx, panel.Options{Scope: paths.Scope}); err != nil {
+		return errors.WithMessage(err, "failed to stop GameAP")
+	}
+
+	steps.Next("backup.files", "files restore")
+
+	values, err := restoreFiles(ctx, staging, manifest, paths)
+	if err != nil {
+		return err
+	}
+
+	steps.Next("backup.database", "database restore")
+
+	log.Printf("Restoring %s database ...\n", driver)
+	if err := restoreDatabaseFrom(ctx, staging, manifest, driver, values["DATABASE_URL"]); err != nil {
+		return errors.WithMessage(err, "failed to restore database")
+	}
+
+	if gameap.ScopeOrDefault(paths.Scope) == gameap.ScopeSystem && paths.User != "" {
+		if err := chownRestored(ctx, paths, values); err != nil {
+			return err
+		}
+	}
+
+	steps.Next("panel.start", "panel start")
+
+	log.Println("Starting GameAP ...")
+	if err := panel.Start(ctx, panel.Options{Scope: paths.Scope}); err != nil {
+		return errors.WithMessage(err, "failed to start GameAP")
+	}
+
+	steps.Next("panel.health", "panel health check")
+
+	if err := panelpkg.WaitHealthy(ctx, panelpkg.HTTPPort(paths, values)); err != nil {
+		return errors.WithMessage(err, "health check failed after the restore")
+	}
+
+	fmt.Printf("Backup from %s restored\n", manifest.CreatedAt.Local().Format(time.DateTime))
+
+	return nil
+}
+
+func checkManifest(manifest Manifest, driver string) error {
+	if manifest.DatabaseDriver != driver {
+		return errors.Errorf(
+			"the backup contains a %s database, the panel uses %s: install the panel with the %s database",
+			manifest.DatabaseDriver, driver, manifest.DatabaseDriver,
+		)
+	}
+
+	for _, table := range manifest.Tables {
+		if table == "" || strings.ContainsAny(table, `/\`) || table == ".." {
+			return errors.Errorf("invalid table name %q in manifest", table)
+		}
+	}
+
+	return nil
+}
+
+// restoreFiles restores config.env, the install state and the data and files
+// directories. It returns the resulting config.env values.
+func restoreFiles(
+	ctx context.Context, staging string, manifest Manifest, paths gameap.PanelPaths,
+) (map[string]string, error) {
+	log.Printf("Restoring %s ...\n", paths.ConfigFilePath)
+
+	values, err := mergeConfig(filepath.Join(staging, configEntry), paths.ConfigFilePath)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to restore config.env")
+	}
+
+	if manifest.State {
+		if err := restoreState(ctx, filepath.Join(staging, filepath.FromSlash(stateEntry))); err != nil {
+			return nil, err
+		}
+	}
+
+	if dir := filepath.Join(staging, dataPrefix); dirExists(dir) {
+		log.Printf("Restoring %s ...\n", paths.DataDir)
+
+		if err := utils.Copy(dir, paths.DataDir); err != nil {
+			return nil, errors.Wrap(err, "failed to restore data directory")
+		}
+	}
+
+	if manifest.Files {
+		filesDir := filesDirectory(paths, values)
+		if filesDir == "" {
+			log.Println("Warning: the panel does not use the local files driver, archived files are not restored")
+
+			return values, nil
+		}
+
+		log.Printf("Restoring %s ...\n", filesDir)
+
+		if err := utils.Copy(filepath.Join(staging, filesPrefix), filesDir); err != nil {
+			return nil, errors.Wrap(err, "failed to restore files directory")
+		}
+	}
+
+	return values, nil
+}
+
+// mergeConfig writes the settings of the archived config.env into the target
+// one, except for keptConfigKeys. ENCRYPTION_KEY and AUTH_SECRET must match
+// the restored database, so they are taken from the backup.
+func mergeConfig(src, dst string) (map[string]string, error) {
+	_, backupValues, err := panelpkg.ReadConfigEnv(src)
+	if err != nil {
+		return nil, err
+	}
+
+	lines, values, err := panelpkg.ReadConfigEnv(dst)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make(map[string]string, len(backupValues))
+	for key, value := range backupValues {
+		if !slices.Contains(keptConfigKeys, key) && values[key] != value {
+			updates[key] = value
+		}
+	}
+
+	if len(updates) == 0 {
+		return values, nil
+	}
+
+	if err := panelpkg.WriteConfigEnv(dst, lines, updates); err != nil {
+		return nil, err
+	}
+
+	for key, value := range updates {
+		values[key] = value
+	}
+
+	return values, nil
+}
+
+// restoreState saves the archived install state unless the target has its own.
+func restoreState(ctx context.Context, src string) error {
+	if _, err := gameapctl.LoadPanelInstallState(ctx); err == nil {
+		return nil
+	}
+
+	b, err := os.ReadFile(src)
+	if err != nil {
+		return errors.Wrap(err, "failed to read install state")
+	}
+
+	var state gameapctl.PanelInstallState
+	if err := json.Unmarshal(b, &state); err != nil {
+		return errors.Wrap(err, "failed to parse install state")
+	}
+
+	return errors.WithMessage(gameapctl.SavePanelInstallState(ctx, state), "failed to save install state")
+}
+
+func restoreDatabaseFrom(ctx context.Context, staging string, manifest Manifest, driver, dsn string) error {
+	dir := filepath.Join(staging, databasePrefix)
+
+	if driver == panelpkg.DatabaseSQLite {
+		return restoreSQLite(filepath.Join(staging, filepath.FromSlash(sqliteEntry)), dsn)
+	}
+
+	db, err := panelpkg.OpenDatabase(ctx, driver, dsn)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
+
+	return restoreDatabase(ctx, db, driver, dir, manifest.Tables)
+}
+
+func chownRestored(ctx context.Context, paths gameap.PanelPaths, values map[string]string) error {
+	dirs := []string{paths.DataDir}
+	if filesDir := filesDirectory(paths, values); filesDir != "" && !isWithin(filesDir, paths.DataDir) {
+		dirs = append(dirs, filesDir)
+	}
+	if driver, _ := panelpkg.DatabaseDriver(values["DATABASE_DRIVER"]); driver == panelpkg.DatabaseSQLite {
+		if dbPath := panelpkg.SQLitePath(values["DATABASE_URL"]); !isWithin(dbPath, paths.DataDir) {
+			dirs = append(dirs, dbPath)
+		}
+	}
+
+	for _, dir := range dirs {
+		if !utils.IsFileExists(dir) {
+			continue
+		}
+
+		if err := oscore.ChownRecursive(ctx, dir, paths.User, paths.Group); err != nil {
+			return errors.WithMessagef(err, "failed to change owner of %s", dir)
+		}
+	}
+
+	return nil
+}
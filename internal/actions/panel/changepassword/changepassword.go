@@ -5,7 +5,6 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
@@ -14,10 +13,7 @@ import (
 	"time"
 
 	panelpkg "github.com/gameap/gameapctl/internal/pkg/panel"
-	_ "github.com/go-sql-driver/mysql"
-	_ "github.com/jackc/pgx/v5/stdlib"
-	_ "modernc.org/sqlite"
-
+	"github.com/gameap/gameapctl/internal/pkg/redact"
 	"github.com/pkg/errors"
 	"github.com/urfave/cli/v2"
 	"golang.org/x/crypto/bcrypt"
@@ -39,8 +35,15 @@ func Handle(cliCtx *cli.Context) error {
 		return errors.New("username is required as first argument")
 	}
 
-	// Get password from second argument or prompt
+	// Get password from second argument, --password (file, stdin, env) or prompt
 	password := cliCtx.Args().Get(1)
+	if password != "" {
+		redact.Register(password)
+		log.Println("Warning: the password argument is visible in the shell history and the process list, " +
+			"use --password-file or --password-stdin instead")
+	} else {
+		password = cliCtx.String("password")
+	}
 	if password == "" {
 		var err error
 		password, err = promptPassword()
@@ -93,28 +96,9 @@ func ChangePassword(ctx context.Context, username, password string, opts ...Opti
 	}
 	log.Printf("Reading config from: %s\n", configPath)
 
-	// Parse config.env file
-	config, err := parseConfig(configPath)
+	db, driver, err := panelpkg.OpenConfiguredDatabase(ctx, configPath)
 	if err != nil {
-		return errors.WithMessage(err, "failed to parse config file")
-	}
-
-	databaseDriver := config["DATABASE_DRIVER"]
-	databaseURL := config["DATABASE_URL"]
-
-	if databaseDriver == "" {
-		return errors.New("DATABASE_DRIVER not found in config.env")
-	}
-	if databaseURL == "" {
-		return errors.New("DATABASE_URL not found in config.env")
-	}
-
-	log.Printf("Connecting to database (driver: %s)...\n", databaseDriver)
-
-	// Connect to database
-	db, err := connectDatabase(databaseDriver, databaseURL)
-	if err != nil {
-		return errors.WithMessage(err, "failed to connect to database")
+		return err
 	}
 	defer func(db *sql.DB) {
 		err := db.Close()
@@ -123,17 +107,14 @@ func ChangePassword(ctx context.Context, username, password string, opts ...Opti
 		}
 	}(db)
 
-	// Determine placeholder syntax based on driver
-	isPostgres := databaseDriver == "postgres" || databaseDriver == "postgresql" || databaseDriver == "pgsql"
-
 	// Verify user exists
-	var userID int
-	selectQuery := "SELECT id FROM users WHERE login = ?"
-	if isPostgres {
-		selectQuery = "SELECT id FROM users WHERE login = $1"
-	}
+	var (
+		userID  int
+		current string
+	)
+	selectQuery := "SELECT id, password FROM users WHERE login = " + panelpkg.Placeholder(driver, 1)
 
-	err = db.QueryRowContext(ctx, selectQuery, username).Scan(&userID)
+	err = db.QueryRowContext(ctx, selectQuery, username).Scan(&userID, &current)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return errors.Errorf("user '%s' not found in database", username)
@@ -150,16 +131,23 @@ func ChangePassword(ctx context.Context, username, password string, opts ...Opti
 		return errors.WithMessage(err, "failed to hash password")
 	}
 
-	now := time.Now().UTC()
-	updateQuery := "UPDATE users SET password = ?, updated_at = ? WHERE login = ?"
-	if isPostgres {
-		updateQuery = "UPDATE users SET password = $1, updated_at = $2 WHERE login = $3"
+	// A user disabled by 'panel user disable' stays disabled with the new password.
+	newHash := string(hashedPassword)
+	if panelpkg.IsPasswordLocked(current) {
+		log.Printf("User '%s' is disabled, the new password takes effect once it is enabled\n", username)
+		newHash = panelpkg.LockedPasswordPrefix + newHash
 	}
 
+	now := time.Now().UTC()
+	updateQuery := fmt.Sprintf(
+		"UPDATE users SET password = %s, updated_at = %s WHERE login = %s",
+		panelpkg.Placeholder(driver, 1), panelpkg.Placeholder(driver, 2), panelpkg.Placeholder(driver, 3), //nolint:mnd
+	)
+
 	result, err := db.ExecContext(
 		ctx,
 		updateQuery,
-		string(hashedPassword),
+		newHash,
 		now,
 		username,
 	)
@@ -192,91 +180,6 @@ func getConfigPath() string {
 	return filepath.Join(configDir, configFileName)
 }
 
-func parseConfig(configPath string) (map[string]string, error) {
-	config := make(map[string]string)
-
-	file, err := os.Open(configPath)
-	if err != nil {
-		if errors.Is(err, fs.ErrNotExist) {
-			return nil, errors.Errorf("config file not found at %s", configPath)
-		}
-
-		return nil, errors.WithMessage(err, "failed to open config file")
-	}
-	defer func(file *os.File) {
-		err := file.Close()
-		if err != nil {
-			log.Printf("Failed to close config file: %v\n", err)
-		}
-	}(file)
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-
-		// Skip empty lines and comments
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-
-		// Parse KEY=VALUE format
-		if strings.Contains(line, "=") {
-			parts := strings.SplitN(line, "=", 2)
-			if len(parts) == 2 {
-				key := strings.TrimSpace(parts[0])
-				value := strings.TrimSpace(parts[1])
-				config[key] = value
-			}
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, errors.WithMessage(err, "failed to read config file")
-	}
-
-	return config, nil
-}
-
-func connectDatabase(driver, dsn string) (*sql.DB, error) {
-	var driverName string
-
-	switch driver {
-	case "mysql":
-		driverName = "mysql"
-		if !strings.Contains(dsn, "parseTime=") {
-			if strings.Contains(dsn, "?") {
-				dsn += "&parseTime=true"
-			} else {
-				dsn += "?parseTime=true"
-			}
-		}
-	case "postgres", "postgresql", "pgsql":
-		driverName = "pgx"
-	case "sqlite":
-		driverName = "sqlite"
-	default:
-		return nil, errors.Errorf("unsupported database driver: %s", driver)
-	}
-
-	db, err := sql.Open(driverName, dsn)
-	if err != nil {
-		return nil, errors.WithMessage(err, "failed to open database connection")
-	}
-
-	// Test the connection
-	err = db.Ping()
-	if err != nil {
-		closeErr := db.Close()
-		if closeErr != nil {
-			log.Printf("Failed to close database connection: %v\n", closeErr)
-		}
-
-		return nil, errors.WithMessage(err, "failed to ping database")
-	}
-
-	return db, nil
-}
-
 func promptPassword() (string, error) {
 	fmt.Print("Enter new password: ")
 
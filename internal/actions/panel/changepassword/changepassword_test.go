@@ -0,0 +1,47 @@
+package changepassword
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	_ "modernc.org/sqlite"
+)
+
+func TestChangePassword_KeepsLock(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	dbPath := filepath.Join(dir, "database.sqlite")
+	db, err := sql.Open("sqlite", dbPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	for _, query := range []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, login TEXT, password TEXT, updated_at DATETIME)",
+		"INSERT INTO users (id, login, password) VALUES (1, 'alice', '!old-hash'), (2, 'bob', 'old-hash')",
+	} {
+		_, err := db.Exec(query)
+		require.NoError(t, err, query)
+	}
+
+	configPath := filepath.Join(dir, "config.env")
+	require.NoError(t, os.WriteFile(configPath,
+		[]byte("DATABASE_DRIVER=sqlite\nDATABASE_URL=file:"+dbPath+"\n"), 0o600))
+
+	for login, locked := range map[string]bool{"alice": true, "bob": false} {
+		require.NoError(t, ChangePassword(ctx, login, "new-password", Options{ConfigPath: configPath}))
+
+		var hash string
+		require.NoError(t, db.QueryRow("SELECT password FROM users WHERE login = ?", login).Scan(&hash))
+
+		assert.Equal(t, locked, strings.HasPrefix(hash, "!"), login)
+		require.NoError(t, bcrypt.CompareHashAndPassword([]byte(strings.TrimPrefix(hash, "!")), []byte("new-password")))
+	}
+}
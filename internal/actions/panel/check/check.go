@@ -0,0 +1,22 @@
+package check
+
+import (
+	doctoraction "github.com/gameap/gameapctl/internal/actions/doctor"
+	"github.com/gameap/gameapctl/internal/pkg/doctor"
+	panelpkg "github.com/gameap/gameapctl/internal/pkg/panel"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+func Handle(cliCtx *cli.Context) error {
+	paths, err := panelpkg.ResolveScope(cliCtx.Context, cliCtx.String("scope"))
+	if err != nil {
+		return errors.WithMessage(err, "failed to resolve installation scope")
+	}
+
+	registry := doctor.NewRegistry()
+	registry.Register(panelpkg.Checks(paths)...)
+	registry.Register(doctor.SystemChecks(paths.Scope)...)
+
+	return doctoraction.Run(cliCtx, registry)
+}
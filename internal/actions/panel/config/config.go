@@ -0,0 +1,252 @@
+// Package config implements the 'panel config' commands editing the panel
+// config.env. Changes keep the order and the comments of the file.
+package config
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/gameap/gameapctl/internal/pkg/output"
+	panelpkg "github.com/gameap/gameapctl/internal/pkg/panel"
+	"github.com/gameap/gameapctl/pkg/dryrun"
+	"github.com/gameap/gameapctl/pkg/gameap"
+	"github.com/gameap/gameapctl/pkg/panel"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+var keyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+type Entry struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+func (e Entry) WriteText(w io.Writer) error {
+	_, err := fmt.Fprintln(w, e.Value)
+
+	return errors.Wrap(err, "failed to write value")
+}
+
+type List struct {
+	Path    string  `json:"path"`
+	Entries []Entry `json:"entries"`
+}
+
+func (l List) WriteText(w io.Writer) error {
+	for _, e := range l.Entries {
+		if _, err := fmt.Fprintf(w, "%s=%s\n", e.Key, e.Value); err != nil {
+			return errors.Wrap(err, "failed to write entries")
+		}
+	}
+
+	return nil
+}
+
+func Get(cliCtx *cli.Context) error {
+	if cliCtx.NArg() != 1 {
+		return errors.New("expected exactly one argument: the key")
+	}
+
+	key := cliCtx.Args().First()
+
+	paths, err := panelpkg.ResolveScope(cliCtx.Context, cliCtx.String("scope"))
+	if err != nil {
+		return err
+	}
+
+	_, values, err := panelpkg.ReadConfigEnv(paths.ConfigFilePath)
+	if err != nil {
+		return err
+	}
+
+	value, ok := values[key]
+	if !ok {
+		return errors.Errorf("%s is not set in %s", key, paths.ConfigFilePath)
+	}
+
+	return output.Print(cliCtx, Entry{Key: key, Value: value})
+}
+
+func ListEntries(cliCtx *cli.Context) error {
+	paths, err := panelpkg.ResolveScope(cliCtx.Context, cliCtx.String("scope"))
+	if err != nil {
+		return err
+	}
+
+	_, values, err := panelpkg.ReadConfigEnv(paths.ConfigFilePath)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	list := List{Path: paths.ConfigFilePath, Entries: make([]Entry, 0, len(keys))}
+	for _, key := range keys {
+		value := values[key]
+		if !cliCtx.Bool("show-secrets") {
+			value = maskValue(key, value)
+		}
+
+		list.Entries = append(list.Entries, Entry{Key: key, Value: value})
+	}
+
+	return output.Print(cliCtx, list)
+}
+
+// Set accepts 'KEY VALUE' or any number of 'KEY=VALUE' arguments.
+func Set(cliCtx *cli.Context) error {
+	updates, err := parseSetArgs(cliCtx.Args().Slice())
+	if err != nil {
+		return err
+	}
+
+	force := cliCtx.Bool("force")
+
+	for key, value := range updates {
+		if _, known := knownKeys[key]; !known {
+			log.Printf("Warning: %s is not a known panel setting, check the key for typos\n", key)
+		}
+
+		if force {
+			continue
+		}
+
+		if err := validateValue(key, value); err != nil {
+			return errors.WithMessage(err, "use --force to write it anyway")
+		}
+	}
+
+	return apply(cliCtx, updates)
+}
+
+func Unset(cliCtx *cli.Context) error {
+	if cliCtx.NArg() == 0 {
+		return errors.New("expected at least one key")
+	}
+
+	updates := make(map[string]string, cliCtx.NArg())
+
+	for _, key := range cliCtx.Args().Slice() {
+		if knownKeys[key].required && !cliCtx.Bool("force") {
+			return errors.Errorf("%s is required by the panel, use --force to remove it anyway", key)
+		}
+
+		updates[key] = panelpkg.ConfigEnvRemove
+	}
+
+	return apply(cliCtx, updates)
+}
+
+func parseSetArgs(args []string) (map[string]string, error) {
+	if len(args) == 2 && !strings.Contains(args[0], "=") {
+		args = []string{args[0] + "=" + args[1]}
+	}
+
+	if len(args) == 0 {
+		return nil, errors.New("expected 'KEY VALUE' or 'KEY=VALUE' arguments")
+	}
+
+	updates := make(map[string]string, len(args))
+
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			return nil, errors.Errorf("invalid argument %q, expected KEY=VALUE", arg)
+		}
+
+		key = strings.TrimSpace(key)
+		if !keyPattern.MatchString(key) {
+			return nil, errors.Errorf("invalid key %q", key)
+		}
+
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, errors.Errorf("value of %s must be a single line", key)
+		}
+
+		updates[key] = strings.TrimSpace(value)
+	}
+
+	return updates, nil
+}
+
+func apply(cliCtx *cli.Context, updates map[string]string) error {
+	ctx := cliCtx.Context
+
+	paths, err := panelpkg.ResolveScope(ctx, cliCtx.String("scope"))
+	if err != nil {
+		return err
+	}
+
+	if err := writeUpdates(ctx, paths.ConfigFilePath, updates); err != nil {
+		return err
+	}
+
+	if !cliCtx.Bool("restart") {
+		fmt.Println("Restart the panel to apply the changes: gameapctl panel restart")
+
+		return nil
+	}
+
+	return restart(ctx, paths)
+}
+
+func writeUpdates(ctx context.Context, configPath string, updates map[string]string) error {
+	keys := make([]string, 0, len(updates))
+	for key := range updates {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	if dryrun.Record(ctx, dryrun.KindFile, "update %s in %s", strings.Join(keys, ", "), configPath) {
+		return nil
+	}
+
+	lines, values, err := panelpkg.ReadConfigEnv(configPath)
+	if err != nil {
+		return err
+	}
+
+	if err := panelpkg.WriteConfigEnv(configPath, lines, updates); err != nil {
+		return errors.WithMessage(err, "failed to write config")
+	}
+
+	for _, key := range keys {
+		_, existed := values[key]
+
+		switch {
+		case updates[key] == panelpkg.ConfigEnvRemove && !existed:
+			fmt.Printf("%s is not set\n", key)
+		case updates[key] == panelpkg.ConfigEnvRemove:
+			fmt.Printf("%s removed\n", key)
+		default:
+			fmt.Printf("%s=%s\n", key, maskValue(key, updates[key]))
+		}
+	}
+
+	return nil
+}
+
+func restart(ctx context.Context, paths gameap.PanelPaths) error {
+	if err := panelpkg.CheckBinaryInstalled(paths); err != nil {
+		return err
+	}
+
+	fmt.Println("Restarting GameAP ...")
+
+	if err := panel.Restart(ctx, panel.Options{Scope: paths.Scope}); err != nil {
+		return errors.WithMessage(err, "failed to restart gameap")
+	}
+
+	return nil
+}
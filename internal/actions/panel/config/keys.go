@@ -0,0 +1,142 @@
+package config
+
+import (
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const maskedValue = "********"
+
+type keySpec struct {
+	validate func(value string) error
+	// secret values are masked by 'panel config list'.
+	secret bool
+	// required keys cannot be unset, the panel does not start without them.
+	required bool
+}
+
+// knownKeys lists the config.env keys read by the panel. Other keys (DNS
+// provider credentials, for example) are accepted with a warning.
+var knownKeys = map[string]keySpec{
+	"HTTP_HOST":                   {validate: notEmpty, required: true},
+	"HTTP_PORT":                   {validate: port, required: true},
+	"GRPC_ENABLED":                {validate: boolean},
+	"GRPC_PORT":                   {validate: port},
+	"DATABASE_DRIVER":             {validate: oneOf("mysql", "postgres", "sqlite"), required: true},
+	"DATABASE_URL":                {validate: notEmpty, secret: true, required: true},
+	"ENCRYPTION_KEY":              {validate: notEmpty, secret: true, required: true},
+	"AUTH_SECRET":                 {validate: notEmpty, secret: true, required: true},
+	"AUTH_SERVICE":                {validate: notEmpty},
+	"CACHE_DRIVER":                {validate: oneOf("inmemory", "redis")},
+	"FILES_DRIVER":                {validate: oneOf("local", "s3")},
+	"FILES_LOCAL_BASE_PATH":       {validate: notEmpty},
+	"LEGACY_PATH":                 {},
+	"GLOBAL_API_URL":              {validate: absoluteURL},
+	"ACME_ENABLED":                {validate: boolean},
+	"ACME_CHALLENGE_TYPE":         {validate: oneOf("http-01", "dns-01")},
+	"ACME_EMAIL":                  {validate: notEmpty},
+	"ACME_DOMAINS":                {validate: notEmpty},
+	"ACME_DIRECTORY_URL":          {validate: absoluteURL},
+	"ACME_DNS_PROVIDER":           {},
+	"ACME_RENEWAL_THRESHOLD":      {},
+	"ACME_RENEWAL_CHECK_INTERVAL": {},
+	"ACME_PROPAGATION_TIMEOUT":    {},
+	"ACME_STORAGE_PATH":           {},
+}
+
+// secretKeyMarkers mark the unknown keys holding credentials, such as the DNS
+// provider tokens written by 'panel letsencrypt setup'.
+var secretKeyMarkers = []string{"PASSWORD", "SECRET", "TOKEN", "KEY"}
+
+func validateValue(key, value string) error {
+	spec, ok := knownKeys[key]
+	if !ok || spec.validate == nil {
+		return nil
+	}
+
+	if err := spec.validate(value); err != nil {
+		return errors.WithMessagef(err, "invalid value for %s", key)
+	}
+
+	return nil
+}
+
+func isSecret(key string) bool {
+	if spec, ok := knownKeys[key]; ok {
+		return spec.secret
+	}
+
+	for _, marker := range secretKeyMarkers {
+		if strings.Contains(key, marker) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// maskValue hides the secret values. The password is the only secret part of
+// DATABASE_URL, the rest of it helps to see where the panel connects.
+func maskValue(key, value string) string {
+	if value == "" || !isSecret(key) {
+		return value
+	}
+
+	if key == "DATABASE_URL" {
+		if u, err := url.Parse(value); err == nil && u.User != nil {
+			if _, ok := u.User.Password(); ok {
+				return u.Redacted()
+			}
+		}
+	}
+
+	return maskedValue
+}
+
+func notEmpty(value string) error {
+	if strings.TrimSpace(value) == "" {
+		return errors.New("must not be empty")
+	}
+
+	return nil
+}
+
+func port(value string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 || n > 65535 {
+		return errors.New("must be a port number between 1 and 65535")
+	}
+
+	return nil
+}
+
+func boolean(value string) error {
+	if value != "true" && value != "false" {
+		return errors.New("must be true or false")
+	}
+
+	return nil
+}
+
+func oneOf(values ...string) func(string) error {
+	return func(value string) error {
+		if !slices.Contains(values, value) {
+			return errors.Errorf("expected one of: %s", strings.Join(values, ", "))
+		}
+
+		return nil
+	}
+}
+
+func absoluteURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return errors.New("must be an absolute URL")
+	}
+
+	return nil
+}
@@ -0,0 +1,396 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	panelpkg "github.com/gameap/gameapctl/internal/pkg/panel"
+	"github.com/pkg/errors"
+)
+
+type TableResult struct {
+	Table string `json:"table"`
+	Rows  int64  `json:"rows"`
+}
+
+type columnKind int
+
+const (
+	kindOther columnKind = iota
+	kindBool
+	kindInteger
+	kindTime
+	kindBinary
+)
+
+var integerTypes = []string{
+	"INT", "INTEGER", "TINYINT", "SMALLINT", "MEDIUMINT", "BIGINT", "INT2", "INT4", "INT8",
+}
+
+var binaryTypes = []string{
+	"BINARY", "VARBINARY", "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "BYTEA",
+}
+
+// timeLayouts are the text forms of dates found in SQLite databases.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	time.DateOnly,
+}
+
+// seedLimits are the row counts a freshly started panel leaves in the tables
+// holding user data: the admin account and nothing else. Other tables, such as
+// games, are seeded with the default content and are not checked.
+var seedLimits = map[string]int64{
+	"users":             1,
+	"dedicated_servers": 0,
+	"servers":           0,
+}
+
+var errTargetNotEmpty = errors.New("the target database already contains panel data")
+
+// checkTargetEmpty refuses a target database with more than the seed rows: its
+// tables are emptied by the copy, a wrong DATABASE_URL would wipe a live panel.
+func checkTargetEmpty(ctx context.Context, db *sql.DB, driver string) error {
+	tables, err := panelpkg.ListTables(ctx, db, driver)
+	if err != nil {
+		return errors.WithMessage(err, "failed to list target tables")
+	}
+
+	var found []string
+
+	for _, table := range tables {
+		limit, ok := seedLimits[table]
+		if !ok {
+			continue
+		}
+
+		n, err := countRows(ctx, db.QueryRowContext, driver, table)
+		if err != nil {
+			return err
+		}
+
+		if n > limit {
+			found = append(found, fmt.Sprintf("%s (%d rows)", table, n))
+		}
+	}
+
+	if len(found) > 0 {
+		return errors.WithMessagef(
+			errTargetNotEmpty, "%s; pass --force to replace its data", strings.Join(found, ", "),
+		)
+	}
+
+	return nil
+}
+
+// copyDatabase replaces the rows of the target tables with the rows of the
+// source database. The target schema must exist, the panel creates it on start.
+// All changes are made in one transaction which is committed only when the row
+// counts of every table match.
+func copyDatabase(
+	ctx context.Context,
+	src *sql.DB, srcDriver string,
+	dst *sql.DB, dstDriver string,
+) ([]TableResult, error) {
+	srcTables, err := panelpkg.ListTables(ctx, src, srcDriver)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to list source tables")
+	}
+
+	dstTables, err := panelpkg.ListTables(ctx, dst, dstDriver)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to list target tables")
+	}
+
+	var missing []string
+	for _, table := range srcTables {
+		if !slices.Contains(dstTables, table) {
+			missing = append(missing, table)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, errors.Errorf("tables %s do not exist in the target database", strings.Join(missing, ", "))
+	}
+
+	// The target order respects the target foreign keys.
+	tables := slices.DeleteFunc(dstTables, func(table string) bool {
+		return !slices.Contains(srcTables, table)
+	})
+
+	tx, err := dst.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to begin transaction")
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := panelpkg.DeferForeignKeys(ctx, tx, dstDriver); err != nil {
+		return nil, err
+	}
+
+	// Rows seeded by the panel on the first start are replaced.
+	for _, table := range slices.Backward(tables) {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+panelpkg.QuoteIdent(dstDriver, table)); err != nil { //nolint:gosec
+			return nil, errors.Wrapf(err, "failed to clear table %s", table)
+		}
+	}
+
+	results := make([]TableResult, 0, len(tables))
+
+	for _, table := range tables {
+		n, err := copyTable(ctx, src, srcDriver, tx, dstDriver, table)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed to copy table %s", table)
+		}
+
+		results = append(results, TableResult{Table: table, Rows: n})
+	}
+
+	if err := panelpkg.RestoreForeignKeys(ctx, tx, dstDriver); err != nil {
+		return nil, err
+	}
+
+	if err := panelpkg.ResetSequences(ctx, tx, dstDriver); err != nil {
+		return nil, err
+	}
+
+	if err := verifyCounts(ctx, src, srcDriver, tx, dstDriver, results); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return results, nil
+}
+
+func copyTable(
+	ctx context.Context,
+	src *sql.DB, srcDriver string,
+	tx *sql.Tx, dstDriver string,
+	table string,
+) (int64, error) {
+	kinds, err := targetColumns(ctx, tx, dstDriver, table)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := src.QueryContext(ctx, "SELECT * FROM "+panelpkg.QuoteIdent(srcDriver, table)) //nolint:gosec
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to select rows")
+	}
+	defer func() { _ = rows.Close() }()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get columns")
+	}
+
+	for _, column := range columns {
+		if _, ok := kinds[column]; !ok {
+			return 0, errors.Errorf("column %s does not exist in the target table", column)
+		}
+	}
+
+	stmt, err := tx.PrepareContext(ctx, panelpkg.InsertQuery(dstDriver, table, columns))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to prepare insert")
+	}
+	defer func() { _ = stmt.Close() }()
+
+	values := make([]any, len(columns))
+	pointers := make([]any, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	var n int64
+
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return n, errors.Wrap(err, "failed to scan row")
+		}
+
+		args := make([]any, len(values))
+		for i, v := range values {
+			if args[i], err = convertValue(v, kinds[columns[i]]); err != nil {
+				return n, errors.WithMessagef(err, "invalid value of %s", columns[i])
+			}
+		}
+
+		if _, err := stmt.ExecContext(ctx, args...); err != nil {
+			return n, errors.Wrap(err, "failed to insert row")
+		}
+
+		n++
+	}
+
+	return n, errors.Wrap(rows.Err(), "failed to read rows")
+}
+
+func targetColumns(ctx context.Context, tx *sql.Tx, driver, table string) (map[string]columnKind, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT * FROM "+panelpkg.QuoteIdent(driver, table)+" WHERE 1 = 0") //nolint:gosec
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get target columns")
+	}
+	defer func() { _ = rows.Close() }()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get target column types")
+	}
+
+	kinds := make(map[string]columnKind, len(columnTypes))
+	for _, ct := range columnTypes {
+		kinds[ct.Name()] = kindOf(ct.DatabaseTypeName())
+	}
+
+	return kinds, nil
+}
+
+func kindOf(databaseType string) columnKind {
+	t := strings.TrimPrefix(strings.ToUpper(databaseType), "UNSIGNED ")
+
+	switch {
+	case strings.HasPrefix(t, "BOOL"):
+		return kindBool
+	case slices.Contains(integerTypes, t):
+		return kindInteger
+	case strings.HasPrefix(t, "TIMESTAMP"), t == "DATETIME", t == "DATE":
+		return kindTime
+	case slices.Contains(binaryTypes, t):
+		return kindBinary
+	default:
+		return kindOther
+	}
+}
+
+// convertValue adapts a value read from the source driver to the type of the
+// target column: SQLite keeps booleans as integers and dates as text, MySQL
+// returns text as bytes.
+func convertValue(v any, kind columnKind) (any, error) {
+	if v == nil {
+		return nil, nil //nolint:nilnil
+	}
+
+	if b, ok := v.([]byte); ok && kind != kindBinary {
+		v = string(b)
+	}
+
+	switch kind {
+	case kindBool:
+		return toBool(v)
+	case kindInteger:
+		switch value := v.(type) {
+		case bool:
+			if value {
+				return int64(1), nil
+			}
+
+			return int64(0), nil
+		case string:
+			i, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+
+			return i, errors.Wrapf(err, "invalid integer %q", value)
+		}
+	case kindTime:
+		if s, ok := v.(string); ok {
+			return parseTime(s)
+		}
+	case kindBinary:
+		if s, ok := v.(string); ok {
+			return []byte(s), nil
+		}
+	case kindOther:
+		if t, ok := v.(time.Time); ok {
+			return t.UTC().Format("2006-01-02 15:04:05"), nil
+		}
+	}
+
+	return v, nil
+}
+
+func toBool(v any) (any, error) {
+	switch value := v.(type) {
+	case bool:
+		return value, nil
+	case int64:
+		return value != 0, nil
+	case float64:
+		return value != 0, nil
+	case string:
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+
+		return b, errors.Wrapf(err, "invalid boolean %q", value)
+	default:
+		return nil, errors.Errorf("invalid boolean %v", v)
+	}
+}
+
+// zeroDates are the MySQL "zero" values, they have no time.Time equivalent.
+var zeroDates = []string{"0000-00-00", "0000-00-00 00:00:00"}
+
+func parseTime(s string) (any, error) {
+	s = strings.TrimSpace(s)
+
+	if slices.Contains(zeroDates, s) || strings.HasPrefix(s, "0000-00-00 00:00:00.") {
+		return nil, nil //nolint:nilnil
+	}
+
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+
+	return nil, errors.Errorf("invalid time %q", s)
+}
+
+func verifyCounts(
+	ctx context.Context,
+	src *sql.DB, srcDriver string,
+	tx *sql.Tx, dstDriver string,
+	results []TableResult,
+) error {
+	for _, result := range results {
+		srcCount, err := countRows(ctx, src.QueryRowContext, srcDriver, result.Table)
+		if err != nil {
+			return errors.WithMessage(err, "failed to count source rows")
+		}
+
+		dstCount, err := countRows(ctx, tx.QueryRowContext, dstDriver, result.Table)
+		if err != nil {
+			return errors.WithMessage(err, "failed to count target rows")
+		}
+
+		if srcCount != dstCount || srcCount != result.Rows {
+			return errors.Errorf(
+				"row count mismatch in table %s: source %d, copied %d, target %d",
+				result.Table, srcCount, result.Rows, dstCount,
+			)
+		}
+	}
+
+	return nil
+}
+
+func countRows(
+	ctx context.Context,
+	queryRow func(ctx context.Context, query string, args ...any) *sql.Row,
+	driver, table string,
+) (int64, error) {
+	var n int64
+
+	err := queryRow(ctx, "SELECT COUNT(*) FROM "+panelpkg.QuoteIdent(driver, table)).Scan(&n) //nolint:gosec
+
+	return n, errors.Wrapf(err, "failed to count rows of %s", table)
+}
@@ -0,0 +1,175 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	panelpkg "github.com/gameap/gameapctl/internal/pkg/panel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openSQLite(t *testing.T, schema ...string) *sql.DB {
+	t.Helper()
+
+	ctx := context.Background()
+
+	db, err := panelpkg.OpenDatabase(ctx, panelpkg.DatabaseSQLite, "file:"+filepath.Join(t.TempDir(), "database.sqlite"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	for _, query := range schema {
+		_, err := db.ExecContext(ctx, query)
+		require.NoError(t, err)
+	}
+
+	return db
+}
+
+func TestCopyDatabase(t *testing.T) {
+	ctx := context.Background()
+
+	src := openSQLite(t,
+		"CREATE TABLE games (code TEXT PRIMARY KEY, name TEXT)",
+		"CREATE TABLE servers (id INTEGER PRIMARY KEY, game_id TEXT REFERENCES games (code), "+
+			"enabled INTEGER, created_at TEXT)",
+		"INSERT INTO games VALUES ('cs', 'Counter-Strike'), ('rust', 'Rust')",
+		"INSERT INTO servers VALUES (1, 'cs', 1, '2024-05-01 10:00:00'), (2, 'rust', 0, NULL)",
+	)
+
+	dst := openSQLite(t,
+		"CREATE TABLE games (code TEXT PRIMARY KEY, name TEXT)",
+		"CREATE TABLE servers (id INTEGER PRIMARY KEY, game_id TEXT REFERENCES games (code), "+
+			"enabled BOOLEAN, created_at DATETIME, extra TEXT DEFAULT 'x')",
+		"CREATE TABLE goose_db_version (id INTEGER PRIMARY KEY)",
+		"INSERT INTO games VALUES ('seeded', 'Seeded game')",
+	)
+
+	results, err := copyDatabase(ctx, src, panelpkg.DatabaseSQLite, dst, panelpkg.DatabaseSQLite)
+	require.NoError(t, err)
+
+	assert.Equal(t, []TableResult{{Table: "games", Rows: 2}, {Table: "servers", Rows: 2}}, results)
+
+	var (
+		enabled   bool
+		createdAt time.Time
+		extra     string
+	)
+	require.NoError(t, dst.QueryRowContext(ctx,
+		"SELECT enabled, created_at, extra FROM servers WHERE id = 1").Scan(&enabled, &createdAt, &extra))
+	assert.True(t, enabled)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), createdAt.UTC())
+	assert.Equal(t, "x", extra)
+
+	var seeded int
+	require.NoError(t, dst.QueryRowContext(ctx, "SELECT COUNT(*) FROM games WHERE code = 'seeded'").Scan(&seeded))
+	assert.Zero(t, seeded)
+}
+
+func TestCopyDatabase_MissingTable(t *testing.T) {
+	src := openSQLite(t, "CREATE TABLE games (code TEXT)", "CREATE TABLE nodes (id INTEGER)")
+	dst := openSQLite(t, "CREATE TABLE games (code TEXT)")
+
+	_, err := copyDatabase(context.Background(), src, panelpkg.DatabaseSQLite, dst, panelpkg.DatabaseSQLite)
+	assert.EqualError(t, err, "tables nodes do not exist in the target database")
+}
+
+func TestCopyDatabase_MissingColumnRollsBack(t *testing.T) {
+	ctx := context.Background()
+
+	src := openSQLite(t,
+		"CREATE TABLE games (code TEXT)",
+		"CREATE TABLE nodes (id INTEGER, location TEXT)",
+		"INSERT INTO games VALUES ('cs')",
+	)
+	dst := openSQLite(t,
+		"CREATE TABLE games (code TEXT)",
+		"CREATE TABLE nodes (id INTEGER)",
+		"INSERT INTO games VALUES ('seeded')",
+	)
+
+	_, err := copyDatabase(ctx, src, panelpkg.DatabaseSQLite, dst, panelpkg.DatabaseSQLite)
+	require.EqualError(t, err, "failed to copy table nodes: column location does not exist in the target table")
+
+	var code string
+	require.NoError(t, dst.QueryRowContext(ctx, "SELECT code FROM games").Scan(&code))
+	assert.Equal(t, "seeded", code)
+}
+
+func TestCheckTargetEmpty(t *testing.T) {
+	ctx := context.Background()
+
+	schema := []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, login TEXT)",
+		"CREATE TABLE servers (id INTEGER PRIMARY KEY)",
+		"CREATE TABLE games (code TEXT PRIMARY KEY)",
+		"INSERT INTO users VALUES (1, 'admin')",
+		"INSERT INTO games VALUES ('cs'), ('rust')",
+	}
+
+	t.Run("no_schema", func(t *testing.T) {
+		require.NoError(t, checkTargetEmpty(ctx, openSQLite(t), panelpkg.DatabaseSQLite))
+	})
+
+	t.Run("seed_rows", func(t *testing.T) {
+		require.NoError(t, checkTargetEmpty(ctx, openSQLite(t, schema...), panelpkg.DatabaseSQLite))
+	})
+
+	t.Run("live_panel", func(t *testing.T) {
+		db := openSQLite(t, append(schema,
+			"INSERT INTO users VALUES (2, 'operator')",
+			"INSERT INTO servers VALUES (1)",
+		)...)
+
+		err := checkTargetEmpty(ctx, db, panelpkg.DatabaseSQLite)
+		require.ErrorIs(t, err, errTargetNotEmpty)
+		assert.Contains(t, err.Error(), "users (2 rows)")
+		assert.Contains(t, err.Error(), "servers (1 rows)")
+	})
+}
+
+func TestConvertValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		kind  columnKind
+		want  any
+	}{
+		{"nil", nil, kindBool, nil},
+		{"int_to_bool", int64(1), kindBool, true},
+		{"text_to_bool", "false", kindBool, false},
+		{"bool_to_int", true, kindInteger, int64(1)},
+		{"bytes_to_int", []byte("42"), kindInteger, int64(42)},
+		{"text_to_time", "2024-05-01 10:00:00", kindTime, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+		{"mysql_zero_date", []byte("0000-00-00 00:00:00"), kindTime, nil},
+		{"mysql_zero_date_fraction", "0000-00-00 00:00:00.000000", kindTime, nil},
+		{"bytes_to_text", []byte("text"), kindOther, "text"},
+		{"text_to_binary", "raw", kindBinary, []byte("raw")},
+		{"bytes_stay_binary", []byte{0, 1}, kindBinary, []byte{0, 1}},
+		{"time_to_text", time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), kindOther, "2024-05-01 10:00:00"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := convertValue(test.value, test.kind)
+			require.NoError(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+
+	_, err := convertValue("yes please", kindBool)
+	assert.EqualError(t, err, `invalid boolean "yes please": strconv.ParseBool: parsing "yes please": invalid syntax`)
+}
+
+func TestKindOf(t *testing.T) {
+	assert.Equal(t, kindBool, kindOf("BOOL"))
+	assert.Equal(t, kindInteger, kindOf("UNSIGNED BIGINT"))
+	assert.Equal(t, kindInteger, kindOf("int8"))
+	assert.Equal(t, kindTime, kindOf("TIMESTAMPTZ"))
+	assert.Equal(t, kindBinary, kindOf("BYTEA"))
+	assert.Equal(t, kindOther, kindOf("POINT"))
+	assert.Equal(t, kindOther, kindOf("INTERVAL"))
+}
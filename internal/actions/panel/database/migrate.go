@@ -0,0 +1,302 @@
+// Package database implements 'panel database' commands.
+package database
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/gameap/gameapctl/internal/pkg/output"
+	panelpkg "github.com/gameap/gameapctl/internal/pkg/panel"
+	"github.com/gameap/gameapctl/pkg/dryrun"
+	"github.com/gameap/gameapctl/pkg/gameap"
+	"github.com/gameap/gameapctl/pkg/oscore"
+	"github.com/gameap/gameapctl/pkg/panel"
+	"github.com/gameap/gameapctl/pkg/progress"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+type MigrateResult struct {
+	From   string        `json:"from"`
+	To     string        `json:"to"`
+	Tables []TableResult `json:"tables"`
+}
+
+func (r MigrateResult) WriteText(w io.Writer) error {
+	for _, t := range r.Tables {
+		if _, err := fmt.Fprintf(w, "%-40s %d rows\n", t.Table, t.Rows); err != nil {
+			return errors.Wrap(err, "failed to write result")
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "GameAP now uses the %s database instead of %s\n", r.To, r.From)
+
+	return errors.Wrap(err, "failed to write result")
+}
+
+type migration struct {
+	paths      gameap.PanelPaths
+	from, to   string
+	fromURL    string
+	toURL      string
+	configPath string
+	// force allows a target database which already holds panel data.
+	force bool
+	// config is the original config.env, written back on failure.
+	config []byte
+}
+
+// Migrate moves the panel to another database: the panel is started once on
+// the new database to create the schema, then the rows are copied from the
+// current database. On failure the original config.env is restored and the
+// panel is started on the old database, which is never modified.
+func Migrate(cliCtx *cli.Context) (err error) {
+	ctx := cliCtx.Context
+
+	m, err := newMigration(cliCtx)
+	if err != nil {
+		return err
+	}
+
+	if dryrun.Enabled(ctx) {
+		dryrun.Record(ctx, dryrun.KindService, "stop gameap")
+		dryrun.Record(ctx, dryrun.KindFile, "switch %s to the %s database", m.configPath, m.to)
+		dryrun.Record(ctx, dryrun.KindDatabase, "copy all tables from %s to %s", m.from, m.to)
+		dryrun.Record(ctx, dryrun.KindService, "start gameap")
+
+		return nil
+	}
+
+	if err := m.prepareTarget(ctx); err != nil {
+		return err
+	}
+
+	steps := progress.Steps(ctx, 5) //nolint:mnd
+	defer func() { steps.End(err) }()
+
+	steps.Next("panel.stop", "panel stop")
+
+	log.Println("Stopping GameAP ...")
+	if err := panel.Stop(ctx, panel.Options{Scope: m.paths.Scope}); err != nil {
+		return errors.WithMessage(err, "failed to stop GameAP")
+	}
+
+	tables, err := m.run(ctx, steps)
+	if err != nil {
+		log.Printf("Migration failed: %v\n", err)
+
+		if rollbackErr := m.rollback(ctx); rollbackErr != nil {
+			return errors.WithMessagef(err, "rollback failed: %v", rollbackErr)
+		}
+
+		return errors.WithMessagef(err, "migration failed, the panel is switched back to %s", m.from)
+	}
+
+	return output.Print(cliCtx, MigrateResult{From: m.from, To: m.to, Tables: tables})
+}
+
+func newMigration(cliCtx *cli.Context) (*migration, error) {
+	ctx := cliCtx.Context
+
+	to, err := panelpkg.DatabaseDriver(cliCtx.String("to"))
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid --to")
+	}
+
+	paths, err := panelpkg.ResolveScope(ctx, cliCtx.String("scope"))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := panelpkg.CheckBinaryInstalled(paths); err != nil {
+		return nil, err
+	}
+
+	config, err := os.ReadFile(paths.ConfigFilePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read config.env")
+	}
+
+	_, values, err := panelpkg.ReadConfigEnv(paths.ConfigFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := panelpkg.DatabaseDriver(values["DATABASE_DRIVER"])
+	if err != nil {
+		return nil, err
+	}
+
+	toURL := cliCtx.String("database-url")
+	if toURL == "" {
+		if to != panelpkg.DatabaseSQLite {
+			return nil, errors.Errorf("--database-url is required to migrate to %s", to)
+		}
+
+		toURL = "file:" + filepath.Join(paths.DataDir, "database.sqlite") +
+			"?_busy_timeout=5000&_journal_mode=WAL&cache=shared"
+	}
+
+	if from == to && sameDatabase(to, values["DATABASE_URL"], toURL) {
+		return nil, errors.Errorf("the panel already uses this %s database", to)
+	}
+
+	return &migration{
+		paths:      paths,
+		from:       from,
+		to:         to,
+		fromURL:    values["DATABASE_URL"],
+		toURL:      toURL,
+		configPath: paths.ConfigFilePath,
+		force:      cliCtx.Bool("force"),
+		config:     config,
+	}, nil
+}
+
+// prepareTarget creates the SQLite file for the panel user and makes sure the
+// target database accepts connections and holds no panel data before the panel
+// is stopped.
+func (m *migration) prepareTarget(ctx context.Context) error {
+	if m.to == panelpkg.DatabaseSQLite {
+		dbPath := panelpkg.SQLitePath(m.toURL)
+
+		if err := oscore.MkdirAll(ctx, filepath.Dir(dbPath), 0o755); err != nil {
+			return errors.WithMessage(err, "failed to create directory for sqlite database")
+		}
+
+		f, err := os.OpenFile(dbPath, os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return errors.Wrap(err, "failed to create sqlite database")
+		}
+		_ = f.Close()
+
+		if gameap.ScopeOrDefault(m.paths.Scope) == gameap.ScopeSystem && m.paths.User != "" {
+			if err := oscore.ChownRecursive(ctx, dbPath, m.paths.User, m.paths.Group); err != nil {
+				return errors.WithMessage(err, "failed to change owner of sqlite database")
+			}
+		}
+	}
+
+	db, err := panelpkg.OpenDatabase(ctx, m.to, m.toURL)
+	if err != nil {
+		return errors.WithMessagef(err, "failed to connect to the %s database", m.to)
+	}
+	defer func() { _ = db.Close() }()
+
+	if !m.force {
+		if err := checkTargetEmpty(ctx, db, m.to); err != nil {
+			return err
+		}
+	}
+
+	return errors.Wrap(db.Close(), "failed to close database")
+}
+
+func (m *migration) run(ctx context.Context, steps *progress.Sequence) ([]TableResult, error) {
+	steps.Next("database.schema", "database schema")
+
+	log.Printf("Switching %s to %s ...\n", m.configPath, m.to)
+
+	if err := m.switchConfig(); err != nil {
+		return nil, err
+	}
+
+	// The panel runs its migrations on start.
+	log.Printf("Creating the %s schema ...\n", m.to)
+	if err := m.startHealthy(ctx); err != nil {
+		return nil, errors.WithMessage(err, "failed to create the schema")
+	}
+
+	if err := panel.Stop(ctx, panel.Options{Scope: m.paths.Scope}); err != nil {
+		return nil, errors.WithMessage(err, "failed to stop GameAP")
+	}
+
+	steps.Next("database.copy", "database copy")
+
+	log.Printf("Copying tables from %s to %s ...\n", m.from, m.to)
+
+	tables, err := m.copy(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	steps.Next("panel.start", "panel start")
+
+	log.Println("Starting GameAP ...")
+	if err := m.startHealthy(ctx); err != nil {
+		return nil, err
+	}
+
+	return tables, nil
+}
+
+func (m *migration) switchConfig() error {
+	lines, _, err := panelpkg.ReadConfigEnv(m.configPath)
+	if err != nil {
+		return err
+	}
+
+	err = panelpkg.WriteConfigEnv(m.configPath, lines, map[string]string{
+		"DATABASE_DRIVER": m.to,
+		"DATABASE_URL":    m.toURL,
+	})
+
+	return errors.WithMessage(err, "failed to update config.env")
+}
+
+func (m *migration) copy(ctx context.Context) ([]TableResult, error) {
+	src, err := panelpkg.OpenDatabase(ctx, m.from, m.fromURL)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to connect to the source database")
+	}
+	defer func() { _ = src.Close() }()
+
+	dst, err := panelpkg.OpenDatabase(ctx, m.to, m.toURL)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to connect to the target database")
+	}
+	defer func() { _ = dst.Close() }()
+
+	return copyDatabase(ctx, src, m.from, dst, m.to)
+}
+
+func (m *migration) startHealthy(ctx context.Context) error {
+	if err := panel.Start(ctx, panel.Options{Scope: m.paths.Scope}); err != nil {
+		return errors.WithMessage(err, "failed to start GameAP")
+	}
+
+	_, values, err := panelpkg.ReadConfigEnv(m.configPath)
+	if err != nil {
+		return err
+	}
+
+	return panelpkg.WaitHealthy(ctx, panelpkg.HTTPPort(m.paths, values))
+}
+
+func (m *migration) rollback(ctx context.Context) error {
+	log.Println("Restoring the original config.env ...")
+
+	if err := panel.Stop(ctx, panel.Options{Scope: m.paths.Scope}); err != nil {
+		log.Printf("Failed to stop GameAP: %v\n", err)
+	}
+
+	if err := os.WriteFile(m.configPath, m.config, 0o600); err != nil {
+		return errors.Wrap(err, "failed to restore config.env")
+	}
+
+	log.Println("Starting GameAP ...")
+
+	return m.startHealthy(ctx)
+}
+
+func sameDatabase(driver, a, b string) bool {
+	if driver == panelpkg.DatabaseSQLite {
+		return filepath.Clean(panelpkg.SQLitePath(a)) == filepath.Clean(panelpkg.SQLitePath(b))
+	}
+
+	return a == b
+}
@@ -0,0 +1,285 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+
+	"github.com/gameap/gameapctl/internal/actions/panel/install"
+	"github.com/gameap/gameapctl/internal/pkg/dbprovision"
+	"github.com/gameap/gameapctl/internal/pkg/gameapctl"
+	"github.com/gameap/gameapctl/internal/pkg/output"
+	panelpkg "github.com/gameap/gameapctl/internal/pkg/panel"
+	"github.com/gameap/gameapctl/internal/pkg/redact"
+	"github.com/gameap/gameapctl/pkg/dryrun"
+	"github.com/gameap/gameapctl/pkg/gameap"
+	"github.com/gameap/gameapctl/pkg/panel"
+	"github.com/gameap/gameapctl/pkg/progress"
+	"github.com/pkg/errors"
+	"github.com/sethvargo/go-password/password"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	rotatedPasswordLen       = 24
+	rotatedPasswordNumDigits = 6
+)
+
+type RotateResult struct {
+	Driver   string `json:"driver"`
+	Username string `json:"username"`
+}
+
+func (r RotateResult) WriteText(w io.Writer) error {
+	_, err := fmt.Fprintf(w,
+		"The password of the %s user %s was rotated, "+
+			"the new one is in config.env and in 'gameapctl state show --reveal'\n",
+		r.Driver, r.Username,
+	)
+
+	return errors.Wrap(err, "failed to write result")
+}
+
+type rotation struct {
+	paths      gameap.PanelPaths
+	driver     string
+	user       panelpkg.DatabaseUser
+	dsn        string
+	configPath string
+	// config is the original config.env, written back on failure.
+	config []byte
+	db     *sql.DB
+	// conn stays open for the rollback, new connections would need the new password.
+	conn        *sql.Conn
+	provisioner dbprovision.Provisioner
+}
+
+// RotatePassword generates a new password for the database user of the panel,
+// changes it on the server and in config.env and restarts the panel. If the
+// panel does not come up, the old password is set back.
+func RotatePassword(cliCtx *cli.Context) (err error) {
+	ctx := cliCtx.Context
+
+	r, err := newRotation(cliCtx)
+	if err != nil {
+		return err
+	}
+
+	if dryrun.Enabled(ctx) {
+		dryrun.Record(ctx, dryrun.KindDatabase, "change the password of the %s user %s", r.driver, r.user.Username)
+		dryrun.Record(ctx, dryrun.KindFile, "update DATABASE_URL in %s", r.configPath)
+		dryrun.Record(ctx, dryrun.KindService, "restart gameap")
+
+		return nil
+	}
+
+	steps := progress.Steps(ctx, 3) //nolint:mnd
+	defer func() { steps.End(err) }()
+
+	steps.Next("database.connect", "database connect")
+
+	if err := r.connect(ctx, cliCtx.String("root-password")); err != nil {
+		return err
+	}
+	defer r.close()
+
+	newPassword, err := password.Generate(rotatedPasswordLen, rotatedPasswordNumDigits, 0, false, false)
+	if err != nil {
+		return errors.WithMessage(err, "failed to generate password")
+	}
+	redact.Register(newPassword)
+
+	steps.Next("database.password", "database password")
+
+	log.Printf("Changing the password of %s ...\n", r.user.Username)
+	if err := r.setPassword(ctx, newPassword); err != nil {
+		return err
+	}
+
+	steps.Next("panel.restart", "panel restart")
+
+	if err := r.apply(ctx, newPassword); err != nil {
+		log.Printf("Rotation failed: %v\n", err)
+
+		if rollbackErr := r.rollback(ctx); rollbackErr != nil {
+			return errors.WithMessagef(err, "rollback failed: %v", rollbackErr)
+		}
+
+		return errors.WithMessage(err, "rotation failed, the old password is restored")
+	}
+
+	return output.Print(cliCtx, RotateResult{Driver: r.driver, Username: r.user.Username})
+}
+
+func newRotation(cliCtx *cli.Context) (*rotation, error) {
+	paths, err := panelpkg.ResolveScope(cliCtx.Context, cliCtx.String("scope"))
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := os.ReadFile(paths.ConfigFilePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read config.env")
+	}
+
+	_, values, err := panelpkg.ReadConfigEnv(paths.ConfigFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	driver, err := panelpkg.DatabaseDriver(values["DATABASE_DRIVER"])
+	if err != nil {
+		return nil, err
+	}
+
+	if driver == panelpkg.DatabaseSQLite {
+		return nil, errors.New("the panel uses SQLite, there is no database password to rotate")
+	}
+
+	user, err := panelpkg.ParseDatabaseUser(driver, values["DATABASE_URL"])
+	if err != nil {
+		return nil, err
+	}
+
+	return &rotation{
+		paths:      paths,
+		driver:     driver,
+		user:       user,
+		dsn:        values["DATABASE_URL"],
+		configPath: paths.ConfigFilePath,
+		config:     config,
+	}, nil
+}
+
+// connect opens the connection the password is changed with: the root account
+// for MySQL, where the panel user exists for several hosts, and the panel user
+// itself for PostgreSQL.
+func (r *rotation) connect(ctx context.Context, rootPassword string) error {
+	var (
+		db  *sql.DB
+		err error
+	)
+
+	switch r.driver {
+	case panelpkg.DatabaseMySQL:
+		if rootPassword == "" {
+			state, stateErr := gameapctl.LoadPanelInstallState(ctx)
+			if stateErr == nil {
+				rootPassword = state.DBRootPassword
+			}
+		}
+
+		db, err = install.MySQLAdminConnection(ctx, r.user.Host, r.user.Port, rootPassword)
+	default:
+		db, err = panelpkg.OpenDatabase(ctx, r.driver, r.dsn)
+	}
+	if err != nil {
+		return errors.WithMessage(err, "failed to connect to the database")
+	}
+
+	r.db = db
+
+	r.conn, err = db.Conn(ctx)
+	if err != nil {
+		_ = db.Close()
+
+		return errors.Wrap(err, "failed to connect to the database")
+	}
+
+	r.provisioner, err = dbprovision.New(ctx, r.driver, r.conn)
+	if err != nil {
+		r.close()
+
+		return err
+	}
+
+	return nil
+}
+
+func (r *rotation) close() {
+	_ = r.conn.Close()
+	_ = r.db.Close()
+}
+
+func (r *rotation) setPassword(ctx context.Context, secret string) error {
+	return r.provisioner.AlterUserPassword(ctx, r.user.Username, secret)
+}
+
+// apply writes the new password to config.env and to the install state and
+// restarts the panel.
+func (r *rotation) apply(ctx context.Context, secret string) error {
+	dsn, err := panelpkg.SetDatabasePassword(r.driver, r.dsn, secret)
+	if err != nil {
+		return err
+	}
+
+	lines, _, err := panelpkg.ReadConfigEnv(r.configPath)
+	if err != nil {
+		return err
+	}
+
+	if err := panelpkg.WriteConfigEnv(r.configPath, lines, map[string]string{"DATABASE_URL": dsn}); err != nil {
+		return errors.WithMessage(err, "failed to update config.env")
+	}
+
+	if err := r.saveState(ctx, secret); err != nil {
+		return err
+	}
+
+	return r.restartHealthy(ctx)
+}
+
+func (r *rotation) saveState(ctx context.Context, secret string) error {
+	state, err := gameapctl.LoadPanelInstallState(ctx)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return errors.WithMessage(err, "failed to load panel install state")
+	}
+
+	if state.DBUsername != "" && state.DBUsername != r.user.Username {
+		return nil
+	}
+
+	state.DBPassword = secret
+
+	return errors.WithMessage(gameapctl.SavePanelInstallState(ctx, state), "failed to save panel install state")
+}
+
+func (r *rotation) restartHealthy(ctx context.Context) error {
+	log.Println("Restarting GameAP ...")
+
+	if err := panel.Restart(ctx, panel.Options{Scope: r.paths.Scope}); err != nil {
+		return errors.WithMessage(err, "failed to restart GameAP")
+	}
+
+	_, values, err := panelpkg.ReadConfigEnv(r.configPath)
+	if err != nil {
+		return err
+	}
+
+	return panelpkg.WaitHealthy(ctx, panelpkg.HTTPPort(r.paths, values))
+}
+
+func (r *rotation) rollback(ctx context.Context) error {
+	log.Println("Restoring the old password ...")
+
+	if err := r.setPassword(ctx, r.user.Password); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(r.configPath, r.config, 0o600); err != nil {
+		return errors.Wrap(err, "failed to restore config.env")
+	}
+
+	if err := r.saveState(ctx, r.user.Password); err != nil {
+		log.Println(err)
+	}
+
+	return r.restartHealthy(ctx)
+}
@@ -0,0 +1,30 @@
+package install
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/gameap/gameapctl/internal/pkg/dbprovision"
+	panelpkg "github.com/gameap/gameapctl/internal/pkg/panel"
+)
+
+// isDatabaseEmpty reports whether the panel has no data in the database yet,
+// the seeders run only on an empty one.
+func isDatabaseEmpty(ctx context.Context, driver string, db *sql.DB, database string) (bool, error) {
+	provisioner, err := dbprovision.New(ctx, driver, db)
+	if err != nil {
+		return false, err
+	}
+
+	return provisioner.IsDatabaseEmpty(ctx, database)
+}
+
+func isSQLiteDatabaseEmpty(ctx context.Context, dbPath string) (bool, error) {
+	db, err := panelpkg.OpenDatabase(ctx, panelpkg.DatabaseSQLite, "file:"+dbPath)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = db.Close() }()
+
+	return isDatabaseEmpty(ctx, dbprovision.SQLite, db, dbPath)
+}
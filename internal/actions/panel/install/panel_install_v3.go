@@ -15,8 +15,12 @@ import (
 
 	daemoninstall "github.com/gameap/gameapctl/internal/actions/daemon/install"
 	contextInternal "github.com/gameap/gameapctl/internal/context"
+	"github.com/gameap/gameapctl/internal/pkg/answers"
+	"github.com/gameap/gameapctl/internal/pkg/dbprovision"
 	"github.com/gameap/gameapctl/internal/pkg/gameapctl"
 	"github.com/gameap/gameapctl/internal/pkg/panel"
+	"github.com/gameap/gameapctl/internal/pkg/redact"
+	"github.com/gameap/gameapctl/pkg/dryrun"
 	osinfo "github.com/gameap/gameapctl/pkg/os_info"
 	packagemanager "github.com/gameap/gameapctl/pkg/package_manager"
 	"github.com/gameap/gameapctl/pkg/service"
@@ -92,24 +96,29 @@ type databaseCredentials struct {
 
 //nolint:gocognit,gocyclo,funlen
 func HandleV3(cliCtx *cli.Context) error {
-	var err error
 	state := panelInstallStateV3{}
 
-	state.NonInteractive = cliCtx.Bool("non-interactive")
+	a, err := answers.LoadFromFlag(cliCtx, (*answers.File).ValidatePanel)
+	if err != nil {
+		return err
+	}
+	db := a.DatabaseOrEmpty()
+
+	state.NonInteractive = cliCtx.Bool("non-interactive") || a.Provided()
 	state.SkipWarnings = cliCtx.Bool("skip-warnings")
-	state.Host = cliCtx.String("host")
-	state.Port = cliCtx.String("port")
-	state.Path = cliCtx.String("path")
-	state.WebServer = cliCtx.String("web-server")
-	state.Database = cliCtx.String("database")
+	state.Host = answers.FlagOr(cliCtx, "host", a.Host)
+	state.Port = answers.FlagOr(cliCtx, "port", a.Port)
+	state.Path = answers.FlagOr(cliCtx, "path", a.Path)
+	state.WebServer = answers.FlagOr(cliCtx, "web-server", a.WebServer)
+	state.Database = answers.FlagOr(cliCtx, "database", db.Type)
 	state.DBCreds = databaseCredentials{
-		Host:         cliCtx.String("database-host"),
-		Port:         cliCtx.String("database-port"),
-		DatabaseName: cliCtx.String("database-name"),
-		Username:     cliCtx.String("database-username"),
-		Password:     cliCtx.String("database-password"),
+		Host:         answers.FlagOr(cliCtx, "database-host", db.Host),
+		Port:         answers.FlagOr(cliCtx, "database-port", db.Port),
+		DatabaseName: answers.FlagOr(cliCtx, "database-name", db.Name),
+		Username:     answers.FlagOr(cliCtx, "database-username", db.Username),
+		Password:     answers.FlagOr(cliCtx, "database-password", db.Password),
 	}
-	state.WithDaemon = cliCtx.Bool("with-daemon")
+	state.WithDaemon = cliCtx.Bool("with-daemon") || a.WithDaemon
 	state.OSInfo = contextInternal.OSInfoFromContext(cliCtx.Context)
 
 	state.FromGithub = cliCtx.Bool("github")
@@ -148,25 +157,25 @@ func HandleV3(cliCtx *cli.Context) error {
 		if state.WebServer == "" {
 			needToAsk["webServer"] = struct{}{}
 		}
-		answers, err := askUserV3(cliCtx.Context, state, needToAsk)
+		asked, err := askUserV3(cliCtx.Context, state, needToAsk)
 		if err != nil {
 			return err
 		}
 
 		if _, ok := needToAsk["path"]; ok {
-			state.Path = answers.path
+			state.Path = asked.path
 		}
 
 		if _, ok := needToAsk["host"]; ok {
-			state.Host = answers.host
+			state.Host = asked.host
 		}
 
 		if _, ok := needToAsk["database"]; ok {
-			state.Database = answers.database
+			state.Database = asked.database
 		}
 
 		if _, ok := needToAsk["webServer"]; ok {
-			state.WebServer = answers.webServer
+			state.WebServer = asked.webServer
 		}
 	}
 
@@ -751,12 +760,12 @@ func checkMySQLConnection(
 		return state, errors.WithMessage(err, "failed to execute MySQL query")
 	}
 
-	isDatabaseEmpty, err := mysqlIsDatabaseEmpty(ctx, db, state.DBCreds.DatabaseName)
+	empty, err := isDatabaseEmpty(ctx, dbprovision.MySQL, db, state.DBCreds.DatabaseName)
 	if err != nil {
 		return state, errors.WithMessage(err, "failed to check database")
 	}
 
-	state.DatabaseIsNotEmpty = !isDatabaseEmpty
+	state.DatabaseIsNotEmpty = !empty
 
 	return state, nil
 }
@@ -794,6 +803,8 @@ func preconfigureDatabase(_ context.Context, dbCreds databaseCredentials) (datab
 		}
 	}
 
+	redact.Register(dbCreds.Password, dbCreds.RootPassword)
+
 	return dbCreds, nil
 }
 
@@ -814,6 +825,14 @@ func runMigrationWithRetry(ctx context.Context, state panelInstallStateV3) (pane
 }
 
 func configureMysql(ctx context.Context, dbCreds databaseCredentials) error {
+	// The server is not installed in a dry run, there is nothing to connect to.
+	if dryrun.Record(
+		ctx, dryrun.KindDatabase, "create MySQL database %s and user %s, grant privileges",
+		dbCreds.DatabaseName, dbCreds.Username,
+	) {
+		return nil
+	}
+
 	db, err := mysqlMakeAdminConnection(ctx, dbCreds)
 	if err != nil {
 		return errors.WithMessage(err, "failed to make admin connection")
@@ -825,29 +844,32 @@ func configureMysql(ctx context.Context, dbCreds databaseCredentials) error {
 		}
 	}(db)
 
-	var databaseExists bool
-	databaseExists, err = mysqlIsDatabaseExists(ctx, db, dbCreds.DatabaseName)
+	provisioner, err := dbprovision.New(ctx, dbprovision.MySQL, db)
+	if err != nil {
+		return errors.WithMessage(err, "failed to get MySQL version")
+	}
+
+	databaseExists, err := provisioner.DatabaseExists(ctx, dbCreds.DatabaseName)
 	if err != nil {
 		return errors.WithMessage(err, "failed to check database")
 	}
 
 	if !databaseExists {
 		fmt.Println("Creating database ...")
-		err = mysqlCreateDatabase(ctx, db, dbCreds.DatabaseName)
+		err = provisioner.CreateDatabase(ctx, dbCreds.DatabaseName)
 		if err != nil {
 			return errors.WithMessage(err, "failed to create database")
 		}
 	}
 
-	var userExists bool
-	userExists, err = mysqlIsUsereExists(ctx, db, dbCreds.Username, dbCreds.Host)
+	userExists, err := provisioner.UserExists(ctx, dbCreds.Username)
 	if err != nil {
-		return errors.WithMessage(err, "failed to create database")
+		return errors.WithMessage(err, "failed to check user")
 	}
 
 	if !userExists {
 		fmt.Println("Creating user ...")
-		err = mysqlCreateUser(ctx, db, dbCreds.Username, dbCreds.Password)
+		err = provisioner.CreateUser(ctx, dbCreds.Username, dbCreds.Password)
 		if err != nil {
 			return errors.WithMessage(err, "failed to create user")
 		}
@@ -857,14 +879,14 @@ func configureMysql(ctx context.Context, dbCreds databaseCredentials) error {
 
 	if userExists && dbCreds.Username == "gameap" {
 		fmt.Println("Changing mysql gameap user password ...")
-		err = mysqlChangeUserPassword(ctx, db, dbCreds.Username, dbCreds.Password)
+		err = provisioner.AlterUserPassword(ctx, dbCreds.Username, dbCreds.Password)
 		if err != nil {
 			return errors.WithMessage(err, "failed to change user password")
 		}
 	}
 
 	fmt.Println("Granting privileges ...")
-	err = mysqlGrantPrivileges(ctx, db, dbCreds.Username, dbCreds.DatabaseName)
+	err = provisioner.Grant(ctx, dbCreds.Username, dbCreds.DatabaseName)
 	if err != nil {
 		return errors.WithMessage(err, "failed to grant privileges")
 	}
@@ -1321,6 +1343,8 @@ func updateAdminPassword(ctx context.Context, state panelInstallStateV3) (panelI
 		}
 	}
 
+	redact.Register(state.AdminPassword)
+
 	err = panel.ChangePassword(ctx, state.Path, "admin", state.AdminPassword)
 	if err != nil {
 		return state, errors.WithMessage(err, "failed to change admin password")
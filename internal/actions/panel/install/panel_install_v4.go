@@ -18,16 +18,22 @@ import (
 
 	daemoninstall "github.com/gameap/gameapctl/internal/actions/daemon/install"
 	"github.com/gameap/gameapctl/internal/actions/panel/changepassword"
+	"github.com/gameap/gameapctl/internal/actions/panel/letsencrypt"
 	contextInternal "github.com/gameap/gameapctl/internal/context"
+	"github.com/gameap/gameapctl/internal/pkg/answers"
 	daemonpkg "github.com/gameap/gameapctl/internal/pkg/daemon"
+	"github.com/gameap/gameapctl/internal/pkg/dbprovision"
 	"github.com/gameap/gameapctl/internal/pkg/gameapctl"
 	panelpkg "github.com/gameap/gameapctl/internal/pkg/panel"
+	"github.com/gameap/gameapctl/internal/pkg/redact"
 	"github.com/gameap/gameapctl/pkg/daemon"
+	"github.com/gameap/gameapctl/pkg/dryrun"
 	"github.com/gameap/gameapctl/pkg/gameap"
 	osinfo "github.com/gameap/gameapctl/pkg/os_info"
 	"github.com/gameap/gameapctl/pkg/oscore"
 	packagemanager "github.com/gameap/gameapctl/pkg/package_manager"
 	"github.com/gameap/gameapctl/pkg/panel"
+	"github.com/gameap/gameapctl/pkg/progress"
 	"github.com/gameap/gameapctl/pkg/releasefinder"
 	"github.com/gameap/gameapctl/pkg/service"
 	"github.com/gameap/gameapctl/pkg/utils"
@@ -57,6 +63,10 @@ type panelInstallStateV4 struct {
 	WithDaemon       bool
 	OSInfo           osinfo.Info
 
+	// ACME settings from the answers file, written to config.env before the
+	// panel is started for the first time.
+	ACME *answers.ACME
+
 	FromGithub bool
 	Branch     string
 
@@ -77,6 +87,11 @@ type panelInstallStateV4 struct {
 	DatabaseWasInstalled     bool
 	DatabaseDirExistedBefore bool
 	DatabaseIsNotEmpty       bool
+
+	// Resume is set with --resume, CompletedSteps are the steps finished by this
+	// or the resumed run.
+	Resume         bool
+	CompletedSteps []string
 }
 
 func restorePreviousStateV4(ctx context.Context, state panelInstallStateV4) panelInstallStateV4 {
@@ -138,27 +153,35 @@ func restorePreviousStateV4(ctx context.Context, state panelInstallStateV4) pane
 func loadPanelInstallStateV4(cliCtx *cli.Context) (panelInstallStateV4, error) {
 	state := panelInstallStateV4{}
 
-	state.NonInteractive = cliCtx.Bool("non-interactive")
+	a, err := answers.LoadFromFlag(cliCtx, (*answers.File).ValidatePanel)
+	if err != nil {
+		return state, err
+	}
+	db := a.DatabaseOrEmpty()
+
+	state.NonInteractive = cliCtx.Bool("non-interactive") || a.Provided()
 	state.SkipWarnings = cliCtx.Bool("skip-warnings")
 
-	scope, err := gameap.ResolveScope(cliCtx.String("scope"))
+	scope, err := gameap.ResolveScope(answers.FlagOr(cliCtx, "scope", a.Scope))
 	if err != nil {
 		return state, err
 	}
 	state.Scope = scope
 
-	state.Host = cliCtx.String("host")
-	state.Port = cliCtx.String("port")
-	state.Database = cliCtx.String("database")
+	state.Host = answers.FlagOr(cliCtx, "host", a.Host)
+	state.Port = answers.FlagOr(cliCtx, "port", a.Port)
+	state.Database = answers.FlagOr(cliCtx, "database", db.Type)
 	state.DBCreds = databaseCredentials{
-		Host:         cliCtx.String("database-host"),
-		Port:         cliCtx.String("database-port"),
-		DatabaseName: cliCtx.String("database-name"),
-		Username:     cliCtx.String("database-username"),
-		Password:     cliCtx.String("database-password"),
-	}
-	state.ExistingDatabase = state.DBCreds.Host != "" && state.DBCreds.Password != ""
-	state.WithDaemon = cliCtx.Bool("with-daemon")
+		Host:         answers.FlagOr(cliCtx, "database-host", db.Host),
+		Port:         answers.FlagOr(cliCtx, "database-port", db.Port),
+		DatabaseName: answers.FlagOr(cliCtx, "database-name", db.Name),
+		Username:     answers.FlagOr(cliCtx, "database-username", db.Username),
+		Password:     answers.FlagOr(cliCtx, "database-password", db.Password),
+	}
+	state.ExistingDatabase = db.Existing || (state.DBCreds.Host != "" && state.DBCreds.Password != "")
+	state.WithDaemon = cliCtx.Bool("with-daemon") || a.WithDaemon
+	state.AdminPassword = a.AdminPassword
+	state.ACME = a.ACME
 	state.OSInfo = contextInternal.OSInfoFromContext(cliCtx.Context)
 
 	state.FromGithub = cliCtx.Bool("github")
@@ -169,10 +192,10 @@ func loadPanelInstallStateV4(cliCtx *cli.Context) (panelInstallStateV4, error) {
 		state.Branch = cliCtx.String("branch")
 	}
 
-	state.GRPCPortInput = cliCtx.String("grpc-port")
+	state.GRPCPortInput = answers.FlagOr(cliCtx, "grpc-port", a.GRPCPort)
 	state.GRPCPort = state.GRPCPortInput
 
-	state.VersionInput = cliCtx.String("version")
+	state.VersionInput = answers.FlagOr(cliCtx, "version", a.Version)
 	if state.VersionInput != "" {
 		if state.FromGithub || developBranch || cliCtx.String("branch") != "" {
 			return state, errors.New("--version is mutually exclusive with --github, --branch and --develop")
@@ -254,7 +277,7 @@ func isPrevStateV4(version string) bool {
 }
 
 //nolint:gocognit,gocyclo,funlen
-func HandleV4(cliCtx *cli.Context) error {
+func HandleV4(cliCtx *cli.Context) (err error) {
 	ctx := cliCtx.Context
 
 	state, err := loadPanelInstallStateV4(cliCtx)
@@ -262,6 +285,13 @@ func HandleV4(cliCtx *cli.Context) error {
 		return errors.WithMessage(err, "failed to load panel install state")
 	}
 
+	if cliCtx.Bool("resume") {
+		state, err = resumeStateV4(ctx, state)
+		if err != nil {
+			return err
+		}
+	}
+
 	if err = panel.CheckScope(state.Scope); err != nil {
 		return err
 	}
@@ -284,25 +314,27 @@ func HandleV4(cliCtx *cli.Context) error {
 		if state.Database == "" {
 			needToAsk["database"] = struct{}{}
 		}
-		answers, err := askUserV4(ctx, needToAsk, state.Scope)
+		asked, err := askUserV4(ctx, needToAsk, state.Scope)
 		if err != nil {
 			return err
 		}
 
 		if _, ok := needToAsk["host"]; ok {
-			state.Host = answers.host
+			state.Host = asked.host
 		}
 
 		if _, ok := needToAsk["database"]; ok {
-			state.Database = answers.database
-			if answers.existingDatabase {
+			state.Database = asked.database
+			if asked.existingDatabase {
 				state.ExistingDatabase = true
-				state.DBCreds = answers.dbCreds
+				state.DBCreds = asked.dbCreds
 			}
 		}
 	}
 
-	state = restorePreviousStateV4(ctx, state)
+	if !state.Resume {
+		state = restorePreviousStateV4(ctx, state)
+	}
 
 	if state.Host == "" {
 		return errEmptyHost
@@ -350,6 +382,9 @@ func HandleV4(cliCtx *cli.Context) error {
 	}
 	fmt.Println()
 
+	steps := progress.Steps(ctx, countStepsV4(state))
+	defer func() { steps.End(err) }()
+
 	pm, err := packagemanager.Load(ctx)
 	if err != nil {
 		return errors.WithMessage(err, "failed to load package manager")
@@ -360,11 +395,15 @@ func HandleV4(cliCtx *cli.Context) error {
 		return errors.WithMessage(err, "failed to check selinux")
 	}
 
-	if state.Scope == gameap.ScopeUser {
+	switch {
+	case state.Scope == gameap.ScopeUser:
 		// Nothing the panel needs in user scope comes from a system package: downloads,
 		// archive extraction, SQLite and password hashing are all in-process.
-		fmt.Println("Skipping system package installation in user scope ...")
-	} else {
+		steps.Skip(stepDependencies, stepTitles[stepDependencies], "system packages are not used in user scope")
+	case skipStepV4(steps, state, stepDependencies):
+	default:
+		steps.Next(stepDependencies, stepTitles[stepDependencies])
+
 		rerunRequired, depErr := installSystemDependenciesV4(ctx, pm, state)
 		if depErr != nil {
 			return depErr
@@ -374,68 +413,66 @@ func HandleV4(cliCtx *cli.Context) error {
 		}
 	}
 
-	if state.Database != noneDatabase && !state.ExistingDatabase {
-		state.DBCreds, err = preconfigureDatabase(ctx, state.DBCreds)
+	state = completeStepV4(ctx, state, stepDependencies)
+
+	if !skipStepV4(steps, state, stepDatabase) {
+		steps.Next(stepDatabase, stepTitles[stepDatabase])
+
+		state, err = setupDatabaseV4(ctx, pm, state)
 		if err != nil {
-			return errors.WithMessage(err, "failed to preconfigure database")
+			return err
 		}
 
-		saveStateCheckpointV4(cliCtx.Context, state)
+		state = completeStepV4(ctx, state, stepDatabase)
 	}
 
-	switch {
-	case state.ExistingDatabase && state.Database == postgresDatabase:
-		state, err = connectExistingPostgreSQL(ctx, state)
-		if err != nil {
-			return errors.WithMessage(err, "failed to connect to existing PostgreSQL")
-		}
-	case state.ExistingDatabase && state.Database == mysqlDatabase:
-		state, err = connectExistingMySQL(ctx, state)
-		if err != nil {
-			return errors.WithMessage(err, "failed to connect to existing MySQL")
-		}
-	case state.Database == postgresDatabase:
-		state, err = installPostgreSQL(ctx, pm, state)
-		if err != nil {
-			return errors.WithMessage(err, "failed to install postgres")
-		}
-	case state.Database == mysqlDatabase:
-		state, err = installMySQLOrMariaDBV4(ctx, pm, state)
-		if err != nil {
-			return errors.WithMessage(err, "failed to install mysql")
+	if !skipStepV4(steps, state, stepPanel) {
+		steps.Next(stepPanel, stepTitles[stepPanel])
+
+		if state.FromGithub {
+			fmt.Println("Installing GameAP from github ...")
+			state, err = installGameAPFromGithubV4(cliCtx.Context, pm, state)
+		} else {
+			state, err = installGameAPV4(cliCtx.Context, state)
 		}
-	case state.Database == sqliteDatabase:
-		state, err = installSqliteV4(ctx, state)
 		if err != nil {
-			return errors.WithMessage(err, "failed to install sqlite")
+			return errors.WithMessage(err, "failed to install GameAP")
 		}
+
+		state = completeStepV4(ctx, state, stepPanel)
 	}
 
-	saveStateCheckpointV4(cliCtx.Context, state)
+	if state.ACME != nil && !skipStepV4(steps, state, stepACME) {
+		steps.Next(stepACME, stepTitles[stepACME])
 
-	fmt.Println("Installing GameAP ...")
+		if err = configureACMEV4(ctx, state); err != nil {
+			return errors.WithMessage(err, "failed to configure ACME")
+		}
 
-	if state.FromGithub {
-		fmt.Println("Installing GameAP from github ...")
-		state, err = installGameAPFromGithubV4(cliCtx.Context, pm, state)
-	} else {
-		state, err = installGameAPV4(cliCtx.Context, state)
-	}
-	if err != nil {
-		return errors.WithMessage(err, "failed to install GameAP")
+		state.HTTPS = true
+		state = completeStepV4(ctx, state, stepACME)
 	}
 
-	saveStateCheckpointV4(cliCtx.Context, state)
+	var daemonInstalled, daemonFailed bool
 
-	var daemonInstalled bool
+	switch {
+	case !state.WithDaemon:
+	case skipStepV4(steps, state, stepDaemon):
+		daemonInstalled = true
+	default:
+		steps.Next(stepDaemon, stepTitles[stepDaemon])
 
-	if state.WithDaemon {
 		state, err = daemonInstallV4(ctx, state)
 		if err != nil {
+			steps.Fail(err)
 			fmt.Println("Failed to install daemon: ", err.Error())
 			log.Println(errors.WithMessage(err, "failed to install daemon, try to install it manually"))
+			fmt.Println("Run 'gameapctl panel install --resume' to retry the daemon installation.")
+
+			daemonFailed = true
 		} else {
 			daemonInstalled = true
+			state = completeStepV4(ctx, state, stepDaemon)
 		}
 	}
 
@@ -445,6 +482,12 @@ func HandleV4(cliCtx *cli.Context) error {
 	}
 
 	if state.Database == noneDatabase {
+		state = completeFinishStepV4(ctx, state, daemonFailed)
+
+		if dryrun.Enabled(ctx) {
+			return nil
+		}
+
 		fmt.Println()
 		log.Println("GameAP files installed successfully")
 
@@ -480,7 +523,8 @@ func HandleV4(cliCtx *cli.Context) error {
 		return nil
 	}
 
-	fmt.Println("Starting GameAP ...")
+	steps.Next(stepFinish, stepTitles[stepFinish])
+
 	err = panel.Start(ctx, panel.Options{Scope: state.Scope})
 	if err != nil {
 		return errors.WithMessage(err, "failed to start GameAP after installation")
@@ -501,7 +545,7 @@ func HandleV4(cliCtx *cli.Context) error {
 		return errors.WithMessage(err, "failed to update admin password")
 	}
 
-	saveStateCheckpointV4(cliCtx.Context, state)
+	state = completeFinishStepV4(ctx, state, daemonFailed)
 
 	if daemonInstalled {
 		err = daemon.Start(ctx, daemon.Options{Scope: state.Scope})
@@ -511,6 +555,11 @@ func HandleV4(cliCtx *cli.Context) error {
 		}
 	}
 
+	// Credentials below were generated for this run only and are never applied in a dry run.
+	if dryrun.Enabled(ctx) {
+		return nil
+	}
+
 	fmt.Println()
 	log.Println("GameAP successfully installed")
 
@@ -566,6 +615,53 @@ func HandleV4(cliCtx *cli.Context) error {
 	return nil
 }
 
+func setupDatabaseV4(
+	ctx context.Context,
+	pm packagemanager.PackageManager,
+	state panelInstallStateV4,
+) (panelInstallStateV4, error) {
+	var err error
+
+	if state.Database != noneDatabase && !state.ExistingDatabase {
+		state.DBCreds, err = preconfigureDatabase(ctx, state.DBCreds)
+		if err != nil {
+			return state, errors.WithMessage(err, "failed to preconfigure database")
+		}
+
+		saveStateCheckpointV4(ctx, state)
+	}
+
+	switch {
+	case state.ExistingDatabase && state.Database == postgresDatabase:
+		state, err = connectExistingPostgreSQL(ctx, state)
+		if err != nil {
+			return state, errors.WithMessage(err, "failed to connect to existing PostgreSQL")
+		}
+	case state.ExistingDatabase && state.Database == mysqlDatabase:
+		state, err = connectExistingMySQL(ctx, state)
+		if err != nil {
+			return state, errors.WithMessage(err, "failed to connect to existing MySQL")
+		}
+	case state.Database == postgresDatabase:
+		state, err = installPostgreSQL(ctx, pm, state)
+		if err != nil {
+			return state, errors.WithMessage(err, "failed to install postgres")
+		}
+	case state.Database == mysqlDatabase:
+		state, err = installMySQLOrMariaDBV4(ctx, pm, state)
+		if err != nil {
+			return state, errors.WithMessage(err, "failed to install mysql")
+		}
+	case state.Database == sqliteDatabase:
+		state, err = installSqliteV4(ctx, state)
+		if err != nil {
+			return state, errors.WithMessage(err, "failed to install sqlite")
+		}
+	}
+
+	return state, nil
+}
+
 func printUserScopeSummaryV4(ctx context.Context, state panelInstallStateV4) {
 	if state.Scope != gameap.ScopeUser {
 		return
@@ -700,6 +796,10 @@ func installSystemDependenciesV4(
 
 		fmt.Println(cmdLineFromPanelInstallStateV4(state))
 
+		if state.ExistingDatabase {
+			fmt.Println("Pass the database password on stdin or set GAMEAPCTL_DATABASE_PASSWORD.")
+		}
+
 		return true, nil
 	}
 
@@ -867,7 +967,7 @@ func installMySQLOrMariaDBV4(
 		}
 
 		if state.OSInfo.IsLinux() && !state.DatabaseDirExistedBefore && utils.IsFileExists("/var/lib/mysql") {
-			err := os.RemoveAll("/var/lib/mysql")
+			err := oscore.RemoveAll(ctx, "/var/lib/mysql")
 			if err != nil {
 				return state, errors.WithMessage(err, "failed to remove MySQL data directory")
 			}
@@ -1104,6 +1204,11 @@ func checkMySQLConnectionV4(
 	ctx context.Context,
 	state panelInstallStateV4,
 ) (panelInstallStateV4, error) {
+	// A database installed by this run does not exist in a dry run, an existing one is still checked.
+	if !state.ExistingDatabase && dryrun.Enabled(ctx) {
+		return state, nil
+	}
+
 	fmt.Println("Checking MySQL connection ...")
 	db, err := sql.Open(
 		mysqlDatabase,
@@ -1135,12 +1240,12 @@ func checkMySQLConnectionV4(
 		return state, errors.WithMessage(err, "failed to execute MySQL query")
 	}
 
-	isDatabaseEmpty, err := mysqlIsDatabaseEmpty(ctx, db, state.DBCreds.DatabaseName)
+	empty, err := isDatabaseEmpty(ctx, dbprovision.MySQL, db, state.DBCreds.DatabaseName)
 	if err != nil {
 		return state, errors.WithMessage(err, "failed to check database")
 	}
 
-	state.DatabaseIsNotEmpty = !isDatabaseEmpty
+	state.DatabaseIsNotEmpty = !empty
 
 	return state, nil
 }
@@ -1149,11 +1254,15 @@ func checkPostgreSQLConnectionV4(
 	ctx context.Context,
 	state panelInstallStateV4,
 ) (panelInstallStateV4, error) {
+	// A database installed by this run does not exist in a dry run, an existing one is still checked.
+	if !state.ExistingDatabase && dryrun.Enabled(ctx) {
+		return state, nil
+	}
+
 	fmt.Println("Checking PostgreSQL connection ...")
 	db, err := sql.Open(
 		"pgx",
-		fmt.Sprintf(
-			"postgres://%s:%s@%s:%s/%s?sslmode=disable",
+		postgresDSN(
 			state.DBCreds.Username,
 			state.DBCreds.Password,
 			state.DBCreds.Host,
@@ -1180,46 +1289,17 @@ func checkPostgreSQLConnectionV4(
 		return state, errors.WithMessage(err, "failed to execute PostgreSQL query")
 	}
 
-	isDatabaseEmpty, err := postgresqlIsDatabaseEmpty(ctx, db, state.DBCreds.DatabaseName)
+	empty, err := isDatabaseEmpty(ctx, dbprovision.Postgres, db, state.DBCreds.DatabaseName)
 	if err != nil {
 		return state, errors.WithMessage(err, "failed to check database")
 	}
 
-	state.DatabaseIsNotEmpty = !isDatabaseEmpty
+	state.DatabaseIsNotEmpty = !empty
 
 	return state, nil
 }
 
-func postgresqlIsDatabaseEmpty(ctx context.Context, db *sql.DB, _ string) (bool, error) {
-	var tableExists bool
-
-	err := db.QueryRowContext(ctx, `SELECT EXISTS (
-		SELECT 1
-		FROM information_schema.tables
-		WHERE table_schema = 'public'
-		AND table_name = $1
-	)`, "games").Scan(&tableExists)
-	if err != nil {
-		return false, errors.WithMessage(err, "failed to execute query")
-	}
-	if !tableExists {
-		return true, nil
-	}
-
-	var recordsExist bool
-
-	err = db.QueryRowContext(
-		ctx,
-		"SELECT EXISTS(SELECT 1 FROM games LIMIT 1)",
-	).Scan(&recordsExist)
-	if err != nil {
-		return false, errors.WithMessage(err, "failed to execute query")
-	}
-
-	return !recordsExist, nil
-}
-
-func installSqliteV4(_ context.Context, state panelInstallStateV4) (panelInstallStateV4, error) {
+func installSqliteV4(ctx context.Context, state panelInstallStateV4) (panelInstallStateV4, error) {
 	dbPath := filepath.Join(state.DataDirectory, "database.sqlite")
 
 	if utils.IsFileExists(dbPath) {
@@ -1227,22 +1307,28 @@ func installSqliteV4(_ context.Context, state panelInstallStateV4) (panelInstall
 		state.DBCreds.DatabaseName = dbPath
 		state.DatabaseWasInstalled = true
 
+		empty, err := isSQLiteDatabaseEmpty(ctx, dbPath)
+		if err != nil {
+			return state, errors.WithMessage(err, "failed to check database")
+		}
+
+		state.DatabaseIsNotEmpty = !empty
+		if !empty {
+			fmt.Println("SQLite database contains panel data, it is kept as is")
+		}
+
 		return state, nil
 	}
 
-	err := os.MkdirAll(state.DataDirectory, 0755)
+	err := oscore.MkdirAll(ctx, state.DataDirectory, 0755)
 	if err != nil {
 		return state, errors.WithMessage(err, "failed to create data directory for sqlite database")
 	}
 
-	f, err := os.Create(dbPath)
+	err = oscore.WriteFile(ctx, dbPath, nil, 0644)
 	if err != nil {
 		return state, errors.WithMessage(err, "failed to create database.sqlite")
 	}
-	err = f.Close()
-	if err != nil {
-		return state, errors.WithMessage(err, "failed to close database.sqlite")
-	}
 
 	state.DBCreds.DatabaseName = dbPath
 	state.DatabaseWasInstalled = true
@@ -1301,9 +1387,6 @@ func installPostgreSQL(
 		ctx,
 		packagemanager.PostgreSQLPackage,
 		packagemanager.WithConfigValue(packagemanager.ConfigValueDBRootPassword, state.DBCreds.RootPassword),
-		packagemanager.WithConfigValue(packagemanager.ConfigValueDBUser, state.DBCreds.Username),
-		packagemanager.WithConfigValue(packagemanager.ConfigValueDBPassword, state.DBCreds.Password),
-		packagemanager.WithConfigValue(packagemanager.ConfigValueDBName, state.DBCreds.DatabaseName),
 	)
 	if err != nil {
 		return state, errors.WithMessage(err, "failed to install PostgreSQL")
@@ -1311,6 +1394,10 @@ func installPostgreSQL(
 
 	state.DatabaseWasInstalled = true
 
+	if err = configurePostgreSQL(ctx, state.DBCreds); err != nil {
+		return state, errors.WithMessage(err, "failed to configure PostgreSQL")
+	}
+
 	state, err = checkPostgreSQLConnectionV4(ctx, state)
 	if err != nil {
 		log.Println(err)
@@ -1338,6 +1425,10 @@ func installPostgreSQL(
 // daemonInstallV4 dispatches to the daemon enrollment flow that matches the
 // panel's transport: gRPC bidi for >= v4.2, legacy HTTP otherwise.
 func daemonInstallV4(ctx context.Context, state panelInstallStateV4) (panelInstallStateV4, error) {
+	if dryrun.Enabled(ctx) {
+		return daemonInstallV4DryRun(ctx, state)
+	}
+
 	if state.GRPCEnabled {
 		return daemonInstallV4GRPC(ctx, state)
 	}
@@ -1345,6 +1436,31 @@ func daemonInstallV4(ctx context.Context, state panelInstallStateV4) (panelInsta
 	return daemonInstallV4Legacy(ctx, state)
 }
 
+// daemonInstallV4DryRun plans the daemon installation without the enrollment
+// round-trip: the panel is not started in a dry run, so placeholders stand in
+// for the setup key and the create token.
+func daemonInstallV4DryRun(ctx context.Context, state panelInstallStateV4) (panelInstallStateV4, error) {
+	opts := daemoninstall.InstallOptions{
+		Host:  "http://" + state.Host + ":" + state.Port,
+		Token: "dry-run",
+		Scope: state.Scope,
+	}
+
+	if state.GRPCEnabled {
+		grpcPort := state.GRPCPort
+		if grpcPort == "" {
+			grpcPort = gameap.DefaultGRPCPort
+		}
+
+		opts = daemoninstall.InstallOptions{
+			ConnectURL: fmt.Sprintf("grpc://%s/dry-run", net.JoinHostPort(state.Host, grpcPort)),
+			Scope:      state.Scope,
+		}
+	}
+
+	return state, daemoninstall.Install(ctx, opts)
+}
+
 const (
 	daemonSetupTokenEnv = "DAEMON_SETUP_TOKEN"
 	daemonSetupKeyEnv   = "DAEMON_SETUP_KEY"
@@ -1567,6 +1683,10 @@ func removeConfigEnvVar(configPath, name string) {
 }
 
 func waitForPanelHealthCheck(ctx context.Context, host string, maxRetries int, retryDelay time.Duration) error {
+	if dryrun.Enabled(ctx) {
+		return nil
+	}
+
 	client := &http.Client{
 		Timeout: 5 * time.Second, //nolint:mnd
 	}
@@ -1600,6 +1720,17 @@ func waitForPanelHealthCheck(ctx context.Context, host string, maxRetries int, r
 	return nil
 }
 
+func configureACMEV4(ctx context.Context, state panelInstallStateV4) error {
+	paths, err := gameap.PanelPathsForScope(state.Scope)
+	if err != nil {
+		return errors.WithMessage(err, "failed to resolve panel paths for scope")
+	}
+
+	fmt.Println("Configuring ACME certificates for", strings.Join(state.ACME.Domains, ", "), "...")
+
+	return letsencrypt.Configure(ctx, paths, letsencrypt.OptionsFromAnswers(state.ACME))
+}
+
 func updateAdminPasswordv4(ctx context.Context, state panelInstallStateV4) (panelInstallStateV4, error) {
 	var err error
 	if state.AdminPassword == "" {
@@ -1613,6 +1744,12 @@ func updateAdminPasswordv4(ctx context.Context, state panelInstallStateV4) (pane
 		}
 	}
 
+	redact.Register(state.AdminPassword)
+
+	if dryrun.Record(ctx, dryrun.KindDatabase, "set the admin user password") {
+		return state, nil
+	}
+
 	err = changepassword.ChangePassword(
 		ctx, "admin", state.AdminPassword,
 		changepassword.Options{ConfigPath: filepath.Join(state.ConfigDirectory, "config.env")},
@@ -1658,8 +1795,8 @@ func cmdLineFromPanelInstallStateV4(state panelInstallStateV4) string {
 		sb.WriteString(state.DBCreds.DatabaseName)
 		sb.WriteString(" --database-username=")
 		sb.WriteString(state.DBCreds.Username)
-		sb.WriteString(" --database-password=")
-		sb.WriteString(state.DBCreds.Password)
+		// The password is not printed, it would end up in the shell history.
+		sb.WriteString(" --database-password-stdin")
 	}
 
 	if state.WithDaemon {
@@ -1705,5 +1842,45 @@ func savePanelInstallationDetailsV4(ctx context.Context, state panelInstallState
 		DBPassword:           state.DBCreds.Password,
 		DBRootPassword:       state.DBCreds.RootPassword,
 		AdminPassword:        state.AdminPassword,
+		ExistingDatabase:     state.ExistingDatabase,
+		WithDaemon:           state.WithDaemon,
+		BinaryPath:           state.BinaryPath,
+		Tag:                  state.Tag,
+		TagPrefix:            state.TagPrefix,
+		GRPCEnabled:          state.GRPCEnabled,
+		GRPCPort:             state.GRPCPort,
+		HTTPS:                state.HTTPS,
+		ACME:                 acmeToStateV4(state.ACME),
+		CompletedSteps:       state.CompletedSteps,
 	})
 }
+
+func acmeToStateV4(acme *answers.ACME) *gameapctl.PanelACMEState {
+	if acme == nil {
+		return nil
+	}
+
+	return &gameapctl.PanelACMEState{
+		Challenge:   acme.Challenge,
+		Domains:     acme.Domains,
+		Email:       acme.Email,
+		DNSProvider: acme.DNSProvider,
+		Staging:     acme.Staging,
+		Env:         acme.Env,
+	}
+}
+
+func acmeFromStateV4(acme *gameapctl.PanelACMEState) *answers.ACME {
+	if acme == nil {
+		return nil
+	}
+
+	return &answers.ACME{
+		Challenge:   acme.Challenge,
+		Domains:     acme.Domains,
+		Email:       acme.Email,
+		DNSProvider: acme.DNSProvider,
+		Staging:     acme.Staging,
+		Env:         acme.Env,
+	}
+}
@@ -0,0 +1,160 @@
+package install
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"runtime"
+
+	"github.com/gameap/gameapctl/internal/pkg/dbprovision"
+	"github.com/gameap/gameapctl/pkg/dryrun"
+	"github.com/gameap/gameapctl/pkg/oscore"
+	"github.com/pkg/errors"
+)
+
+// Some postgres helpers
+
+const (
+	postgresSuperuser       = "postgres"
+	postgresDefaultPort     = "5432"
+	postgresDefaultDatabase = "postgres"
+)
+
+// postgresDSN builds the connection URL, the credentials are escaped.
+func postgresDSN(username, password, host, port, database string) string {
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(username, password),
+		Host:     net.JoinHostPort(host, port),
+		Path:     "/" + database,
+		RawQuery: "sslmode=disable",
+	}
+
+	return u.String()
+}
+
+// configurePostgreSQL creates the panel database and user on the server
+// installed by this run. An existing user gets the password of this installation.
+func configurePostgreSQL(ctx context.Context, dbCreds databaseCredentials) error {
+	// The server is not installed in a dry run, there is nothing to connect to.
+	if dryrun.Record(
+		ctx, dryrun.KindDatabase, "create PostgreSQL database %s and user %s, grant privileges",
+		dbCreds.DatabaseName, dbCreds.Username,
+	) {
+		return nil
+	}
+
+	// On Windows initdb sets the superuser password.
+	if runtime.GOOS != "windows" {
+		if err := setPostgresRootPassword(ctx, dbCreds.RootPassword); err != nil {
+			return errors.WithMessage(err, "failed to set postgres user password")
+		}
+	}
+
+	db, err := postgresMakeAdminConnection(ctx, dbCreds)
+	if err != nil {
+		return errors.WithMessage(err, "failed to make admin connection")
+	}
+	defer func(db *sql.DB) {
+		err := db.Close()
+		if err != nil {
+			log.Println(err)
+		}
+	}(db)
+
+	provisioner, err := dbprovision.New(ctx, dbprovision.Postgres, db)
+	if err != nil {
+		return errors.WithMessage(err, "failed to create provisioner")
+	}
+
+	userExists, err := provisioner.UserExists(ctx, dbCreds.Username)
+	if err != nil {
+		return errors.WithMessage(err, "failed to check user")
+	}
+
+	if !userExists {
+		fmt.Println("Creating user ...")
+		err = provisioner.CreateUser(ctx, dbCreds.Username, dbCreds.Password)
+		if err != nil {
+			return errors.WithMessage(err, "failed to create user")
+		}
+	} else {
+		fmt.Printf("User '%s' already exists, changing password ...\n", dbCreds.Username)
+		err = provisioner.AlterUserPassword(ctx, dbCreds.Username, dbCreds.Password)
+		if err != nil {
+			return errors.WithMessage(err, "failed to change user password")
+		}
+	}
+
+	databaseExists, err := provisioner.DatabaseExists(ctx, dbCreds.DatabaseName)
+	if err != nil {
+		return errors.WithMessage(err, "failed to check database")
+	}
+
+	if !databaseExists {
+		fmt.Println("Creating database ...")
+		err = provisioner.CreateDatabase(ctx, dbCreds.DatabaseName)
+		if err != nil {
+			return errors.WithMessage(err, "failed to create database")
+		}
+	}
+
+	fmt.Println("Granting privileges ...")
+	err = provisioner.Grant(ctx, dbCreds.Username, dbCreds.DatabaseName)
+	if err != nil {
+		return errors.WithMessage(err, "failed to grant privileges")
+	}
+
+	return nil
+}
+
+// setPostgresRootPassword sets the password of the postgres superuser. A fresh
+// server lets it in only through peer authentication, so psql runs as the
+// postgres system user. No shell is involved, the statement reaches psql as is.
+func setPostgresRootPassword(ctx context.Context, rootPassword string) error {
+	query, err := dbprovision.PostgresAlterPasswordQuery(postgresSuperuser, rootPassword)
+	if err != nil {
+		return err
+	}
+
+	return oscore.ExecCommand(
+		ctx, "runuser", "-u", postgresSuperuser, "--", "psql", "-v", "ON_ERROR_STOP=1", "-c", query,
+	)
+}
+
+// postgresMakeAdminConnection connects to the server as the postgres superuser,
+// trying the configured host and port first and then the local default port.
+func postgresMakeAdminConnection(ctx context.Context, dbCreds databaseCredentials) (*sql.DB, error) {
+	addrs := [][2]string{{dbCreds.Host, dbCreds.Port}}
+	if dbCreds.Host != "127.0.0.1" || dbCreds.Port != postgresDefaultPort {
+		addrs = append(addrs, [2]string{"127.0.0.1", postgresDefaultPort})
+	}
+
+	var err error
+	for _, addr := range addrs {
+		var db *sql.DB
+
+		db, err = sql.Open("pgx", postgresDSN(
+			postgresSuperuser, dbCreds.RootPassword, addr[0], addr[1], postgresDefaultDatabase,
+		))
+		if err != nil {
+			continue
+		}
+
+		log.Printf("Checking PostgreSQL at %s\n", net.JoinHostPort(addr[0], addr[1]))
+
+		if err = db.PingContext(ctx); err != nil {
+			log.Println(err)
+			_ = db.Close()
+
+			continue
+		}
+
+		return db, nil
+	}
+
+	return nil, errors.WithMessage(err, "failed to get PostgreSQL connection")
+}
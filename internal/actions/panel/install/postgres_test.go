@@ -0,0 +1,68 @@
+package install
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gameap/gameapctl/internal/pkg/redact"
+	"github.com/gameap/gameapctl/pkg/dryrun"
+	"github.com/gameap/gameapctl/pkg/oscore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresDSN(t *testing.T) {
+	dsn := postgresDSN("gameap", "p@ss:w/rd?#'", "127.0.0.1", "5432", "gameap")
+
+	u, err := url.Parse(dsn)
+	require.NoError(t, err)
+
+	password, _ := u.User.Password()
+	assert.Equal(t, "p@ss:w/rd?#'", password)
+	assert.Equal(t, "gameap", u.User.Username())
+	assert.Equal(t, "127.0.0.1:5432", u.Host)
+	assert.Equal(t, "/gameap", u.Path)
+	assert.Equal(t, "disable", u.Query().Get("sslmode"))
+}
+
+func TestPreconfigureDatabase_GeneratedPasswordIsRedactedInCommandLog(t *testing.T) {
+	dbCreds, err := preconfigureDatabase(context.Background(), databaseCredentials{})
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	prev := log.Writer()
+	log.SetOutput(buf)
+	t.Cleanup(func() { log.SetOutput(prev) })
+
+	require.NoError(t, oscore.ExecCommand(context.Background(), "echo", "-n", "PASSWORD '"+dbCreds.Password+"'"))
+
+	// The command output itself follows the logged command line.
+	var commandLine string
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.Contains(line, "echo") {
+			commandLine = line
+		}
+	}
+	require.NotEmpty(t, commandLine)
+	assert.NotContains(t, commandLine, dbCreds.Password)
+	assert.Contains(t, commandLine, redact.Mask)
+}
+
+func TestSetPostgresRootPassword_GeneratedPasswordIsRedactedInDryRun(t *testing.T) {
+	dbCreds, err := preconfigureDatabase(context.Background(), databaseCredentials{})
+	require.NoError(t, err)
+
+	plan := dryrun.NewPlan()
+	ctx := dryrun.WithPlan(context.Background(), plan)
+
+	require.NoError(t, setPostgresRootPassword(ctx, dbCreds.RootPassword))
+
+	actions := plan.Actions()
+	require.Len(t, actions, 1)
+	assert.NotContains(t, actions[0].Description, dbCreds.RootPassword)
+	assert.Contains(t, actions[0].Description, redact.Mask)
+}
@@ -0,0 +1,164 @@
+package install
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gameap/gameapctl/internal/pkg/gameapctl"
+	"github.com/gameap/gameapctl/pkg/gameap"
+	"github.com/gameap/gameapctl/pkg/progress"
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+)
+
+// Installation steps recorded in the state checkpoint. 'panel install --resume'
+// skips the steps completed by the previous run.
+const (
+	stepDependencies = "dependencies"
+	stepDatabase     = "database"
+	stepPanel        = "panel"
+	stepACME         = "acme"
+	stepDaemon       = "daemon"
+	stepFinish       = "finish"
+)
+
+var stepTitles = map[string]string{
+	stepDependencies: "system dependencies",
+	stepDatabase:     "database setup",
+	stepPanel:        "panel binaries download and configuration",
+	stepACME:         "ACME configuration",
+	stepDaemon:       "daemon installation",
+	stepFinish:       "panel start and admin password setup",
+}
+
+var errNothingToResume = errors.New(
+	"the previous installation has finished, nothing to resume; run 'panel install' without --resume to reinstall",
+)
+
+// resumeStateV4 replaces the installation parameters with the ones saved in the
+// checkpoint of the failed run. The flags only decide how to run the installer
+// (interactivity, warnings), not what to install.
+func resumeStateV4(ctx context.Context, state panelInstallStateV4) (panelInstallStateV4, error) {
+	prev, err := gameapctl.LoadPanelInstallState(ctx)
+	if err != nil {
+		return state, errors.WithMessage(err, "failed to load the installation checkpoint")
+	}
+
+	if !isPrevStateV4(prev.Version) || len(prev.CompletedSteps) == 0 {
+		return state, errors.New(
+			"no installation checkpoint to resume from, run 'panel install' without --resume",
+		)
+	}
+
+	if slices.Contains(prev.CompletedSteps, stepFinish) &&
+		(!prev.WithDaemon || slices.Contains(prev.CompletedSteps, stepDaemon)) {
+		return state, errNothingToResume
+	}
+
+	state.Resume = true
+	state.NonInteractive = true
+	state.CompletedSteps = prev.CompletedSteps
+
+	state.Scope = gameap.ScopeOrDefault(prev.Scope)
+	state.Host = prev.Host
+	state.HostIP = prev.HostIP
+	state.Port = prev.Port
+	state.Database = prev.Database
+	state.ExistingDatabase = prev.ExistingDatabase
+	state.DatabaseWasInstalled = prev.DatabaseWasInstalled
+	state.DBCreds = databaseCredentials{
+		Host:         prev.DBHost,
+		Port:         prev.DBPort,
+		DatabaseName: prev.DBName,
+		Username:     prev.DBUsername,
+		Password:     prev.DBPassword,
+		RootPassword: prev.DBRootPassword,
+	}
+	state.AdminPassword = prev.AdminPassword
+	state.WithDaemon = prev.WithDaemon
+	state.FromGithub = prev.FromGithub
+	state.Branch = prev.Branch
+	state.Tag = prev.Tag
+	state.TagPrefix = prev.TagPrefix
+	state.GRPCEnabled = prev.GRPCEnabled
+	state.GRPCPort = prev.GRPCPort
+	state.HTTPS = prev.HTTPS
+	state.ACME = acmeFromStateV4(prev.ACME)
+
+	if prev.Version != "v4" {
+		state.ResolvedTag = prev.Version
+	}
+
+	paths, err := gameap.PanelPathsForScope(state.Scope)
+	if err != nil {
+		return state, errors.WithMessage(err, "failed to resolve panel paths for scope")
+	}
+	state.ConfigDirectory = lo.CoalesceOrEmpty(prev.ConfigDirectory, paths.ConfigDir)
+	state.DataDirectory = lo.CoalesceOrEmpty(prev.DataDirectory, paths.DataDir)
+	state.BinaryPath = lo.CoalesceOrEmpty(prev.BinaryPath, paths.BinaryPath)
+
+	titles := make([]string, 0, len(state.CompletedSteps))
+	for _, step := range state.CompletedSteps {
+		titles = append(titles, stepTitles[step])
+	}
+
+	fmt.Println("Resuming the installation from the last checkpoint.")
+	fmt.Println("Completed steps:", strings.Join(titles, ", "))
+	fmt.Println()
+
+	return state, nil
+}
+
+// skipStepV4 reports whether the step was completed by the resumed run.
+func skipStepV4(steps *progress.Sequence, state panelInstallStateV4, step string) bool {
+	if !state.Resume || !slices.Contains(state.CompletedSteps, step) {
+		return false
+	}
+
+	steps.Skip(step, stepTitles[step], "completed by the previous run")
+
+	return true
+}
+
+// countStepsV4 returns the number of steps reported to the progress display.
+func countStepsV4(state panelInstallStateV4) int {
+	total := 3 //nolint:mnd // dependencies, database and panel
+
+	if state.ACME != nil {
+		total++
+	}
+	if state.WithDaemon {
+		total++
+	}
+	if state.Database != noneDatabase {
+		total++
+	}
+
+	return total
+}
+
+// completeFinishStepV4 records the last step unless the daemon installation
+// failed: the checkpoint stays resumable, so --resume retries the daemon.
+func completeFinishStepV4(ctx context.Context, state panelInstallStateV4, daemonFailed bool) panelInstallStateV4 {
+	if daemonFailed {
+		saveStateCheckpointV4(ctx, state)
+
+		return state
+	}
+
+	return completeStepV4(ctx, state, stepFinish)
+}
+
+// completeStepV4 records the step and saves the checkpoint, a failure after it
+// can be resumed without repeating the step.
+func completeStepV4(ctx context.Context, state panelInstallStateV4, step string) panelInstallStateV4 {
+	if !slices.Contains(state.CompletedSteps, step) {
+		state.CompletedSteps = append(slices.Clone(state.CompletedSteps), step)
+	}
+
+	saveStateCheckpointV4(ctx, state)
+
+	return state
+}
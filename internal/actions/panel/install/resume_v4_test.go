@@ -0,0 +1,155 @@
+package install
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gameap/gameapctl/internal/pkg/answers"
+	"github.com/gameap/gameapctl/internal/pkg/gameapctl"
+	"github.com/gameap/gameapctl/pkg/gameap"
+	"github.com/gameap/gameapctl/pkg/progress"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResumeStateV4(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	ctx := context.Background()
+
+	require.NoError(t, gameapctl.SavePanelInstallState(ctx, gameapctl.PanelInstallState{
+		Version:          "v4.2.0",
+		Scope:            gameap.ScopeSystem,
+		Host:             "panel.example.com",
+		Port:             "8080",
+		Database:         postgresDatabase,
+		ExistingDatabase: true,
+		DBHost:           "db.example.com",
+		DBPassword:       "secret",
+		WithDaemon:       true,
+		GRPCEnabled:      true,
+		GRPCPort:         "31718",
+		CompletedSteps:   []string{stepDependencies, stepDatabase},
+	}))
+
+	state, err := resumeStateV4(ctx, panelInstallStateV4{Host: "ignored.example.com"})
+	require.NoError(t, err)
+
+	assert.True(t, state.Resume)
+	assert.True(t, state.NonInteractive)
+	assert.Equal(t, "panel.example.com", state.Host)
+	assert.Equal(t, "8080", state.Port)
+	assert.Equal(t, postgresDatabase, state.Database)
+	assert.True(t, state.ExistingDatabase)
+	assert.Equal(t, "db.example.com", state.DBCreds.Host)
+	assert.Equal(t, "secret", state.DBCreds.Password)
+	assert.True(t, state.WithDaemon)
+	assert.Equal(t, "v4.2.0", state.ResolvedTag)
+	assert.True(t, state.GRPCEnabled)
+	assert.NotEmpty(t, state.ConfigDirectory)
+
+	assert.True(t, skipStepV4(progress.Steps(ctx, 0), state, stepDatabase))
+	assert.False(t, skipStepV4(progress.Steps(ctx, 0), state, stepPanel))
+}
+
+func TestResumeStateV4_NoCheckpoint(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	_, err := resumeStateV4(context.Background(), panelInstallStateV4{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to load the installation checkpoint")
+}
+
+func TestResumeStateV4_Finished(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	ctx := context.Background()
+
+	require.NoError(t, gameapctl.SavePanelInstallState(ctx, gameapctl.PanelInstallState{
+		Version:        "v4",
+		CompletedSteps: []string{stepDependencies, stepDatabase, stepPanel, stepFinish},
+	}))
+
+	_, err := resumeStateV4(ctx, panelInstallStateV4{})
+	require.ErrorIs(t, err, errNothingToResume)
+}
+
+func TestResumeStateV4_FailedDaemon(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	ctx := context.Background()
+
+	require.NoError(t, gameapctl.SavePanelInstallState(ctx, gameapctl.PanelInstallState{
+		Version:        "v4",
+		WithDaemon:     true,
+		CompletedSteps: []string{stepDependencies, stepDatabase, stepPanel, stepFinish},
+	}))
+
+	state, err := resumeStateV4(ctx, panelInstallStateV4{})
+	require.NoError(t, err)
+
+	assert.True(t, state.WithDaemon)
+	assert.False(t, skipStepV4(progress.Steps(ctx, 0), state, stepDaemon))
+}
+
+func TestResumeStateV4_ACME(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	ctx := context.Background()
+
+	state := panelInstallStateV4{
+		Host:     "panel.example.com",
+		Database: sqliteDatabase,
+		HTTPS:    true,
+		ACME: &answers.ACME{
+			Challenge:   "dns-01",
+			Domains:     []string{"panel.example.com"},
+			DNSProvider: "cloudflare",
+			Env:         map[string]string{"CF_DNS_API_TOKEN": "token"},
+		},
+	}
+	state = completeStepV4(ctx, state, stepDependencies)
+	state = completeStepV4(ctx, state, stepACME)
+
+	resumed, err := resumeStateV4(ctx, panelInstallStateV4{})
+	require.NoError(t, err)
+
+	assert.True(t, resumed.HTTPS)
+	assert.Equal(t, state.ACME, resumed.ACME)
+	assert.True(t, skipStepV4(progress.Steps(ctx, 0), resumed, stepACME))
+}
+
+func TestCompleteFinishStepV4_DaemonFailed(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	ctx := context.Background()
+
+	state := panelInstallStateV4{Host: "panel.example.com", WithDaemon: true}
+	state = completeStepV4(ctx, state, stepPanel)
+	state = completeFinishStepV4(ctx, state, true)
+
+	assert.Equal(t, []string{stepPanel}, state.CompletedSteps)
+
+	_, err := resumeStateV4(ctx, panelInstallStateV4{})
+	require.NoError(t, err)
+}
+
+func TestCompleteStepV4(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	ctx := context.Background()
+
+	state := panelInstallStateV4{Host: "panel.example.com"}
+	state = completeStepV4(ctx, state, stepDependencies)
+	state = completeStepV4(ctx, state, stepDatabase)
+	state = completeStepV4(ctx, state, stepDatabase)
+
+	assert.Equal(t, []string{stepDependencies, stepDatabase}, state.CompletedSteps)
+
+	saved, err := gameapctl.LoadPanelInstallState(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{stepDependencies, stepDatabase}, saved.CompletedSteps)
+	assert.Equal(t, "panel.example.com", saved.Host)
+
+	assert.False(t, skipStepV4(progress.Steps(ctx, 0), state, stepDatabase), "steps are skipped only when resuming")
+}
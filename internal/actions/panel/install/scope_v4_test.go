@@ -74,3 +74,19 @@ func TestCmdLineFromPanelInstallStateV4_SystemScope(t *testing.T) {
 
 	assert.NotContains(t, cmdLine, "--scope")
 }
+
+func TestCmdLineFromPanelInstallStateV4_OmitsDatabasePassword(t *testing.T) {
+	state := panelInstallStateV4{
+		Scope:            gameap.ScopeSystem,
+		Host:             "127.0.0.1",
+		Port:             "80",
+		Database:         mysqlDatabase,
+		ExistingDatabase: true,
+	}
+	state.DBCreds.Password = "s3cr3t"
+
+	cmdLine := cmdLineFromPanelInstallStateV4(state)
+
+	assert.NotContains(t, cmdLine, "s3cr3t")
+	assert.Contains(t, cmdLine, "--database-password-stdin")
+}
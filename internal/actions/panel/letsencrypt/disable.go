@@ -20,7 +20,7 @@ func Disable(cliCtx *cli.Context) error {
 	configPath := paths.ConfigFilePath
 	log.Printf("Reading config from: %s\n", configPath)
 
-	lines, _, err := readEnv(configPath)
+	lines, _, err := panelpkg.ReadConfigEnv(configPath)
 	if err != nil {
 		return err
 	}
@@ -34,10 +34,10 @@ func Disable(cliCtx *cli.Context) error {
 			continue
 		}
 
-		updates[k] = removeMarker
+		updates[k] = panelpkg.ConfigEnvRemove
 	}
 
-	if err := writeEnv(configPath, lines, updates); err != nil {
+	if err := panelpkg.WriteConfigEnv(configPath, lines, updates); err != nil {
 		return errors.WithMessage(err, "failed to write config")
 	}
 
@@ -2,13 +2,17 @@ package letsencrypt
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"log"
 	"net/mail"
 	"os"
+	"sort"
 	"strings"
 
+	"github.com/gameap/gameapctl/internal/pkg/answers"
 	panelpkg "github.com/gameap/gameapctl/internal/pkg/panel"
+	"github.com/gameap/gameapctl/pkg/dryrun"
 	"github.com/gameap/gameapctl/pkg/gameap"
 	"github.com/gameap/gameapctl/pkg/oscore"
 	"github.com/gameap/gameapctl/pkg/panel"
@@ -43,36 +47,10 @@ func Setup(cliCtx *cli.Context) error {
 		return err
 	}
 
-	if err := validateSetupParams(params); err != nil {
-		return err
-	}
-
-	// http-01 needs the ACME server to reach port 80, which an unprivileged process
-	// cannot bind, and there is no root to put a reverse proxy in front.
-	if paths.Scope == gameap.ScopeUser && params.challengeType == ChallengeHTTP01 {
-		return errors.New(
-			"the http-01 challenge requires port 80, which is not available with --scope=user; " +
-				"use --challenge=dns-01, forward /.well-known/acme-challenge/* from a " +
-				"system-level reverse proxy, or reinstall the panel with --scope=system",
-		)
-	}
-
-	configPath := paths.ConfigFilePath
-
-	lines, _, err := readEnv(configPath)
-	if err != nil {
-		return err
-	}
-
-	updates, err := buildUpdates(params)
-	if err != nil {
+	if err := writeSetupParams(ctx, paths, params); err != nil {
 		return err
 	}
 
-	if err := writeEnv(configPath, lines, updates); err != nil {
-		return errors.WithMessage(err, "failed to write config")
-	}
-
 	if err := panelpkg.CheckBinaryInstalled(paths); err != nil {
 		return err
 	}
@@ -103,6 +81,91 @@ func Setup(cliCtx *cli.Context) error {
 	return nil
 }
 
+// Options are the ACME settings written to config.env.
+type Options struct {
+	Challenge   string
+	Domains     []string
+	Email       string
+	DNSProvider string
+	Staging     bool
+	// Env holds additional KEY=VALUE entries, e.g. DNS provider credentials.
+	Env []string
+}
+
+// OptionsFromAnswers converts the acme section of an answers file.
+func OptionsFromAnswers(a *answers.ACME) Options {
+	env := make([]string, 0, len(a.Env))
+	for k, v := range a.Env {
+		env = append(env, k+"="+v)
+	}
+	sort.Strings(env)
+
+	return Options{
+		Challenge:   a.Challenge,
+		Domains:     a.Domains,
+		Email:       a.Email,
+		DNSProvider: a.DNSProvider,
+		Staging:     a.Staging,
+		Env:         env,
+	}
+}
+
+// Configure writes the ACME settings to config.env without restarting the panel.
+// The installer uses it to enable ACME before the panel is started for the first time.
+func Configure(ctx context.Context, paths gameap.PanelPaths, opts Options) error {
+	params := setupParams{
+		domains:       opts.Domains,
+		email:         opts.Email,
+		challengeType: opts.Challenge,
+		dnsProvider:   opts.DNSProvider,
+		envKVs:        opts.Env,
+		staging:       opts.Staging,
+	}
+	if params.challengeType == "" {
+		params.challengeType = ChallengeHTTP01
+	}
+
+	return writeSetupParams(ctx, paths, params)
+}
+
+func writeSetupParams(ctx context.Context, paths gameap.PanelPaths, params setupParams) error {
+	if err := validateSetupParams(params); err != nil {
+		return err
+	}
+
+	// http-01 needs the ACME server to reach port 80, which an unprivileged process
+	// cannot bind, and there is no root to put a reverse proxy in front.
+	if paths.Scope == gameap.ScopeUser && params.challengeType == ChallengeHTTP01 {
+		return errors.New(
+			"the http-01 challenge requires port 80, which is not available with --scope=user; " +
+				"use --challenge=dns-01, forward /.well-known/acme-challenge/* from a " +
+				"system-level reverse proxy, or reinstall the panel with --scope=system",
+		)
+	}
+
+	configPath := paths.ConfigFilePath
+
+	updates, err := buildUpdates(params)
+	if err != nil {
+		return err
+	}
+
+	if dryrun.Record(ctx, dryrun.KindFile, "write ACME settings to %s", configPath) {
+		return nil
+	}
+
+	lines, _, err := panelpkg.ReadConfigEnv(configPath)
+	if err != nil {
+		return err
+	}
+
+	if err := panelpkg.WriteConfigEnv(configPath, lines, updates); err != nil {
+		return errors.WithMessage(err, "failed to write config")
+	}
+
+	return nil
+}
+
 func collectSetupParams(cliCtx *cli.Context, paths gameap.PanelPaths) (setupParams, error) {
 	p := setupParams{
 		domains:       splitAndTrim(cliCtx.String("domains")),
@@ -113,6 +176,10 @@ func collectSetupParams(cliCtx *cli.Context, paths gameap.PanelPaths) (setupPara
 		envKVs:        cliCtx.StringSlice("env"),
 	}
 
+	if path := cliCtx.String(answers.FlagName); path != "" {
+		return applyAnswers(p, path)
+	}
+
 	if cliCtx.Bool("non-interactive") {
 		return p, nil
 	}
@@ -120,7 +187,7 @@ func collectSetupParams(cliCtx *cli.Context, paths gameap.PanelPaths) (setupPara
 	configPath := paths.ConfigFilePath
 	log.Printf("Reading config from: %s\n", configPath)
 
-	_, values, err := readEnv(configPath)
+	_, values, err := panelpkg.ReadConfigEnv(configPath)
 	if err != nil {
 		return p, err
 	}
@@ -142,6 +209,42 @@ func collectSetupParams(cliCtx *cli.Context, paths gameap.PanelPaths) (setupPara
 	return p, nil
 }
 
+// applyAnswers fills the parameters not passed as flags from the acme section of
+// the answers file. An answers file means an unattended run, nothing is prompted.
+func applyAnswers(p setupParams, path string) (setupParams, error) {
+	a, err := answers.Load(path)
+	if err != nil {
+		return p, err
+	}
+	if err = a.ValidateACME(); err != nil {
+		return p, err
+	}
+
+	opts := OptionsFromAnswers(a.ACME)
+
+	if len(p.domains) == 0 {
+		p.domains = opts.Domains
+	}
+	if p.email == "" {
+		p.email = opts.Email
+	}
+	if p.challengeType == "" {
+		p.challengeType = opts.Challenge
+	}
+	if p.challengeType == "" {
+		p.challengeType = ChallengeHTTP01
+	}
+	if p.dnsProvider == "" {
+		p.dnsProvider = opts.DNSProvider
+	}
+	if !p.staging {
+		p.staging = opts.Staging
+	}
+	p.envKVs = append(opts.Env, p.envKVs...)
+
+	return p, nil
+}
+
 func validateSetupParams(p setupParams) error {
 	if p.challengeType == "" {
 		return errors.New("challenge type is required")
@@ -196,7 +299,7 @@ func buildUpdates(p setupParams) (map[string]string, error) {
 	if p.challengeType == ChallengeDNS01 {
 		updates["ACME_DNS_PROVIDER"] = p.dnsProvider
 	} else {
-		updates["ACME_DNS_PROVIDER"] = removeMarker
+		updates["ACME_DNS_PROVIDER"] = panelpkg.ConfigEnvRemove
 	}
 
 	for _, kv := range p.envKVs {
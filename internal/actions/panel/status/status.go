@@ -1,10 +1,9 @@
 package status
 
 import (
-	"log"
-
+	"github.com/gameap/gameapctl/internal/pkg/output"
 	panelpkg "github.com/gameap/gameapctl/internal/pkg/panel"
-	"github.com/gameap/gameapctl/pkg/oscore"
+	statuspkg "github.com/gameap/gameapctl/internal/pkg/status"
 	"github.com/pkg/errors"
 	"github.com/urfave/cli/v2"
 )
@@ -12,24 +11,24 @@ import (
 func Handle(cliCtx *cli.Context) error {
 	ctx := cliCtx.Context
 
-	// Scope is informational here: the process lookup below works regardless of it,
-	// so a resolution failure must not hide the actual status.
-	paths, scopeErr := panelpkg.ResolveScope(ctx, cliCtx.String("scope"))
-	if scopeErr != nil {
-		log.Println(errors.WithMessage(scopeErr, "failed to resolve installation scope"))
-	} else {
-		log.Println("Installation scope:", paths.Scope)
+	// Zero value paths would be reported as "not installed", hiding the real problem.
+	paths, err := panelpkg.ResolveScope(ctx, cliCtx.String("scope"))
+	if err != nil {
+		return errors.WithMessage(err, "failed to resolve installation scope")
 	}
 
-	pr, err := oscore.FindProcessByName(ctx, "gameap")
+	result, err := statuspkg.Panel(ctx, paths)
 	if err != nil {
-		return errors.WithMessage(err, "failed to find started gameap process")
+		return err
 	}
-	if pr == nil {
-		return errors.New("started gameap process not found")
+
+	if err := output.Print(cliCtx, result); err != nil {
+		return err
 	}
 
-	log.Println("Success! GameAP process found with pid", pr.Pid)
+	if result.State != statuspkg.StateRunning {
+		return errors.New("started gameap process not found")
+	}
 
 	return nil
 }
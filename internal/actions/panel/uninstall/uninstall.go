@@ -8,6 +8,7 @@ import (
 	"github.com/gameap/gameapctl/internal/pkg/gameapctl"
 	panelpkg "github.com/gameap/gameapctl/internal/pkg/panel"
 	"github.com/gameap/gameapctl/pkg/daemon"
+	"github.com/gameap/gameapctl/pkg/dryrun"
 	"github.com/gameap/gameapctl/pkg/gameap"
 	packagemanager "github.com/gameap/gameapctl/pkg/package_manager"
 	"github.com/gameap/gameapctl/pkg/panel"
@@ -83,6 +84,10 @@ func Handle(cliCtx *cli.Context) error {
 		}
 	}
 
+	if dryrun.Enabled(ctx) {
+		return nil
+	}
+
 	fmt.Println()
 	fmt.Println("GameAP has been successfully uninstalled!")
 
@@ -6,10 +6,10 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"os"
 
 	"github.com/gameap/gameapctl/internal/pkg/gameapctl"
 	"github.com/gameap/gameapctl/pkg/gameap"
+	"github.com/gameap/gameapctl/pkg/oscore"
 	packagemanager "github.com/gameap/gameapctl/pkg/package_manager"
 	"github.com/gameap/gameapctl/pkg/systemd"
 	"github.com/gameap/gameapctl/pkg/utils"
@@ -24,8 +24,8 @@ func uninstallGameAP(ctx context.Context, paths gameap.PanelPaths, removeData bo
 
 	if utils.IsFileExists(paths.SystemdUnitPath) {
 		fmt.Println("Removing GameAP systemd service file...")
-		if err := os.Remove(paths.SystemdUnitPath); err != nil {
-			log.Println(errors.WithMessagef(err, "failed to remove %s", paths.SystemdUnitPath))
+		if err := oscore.RemoveAll(ctx, paths.SystemdUnitPath); err != nil {
+			log.Println(err)
 		}
 	}
 
@@ -37,8 +37,8 @@ func uninstallGameAP(ctx context.Context, paths gameap.PanelPaths, removeData bo
 	if removeData {
 		fmt.Println("Removing GameAP binary...")
 		if utils.IsFileExists(paths.BinaryPath) {
-			if err := os.Remove(paths.BinaryPath); err != nil {
-				log.Println(errors.WithMessagef(err, "failed to remove %s", paths.BinaryPath))
+			if err := oscore.RemoveAll(ctx, paths.BinaryPath); err != nil {
+				log.Println(err)
 			}
 		}
 	}
@@ -68,8 +68,8 @@ func uninstallDaemon(ctx context.Context, paths gameap.DaemonPaths, removeData b
 
 	if utils.IsFileExists(paths.SystemdUnitPath) {
 		fmt.Println("Removing GameAP Daemon systemd service file...")
-		if err := os.Remove(paths.SystemdUnitPath); err != nil {
-			log.Println(errors.WithMessagef(err, "failed to remove %s", paths.SystemdUnitPath))
+		if err := oscore.RemoveAll(ctx, paths.SystemdUnitPath); err != nil {
+			log.Println(err)
 		}
 	}
 
@@ -81,22 +81,22 @@ func uninstallDaemon(ctx context.Context, paths gameap.DaemonPaths, removeData b
 	if removeData {
 		fmt.Println("Removing GameAP Daemon binary...")
 		if utils.IsFileExists(paths.DaemonFilePath) {
-			if err := os.Remove(paths.DaemonFilePath); err != nil {
-				log.Println(errors.WithMessagef(err, "failed to remove %s", paths.DaemonFilePath))
+			if err := oscore.RemoveAll(ctx, paths.DaemonFilePath); err != nil {
+				log.Println(err)
 			}
 		}
 
 		fmt.Println("Removing GameAP Daemon configuration...")
 		if utils.IsFileExists(paths.DaemonConfigFilePath) {
-			if err := os.Remove(paths.DaemonConfigFilePath); err != nil {
-				log.Println(errors.WithMessagef(err, "failed to remove %s", paths.DaemonConfigFilePath))
+			if err := oscore.RemoveAll(ctx, paths.DaemonConfigFilePath); err != nil {
+				log.Println(err)
 			}
 		}
 
 		fmt.Println("Removing GameAP Daemon certificates...")
 		if utils.IsFileExists(paths.CertsPath) {
-			if err := os.RemoveAll(paths.CertsPath); err != nil {
-				log.Println(errors.WithMessagef(err, "failed to remove %s", paths.CertsPath))
+			if err := oscore.RemoveAll(ctx, paths.CertsPath); err != nil {
+				log.Println(err)
 			}
 		}
 	}
@@ -122,15 +122,15 @@ func removeData(ctx context.Context, paths gameap.PanelPaths) error {
 
 	if utils.IsFileExists(configDir) {
 		fmt.Printf("Removing GameAP configuration directory: %s\n", configDir)
-		if err := os.RemoveAll(configDir); err != nil {
-			log.Println(errors.WithMessagef(err, "failed to remove %s", configDir))
+		if err := oscore.RemoveAll(ctx, configDir); err != nil {
+			log.Println(err)
 		}
 	}
 
 	if utils.IsFileExists(dataDir) {
 		fmt.Printf("Removing GameAP data directory: %s\n", dataDir)
-		if err := os.RemoveAll(dataDir); err != nil {
-			log.Println(errors.WithMessagef(err, "failed to remove %s", dataDir))
+		if err := oscore.RemoveAll(ctx, dataDir); err != nil {
+			log.Println(err)
 		}
 	}
 
@@ -6,7 +6,6 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"os"
 	"strings"
 
 	"github.com/gameap/gameapctl/internal/pkg/gameapctl"
@@ -36,8 +35,8 @@ func uninstallGameAP(ctx context.Context, _ gameap.PanelPaths, removeData bool)
 	serviceConfigPath := defaultServicesConfigPath + "\\GameAP.yaml"
 	if utils.IsFileExists(serviceConfigPath) {
 		fmt.Printf("Removing service config: %s\n", serviceConfigPath)
-		if err := os.Remove(serviceConfigPath); err != nil {
-			log.Println(errors.WithMessagef(err, "failed to remove %s", serviceConfigPath))
+		if err := oscore.RemoveAll(ctx, serviceConfigPath); err != nil {
+			log.Println(err)
 		}
 	}
 
@@ -45,8 +44,8 @@ func uninstallGameAP(ctx context.Context, _ gameap.PanelPaths, removeData bool)
 		binaryPath := gameap.DefaultBinaryPath
 		if utils.IsFileExists(binaryPath) {
 			fmt.Printf("Removing GameAP binary: %s\n", binaryPath)
-			if err := os.Remove(binaryPath); err != nil {
-				log.Println(errors.WithMessagef(err, "failed to remove %s", binaryPath))
+			if err := oscore.RemoveAll(ctx, binaryPath); err != nil {
+				log.Println(err)
 			}
 		}
 	}
@@ -66,8 +65,8 @@ func uninstallDaemon(ctx context.Context, _ gameap.DaemonPaths, removeData bool)
 	serviceConfigPath := defaultServicesConfigPath + "\\GameAP Daemon.yaml"
 	if utils.IsFileExists(serviceConfigPath) {
 		fmt.Printf("Removing service config: %s\n", serviceConfigPath)
-		if err := os.Remove(serviceConfigPath); err != nil {
-			log.Println(errors.WithMessagef(err, "failed to remove %s", serviceConfigPath))
+		if err := oscore.RemoveAll(ctx, serviceConfigPath); err != nil {
+			log.Println(err)
 		}
 	}
 
@@ -75,32 +74,32 @@ func uninstallDaemon(ctx context.Context, _ gameap.DaemonPaths, removeData bool)
 		daemonBinaryPath := gameap.DefaultDaemonFilePath
 		if utils.IsFileExists(daemonBinaryPath) {
 			fmt.Printf("Removing daemon binary: %s\n", daemonBinaryPath)
-			if err := os.Remove(daemonBinaryPath); err != nil {
-				log.Println(errors.WithMessagef(err, "failed to remove %s", daemonBinaryPath))
+			if err := oscore.RemoveAll(ctx, daemonBinaryPath); err != nil {
+				log.Println(err)
 			}
 		}
 
 		daemonConfigPath := gameap.DefaultDaemonConfigFilePath
 		if utils.IsFileExists(daemonConfigPath) {
 			fmt.Printf("Removing daemon config: %s\n", daemonConfigPath)
-			if err := os.Remove(daemonConfigPath); err != nil {
-				log.Println(errors.WithMessagef(err, "failed to remove %s", daemonConfigPath))
+			if err := oscore.RemoveAll(ctx, daemonConfigPath); err != nil {
+				log.Println(err)
 			}
 		}
 
 		daemonCertPath := gameap.DefaultDaemonCertPath
 		if utils.IsFileExists(daemonCertPath) {
 			fmt.Printf("Removing daemon certificates: %s\n", daemonCertPath)
-			if err := os.RemoveAll(daemonCertPath); err != nil {
-				log.Println(errors.WithMessagef(err, "failed to remove %s", daemonCertPath))
+			if err := oscore.RemoveAll(ctx, daemonCertPath); err != nil {
+				log.Println(err)
 			}
 		}
 
 		daemonDir := "C:\\gameap\\daemon"
 		if utils.IsFileExists(daemonDir) {
 			fmt.Printf("Removing daemon directory: %s\n", daemonDir)
-			if err := os.RemoveAll(daemonDir); err != nil {
-				log.Println(errors.WithMessagef(err, "failed to remove %s", daemonDir))
+			if err := oscore.RemoveAll(ctx, daemonDir); err != nil {
+				log.Println(err)
 			}
 		}
 	}
@@ -121,8 +120,8 @@ func removeData(ctx context.Context, _ gameap.PanelPaths) error {
 
 	if utils.IsFileExists(dataDir) {
 		fmt.Printf("Removing GameAP data directory: %s\n", dataDir)
-		if err := os.RemoveAll(dataDir); err != nil {
-			log.Println(errors.WithMessagef(err, "failed to remove %s", dataDir))
+		if err := oscore.RemoveAll(ctx, dataDir); err != nil {
+			log.Println(err)
 		}
 	}
 
@@ -130,8 +129,8 @@ func removeData(ctx context.Context, _ gameap.PanelPaths) error {
 	if utils.IsFileExists(webDir) && webDir != dataDir {
 		fmt.Printf("Removing GameAP web directory: %s\n", webDir)
 
-		if err := os.RemoveAll(webDir); err != nil {
-			log.Println(errors.WithMessagef(err, "failed to remove %s", webDir))
+		if err := oscore.RemoveAll(ctx, webDir); err != nil {
+			log.Println(err)
 		}
 	}
 
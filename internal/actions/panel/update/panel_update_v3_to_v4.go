@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/gameap/gameapctl/internal/pkg/gameapctl"
+	"github.com/gameap/gameapctl/pkg/dryrun"
 	"github.com/gameap/gameapctl/pkg/gameap"
 	packagemanager "github.com/gameap/gameapctl/pkg/package_manager"
 	"github.com/gameap/gameapctl/pkg/panel"
@@ -41,6 +42,10 @@ const (
 func handleV3toV4(cliCtx *cli.Context) error {
 	ctx := cliCtx.Context
 
+	if dryrun.Enabled(ctx) {
+		return errors.New("dry run is not supported for the v3 to v4 migration")
+	}
+
 	log.Println("Starting GameAP v3 to v4 migration...")
 
 	state, err := gameapctl.LoadPanelInstallState(ctx)
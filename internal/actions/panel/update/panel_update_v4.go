@@ -14,9 +14,12 @@ import (
 
 	"github.com/gameap/gameapctl/internal/pkg/gameapctl"
 	installpkg "github.com/gameap/gameapctl/internal/pkg/panel"
+	"github.com/gameap/gameapctl/pkg/dryrun"
 	"github.com/gameap/gameapctl/pkg/gameap"
+	"github.com/gameap/gameapctl/pkg/oscore"
 	packagemanager "github.com/gameap/gameapctl/pkg/package_manager"
 	"github.com/gameap/gameapctl/pkg/panel"
+	"github.com/gameap/gameapctl/pkg/progress"
 	"github.com/gameap/gameapctl/pkg/releasefinder"
 	"github.com/gameap/gameapctl/pkg/releasesource"
 	"github.com/gameap/gameapctl/pkg/utils"
@@ -30,7 +33,8 @@ const (
 	healthCheckDelay   = 2 * time.Second
 )
 
-func handleV4(cliCtx *cli.Context, paths gameap.PanelPaths, tag, tagPrefix string) error {
+//nolint:funlen
+func handleV4(cliCtx *cli.Context, paths gameap.PanelPaths, tag, tagPrefix string) (err error) {
 	ctx := cliCtx.Context
 
 	fromGithub := cliCtx.Bool("github")
@@ -50,6 +54,14 @@ func handleV4(cliCtx *cli.Context, paths gameap.PanelPaths, tag, tagPrefix strin
 		}
 	}
 
+	channel := gameapctl.ReleaseChannel("")
+	if stateErr == nil {
+		channel = state.ReleaseChannel()
+	}
+	if tag == "" && tagPrefix == "" && channel.FromSource() {
+		fromGithub = true
+	}
+
 	if branch == "" {
 		branch = "main"
 	}
@@ -58,8 +70,13 @@ func handleV4(cliCtx *cli.Context, paths gameap.PanelPaths, tag, tagPrefix strin
 		return handleV4FromGithub(ctx, paths, branch)
 	}
 
+	steps := progress.Steps(ctx, 4) //nolint:mnd
+	defer func() { steps.End(err) }()
+
+	steps.Next("panel.download", "GameAP release download")
+
 	log.Println("Downloading GameAP release...")
-	tmpDir, downloadedBinary, resolvedTag, err := downloadRelease(ctx, tag, tagPrefix)
+	tmpDir, downloadedBinary, resolvedTag, err := downloadRelease(ctx, tag, tagPrefix, channel)
 	if err != nil {
 		return errors.WithMessage(err, "failed to download release")
 	}
@@ -69,22 +86,30 @@ func handleV4(cliCtx *cli.Context, paths gameap.PanelPaths, tag, tagPrefix strin
 		}
 	}()
 
+	steps.Next("panel.replace", "panel binary replacement")
+
 	log.Println("Stopping GameAP...")
 	if err := panel.Stop(ctx, panel.Options{Scope: paths.Scope}); err != nil {
 		return errors.WithMessage(err, "failed to stop GameAP")
 	}
 
+	keepRelease(ctx, paths)
+
 	log.Println("Backing up and replacing binary...")
 	backupPath := paths.BinaryPath + backupSuffix
-	if err := backupAndReplace(downloadedBinary, paths.BinaryPath, backupPath); err != nil {
+	if err := backupAndReplace(ctx, downloadedBinary, paths.BinaryPath, backupPath); err != nil {
 		return errors.WithMessage(err, "failed to backup and replace binary")
 	}
 
+	steps.Next("panel.start", "panel start")
+
 	log.Println("Starting GameAP...")
 	if err := panel.Start(ctx, panel.Options{Scope: paths.Scope}); err != nil {
 		return errors.WithMessage(err, "failed to start GameAP")
 	}
 
+	steps.Next("panel.health", "panel health check")
+
 	log.Println("Checking if new version is working...")
 	httpHost, httpPort, httpsEnabled, err := readConfigEnv(paths.ConfigFilePath)
 	if err != nil {
@@ -115,12 +140,16 @@ func handleV4(cliCtx *cli.Context, paths gameap.PanelPaths, tag, tagPrefix strin
 	}
 
 	log.Println("Update successful! Removing backup...")
-	if err := os.Remove(backupPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+	if err := oscore.RemoveAll(ctx, backupPath); err != nil {
 		log.Printf("Warning: failed to remove backup file: %v\n", err)
 	}
 
 	if resolvedTag != "" {
-		updatePanelStateVersion(ctx, resolvedTag)
+		updatePanelStateVersion(ctx, paths.Scope, resolvedTag)
+	}
+
+	if dryrun.Enabled(ctx) {
+		return nil
 	}
 
 	fmt.Println("GameAP has been successfully updated!")
@@ -128,14 +157,16 @@ func handleV4(cliCtx *cli.Context, paths gameap.PanelPaths, tag, tagPrefix strin
 	return nil
 }
 
-func updatePanelStateVersion(ctx context.Context, resolvedTag string) {
+// updatePanelStateVersion records the version in the install state, unless the
+// state is of another scope.
+func updatePanelStateVersion(ctx context.Context, scope, resolvedTag string) {
 	state, err := gameapctl.LoadPanelInstallState(ctx)
 	if err != nil {
 		log.Printf("Warning: failed to load panel state to record version: %v\n", err)
 
 		return
 	}
-	if state.Version == resolvedTag {
+	if gameap.ScopeOrDefault(state.Scope) != gameap.ScopeOrDefault(scope) || state.Version == resolvedTag {
 		return
 	}
 	state.Version = resolvedTag
@@ -144,18 +175,21 @@ func updatePanelStateVersion(ctx context.Context, resolvedTag string) {
 	}
 }
 
-// downloadRelease downloads the GameAP release matching tag/prefix to a temporary
-// directory and returns the temporary directory path, the path to the downloaded
-// binary, and the resolved release tag.
-func downloadRelease(ctx context.Context, tag, tagPrefix string) (string, string, string, error) {
+// downloadRelease downloads the GameAP release matching tag/prefix, or the latest
+// release of the channel, to a temporary directory and returns the temporary
+// directory path, the path to the downloaded binary, and the resolved release tag.
+func downloadRelease(
+	ctx context.Context, tag, tagPrefix string, channel releasesource.Channel,
+) (string, string, string, error) {
 	tmpDir, err := os.MkdirTemp("", "gameap-update-*")
 	if err != nil {
 		return "", "", "", errors.WithMessage(err, "failed to create temporary directory")
 	}
 
 	opts := releasefinder.FindOptions{
-		Tag:       tag,
-		TagPrefix: tagPrefix,
+		Tag:             tag,
+		TagPrefix:       tagPrefix,
+		AllowPrerelease: channel.AllowPrerelease(),
 	}
 	if tag != "" {
 		if norm, normErr := releasefinder.NormalizeTag(tag); normErr == nil && norm.HasPrereleaseSuffix() {
@@ -181,6 +215,11 @@ func downloadRelease(ctx context.Context, tag, tagPrefix string) (string, string
 		return tmpDir, "", "", errors.WithMessage(err, "failed to download release")
 	}
 
+	// Nothing is downloaded in a dry run, the binary path is only used in the plan.
+	if dryrun.Enabled(ctx) {
+		return tmpDir, filepath.Join(tmpDir, "gameap"), release.Tag, nil
+	}
+
 	binaryNames := []string{"gameap", "gameap.exe"}
 	for _, name := range binaryNames {
 		binaryPath := filepath.Join(tmpDir, name)
@@ -193,7 +232,13 @@ func downloadRelease(ctx context.Context, tag, tagPrefix string) (string, string
 }
 
 // backupAndReplace creates a backup of the current binary and replaces it with the new one.
-func backupAndReplace(newBinary, currentBinary, backupPath string) error {
+func backupAndReplace(ctx context.Context, newBinary, currentBinary, backupPath string) error {
+	if dryrun.Record(
+		ctx, dryrun.KindFile, "back up %s to %s and replace it with the new binary", currentBinary, backupPath,
+	) {
+		return nil
+	}
+
 	if err := utils.Copy(currentBinary, backupPath); err != nil {
 		return errors.WithMessage(err, "failed to create backup")
 	}
@@ -298,6 +343,10 @@ func readConfigEnv(configPath string) (host, port string, httpsEnabled bool, err
 
 // checkHealth performs health checks on the GameAP instance.
 func checkHealth(ctx context.Context, host, port string, httpsEnabled bool) error {
+	if dryrun.Enabled(ctx) {
+		return nil
+	}
+
 	for i := 0; i < healthCheckRetries; i++ {
 		if i > 0 {
 			log.Printf("Retry %d/%d...\n", i+1, healthCheckRetries)
@@ -327,8 +376,10 @@ func handleV4FromGithub(ctx context.Context, paths gameap.PanelPaths, branch str
 	backupPath := paths.BinaryPath + backupSuffix
 	if _, statErr := os.Stat(paths.BinaryPath); statErr == nil {
 		log.Println("Backing up current binary...")
-		if err := utils.Copy(paths.BinaryPath, backupPath); err != nil {
-			return errors.WithMessage(err, "failed to create backup")
+		if !dryrun.Record(ctx, dryrun.KindFile, "back up %s to %s", paths.BinaryPath, backupPath) {
+			if err := utils.Copy(paths.BinaryPath, backupPath); err != nil {
+				return errors.WithMessage(err, "failed to create backup")
+			}
 		}
 	}
 
@@ -392,10 +443,14 @@ func handleV4FromGithub(ctx context.Context, paths gameap.PanelPaths, branch str
 	}
 
 	log.Println("Update successful! Removing backup...")
-	if err := os.Remove(backupPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+	if err := oscore.RemoveAll(ctx, backupPath); err != nil {
 		log.Printf("Warning: failed to remove backup file: %v\n", err)
 	}
 
+	if dryrun.Enabled(ctx) {
+		return nil
+	}
+
 	fmt.Println("GameAP has been successfully updated from GitHub!")
 
 	return nil
@@ -0,0 +1,110 @@
+package update
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/gameap/gameapctl/internal/pkg/gameapctl"
+	panelpkg "github.com/gameap/gameapctl/internal/pkg/panel"
+	"github.com/gameap/gameapctl/pkg/gameap"
+	"github.com/gameap/gameapctl/pkg/panel"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+const releaseHistoryComponent = "panel"
+
+// keepRelease keeps the installed binary and config.env for 'panel rollback'.
+// The upgrade goes on when they cannot be kept.
+func keepRelease(ctx context.Context, paths gameap.PanelPaths) {
+	history, err := gameapctl.ReleaseHistory(releaseHistoryComponent, paths.Scope)
+	if err != nil {
+		log.Printf("Warning: failed to keep the installed release for rollback: %v\n", err)
+
+		return
+	}
+
+	if err := history.Save(ctx, installedVersion(ctx, paths.Scope), paths.BinaryPath, paths.ConfigFilePath); err != nil {
+		log.Printf("Warning: failed to keep the installed release for rollback: %v\n", err)
+	}
+}
+
+// installedVersion returns the version of the panel installed in the scope,
+// empty when the install state is of another scope.
+func installedVersion(ctx context.Context, scope string) string {
+	state, err := gameapctl.LoadPanelInstallState(ctx)
+	if err != nil || gameap.ScopeOrDefault(state.Scope) != gameap.ScopeOrDefault(scope) {
+		return ""
+	}
+
+	return state.Version
+}
+
+// HandleRollback restores the panel binary kept by an earlier upgrade: the
+// release given with --to, or the one installed before the last upgrade. The
+// kept config.env is restored only with --with-config, it would revert the
+// later changes such as a moved database. The database is not rolled back.
+func HandleRollback(cliCtx *cli.Context) error {
+	ctx := cliCtx.Context
+
+	paths, err := panelpkg.ResolveScope(ctx, cliCtx.String("scope"))
+	if err != nil {
+		return err
+	}
+
+	history, err := gameapctl.ReleaseHistory(releaseHistoryComponent, paths.Scope)
+	if err != nil {
+		return err
+	}
+
+	snapshot, err := history.Find(cliCtx.String("to"))
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Rolling back GameAP to %s...\n", snapshot.Name())
+
+	log.Println("Stopping GameAP...")
+	if err := panel.Stop(ctx, panel.Options{Scope: paths.Scope}); err != nil {
+		return errors.WithMessage(err, "failed to stop GameAP")
+	}
+
+	withConfig := cliCtx.Bool("with-config")
+	if err := history.Rollback(ctx, snapshot, installedVersion(ctx, paths.Scope), withConfig); err != nil {
+		if startErr := panel.Start(ctx, panel.Options{Scope: paths.Scope}); startErr != nil {
+			log.Printf("Failed to start GameAP: %v\n", startErr)
+		}
+
+		return errors.WithMessage(err, "failed to roll back")
+	}
+
+	log.Println("Starting GameAP...")
+	if err := panel.Start(ctx, panel.Options{Scope: paths.Scope}); err != nil {
+		return errors.WithMessage(err, "failed to start GameAP")
+	}
+
+	if snapshot.Tag != "" {
+		updatePanelStateVersion(ctx, paths.Scope, snapshot.Tag)
+	}
+
+	log.Println("Checking if the restored version is working...")
+	httpHost, httpPort, httpsEnabled, err := readConfigEnv(paths.ConfigFilePath)
+	if err != nil {
+		log.Printf("Warning: failed to read config.env: %v\n", err)
+
+		httpHost = "127.0.0.1"
+		httpPort = "8025"
+		httpsEnabled = false
+	}
+
+	if err := checkHealth(ctx, httpHost, httpPort, httpsEnabled); err != nil {
+		return errors.WithMessagef(
+			err, "GameAP %s is not healthy, 'panel rollback' restores the release installed before", snapshot.Name(),
+		)
+	}
+
+	fmt.Printf("GameAP has been rolled back to %s\n", snapshot.Name())
+
+	return nil
+}
@@ -0,0 +1,462 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	panelpkg "github.com/gameap/gameapctl/internal/pkg/panel"
+	"github.com/pkg/errors"
+)
+
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+
+	// defaultEntityType is the morph type of the users in the role assignments
+	// of a panel without assignments yet.
+	defaultEntityType = `Gameap\Models\User`
+)
+
+var roles = []string{RoleAdmin, RoleUser}
+
+// disabledColumns are the names a boolean flag disabling the user has in the
+// panel versions having one. Without it the password hash is locked instead.
+var disabledColumns = []string{"disabled", "is_disabled", "blocked", "is_blocked"}
+
+type User struct {
+	ID       int64    `json:"id"`
+	Login    string   `json:"login"`
+	Email    string   `json:"email"`
+	Roles    []string `json:"roles"`
+	Disabled bool     `json:"disabled"`
+}
+
+// querier is implemented by *sql.DB and *sql.Tx.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// store reads and changes the users of the panel database. The roles are kept
+// in the roles and assigned_roles tables of the panel.
+type store struct {
+	q      querier
+	driver string
+}
+
+func (s *store) ph(n int) string {
+	return panelpkg.Placeholder(s.driver, n)
+}
+
+// inTx runs fn on a store bound to a transaction, which is committed when fn
+// succeeds.
+func (s *store) inTx(ctx context.Context, fn func(tx *store) error) error {
+	db, ok := s.q.(*sql.DB)
+	if !ok {
+		return fn(s)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	if err := fn(&store{q: tx, driver: s.driver}); err != nil {
+		_ = tx.Rollback()
+
+		return err
+	}
+
+	return errors.Wrap(tx.Commit(), "failed to commit transaction")
+}
+
+func (s *store) list(ctx context.Context) ([]User, error) {
+	disabledColumn, err := s.disabledColumn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The constant keeps the scan the same for the panels without the column.
+	disabledExpr := "0"
+	if disabledColumn != "" {
+		disabledExpr = panelpkg.QuoteIdent(s.driver, disabledColumn)
+	}
+
+	rows, err := s.q.QueryContext(ctx,
+		"SELECT id, login, email, password, "+disabledExpr+" FROM users ORDER BY id", //nolint:gosec
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query users")
+	}
+	defer func() { _ = rows.Close() }()
+
+	var users []User
+
+	for rows.Next() {
+		var (
+			u        User
+			email    sql.NullString
+			password string
+			disabled sql.NullBool
+		)
+
+		if err := rows.Scan(&u.ID, &u.Login, &email, &password, &disabled); err != nil {
+			return nil, errors.Wrap(err, "failed to scan user")
+		}
+
+		u.Email = email.String
+		u.Disabled = disabled.Bool || panelpkg.IsPasswordLocked(password)
+		u.Roles = []string{}
+
+		users = append(users, u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to query users")
+	}
+
+	assigned, err := s.assignedRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range users {
+		if r, ok := assigned[users[i].ID]; ok {
+			users[i].Roles = r
+		}
+	}
+
+	return users, nil
+}
+
+func (s *store) find(ctx context.Context, login string) (User, error) {
+	users, err := s.list(ctx)
+	if err != nil {
+		return User{}, err
+	}
+
+	i := slices.IndexFunc(users, func(u User) bool { return u.Login == login })
+	if i < 0 {
+		return User{}, errors.Errorf("user '%s' not found in database", login)
+	}
+
+	return users[i], nil
+}
+
+func (s *store) assignedRoles(ctx context.Context) (map[int64][]string, error) {
+	ok, err := s.hasRoleTables(ctx)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	entityType, err := s.entityType(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.q.QueryContext(ctx,
+		"SELECT ar.entity_id, r.name FROM assigned_roles ar JOIN roles r ON r.id = ar.role_id "+
+			"WHERE ar.entity_type = "+s.ph(1)+" ORDER BY r.name",
+		entityType,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query roles")
+	}
+	defer func() { _ = rows.Close() }()
+
+	assigned := make(map[int64][]string)
+
+	for rows.Next() {
+		var (
+			id   int64
+			name string
+		)
+
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, errors.Wrap(err, "failed to scan roles")
+		}
+
+		assigned[id] = append(assigned[id], name)
+	}
+
+	return assigned, errors.Wrap(rows.Err(), "failed to query roles")
+}
+
+func (s *store) hasRoleTables(ctx context.Context) (bool, error) {
+	tables, err := s.tables(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return slices.Contains(tables, "roles") && slices.Contains(tables, "assigned_roles"), nil
+}
+
+func (s *store) tables(ctx context.Context) ([]string, error) {
+	tables, err := panelpkg.ListTables(ctx, s.q, s.driver)
+
+	return tables, errors.WithMessage(err, "failed to list tables")
+}
+
+// entityType returns the morph type the panel assigns the roles of users with.
+func (s *store) entityType(ctx context.Context) (string, error) {
+	var entityType string
+
+	err := s.q.QueryRowContext(ctx,
+		"SELECT entity_type FROM assigned_roles WHERE entity_type LIKE "+s.ph(1),
+		`%User`,
+	).Scan(&entityType)
+	if errors.Is(err, sql.ErrNoRows) {
+		return defaultEntityType, nil
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "failed to query role assignments")
+	}
+
+	return entityType, nil
+}
+
+func (s *store) create(ctx context.Context, login, email, hash string) (int64, error) {
+	var exists bool
+
+	err := s.q.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM users WHERE login = "+s.ph(1)+")", login,
+	).Scan(&exists)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to query user")
+	}
+	if exists {
+		return 0, errors.Errorf("user '%s' already exists", login)
+	}
+
+	columns, err := s.columns(ctx, "users")
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now().UTC()
+	values := map[string]any{
+		"login":      login,
+		"email":      email,
+		"password":   hash,
+		"name":       login,
+		"created_at": now,
+		"updated_at": now,
+	}
+
+	var (
+		insertColumns []string
+		args          []any
+	)
+
+	for _, column := range []string{"login", "email", "password", "name", "created_at", "updated_at"} {
+		if slices.Contains(columns, column) {
+			insertColumns = append(insertColumns, column)
+			args = append(args, values[column])
+		}
+	}
+
+	if _, err := s.q.ExecContext(ctx, panelpkg.InsertQuery(s.driver, "users", insertColumns), args...); err != nil {
+		return 0, errors.Wrap(err, "failed to create user")
+	}
+
+	// LastInsertId is not supported by PostgreSQL.
+	var id int64
+
+	err = s.q.QueryRowContext(ctx, "SELECT id FROM users WHERE login = "+s.ph(1), login).Scan(&id)
+
+	return id, errors.Wrap(err, "failed to query user")
+}
+
+func (s *store) columns(ctx context.Context, table string) ([]string, error) {
+	rows, err := s.q.QueryContext(ctx, "SELECT * FROM "+panelpkg.QuoteIdent(s.driver, table)+" WHERE 1 = 0")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to query %s", table)
+	}
+	defer func() { _ = rows.Close() }()
+
+	columns, err := rows.Columns()
+
+	return columns, errors.Wrapf(err, "failed to read the columns of %s", table)
+}
+
+// setDisabled sets the disabled flag of the panel or, without one, locks or
+// unlocks the password of the user. Disabling also removes the API tokens and
+// the sessions kept in the database, they would keep working otherwise.
+func (s *store) setDisabled(ctx context.Context, u User, disabled bool) error {
+	return s.inTx(ctx, func(tx *store) error {
+		column, err := tx.disabledColumn(ctx)
+		if err != nil {
+			return err
+		}
+
+		if column != "" {
+			err = tx.setDisabledFlag(ctx, u, column, disabled)
+		} else {
+			err = tx.setPasswordLocked(ctx, u, disabled)
+		}
+		if err != nil || !disabled {
+			return err
+		}
+
+		if err := tx.revokeTokens(ctx, u); err != nil {
+			return err
+		}
+
+		return tx.revokeSessions(ctx, u)
+	})
+}
+
+// disabledColumn returns the disabled flag column of the users table, if any.
+func (s *store) disabledColumn(ctx context.Context) (string, error) {
+	columns, err := s.columns(ctx, "users")
+	if err != nil {
+		return "", err
+	}
+
+	for _, column := range disabledColumns {
+		if slices.Contains(columns, column) {
+			return column, nil
+		}
+	}
+
+	return "", nil
+}
+
+func (s *store) setDisabledFlag(ctx context.Context, u User, column string, disabled bool) error {
+	_, err := s.q.ExecContext(ctx,
+		fmt.Sprintf(
+			"UPDATE users SET %s = %s, updated_at = %s WHERE id = %s",
+			panelpkg.QuoteIdent(s.driver, column), s.ph(1), s.ph(2), s.ph(3), //nolint:mnd
+		),
+		disabled, time.Now().UTC(), u.ID,
+	)
+
+	return errors.Wrap(err, "failed to update user")
+}
+
+// setPasswordLocked locks the password hash, the old password works again
+// once it is unlocked. 'panel user change-password' keeps the lock.
+func (s *store) setPasswordLocked(ctx context.Context, u User, locked bool) error {
+	var password string
+
+	err := s.q.QueryRowContext(ctx, "SELECT password FROM users WHERE id = "+s.ph(1), u.ID).Scan(&password)
+	if err != nil {
+		return errors.Wrap(err, "failed to query user")
+	}
+
+	if panelpkg.IsPasswordLocked(password) == locked {
+		return nil
+	}
+
+	if locked {
+		password = panelpkg.LockedPasswordPrefix + password
+	} else {
+		password = strings.TrimPrefix(password, panelpkg.LockedPasswordPrefix)
+	}
+
+	_, err = s.q.ExecContext(ctx,
+		fmt.Sprintf("UPDATE users SET password = %s, updated_at = %s WHERE id = %s", s.ph(1), s.ph(2), s.ph(3)), //nolint:mnd
+		password, time.Now().UTC(), u.ID,
+	)
+
+	return errors.Wrap(err, "failed to update user")
+}
+
+// revokeSessions removes the sessions of the database session driver. Sessions
+// kept elsewhere (files, Redis, cookies) stay valid until they expire.
+func (s *store) revokeSessions(ctx context.Context, u User) error {
+	tables, err := s.tables(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !slices.Contains(tables, "sessions") {
+		return nil
+	}
+
+	columns, err := s.columns(ctx, "sessions")
+	if err != nil {
+		return err
+	}
+
+	if !slices.Contains(columns, "user_id") {
+		return nil
+	}
+
+	_, err = s.q.ExecContext(ctx, "DELETE FROM sessions WHERE user_id = "+s.ph(1), u.ID)
+
+	return errors.Wrap(err, "failed to revoke sessions")
+}
+
+func (s *store) revokeTokens(ctx context.Context, u User) error {
+	tables, err := s.tables(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !slices.Contains(tables, "personal_access_tokens") {
+		return nil
+	}
+
+	entityType, err := s.entityType(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.q.ExecContext(ctx,
+		"DELETE FROM personal_access_tokens WHERE tokenable_id = "+s.ph(1)+" AND tokenable_type = "+s.ph(2),
+		u.ID, entityType,
+	)
+
+	return errors.Wrap(err, "failed to revoke API tokens")
+}
+
+// setRole replaces the admin or user role of the user.
+func (s *store) setRole(ctx context.Context, u User, role string) error {
+	ok, err := s.hasRoleTables(ctx)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("the panel database has no roles tables")
+	}
+
+	var roleID int64
+
+	err = s.q.QueryRowContext(ctx, "SELECT id FROM roles WHERE name = "+s.ph(1), role).Scan(&roleID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.Errorf("role '%s' not found in database", role)
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to query role")
+	}
+
+	entityType, err := s.entityType(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.q.ExecContext(ctx,
+		fmt.Sprintf(
+			"DELETE FROM assigned_roles WHERE entity_id = %s AND entity_type = %s "+
+				"AND role_id IN (SELECT id FROM roles WHERE name IN (%s, %s))",
+			s.ph(1), s.ph(2), s.ph(3), s.ph(4), //nolint:mnd
+		),
+		u.ID, entityType, RoleAdmin, RoleUser,
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to remove the role")
+	}
+
+	_, err = s.q.ExecContext(ctx,
+		panelpkg.InsertQuery(s.driver, "assigned_roles", []string{"role_id", "entity_id", "entity_type"}),
+		roleID, u.ID, entityType,
+	)
+
+	return errors.Wrap(err, "failed to assign the role")
+}
@@ -0,0 +1,158 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	panelpkg "github.com/gameap/gameapctl/internal/pkg/panel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func setupStore(t *testing.T) (*store, *sql.DB) {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "database.sqlite"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	for _, query := range []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, login TEXT NOT NULL UNIQUE, email TEXT, " +
+			"password TEXT NOT NULL, name TEXT, created_at DATETIME, updated_at DATETIME)",
+		"CREATE TABLE roles (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL)",
+		"CREATE TABLE assigned_roles (id INTEGER PRIMARY KEY AUTOINCREMENT, role_id INTEGER NOT NULL, " +
+			"entity_id INTEGER NOT NULL, entity_type TEXT NOT NULL)",
+		"CREATE TABLE personal_access_tokens (id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+			"tokenable_type TEXT NOT NULL, tokenable_id INTEGER NOT NULL, token TEXT NOT NULL)",
+		"INSERT INTO roles (name) VALUES ('admin'), ('user')",
+		"INSERT INTO users (login, email, password) VALUES ('admin', 'admin@example.com', 'hash')",
+		`INSERT INTO assigned_roles (role_id, entity_id, entity_type) VALUES (1, 1, 'Gameap\Models\User')`,
+	} {
+		_, err := db.Exec(query)
+		require.NoError(t, err, query)
+	}
+
+	return &store{q: db, driver: panelpkg.DatabaseSQLite}, db
+}
+
+func TestStore_CreateAndList(t *testing.T) {
+	ctx := context.Background()
+	s, _ := setupStore(t)
+
+	u, err := createUser(ctx, s, "alice", "alice@example.com", "hash", RoleUser)
+	require.NoError(t, err)
+	assert.Equal(t, User{ID: 2, Login: "alice", Email: "alice@example.com", Roles: []string{RoleUser}}, u)
+
+	_, err = createUser(ctx, s, "alice", "alice@example.com", "hash", RoleUser)
+	require.EqualError(t, err, "user 'alice' already exists")
+
+	users, err := s.list(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []User{
+		{ID: 1, Login: "admin", Email: "admin@example.com", Roles: []string{RoleAdmin}},
+		{ID: 2, Login: "alice", Email: "alice@example.com", Roles: []string{RoleUser}},
+	}, users)
+}
+
+func TestStore_SetDisabled(t *testing.T) {
+	ctx := context.Background()
+	s, db := setupStore(t)
+
+	_, err := db.Exec(`INSERT INTO personal_access_tokens (tokenable_type, tokenable_id, token) ` +
+		`VALUES ('Gameap\Models\User', 1, 'token')`)
+	require.NoError(t, err)
+
+	u, err := s.find(ctx, "admin")
+	require.NoError(t, err)
+
+	require.NoError(t, s.setDisabled(ctx, u, true))
+	require.NoError(t, s.setDisabled(ctx, u, true))
+
+	var (
+		password string
+		tokens   int
+	)
+	require.NoError(t, db.QueryRow("SELECT password FROM users WHERE id = 1").Scan(&password))
+	assert.Equal(t, "!hash", password)
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM personal_access_tokens").Scan(&tokens))
+	assert.Zero(t, tokens)
+
+	u, err = s.find(ctx, "admin")
+	require.NoError(t, err)
+	assert.True(t, u.Disabled)
+
+	require.NoError(t, s.setDisabled(ctx, u, false))
+	require.NoError(t, db.QueryRow("SELECT password FROM users WHERE id = 1").Scan(&password))
+	assert.Equal(t, "hash", password)
+}
+
+func TestStore_SetDisabled_Flag(t *testing.T) {
+	ctx := context.Background()
+	s, db := setupStore(t)
+
+	for _, query := range []string{
+		"ALTER TABLE users ADD COLUMN is_blocked BOOLEAN NOT NULL DEFAULT 0",
+		"CREATE TABLE sessions (id TEXT PRIMARY KEY, user_id INTEGER, payload TEXT)",
+		"INSERT INTO sessions (id, user_id, payload) VALUES ('a', 1, ''), ('b', 2, '')",
+	} {
+		_, err := db.Exec(query)
+		require.NoError(t, err, query)
+	}
+
+	u, err := s.find(ctx, "admin")
+	require.NoError(t, err)
+	assert.False(t, u.Disabled)
+
+	require.NoError(t, s.setDisabled(ctx, u, true))
+
+	var (
+		password string
+		blocked  bool
+		sessions []string
+	)
+	require.NoError(t, db.QueryRow("SELECT password, is_blocked FROM users WHERE id = 1").Scan(&password, &blocked))
+	assert.Equal(t, "hash", password, "the password is not locked when the panel has a flag")
+	assert.True(t, blocked)
+
+	rows, err := db.Query("SELECT id FROM sessions ORDER BY id")
+	require.NoError(t, err)
+	for rows.Next() {
+		var id string
+		require.NoError(t, rows.Scan(&id))
+		sessions = append(sessions, id)
+	}
+	require.NoError(t, rows.Close())
+	assert.Equal(t, []string{"b"}, sessions)
+
+	u, err = s.find(ctx, "admin")
+	require.NoError(t, err)
+	assert.True(t, u.Disabled)
+
+	require.NoError(t, s.setDisabled(ctx, u, false))
+	require.NoError(t, db.QueryRow("SELECT is_blocked FROM users WHERE id = 1").Scan(&blocked))
+	assert.False(t, blocked)
+}
+
+func TestStore_SetRole(t *testing.T) {
+	ctx := context.Background()
+	s, _ := setupStore(t)
+
+	alice, err := createUser(ctx, s, "alice", "alice@example.com", "hash", RoleUser)
+	require.NoError(t, err)
+
+	admin, err := s.find(ctx, "admin")
+	require.NoError(t, err)
+	require.EqualError(t, ensureOtherAdmin(ctx, s, admin), "user 'admin' is the last enabled administrator")
+
+	require.NoError(t, s.setRole(ctx, alice, RoleAdmin))
+
+	alice, err = s.find(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, []string{RoleAdmin}, alice.Roles)
+	require.NoError(t, ensureOtherAdmin(ctx, s, admin))
+
+	require.EqualError(t, s.setRole(ctx, alice, "moderator"), "role 'moderator' not found in database")
+}
@@ -0,0 +1,330 @@
+// Package user implements the 'panel user' commands managing the panel users
+// directly in the database config.env points at.
+package user
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/gameap/gameapctl/internal/pkg/output"
+	panelpkg "github.com/gameap/gameapctl/internal/pkg/panel"
+	"github.com/gameap/gameapctl/internal/pkg/redact"
+	"github.com/gameap/gameapctl/pkg/dryrun"
+	"github.com/pkg/errors"
+	"github.com/sethvargo/go-password/password"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	generatedPasswordLen       = 16
+	generatedPasswordNumDigits = 4
+)
+
+type List struct {
+	Users []User `json:"users"`
+}
+
+func (l List) WriteText(w io.Writer) error {
+	for _, u := range l.Users {
+		status := "active"
+		if u.Disabled {
+			status = "disabled"
+		}
+
+		_, err := fmt.Fprintf(w, "%-6d %-24s %-32s %-12s %s\n",
+			u.ID, u.Login, u.Email, strings.Join(u.Roles, ","), status,
+		)
+		if err != nil {
+			return errors.Wrap(err, "failed to write users")
+		}
+	}
+
+	return nil
+}
+
+type CreateResult struct {
+	User User `json:"user"`
+	// Password is set only when it was generated.
+	Password string `json:"password,omitempty"`
+}
+
+func (r CreateResult) WriteText(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "User %s (id: %d) created\n", r.User.Login, r.User.ID)
+	if err == nil && r.Password != "" {
+		_, err = fmt.Fprintf(w, "Password: %s\n", r.Password)
+	}
+
+	return errors.Wrap(err, "failed to write result")
+}
+
+type Result struct {
+	User User `json:"user"`
+}
+
+func (r Result) WriteText(w io.Writer) error {
+	status := "enabled"
+	if r.User.Disabled {
+		status = "disabled"
+	}
+
+	_, err := fmt.Fprintf(w, "User %s is %s, roles: %s\n", r.User.Login, status, strings.Join(r.User.Roles, ","))
+
+	return errors.Wrap(err, "failed to write result")
+}
+
+func ListUsers(cliCtx *cli.Context) error {
+	ctx := cliCtx.Context
+
+	s, closeDB, err := openStore(cliCtx)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	users, err := s.list(ctx)
+	if err != nil {
+		return err
+	}
+
+	return output.Print(cliCtx, List{Users: users})
+}
+
+func Create(cliCtx *cli.Context) error {
+	ctx := cliCtx.Context
+
+	if cliCtx.NArg() != 1 {
+		return errors.New("expected exactly one argument: the login")
+	}
+
+	login := cliCtx.Args().First()
+	email := cliCtx.String("email")
+	role := cliCtx.String("role")
+
+	if email == "" {
+		return errors.New("--email is required")
+	}
+	if err := validateRole(role); err != nil {
+		return err
+	}
+
+	if dryrun.Enabled(ctx) {
+		dryrun.Record(ctx, dryrun.KindDatabase, "create user %s <%s> with role %s", login, email, role)
+
+		return nil
+	}
+
+	secret := cliCtx.String("password")
+	generated := ""
+	if secret == "" {
+		var err error
+
+		secret, err = password.Generate(generatedPasswordLen, generatedPasswordNumDigits, 0, false, false)
+		if err != nil {
+			return errors.WithMessage(err, "failed to generate password")
+		}
+
+		generated = secret
+	}
+	redact.Register(secret)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return errors.WithMessage(err, "failed to hash password")
+	}
+
+	s, closeDB, err := openStore(cliCtx)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	u, err := createUser(ctx, s, login, email, string(hash), role)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Created user '%s' (id: %d)\n", u.Login, u.ID)
+
+	return output.Print(cliCtx, CreateResult{User: u, Password: generated})
+}
+
+// createUser creates the user and assigns the role in one transaction.
+func createUser(ctx context.Context, s *store, login, email, hash, role string) (User, error) {
+	var u User
+
+	err := s.inTx(ctx, func(tx *store) error {
+		id, err := tx.create(ctx, login, email, hash)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.setRole(ctx, User{ID: id, Login: login}, role); err != nil {
+			return err
+		}
+
+		u, err = tx.find(ctx, login)
+
+		return err
+	})
+
+	return u, err
+}
+
+func Disable(cliCtx *cli.Context) error {
+	return setDisabled(cliCtx, true)
+}
+
+func Enable(cliCtx *cli.Context) error {
+	return setDisabled(cliCtx, false)
+}
+
+func setDisabled(cliCtx *cli.Context, disabled bool) error {
+	ctx := cliCtx.Context
+
+	if cliCtx.NArg() != 1 {
+		return errors.New("expected exactly one argument: the login")
+	}
+
+	login := cliCtx.Args().First()
+
+	if dryrun.Enabled(ctx) {
+		action := "enable"
+		if disabled {
+			action = "disable"
+		}
+		dryrun.Record(ctx, dryrun.KindDatabase, "%s user %s", action, login)
+
+		return nil
+	}
+
+	s, closeDB, err := openStore(cliCtx)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	u, err := s.find(ctx, login)
+	if err != nil {
+		return err
+	}
+
+	if disabled {
+		if err := ensureOtherAdmin(ctx, s, u); err != nil {
+			return err
+		}
+	}
+
+	if err := s.setDisabled(ctx, u, disabled); err != nil {
+		return err
+	}
+
+	u.Disabled = disabled
+
+	return output.Print(cliCtx, Result{User: u})
+}
+
+func SetRole(cliCtx *cli.Context) error {
+	ctx := cliCtx.Context
+
+	if cliCtx.NArg() != 2 { //nolint:mnd
+		return errors.New("expected exactly two arguments: the login and the role")
+	}
+
+	login := cliCtx.Args().Get(0)
+	role := cliCtx.Args().Get(1)
+
+	if err := validateRole(role); err != nil {
+		return err
+	}
+
+	if dryrun.Enabled(ctx) {
+		dryrun.Record(ctx, dryrun.KindDatabase, "set the role of user %s to %s", login, role)
+
+		return nil
+	}
+
+	s, closeDB, err := openStore(cliCtx)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	u, err := s.find(ctx, login)
+	if err != nil {
+		return err
+	}
+
+	if role != RoleAdmin {
+		if err := ensureOtherAdmin(ctx, s, u); err != nil {
+			return err
+		}
+	}
+
+	err = s.inTx(ctx, func(tx *store) error {
+		return tx.setRole(ctx, u, role)
+	})
+	if err != nil {
+		return err
+	}
+
+	u, err = s.find(ctx, login)
+	if err != nil {
+		return err
+	}
+
+	return output.Print(cliCtx, Result{User: u})
+}
+
+// ensureOtherAdmin refuses to take the admin role or access away from the last
+// enabled administrator, nobody could manage the panel afterwards.
+func ensureOtherAdmin(ctx context.Context, s *store, u User) error {
+	if u.Disabled || !slices.Contains(u.Roles, RoleAdmin) {
+		return nil
+	}
+
+	users, err := s.list(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, other := range users {
+		if other.ID != u.ID && !other.Disabled && slices.Contains(other.Roles, RoleAdmin) {
+			return nil
+		}
+	}
+
+	return errors.Errorf("user '%s' is the last enabled administrator", u.Login)
+}
+
+func validateRole(role string) error {
+	if !slices.Contains(roles, role) {
+		return errors.Errorf("unknown role %q, expected one of: %s", role, strings.Join(roles, ", "))
+	}
+
+	return nil
+}
+
+func openStore(cliCtx *cli.Context) (*store, func(), error) {
+	paths, err := panelpkg.ResolveScope(cliCtx.Context, cliCtx.String("scope"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db, driver, err := panelpkg.OpenConfiguredDatabase(cliCtx.Context, paths.ConfigFilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	closeDB := func() {
+		if err := db.Close(); err != nil {
+			log.Printf("Failed to close database connection: %v\n", err)
+		}
+	}
+
+	return &store{q: db, driver: driver}, closeDB, nil
+}
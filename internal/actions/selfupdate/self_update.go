@@ -10,6 +10,7 @@ import (
 	gameapctlpkg "github.com/gameap/gameapctl/internal/pkg/gameapctl"
 	"github.com/gameap/gameapctl/pkg/gameap"
 	packagemanager "github.com/gameap/gameapctl/pkg/package_manager"
+	"github.com/gameap/gameapctl/pkg/progress"
 	"github.com/gameap/gameapctl/pkg/releasefinder"
 	"github.com/gameap/gameapctl/pkg/releasesource"
 	"github.com/minio/selfupdate"
@@ -19,7 +20,7 @@ import (
 )
 
 //nolint:funlen
-func Handle(cliCtx *cli.Context) error {
+func Handle(cliCtx *cli.Context) (err error) {
 	ctx := cliCtx.Context
 
 	fmt.Println("Self update")
@@ -34,6 +35,12 @@ func Handle(cliCtx *cli.Context) error {
 	if branch != "" && !fromGithub {
 		return errors.New("--branch requires --github")
 	}
+
+	channel := gameapctlpkg.ReleaseChannel("")
+	if rawVersion == "" && channel.FromSource() {
+		fromGithub = true
+	}
+
 	if fromGithub {
 		if branch == "" {
 			branch = "main"
@@ -51,11 +58,16 @@ func Handle(cliCtx *cli.Context) error {
 		tag, tagPrefix = norm.Full, norm.Prefix
 	}
 
+	steps := progress.Steps(ctx, 3) //nolint:mnd
+	defer func() { steps.End(err) }()
+
+	steps.Next("gameapctl.check", "update check")
+
 	fmt.Println("Checking new versions...")
 	release, err := findRelease(ctx, releasefinder.FindOptions{
 		Tag:             tag,
 		TagPrefix:       tagPrefix,
-		AllowPrerelease: true,
+		AllowPrerelease: rawVersion != "" || channel.AllowPrerelease(),
 	})
 	if err != nil {
 		var notFound releasefinder.FailedToFindReleaseError
@@ -82,6 +94,15 @@ func Handle(cliCtx *cli.Context) error {
 	}
 
 	fmt.Println("Update available")
+
+	steps.Next("gameapctl.download", "gameapctl download")
+
+	if cliCtx.Bool("insecure-skip-verify") {
+		fmt.Println("Warning: --insecure-skip-verify is set, the update is not verified")
+	} else if err := verifyRelease(ctx, release); err != nil {
+		return errors.WithMessage(err, "failed to verify release, pass --insecure-skip-verify to update anyway")
+	}
+
 	fmt.Printf("Downloading from %s \n", release.PrimaryURL())
 
 	f, err := os.CreateTemp("", "gameapctl")
@@ -110,11 +131,10 @@ func Handle(cliCtx *cli.Context) error {
 		return errors.WithMessage(err, "failed to download")
 	}
 
-	_, err = f.Seek(0, 0)
-	if err != nil {
-		return errors.WithMessage(err, "failed to seek temp file")
-	}
+	steps.Next("gameapctl.apply", "gameapctl binary replacement")
 
+	// The archive was checked against the signed checksum before it was
+	// extracted, there is no signed digest of the binary itself.
 	fmt.Println("Applying...")
 	err = selfupdate.Apply(f, selfupdate.Options{})
 	if err != nil {
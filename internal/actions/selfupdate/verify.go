@@ -0,0 +1,57 @@
+package selfupdate
+
+import (
+	"context"
+	_ "embed"
+	"strings"
+
+	"github.com/gameap/gameapctl/pkg/releasesource"
+	"github.com/pkg/errors"
+)
+
+// releasePublicKey verifies the signature of the checksums published with
+// every gameapctl release. The release workflow signs them with the matching
+// secret key. The maintainers holding that key commit its public half to
+// release.pub, a build without it cannot verify updates.
+//
+//go:embed release.pub
+var releasePublicKey string
+
+var errNoReleaseKey = errors.New("this build has no release public key")
+
+// verifyRelease fetches the signed checksums of the release and sets the
+// expected digest of the asset, so the download is verified before it is
+// extracted.
+func verifyRelease(ctx context.Context, release *releasesource.Release) error {
+	if strings.TrimSpace(releasePublicKey) == "" {
+		return errNoReleaseKey
+	}
+
+	checksums, err := releasesource.FetchAsset(ctx, release, releasesource.ChecksumsAsset)
+	if err != nil {
+		return errors.WithMessage(err, "failed to fetch checksums")
+	}
+
+	signature, err := releasesource.FetchAsset(ctx, release, releasesource.SignatureAsset)
+	if err != nil {
+		return errors.WithMessage(err, "failed to fetch checksums signature")
+	}
+
+	if err := releasesource.VerifySignature(releasePublicKey, checksums, signature); err != nil {
+		return errors.WithMessage(err, "invalid checksums signature")
+	}
+
+	sums, err := releasesource.ParseChecksums(checksums)
+	if err != nil {
+		return err
+	}
+
+	digest, ok := sums[release.AssetName]
+	if !ok {
+		return errors.Errorf("no checksum for %s", release.AssetName)
+	}
+
+	release.SHA256 = digest
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package selfupdate
+
+import (
+	"context"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"aead.dev/minisign"
+	"github.com/gameap/gameapctl/pkg/releasesource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testAsset  = "gameapctl-v1.0.0-linux-amd64.tar.gz"
+	testDigest = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+)
+
+func setupRelease(t *testing.T, checksums string, sign func([]byte) []byte) *releasesource.Release {
+	t.Helper()
+
+	publicKey, privateKey, err := minisign.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	publicKeyText, err := publicKey.MarshalText()
+	require.NoError(t, err)
+
+	original := releasePublicKey
+	releasePublicKey = string(publicKeyText)
+	t.Cleanup(func() { releasePublicKey = original })
+
+	if sign == nil {
+		sign = func(content []byte) []byte { return minisign.Sign(privateKey, content) }
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1.0.0/"+releasesource.ChecksumsAsset, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(checksums))
+	})
+	mux.HandleFunc("/v1.0.0/"+releasesource.SignatureAsset, func(w http.ResponseWriter, _ *http.Request) {
+		signature := sign([]byte(checksums))
+		if signature == nil {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		_, _ = w.Write(signature)
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return &releasesource.Release{
+		Tag:       "v1.0.0",
+		AssetName: testAsset,
+		URLs:      []string{srv.URL + "/v1.0.0/" + testAsset},
+	}
+}
+
+func TestVerifyRelease(t *testing.T) {
+	release := setupRelease(t, testDigest+"  "+testAsset+"\n", nil)
+
+	require.NoError(t, verifyRelease(context.Background(), release))
+	assert.Equal(t, testDigest, release.SHA256)
+}
+
+func TestVerifyRelease_MissingSignature(t *testing.T) {
+	release := setupRelease(t, testDigest+"  "+testAsset+"\n", func([]byte) []byte { return nil })
+
+	err := verifyRelease(context.Background(), release)
+	require.ErrorContains(t, err, "failed to fetch checksums signature")
+	assert.Empty(t, release.SHA256)
+}
+
+func TestVerifyRelease_ForeignSignature(t *testing.T) {
+	_, otherKey, err := minisign.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	release := setupRelease(t, testDigest+"  "+testAsset+"\n", func(content []byte) []byte {
+		return minisign.Sign(otherKey, content)
+	})
+
+	err = verifyRelease(context.Background(), release)
+	require.ErrorContains(t, err, "invalid checksums signature")
+}
+
+func TestVerifyRelease_MissingChecksum(t *testing.T) {
+	release := setupRelease(t, testDigest+"  gameapctl-v1.0.0-windows-amd64.zip\n", nil)
+
+	err := verifyRelease(context.Background(), release)
+	require.EqualError(t, err, "no checksum for "+testAsset)
+}
+
+func TestVerifyRelease_NoReleaseKey(t *testing.T) {
+	release := setupRelease(t, testDigest+"  "+testAsset+"\n", nil)
+	releasePublicKey = ""
+
+	err := verifyRelease(context.Background(), release)
+	require.ErrorIs(t, err, errNoReleaseKey)
+	assert.Empty(t, release.SHA256)
+}
@@ -0,0 +1,238 @@
+package sendlogs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const additionalCollector = "additional"
+
+type collector struct {
+	// name selects the collector in --only and --exclude.
+	name  string
+	title string
+	// output is the file or directory the collector writes, relative to the
+	// bundle root.
+	output string
+	fn     func(ctx context.Context, destinationDir string) error
+}
+
+var collectors = []collector{
+	{"gameapctl", "gameapctl logs", "gameapctl", collectGameapCTLLogs},
+	{"daemon", "daemon logs", "gameap-daemon", collectDaemonLogs},
+	{"journal", "journal logs", "journal", collectJournalLogs},
+	{"panel", "panel logs", "panel", collectPanelLogs},
+	{"webserver", "web server logs", "webserver", collectWebServerLogs},
+	{"database", "database logs", "database", collectDatabaseLogs},
+	{"system", "system information", "system_info.txt", collectSystemInfo},
+}
+
+// CollectorNames lists the collectors accepted by --only and --exclude.
+func CollectorNames() []string {
+	names := make([]string, 0, len(collectors))
+	for _, c := range collectors {
+		names = append(names, c.name)
+	}
+
+	return names
+}
+
+// selectCollectors applies --only, then --exclude.
+func selectCollectors(only, exclude []string) ([]collector, error) {
+	names := CollectorNames()
+
+	for _, name := range slices.Concat(only, exclude) {
+		if !slices.Contains(names, name) {
+			return nil, errors.Errorf("unknown collector %q, expected one of: %s", name, strings.Join(names, ", "))
+		}
+	}
+
+	selected := make([]collector, 0, len(collectors))
+	for _, c := range collectors {
+		if len(only) > 0 && !slices.Contains(only, c.name) {
+			continue
+		}
+		if slices.Contains(exclude, c.name) {
+			continue
+		}
+
+		selected = append(selected, c)
+	}
+
+	if len(selected) == 0 {
+		return nil, errors.New("no collectors selected")
+	}
+
+	return selected, nil
+}
+
+type collectOptions struct {
+	collectors     []collector
+	additionalLogs []string
+	// maxSize caps the bytes gathered by each collector, 0 disables the cap.
+	maxSize int64
+}
+
+func collectAllLogs(ctx context.Context, tmpDir string, opts collectOptions) []manifestCollector {
+	runs := slices.Clone(opts.collectors)
+
+	if len(opts.additionalLogs) > 0 {
+		runs = append(runs, collector{
+			name:   additionalCollector,
+			title:  "additional logs",
+			output: "additional",
+			fn: func(ctx context.Context, destinationDir string) error {
+				return collectAdditionalLogs(ctx, opts.additionalLogs, destinationDir)
+			},
+		})
+	}
+
+	results := make([]manifestCollector, 0, len(collectors)+1)
+
+	for _, c := range collectors {
+		if !slices.ContainsFunc(runs, func(r collector) bool { return r.name == c.name }) {
+			results = append(results, manifestCollector{Name: c.name, Status: statusExcluded})
+		}
+	}
+
+	for _, c := range runs {
+		fmt.Printf("Collecting %s...\n", c.title)
+
+		result := manifestCollector{Name: c.name, Status: statusCollected, output: c.output}
+
+		if err := c.fn(ctx, tmpDir); err != nil {
+			log.Println(errors.WithMessagef(err, "failed to collect %s", c.title))
+
+			result.Status = statusFailed
+			result.Error = err.Error()
+		}
+
+		truncated, omitted, err := capSize(tmpDir, c.output, opts.maxSize)
+		if err != nil {
+			log.Println(errors.WithMessagef(err, "failed to apply the size limit to %s", c.title))
+		}
+		result.truncated = truncated
+		result.Omitted = omitted
+
+		if result.Status == statusCollected && !exists(filepath.Join(tmpDir, c.output)) {
+			result.Status = statusEmpty
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+type capFile struct {
+	path string
+	size int64
+	mod  int64
+}
+
+// capSize keeps at most limit bytes under output. The newest files are kept
+// first; the file crossing the limit keeps its tail, the latest log lines,
+// and older files are removed. It returns the truncated and the removed files
+// relative to root.
+func capSize(root, output string, limit int64) ([]string, []string, error) {
+	path := filepath.Join(root, output)
+	if limit <= 0 || !exists(path) {
+		return nil, nil, nil
+	}
+
+	var files []capFile
+
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		files = append(files, capFile{path: p, size: info.Size(), mod: info.ModTime().UnixNano()})
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].mod > files[j].mod
+	})
+
+	var truncated, omitted []string
+	budget := limit
+
+	for _, f := range files {
+		rel, err := filepath.Rel(root, f.path)
+		if err != nil {
+			return truncated, omitted, err
+		}
+		rel = filepath.ToSlash(rel)
+
+		switch {
+		case f.size <= budget:
+			budget -= f.size
+		case budget > 0:
+			if err := keepTail(f.path, budget); err != nil {
+				return truncated, omitted, err
+			}
+			budget = 0
+
+			truncated = append(truncated, rel)
+		default:
+			if err := os.Remove(f.path); err != nil {
+				return truncated, omitted, errors.Wrapf(err, "failed to remove %s", f.path)
+			}
+
+			omitted = append(omitted, rel)
+		}
+	}
+
+	return truncated, omitted, nil
+}
+
+// keepTail truncates the file to its last n bytes.
+func keepTail(path string, n int64) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %s", path)
+	}
+
+	if _, err := f.Seek(-n, io.SeekEnd); err != nil {
+		_ = f.Close()
+
+		return errors.Wrapf(err, "failed to seek %s", path)
+	}
+
+	tail, err := io.ReadAll(f)
+	_ = f.Close()
+	if err != nil {
+		return errors.Wrapf(err, "failed to read %s", path)
+	}
+
+	return errors.Wrapf(os.WriteFile(path, tail, 0o600), "failed to truncate %s", path)
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+
+	return err == nil
+}
@@ -0,0 +1,150 @@
+package sendlogs
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func collectorNames(cs []collector) []string {
+	names := make([]string, 0, len(cs))
+	for _, c := range cs {
+		names = append(names, c.name)
+	}
+
+	return names
+}
+
+func TestSelectCollectors(t *testing.T) {
+	all, err := selectCollectors(nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, CollectorNames(), collectorNames(all))
+
+	only, err := selectCollectors([]string{"panel", "daemon"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"daemon", "panel"}, collectorNames(only))
+
+	excluded, err := selectCollectors(nil, []string{"journal", "system"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gameapctl", "daemon", "panel", "webserver", "database"}, collectorNames(excluded))
+
+	_, err = selectCollectors([]string{"nginx"}, nil)
+	require.EqualError(t, err,
+		`unknown collector "nginx", expected one of: gameapctl, daemon, journal, panel, webserver, database, system`)
+
+	_, err = selectCollectors([]string{"panel"}, []string{"panel"})
+	require.EqualError(t, err, "no collectors selected")
+}
+
+func TestCapSize(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "panel")
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+
+	now := time.Now()
+	for i, name := range []string{"gameap-3.log", "gameap-2.log", "gameap-1.log"} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte("0123456789"), 0o600))
+		mod := now.Add(-time.Duration(i) * time.Hour)
+		require.NoError(t, os.Chtimes(path, mod, mod))
+	}
+
+	truncated, omitted, err := capSize(root, "panel", 14)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"panel/gameap-2.log"}, truncated)
+	assert.Equal(t, []string{"panel/gameap-1.log"}, omitted)
+
+	content, err := os.ReadFile(filepath.Join(dir, "gameap-2.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "6789", string(content))
+	assert.NoFileExists(t, filepath.Join(dir, "gameap-1.log"))
+	assert.FileExists(t, filepath.Join(dir, "gameap-3.log"))
+}
+
+func TestCapSize_Disabled(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "system_info.txt"), []byte("OS: linux\n"), 0o600))
+
+	truncated, omitted, err := capSize(root, "system_info.txt", 0)
+	require.NoError(t, err)
+	assert.Empty(t, truncated)
+	assert.Empty(t, omitted)
+
+	truncated, omitted, err = capSize(root, "missing", 1)
+	require.NoError(t, err)
+	assert.Empty(t, truncated)
+	assert.Empty(t, omitted)
+}
+
+func TestCollectAllLogs_Manifest(t *testing.T) {
+	root := t.TempDir()
+
+	selected := []collector{
+		{"panel", "panel logs", "panel", func(_ context.Context, dir string) error {
+			dir = filepath.Join(dir, "panel")
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return err
+			}
+
+			return os.WriteFile(filepath.Join(dir, "gameap.log"), []byte("0123456789"), 0o600)
+		}},
+		{"database", "database logs", "database", func(context.Context, string) error {
+			return nil
+		}},
+	}
+
+	collected := collectAllLogs(context.Background(), root, collectOptions{
+		collectors: selected,
+		maxSize:    4,
+	})
+
+	files := []collectedFile{
+		{Path: "panel/gameap.log", Size: 4, Redactions: 1},
+		{Path: "panelsettings.txt", Size: 2},
+	}
+
+	file, err := writeManifest(root, newManifest(collected, files, 4))
+	require.NoError(t, err)
+	assert.Equal(t, manifestName, file.Path)
+
+	content, err := os.ReadFile(filepath.Join(root, manifestName))
+	require.NoError(t, err)
+
+	var m manifest
+	require.NoError(t, json.Unmarshal(content, &m))
+
+	statuses := make(map[string]string, len(m.Collectors))
+	for _, c := range m.Collectors {
+		statuses[c.Name] = c.Status
+	}
+
+	assert.Equal(t, map[string]string{
+		"gameapctl": statusExcluded,
+		"daemon":    statusExcluded,
+		"journal":   statusExcluded,
+		"webserver": statusExcluded,
+		"system":    statusExcluded,
+		"panel":     statusCollected,
+		"database":  statusEmpty,
+	}, statuses)
+
+	panel := m.Collectors[len(m.Collectors)-2]
+	assert.Equal(t, "panel", panel.Name)
+	assert.Equal(t, int64(4), panel.Size)
+	assert.Equal(t, []manifestFile{
+		{Path: "panel/gameap.log", Size: 4, Redactions: 1, Truncated: true},
+	}, panel.Files)
+}
+
+func TestValidateEndpoint(t *testing.T) {
+	require.NoError(t, validateEndpoint("https://logs.example.com/upload"))
+	require.Error(t, validateEndpoint("ftp://logs.example.com"))
+	require.Error(t, validateEndpoint("logs.example.com"))
+}
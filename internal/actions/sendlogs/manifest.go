@@ -0,0 +1,99 @@
+package sendlogs
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gameap/gameapctl/pkg/gameap"
+	"github.com/pkg/errors"
+)
+
+const manifestName = "manifest.json"
+
+const (
+	statusCollected = "collected"
+	statusEmpty     = "empty"
+	statusFailed    = "failed"
+	statusExcluded  = "excluded"
+)
+
+// manifest lists what the bundle holds, it is written at the bundle root.
+type manifest struct {
+	CreatedAt        time.Time           `json:"createdAt"`
+	GameapctlVersion string              `json:"gameapctlVersion"`
+	MaxCollectorSize int64               `json:"maxCollectorSize,omitempty"`
+	Collectors       []manifestCollector `json:"collectors"`
+}
+
+type manifestCollector struct {
+	Name   string         `json:"name"`
+	Status string         `json:"status"`
+	Error  string         `json:"error,omitempty"`
+	Size   int64          `json:"size"`
+	Files  []manifestFile `json:"files,omitempty"`
+	// Omitted lists the files removed to fit the size cap.
+	Omitted []string `json:"omitted,omitempty"`
+
+	output    string
+	truncated []string
+}
+
+type manifestFile struct {
+	Path       string `json:"path"`
+	Size       int64  `json:"size"`
+	Redactions int    `json:"redactions,omitempty"`
+	// Truncated is set when only the tail of the file fits the size cap.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+func newManifest(collectors []manifestCollector, files []collectedFile, maxSize int64) manifest {
+	m := manifest{
+		CreatedAt:        time.Now().UTC(),
+		GameapctlVersion: gameap.Version,
+		MaxCollectorSize: maxSize,
+		Collectors:       slices.Clone(collectors),
+	}
+
+	for i := range m.Collectors {
+		c := &m.Collectors[i]
+		if c.output == "" {
+			continue
+		}
+
+		for _, file := range files {
+			if file.Path != c.output && !strings.HasPrefix(file.Path, c.output+"/") {
+				continue
+			}
+
+			c.Size += file.Size
+			c.Files = append(c.Files, manifestFile{
+				Path:       file.Path,
+				Size:       file.Size,
+				Redactions: file.Redactions,
+				Truncated:  slices.Contains(c.truncated, file.Path),
+			})
+		}
+	}
+
+	return m
+}
+
+// writeManifest writes the manifest to the bundle root and returns it as a
+// collected file, so it is shown in the preview.
+func writeManifest(dir string, m manifest) (collectedFile, error) {
+	content, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return collectedFile{}, errors.Wrap(err, "failed to marshal manifest")
+	}
+
+	err = os.WriteFile(filepath.Join(dir, manifestName), content, 0o600)
+	if err != nil {
+		return collectedFile{}, errors.Wrap(err, "failed to write manifest")
+	}
+
+	return collectedFile{Path: manifestName, Size: int64(len(content))}, nil
+}
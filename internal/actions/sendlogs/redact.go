@@ -0,0 +1,128 @@
+package sendlogs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/gameap/gameapctl/internal/pkg/redact"
+	"github.com/gameap/gameapctl/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+type collectedFile struct {
+	Path       string
+	Size       int64
+	Redactions int
+	Binary     bool
+}
+
+// redactFiles masks the secrets in every collected file in place, it runs
+// before the files are compressed.
+func redactFiles(dir string, redactor *redact.Redactor) ([]collectedFile, error) {
+	var files []collectedFile
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s", rel)
+		}
+
+		file := collectedFile{
+			Path:   filepath.ToSlash(rel),
+			Binary: bytes.IndexByte(content, 0) >= 0,
+		}
+
+		redacted, n := redactor.Redact(rel, content)
+		file.Redactions = n
+		file.Size = int64(len(redacted))
+
+		if n > 0 {
+			info, err := d.Info()
+			if err != nil {
+				return errors.Wrapf(err, "failed to stat %s", rel)
+			}
+
+			if err := os.WriteFile(path, redacted, info.Mode().Perm()); err != nil {
+				return errors.Wrapf(err, "failed to write %s", rel)
+			}
+		}
+
+		files = append(files, file)
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to redact collected files")
+	}
+
+	return files, nil
+}
+
+// printPreview shows every file as it will be sent.
+func printPreview(w io.Writer, dir string, files []collectedFile) error {
+	for _, file := range files {
+		_, err := fmt.Fprintf(w, "\n==> %s (%d bytes, %d redacted) <==\n", file.Path, file.Size, file.Redactions)
+		if err != nil {
+			return errors.Wrap(err, "failed to write preview")
+		}
+
+		if file.Binary {
+			if _, err := fmt.Fprintln(w, "(binary content is not shown)"); err != nil {
+				return errors.Wrap(err, "failed to write preview")
+			}
+
+			continue
+		}
+
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(file.Path)))
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s", file.Path)
+		}
+
+		if _, err := w.Write(content); err != nil {
+			return errors.Wrap(err, "failed to write preview")
+		}
+
+		if len(content) > 0 && content[len(content)-1] != '\n' {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return errors.Wrap(err, "failed to write preview")
+			}
+		}
+	}
+
+	return nil
+}
+
+func confirmSend(ctx context.Context, question string) (bool, error) {
+	answer, err := utils.Ask(ctx, question, true, func(s string) (bool, string, error) {
+		switch s {
+		case "y", "Y", "n", "N":
+			return true, "", nil
+		default:
+			return false, "Please answer y or n.", nil
+		}
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return answer == "y" || answer == "Y", nil
+}
@@ -0,0 +1,41 @@
+package sendlogs
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gameap/gameapctl/internal/pkg/redact"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "additional"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "additional", "panel_install_state.json"),
+		[]byte(`{"dbPassword": "secret", "host": "example.com"}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "system_info.txt"), []byte("OS: linux\n"), 0o644))
+
+	files, err := redactFiles(dir, redact.Default())
+	require.NoError(t, err)
+
+	assert.Equal(t, []collectedFile{
+		{Path: "additional/panel_install_state.json", Size: 51, Redactions: 1},
+		{Path: "system_info.txt", Size: 10},
+	}, files)
+
+	content, err := os.ReadFile(filepath.Join(dir, "additional", "panel_install_state.json"))
+	require.NoError(t, err)
+	assert.Equal(t, `{"dbPassword": "[REDACTED]", "host": "example.com"}`, string(content))
+
+	var preview bytes.Buffer
+	require.NoError(t, printPreview(&preview, dir, files))
+	assert.Equal(t,
+		"\n==> additional/panel_install_state.json (51 bytes, 1 redacted) <==\n"+
+			`{"dbPassword": "[REDACTED]", "host": "example.com"}`+"\n"+
+			"\n==> system_info.txt (10 bytes, 0 redacted) <==\nOS: linux\n",
+		preview.String(),
+	)
+}
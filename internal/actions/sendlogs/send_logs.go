@@ -7,20 +7,46 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"time"
 
 	contextInternal "github.com/gameap/gameapctl/internal/context"
 	"github.com/gameap/gameapctl/internal/pkg/gameapctl"
+	"github.com/gameap/gameapctl/internal/pkg/redact"
 	"github.com/gameap/gameapctl/pkg/gameap"
 	"github.com/gameap/gameapctl/pkg/utils"
 	"github.com/pkg/errors"
 	"github.com/urfave/cli/v2"
 )
 
+// megabyte is the unit of --max-size.
+const megabyte = 1 << 20
+
 func Handle(cliCtx *cli.Context) error {
 	ctx := cliCtx.Context
+
+	selected, err := selectCollectors(cliCtx.StringSlice("only"), cliCtx.StringSlice("exclude"))
+	if err != nil {
+		return err
+	}
+
+	outputPath := cliCtx.String("save-to")
+	endpoint := cliCtx.String("endpoint")
+
+	if outputPath != "" && endpoint != "" {
+		return errors.New("--save-to and --endpoint cannot be used together")
+	}
+
+	if endpoint == "" {
+		endpoint = apiPath
+	} else if err := validateEndpoint(endpoint); err != nil {
+		return err
+	}
+
+	maxSize := cliCtx.Int64("max-size") * megabyte
+
 	tmpDir, err := os.MkdirTemp("", "gameapctl-send-logs")
 	if err != nil {
 		return errors.WithMessage(err, "failed to create temp file")
@@ -32,8 +58,107 @@ func Handle(cliCtx *cli.Context) error {
 		}
 	}()
 
-	collectAllLogs(ctx, tmpDir, cliCtx.StringSlice("include-logs"))
+	collected := collectAllLogs(ctx, tmpDir, collectOptions{
+		collectors:     selected,
+		additionalLogs: cliCtx.StringSlice("include-logs"),
+		maxSize:        maxSize,
+	})
+
+	fmt.Println("Redacting secrets...")
+	files, err := redactFiles(tmpDir, redact.Default())
+	if err != nil {
+		return err
+	}
+
+	redactions := 0
+	for _, file := range files {
+		redactions += file.Redactions
+	}
+	fmt.Printf("Redacted %d values in %d files\n", redactions, len(files))
 
+	manifestFile, err := writeManifest(tmpDir, newManifest(collected, files, maxSize))
+	if err != nil {
+		return err
+	}
+	files = append(files, manifestFile)
+
+	if cliCtx.Bool("preview") {
+		if err := printPreview(os.Stdout, tmpDir, files); err != nil {
+			return err
+		}
+
+		if cliCtx.Bool("non-interactive") {
+			fmt.Println("Preview only, nothing was sent")
+
+			return nil
+		}
+
+		question := "Send these files to GameAP support? (y/N): "
+		switch {
+		case outputPath != "":
+			question = fmt.Sprintf("Save these files to %s? (y/N): ", outputPath)
+		case endpoint != apiPath:
+			question = fmt.Sprintf("Send these files to %s? (y/N): ", endpoint)
+		}
+
+		send, err := confirmSend(ctx, question)
+		if err != nil {
+			return err
+		}
+		if !send {
+			fmt.Println("Nothing was sent")
+
+			return nil
+		}
+	}
+
+	if outputPath != "" {
+		return saveBundle(tmpDir, outputPath)
+	}
+
+	return sendBundle(ctx, tmpDir, endpoint)
+}
+
+func validateEndpoint(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return errors.Wrap(err, "invalid endpoint")
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.Errorf("invalid endpoint %q, expected an http or https URL", endpoint)
+	}
+
+	return nil
+}
+
+// saveBundle writes the archive to path, an existing file is never replaced.
+func saveBundle(tmpDir, path string) error {
+	fmt.Println("Compressing logs...")
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return errors.Wrap(err, "failed to create output file")
+	}
+
+	err = compress(tmpDir, f)
+	if err != nil {
+		_ = f.Close()
+		_ = os.Remove(path)
+
+		return errors.WithMessage(err, "failed to compress logs")
+	}
+
+	if err := f.Close(); err != nil {
+		return errors.Wrap(err, "failed to close output file")
+	}
+
+	fmt.Println("Logs saved to", path)
+
+	return nil
+}
+
+func sendBundle(ctx context.Context, tmpDir, endpoint string) error {
 	fmt.Println("Compressing logs...")
 	f, err := os.CreateTemp("", "gameapctl-send-logs")
 	if err != nil {
@@ -56,7 +181,7 @@ func Handle(cliCtx *cli.Context) error {
 	}
 
 	fmt.Println("Sending logs...")
-	id, err := sendFile(ctx, f)
+	id, err := sendFile(ctx, endpoint, f)
 	if err != nil {
 		return errors.WithMessage(err, "failed to send file")
 	}
@@ -67,6 +192,14 @@ func Handle(cliCtx *cli.Context) error {
 
 	fmt.Println()
 	fmt.Println("--------------------------")
+
+	if endpoint != apiPath {
+		fmt.Println("Logs was sent to", endpoint)
+		fmt.Println("Logs ID:", id)
+
+		return nil
+	}
+
 	fmt.Println("Logs was sent")
 	fmt.Println("Logs ID:", id)
 	fmt.Println("Please, send this ID to GameAP support")
@@ -75,35 +208,6 @@ func Handle(cliCtx *cli.Context) error {
 	return nil
 }
 
-func collectAllLogs(ctx context.Context, tmpDir string, additionalLogs []string) {
-	collectors := []struct {
-		name string
-		fn   func() error
-	}{
-		{"gameapctl logs", func() error { return collectGameapCTLLogs(ctx, tmpDir) }},
-		{"daemon logs", func() error { return collectDaemonLogs(ctx, tmpDir) }},
-		{"journal logs", func() error { return collectJournalLogs(ctx, tmpDir) }},
-		{"panel logs", func() error { return collectPanelLogs(ctx, tmpDir) }},
-		{"web server logs", func() error { return collectWebServerLogs(ctx, tmpDir) }},
-		{"database logs", func() error { return collectDatabaseLogs(ctx, tmpDir) }},
-		{"system information", func() error { return collectSystemInfo(ctx, tmpDir) }},
-	}
-
-	for _, c := range collectors {
-		fmt.Printf("Collecting %s...\n", c.name)
-		if err := c.fn(); err != nil {
-			log.Println(errors.WithMessagef(err, "failed to collect %s", c.name))
-		}
-	}
-
-	if len(additionalLogs) > 0 {
-		fmt.Println("Collecting additional logs...")
-		if err := collectAdditionalLogs(ctx, additionalLogs, tmpDir); err != nil {
-			log.Println(errors.WithMessage(err, "failed to collect additional logs"))
-		}
-	}
-}
-
 func collectGameapCTLLogs(_ context.Context, destinationDir string) error {
 	if !utils.IsFileExists(logsPathGamectl) {
 		// skip
@@ -294,8 +398,8 @@ func collectAdditionalLogs(_ context.Context, logs []string, destinationDir stri
 	return nil
 }
 
-func sendFile(ctx context.Context, buf io.Reader) (string, error) {
-	req, err := http.NewRequest(http.MethodPost, apiPath, buf)
+func sendFile(ctx context.Context, endpoint string, buf io.Reader) (string, error) {
+	req, err := http.NewRequest(http.MethodPost, endpoint, buf)
 	if err != nil {
 		return "", errors.WithMessage(err, "failed to create request")
 	}
@@ -0,0 +1,89 @@
+// Package state implements the 'state' commands showing what gameapctl
+// remembers about the installations.
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+
+	"github.com/gameap/gameapctl/internal/pkg/gameapctl"
+	"github.com/gameap/gameapctl/internal/pkg/output"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+type Result struct {
+	Panel  *gameapctl.PanelInstallState  `json:"panel,omitempty"`
+	Daemon *gameapctl.DaemonInstallState `json:"daemon,omitempty"`
+}
+
+func (r Result) WriteText(w io.Writer) error {
+	if r.Panel == nil && r.Daemon == nil {
+		_, err := fmt.Fprintln(w, "No installation state found")
+
+		return errors.Wrap(err, "failed to write state")
+	}
+
+	sections := []struct {
+		title string
+		state any
+		found bool
+	}{
+		{"Panel", r.Panel, r.Panel != nil},
+		{"Daemon", r.Daemon, r.Daemon != nil},
+	}
+
+	for _, section := range sections {
+		if !section.found {
+			continue
+		}
+
+		b, err := json.MarshalIndent(section.state, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal state")
+		}
+
+		if _, err := fmt.Fprintf(w, "%s:\n%s\n", section.title, b); err != nil {
+			return errors.Wrap(err, "failed to write state")
+		}
+	}
+
+	return nil
+}
+
+// Show prints the panel and daemon install states. Secrets are masked unless
+// --reveal is set.
+func Show(cliCtx *cli.Context) error {
+	ctx := cliCtx.Context
+	reveal := cliCtx.Bool("reveal")
+
+	var result Result
+
+	panelState, err := gameapctl.LoadPanelInstallState(ctx)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return errors.WithMessage(err, "failed to load panel install state")
+	default:
+		if !reveal {
+			panelState = panelState.Masked()
+		}
+		result.Panel = &panelState
+	}
+
+	daemonState, err := gameapctl.LoadDaemonInstallState(ctx)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return errors.WithMessage(err, "failed to load daemon install state")
+	default:
+		if !reveal {
+			daemonState = daemonState.Masked()
+		}
+		result.Daemon = &daemonState
+	}
+
+	return output.Print(cliCtx, result)
+}
@@ -0,0 +1,95 @@
+package ui
+
+import (
+	"bytes"
+	"io"
+	"os/exec"
+	"sync"
+
+	"github.com/gameap/gameapctl/pkg/progress"
+)
+
+type eventWriter interface {
+	WriteEvent(e progress.Event) error
+}
+
+// progressWriter splits the output of a gameapctl subprocess started with
+// --progress=json into lines. Progress lines are sent as events, the other
+// lines are written as they are.
+type progressWriter struct {
+	mu  sync.Mutex
+	w   io.Writer
+	buf []byte
+}
+
+func newProgressWriter(w io.Writer) *progressWriter {
+	return &progressWriter{w: w}
+}
+
+func (pw *progressWriter) Write(p []byte) (int, error) {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+
+	pw.buf = append(pw.buf, p...)
+
+	for {
+		i := bytes.IndexByte(pw.buf, '\n')
+		if i < 0 {
+			break
+		}
+
+		line := pw.buf[:i+1]
+		pw.buf = pw.buf[i+1:]
+
+		if err := pw.writeLine(line); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+// Flush writes the rest of the output not terminated by a newline.
+func (pw *progressWriter) Flush() error {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+
+	if len(pw.buf) == 0 {
+		return nil
+	}
+
+	line := pw.buf
+	pw.buf = nil
+
+	return pw.writeLine(line)
+}
+
+func (pw *progressWriter) writeLine(line []byte) error {
+	if ew, ok := pw.w.(eventWriter); ok {
+		if e, ok := progress.ParseLine(string(line)); ok {
+			return ew.WriteEvent(e)
+		}
+	}
+
+	_, err := pw.w.Write(line)
+
+	return err
+}
+
+// progressFlag makes a gameapctl subprocess report its progress as JSON lines.
+const progressFlag = "--" + progress.FlagName + "=" + progress.ModeJSON
+
+// runWithProgress runs the command with the output sent to w, the progress
+// lines as events.
+func runWithProgress(cmd *exec.Cmd, w io.Writer) error {
+	pw := newProgressWriter(w)
+	cmd.Stdout = pw
+	cmd.Stderr = pw
+
+	err := cmd.Run()
+	if flushErr := pw.Flush(); flushErr != nil && err == nil {
+		err = flushErr
+	}
+
+	return err
+}
@@ -0,0 +1,49 @@
+package ui
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gameap/gameapctl/pkg/progress"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeEventWriter struct {
+	bytes.Buffer
+
+	events []progress.Event
+}
+
+func (w *fakeEventWriter) WriteEvent(e progress.Event) error {
+	w.events = append(w.events, e)
+
+	return nil
+}
+
+func TestProgressWriter(t *testing.T) {
+	w := &fakeEventWriter{}
+	pw := newProgressWriter(w)
+
+	_, err := pw.Write([]byte("Installing ...\n" + progress.LinePrefix + `{"type":"step_started",`))
+	require.NoError(t, err)
+	_, err = pw.Write([]byte(`"step":"panel","percent":40}` + "\nno newline"))
+	require.NoError(t, err)
+	require.NoError(t, pw.Flush())
+
+	assert.Equal(t, "Installing ...\nno newline", w.String())
+	assert.Equal(t, []progress.Event{
+		{Type: progress.EventStepStarted, Step: "panel", Percent: 40},
+	}, w.events)
+}
+
+func TestProgressWriter_PlainWriter(t *testing.T) {
+	w := &bytes.Buffer{}
+	pw := newProgressWriter(w)
+
+	line := progress.LinePrefix + `{"type":"step_started"}` + "\n"
+	_, err := pw.Write([]byte(line))
+	require.NoError(t, err)
+
+	assert.Equal(t, line, w.String())
+}
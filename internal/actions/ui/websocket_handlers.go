@@ -15,6 +15,7 @@ import (
 
 	contextInternal "github.com/gameap/gameapctl/internal/context"
 	"github.com/gameap/gameapctl/internal/pkg/gameapctl"
+	"github.com/gameap/gameapctl/internal/pkg/secretflag"
 	"github.com/gameap/gameapctl/pkg/daemon"
 	"github.com/gameap/gameapctl/pkg/gameap"
 	"github.com/gameap/gameapctl/pkg/oscore"
@@ -341,14 +342,12 @@ func gameapInstall(ctx context.Context, w io.Writer, args []string) error {
 
 	exPath := filepath.Dir(ex)
 
-	exArgs := append([]string{"--non-interactive", "panel", "install"}, args...)
+	exArgs := append([]string{"--non-interactive", progressFlag, "panel", "install"}, args...)
 
 	cmd := exec.Command(ex, exArgs...)
-	cmd.Stdout = w
-	cmd.Stderr = w
 	cmd.Dir = exPath
 
-	err = cmd.Run()
+	err = runWithProgress(cmd, w)
 	if err != nil {
 		return errors.Wrap(err, "failed to execute command")
 	}
@@ -482,12 +481,10 @@ func gameapUpgrade(_ context.Context, w io.Writer, _ []string) error {
 
 	exPath := filepath.Dir(ex)
 
-	cmd := exec.Command(ex, "--non-interactive", "panel", "upgrade")
-	cmd.Stdout = w
-	cmd.Stderr = w
+	cmd := exec.Command(ex, "--non-interactive", progressFlag, "panel", "upgrade")
 	cmd.Dir = exPath
 
-	err = cmd.Run()
+	err = runWithProgress(cmd, w)
 	if err != nil {
 		return errors.Wrap(err, "failed to execute command")
 	}
@@ -527,15 +524,16 @@ func daemonInstall(ctx context.Context, w io.Writer, args []string) error {
 		cmd := exec.Command(
 			ex,
 			"--non-interactive",
+			progressFlag,
 			"daemon",
 			"install",
-			"--connect="+connectURL,
 		)
-		cmd.Stdout = w
-		cmd.Stderr = w
 		cmd.Dir = exPath
+		// The secrets are passed in the environment, the command line is
+		// visible to every user of the host.
+		cmd.Env = append(os.Environ(), secretflag.EnvVar("connect")+"="+connectURL)
 
-		if runErr := cmd.Run(); runErr != nil {
+		if runErr := runWithProgress(cmd, w); runErr != nil {
 			return errors.Wrap(runErr, "failed to execute command")
 		}
 
@@ -609,16 +607,15 @@ func daemonInstall(ctx context.Context, w io.Writer, args []string) error {
 	cmd := exec.Command(
 		ex,
 		"--non-interactive",
+		progressFlag,
 		"daemon",
 		"install",
 		"--host="+host,
-		"--token="+createToken,
 	)
-	cmd.Stdout = w
-	cmd.Stderr = w
 	cmd.Dir = exPath
+	cmd.Env = append(os.Environ(), secretflag.EnvVar("token")+"="+createToken)
 
-	err = cmd.Run()
+	err = runWithProgress(cmd, w)
 	if err != nil {
 		return errors.Wrap(err, "failed to execute command")
 	}
@@ -693,12 +690,10 @@ func daemonUpgrade(_ context.Context, w io.Writer, _ []string) error {
 
 	exPath := filepath.Dir(ex)
 
-	cmd := exec.Command(ex, "--non-interactive", "daemon", "upgrade")
-	cmd.Stdout = w
-	cmd.Stderr = w
+	cmd := exec.Command(ex, "--non-interactive", progressFlag, "daemon", "upgrade")
 	cmd.Dir = exPath
 
-	err = cmd.Run()
+	err = runWithProgress(cmd, w)
 	if err != nil {
 		return errors.Wrap(err, "failed to execute command")
 	}
@@ -12,6 +12,7 @@ import (
 	"sync"
 
 	contextInternal "github.com/gameap/gameapctl/internal/context"
+	"github.com/gameap/gameapctl/pkg/progress"
 	"github.com/gorilla/websocket"
 	"github.com/pkg/errors"
 )
@@ -32,6 +33,8 @@ const (
 	messageCodePayload = "payload"
 	messageCodeError   = "error"
 	messageCodeEnd     = "end"
+	// messageCodeProgress carries a progress.Event encoded as JSON in Value.
+	messageCodeProgress = "progress"
 )
 
 func serveWs(w http.ResponseWriter, r *http.Request) {
@@ -165,6 +168,24 @@ func (rw *responseWriter) Write(p []byte) (n int, err error) {
 	return len(p), nil
 }
 
+func (rw *responseWriter) WriteEvent(e progress.Event) error {
+	value, err := json.Marshal(e)
+	if err != nil {
+		return errors.WithMessage(err, "failed to marshal progress event")
+	}
+
+	b, err := json.Marshal(message{
+		Topic: rw.topic,
+		Code:  messageCodeProgress,
+		Value: string(value),
+	})
+	if err != nil {
+		return errors.WithMessage(err, "failed to marshal message")
+	}
+
+	return rw.WriteMessage(websocket.TextMessage, b)
+}
+
 func (rw *responseWriter) WriteMessage(messageType int, data []byte) error {
 	rw.mu.Lock()
 	defer rw.mu.Unlock()
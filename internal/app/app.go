@@ -3,21 +3,34 @@ package app
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/gameap/gameapctl/internal/actions/autoupdate"
+	"github.com/gameap/gameapctl/internal/actions/bundle"
+	"github.com/gameap/gameapctl/internal/actions/cache"
+	"github.com/gameap/gameapctl/internal/actions/channel"
+	daemoncheck "github.com/gameap/gameapctl/internal/actions/daemon/check"
+	daemonconfig "github.com/gameap/gameapctl/internal/actions/daemon/config"
 	daemoninstall "github.com/gameap/gameapctl/internal/actions/daemon/install"
 	daemonrestart "github.com/gameap/gameapctl/internal/actions/daemon/restart"
 	daemonstart "github.com/gameap/gameapctl/internal/actions/daemon/start"
 	daemonstatus "github.com/gameap/gameapctl/internal/actions/daemon/status"
 	daemonstop "github.com/gameap/gameapctl/internal/actions/daemon/stop"
 	daemonupdate "github.com/gameap/gameapctl/internal/actions/daemon/update"
+	"github.com/gameap/gameapctl/internal/actions/doctor"
+	panelbackup "github.com/gameap/gameapctl/internal/actions/panel/backup"
 	panelchangepassword "github.com/gameap/gameapctl/internal/actions/panel/changepassword"
+	panelcheck "github.com/gameap/gameapctl/internal/actions/panel/check"
+	panelconfig "github.com/gameap/gameapctl/internal/actions/panel/config"
+	paneldatabase "github.com/gameap/gameapctl/internal/actions/panel/database"
 	panelinstall "github.com/gameap/gameapctl/internal/actions/panel/install"
 	panelletsencrypt "github.com/gameap/gameapctl/internal/actions/panel/letsencrypt"
 	panelrestart "github.com/gameap/gameapctl/internal/actions/panel/restart"
@@ -26,12 +39,23 @@ import (
 	panelstop "github.com/gameap/gameapctl/internal/actions/panel/stop"
 	paneluninstall "github.com/gameap/gameapctl/internal/actions/panel/uninstall"
 	panelupdate "github.com/gameap/gameapctl/internal/actions/panel/update"
+	paneluser "github.com/gameap/gameapctl/internal/actions/panel/user"
 	"github.com/gameap/gameapctl/internal/actions/selfupdate"
 	"github.com/gameap/gameapctl/internal/actions/sendlogs"
+	"github.com/gameap/gameapctl/internal/actions/state"
 	"github.com/gameap/gameapctl/internal/actions/ui"
 	contextInternal "github.com/gameap/gameapctl/internal/context"
-	"github.com/gameap/gameapctl/pkg/gameap"
+	"github.com/gameap/gameapctl/internal/pkg/answers"
+	"github.com/gameap/gameapctl/internal/pkg/gameapctl"
+	"github.com/gameap/gameapctl/internal/pkg/output"
+	"github.com/gameap/gameapctl/internal/pkg/redact"
+	"github.com/gameap/gameapctl/internal/pkg/secretflag"
+	statuspkg "github.com/gameap/gameapctl/internal/pkg/status"
+	"github.com/gameap/gameapctl/pkg/downloadcache"
+	"github.com/gameap/gameapctl/pkg/dryrun"
 	packagemanager "github.com/gameap/gameapctl/pkg/package_manager"
+	"github.com/gameap/gameapctl/pkg/progress"
+	"github.com/gameap/gameapctl/pkg/releasesource"
 	"github.com/pkg/errors"
 	"github.com/urfave/cli/v2"
 )
@@ -39,6 +63,7 @@ import (
 //nolint:funlen
 func Run(args []string) {
 	logfilepath := ""
+	outputFormat := output.FormatText
 
 	if len(args) == 1 && runtime.GOOS == "windows" {
 		args = []string{args[0], "ui"}
@@ -55,26 +80,40 @@ func Run(args []string) {
 				return err
 			}
 
+			outputFormat, err = output.ParseFormat(ctx.String(output.FlagName))
+			if err != nil {
+				return err
+			}
+
 			if ctx.Bool("debug") {
-				osInfo := contextInternal.OSInfoFromContext(ctx.Context)
-
-				fmt.Println("---------------")
-				fmt.Println("Information")
-				fmt.Println()
-				fmt.Println("Kernel:", osInfo.Kernel)
-				fmt.Println("Core:", osInfo.Core)
-				fmt.Println("Distribution:", osInfo.Distribution)
-				fmt.Println("DistributionVersion:", osInfo.DistributionVersion)
-				fmt.Println("DistributionCodename:", osInfo.DistributionCodename)
-				fmt.Println("Platform:", osInfo.Platform)
-				fmt.Println("OS:", osInfo.OS)
-				fmt.Println("Hostname:", osInfo.Hostname)
-				fmt.Println("CPUs:", osInfo.CPUs)
-				fmt.Println("---------------")
+				printDebugInfo(ctx.Context, infoWriter(outputFormat))
+			}
+
+			if ctx.Bool("dry-run") {
+				ctx.Context = dryrun.WithPlan(ctx.Context, dryrun.NewPlan())
+			}
+
+			applyConfig(infoWriter(outputFormat))
+
+			renderer, err := progress.NewRenderer(ctx.String(progress.FlagName), infoWriter(outputFormat))
+			if err != nil {
+				return err
 			}
 
+			bus := progress.NewBus()
+			bus.Subscribe(renderer)
+			ctx.Context = progress.WithBus(ctx.Context, bus)
+
 			return nil
 		},
+		After: func(ctx *cli.Context) error {
+			plan := dryrun.FromContext(ctx.Context)
+			if plan == nil {
+				return nil
+			}
+
+			return output.Print(ctx, plan.Report())
+		},
 		Flags: []cli.Flag{
 			&cli.BoolFlag{
 				Name:  "non-interactive",
@@ -89,6 +128,23 @@ func Run(args []string) {
 				Value:   false,
 				EnvVars: []string{"DEBUG"},
 			},
+			&cli.StringFlag{
+				Name:    output.FlagName,
+				Aliases: []string{"o"},
+				Value:   string(output.FormatText),
+				EnvVars: []string{"GAMEAPCTL_OUTPUT"},
+				Usage:   "Output format of status and version commands: text, json or yaml.",
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "Print the changes install, upgrade and uninstall commands would make without applying them.",
+			},
+			&cli.StringFlag{
+				Name:    progress.FlagName,
+				Value:   progress.ModeText,
+				EnvVars: []string{"GAMEAPCTL_PROGRESS"},
+				Usage:   "Progress display of install, upgrade and self-update: text, json or none.",
+			},
 		},
 		Commands: []*cli.Command{
 			{
@@ -109,13 +165,15 @@ func Run(args []string) {
 
 							return nil
 						},
-						Action: daemoninstall.Handle,
+						Action: bundle.FromBundle(daemoninstall.Handle),
 						Flags: []cli.Flag{
-							&cli.StringFlag{
+							answersFileFlag(),
+							bundle.Flag(),
+							secretflag.String(&cli.StringFlag{
 								Name:    "connect",
 								EnvVars: []string{"CONNECT_URL"},
 								Usage:   "Connect URL for gRPC enrollment (grpc://host:port/key)",
-							},
+							}),
 							&cli.StringFlag{
 								Name:    "scope",
 								EnvVars: []string{"SCOPE"},
@@ -123,10 +181,10 @@ func Run(args []string) {
 								Usage: "Installation scope: 'system' (default, requires root, writes to /etc and /usr/bin) " +
 									"or 'user' (systemd user units, files under $HOME; Linux only).",
 							},
-							&cli.StringFlag{
+							secretflag.String(&cli.StringFlag{
 								Name:    "token",
 								EnvVars: []string{"CREATE_TOKEN"},
-							},
+							}),
 							&cli.StringFlag{
 								Name:    "host",
 								EnvVars: []string{"PANEL_HOST"},
@@ -164,8 +222,9 @@ func Run(args []string) {
 
 							return nil
 						},
-						Action: daemonupdate.Handle,
+						Action: bundle.FromBundle(daemonupdate.Handle),
 						Flags: []cli.Flag{
+							bundle.Flag(),
 							&cli.BoolFlag{
 								Name:  "github",
 								Usage: "Upgrade daemon from GitHub source.",
@@ -192,6 +251,19 @@ func Run(args []string) {
 							},
 						},
 					},
+					{
+						Name:  "rollback",
+						Usage: "Restore the daemon release installed before an upgrade",
+						Description: "Upgrades keep the replaced daemon binaries with their config in ~/.gameapctl/releases. " +
+							"Without --to the release installed before the last upgrade is restored.",
+						Action: daemonupdate.HandleRollback,
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "to",
+								Usage: "Release tag to restore (e.g. v4.1.2)",
+							},
+						},
+					},
 					{
 						Name:        "start",
 						Aliases:     []string{"s"},
@@ -211,6 +283,15 @@ func Run(args []string) {
 						Usage:       "Daemon status",
 						Action:      daemonstatus.Handle,
 					},
+					{
+						Name:        "check",
+						Description: "Diagnose daemon installation",
+						Usage:       "Diagnose daemon installation",
+						Action:      daemoncheck.Handle,
+						Flags: []cli.Flag{
+							fixFlag(),
+						},
+					},
 					{
 						Name:        "restart",
 						Aliases:     []string{"r"},
@@ -218,6 +299,42 @@ func Run(args []string) {
 						Usage:       "Restart daemon",
 						Action:      daemonrestart.Handle,
 					},
+					{
+						Name:  "config",
+						Usage: "Read, change and validate the daemon config",
+						Description: "Edits gameap-daemon.yaml keeping its comments. Keys are dotted " +
+							"(process_manager.name) or YAMLPaths ($.users.gameap), values are YAML. " +
+							"Changes take effect after the daemon restart.",
+						Subcommands: []*cli.Command{
+							{
+								Name:      "get",
+								Usage:     "Print the value of a key",
+								ArgsUsage: "KEY",
+								Action:    daemonconfig.Get,
+							},
+							{
+								Name:      "set",
+								Usage:     "Change keys, the config is backed up first",
+								ArgsUsage: "KEY VALUE | KEY=VALUE [KEY=VALUE ...]",
+								Action:    daemonconfig.Set,
+								Flags: []cli.Flag{
+									&cli.BoolFlag{
+										Name:  "restart",
+										Usage: "Restart the daemon to apply the changes",
+									},
+									&cli.BoolFlag{
+										Name:  "force",
+										Usage: "Write the config even if it fails validation",
+									},
+								},
+							},
+							{
+								Name:   "validate",
+								Usage:  "Check the listen port, work path, process manager and certificates",
+								Action: daemonconfig.Validate,
+							},
+						},
+					},
 				},
 			},
 			{
@@ -238,8 +355,15 @@ func Run(args []string) {
 
 							return nil
 						},
-						Action: panelinstall.Handle,
+						Action: bundle.FromBundle(panelinstall.Handle),
 						Flags: []cli.Flag{
+							answersFileFlag(),
+							bundle.Flag(),
+							&cli.BoolFlag{
+								Name: "resume",
+								Usage: "Continue a failed installation from the last checkpoint, skipping completed steps. " +
+									"Installation parameters are taken from the checkpoint.",
+							},
 							&cli.StringFlag{
 								Name:  "path",
 								Usage: "Path to GameAP root directory",
@@ -297,9 +421,9 @@ func Run(args []string) {
 							&cli.StringFlag{
 								Name: "database-username",
 							},
-							&cli.StringFlag{
+							secretflag.String(&cli.StringFlag{
 								Name: "database-password",
-							},
+							}),
 							&cli.BoolFlag{
 								Name:  "with-daemon",
 								Usage: "Daemon will be also installed with panel. ",
@@ -343,12 +467,22 @@ func Run(args []string) {
 							panelScopeFlag(),
 						},
 					},
+					{
+						Name:   "check",
+						Usage:  "Diagnose GameAP installation",
+						Action: panelcheck.Handle,
+						Flags: []cli.Flag{
+							panelScopeFlag(),
+							fixFlag(),
+						},
+					},
 					{
 						Name:    "upgrade",
 						Aliases: []string{"update", "u"},
 						Usage:   "Update panel to a new version",
 						Flags: []cli.Flag{
 							panelScopeFlag(),
+							bundle.Flag(),
 							&cli.StringFlag{
 								Name: "version",
 								Usage: "Upgrade to specific GameAP release tag (e.g. 4.2.0, 4.2.0beta1). " +
@@ -373,7 +507,27 @@ func Run(args []string) {
 
 							return nil
 						},
-						Action: panelupdate.Handle,
+						Action: bundle.FromBundle(panelupdate.Handle),
+					},
+					{
+						Name:  "rollback",
+						Usage: "Restore the panel release installed before an upgrade",
+						Description: "Upgrades keep the replaced panel binaries with their config.env in " +
+							"~/.gameapctl/releases. Without --to the release installed before the last upgrade " +
+							"is restored. The current config.env is kept unless --with-config is given. " +
+							"The database is not rolled back, take a backup before upgrading.",
+						Action: panelupdate.HandleRollback,
+						Flags: []cli.Flag{
+							panelScopeFlag(),
+							&cli.StringFlag{
+								Name:  "to",
+								Usage: "Release tag to restore (e.g. v4.1.2)",
+							},
+							&cli.BoolFlag{
+								Name:  "with-config",
+								Usage: "Restore the config.env kept with the release too",
+							},
+						},
 					},
 					{
 						Name:  "uninstall",
@@ -408,10 +562,216 @@ func Run(args []string) {
 						Description: "Change password for a user in GameAP v4 database",
 						Action:      panelchangepassword.Handle,
 						ArgsUsage:   "<username> [password]",
+						Flags: []cli.Flag{
+							panelScopeFlag(),
+							secretflag.String(&cli.StringFlag{
+								Name:  "password",
+								Usage: "New password, prompted for if neither the flag nor the argument is set",
+							}),
+						},
+					},
+					{
+						Name:  "user",
+						Usage: "Manage panel users",
+						Description: "Manages the users in the database config.env of the installation " +
+							"selected by --scope points at.",
+						Subcommands: []*cli.Command{
+							{
+								Name:   "list",
+								Usage:  "List users with their roles",
+								Action: paneluser.ListUsers,
+								Flags: []cli.Flag{
+									panelScopeFlag(),
+								},
+							},
+							{
+								Name:      "create",
+								Usage:     "Create a user",
+								ArgsUsage: "LOGIN",
+								Description: "Creates a user with the given role. " +
+									"Without --password a password is generated and printed.",
+								Action: paneluser.Create,
+								Flags: []cli.Flag{
+									panelScopeFlag(),
+									&cli.StringFlag{
+										Name:     "email",
+										Usage:    "Email of the user",
+										Required: true,
+									},
+									secretflag.String(&cli.StringFlag{
+										Name:  "password",
+										Usage: "Password of the user, generated if empty",
+									}),
+									&cli.StringFlag{
+										Name:  "role",
+										Usage: "Role of the user: admin or user",
+										Value: paneluser.RoleUser,
+									},
+								},
+							},
+							{
+								Name:      "disable",
+								Usage:     "Disable a user",
+								ArgsUsage: "LOGIN",
+								Description: "Sets the disabled flag of the user, or locks the password on the panels " +
+									"without one, and revokes the API tokens and the sessions stored in the database. " +
+									"Sessions kept by other session drivers stay valid until they expire. " +
+									"The last enabled administrator cannot be disabled.",
+								Action: paneluser.Disable,
+								Flags: []cli.Flag{
+									panelScopeFlag(),
+								},
+							},
+							{
+								Name:      "enable",
+								Usage:     "Enable a disabled user",
+								ArgsUsage: "LOGIN",
+								Description: "Clears the disabled flag or unlocks the password of the user, " +
+									"the old password works again.",
+								Action: paneluser.Enable,
+								Flags: []cli.Flag{
+									panelScopeFlag(),
+								},
+							},
+							{
+								Name:      "set-role",
+								Usage:     "Set the role of a user",
+								ArgsUsage: "LOGIN admin|user",
+								Action:    paneluser.SetRole,
+								Flags: []cli.Flag{
+									panelScopeFlag(),
+								},
+							},
+						},
+					},
+					{
+						Name:  "config",
+						Usage: "Read and change panel settings in config.env",
+						Description: "Edits the config.env of the installation selected by --scope, " +
+							"keeping the order and the comments of the file. Changes take effect " +
+							"after the panel restart.",
+						Subcommands: []*cli.Command{
+							{
+								Name:      "get",
+								Usage:     "Print the value of a setting",
+								ArgsUsage: "KEY",
+								Action:    panelconfig.Get,
+								Flags: []cli.Flag{
+									panelScopeFlag(),
+								},
+							},
+							{
+								Name:   "list",
+								Usage:  "Print all settings, secrets are masked",
+								Action: panelconfig.ListEntries,
+								Flags: []cli.Flag{
+									panelScopeFlag(),
+									&cli.BoolFlag{
+										Name:  "show-secrets",
+										Usage: "Print passwords, keys and tokens as they are",
+									},
+								},
+							},
+							{
+								Name:      "set",
+								Usage:     "Change settings",
+								ArgsUsage: "KEY VALUE | KEY=VALUE [KEY=VALUE ...]",
+								Action:    panelconfig.Set,
+								Flags: []cli.Flag{
+									panelScopeFlag(),
+									panelConfigRestartFlag(),
+									&cli.BoolFlag{
+										Name:  "force",
+										Usage: "Write values that fail validation",
+									},
+								},
+							},
+							{
+								Name:      "unset",
+								Usage:     "Remove settings",
+								ArgsUsage: "KEY [KEY ...]",
+								Action:    panelconfig.Unset,
+								Flags: []cli.Flag{
+									panelScopeFlag(),
+									panelConfigRestartFlag(),
+									&cli.BoolFlag{
+										Name:  "force",
+										Usage: "Remove settings required by the panel",
+									},
+								},
+							},
+						},
+					},
+					{
+						Name:  "backup",
+						Usage: "Back up the panel config, files and database",
+						Description: "Writes a tar.gz archive with config.env, the data directory, the install " +
+							"state and a database dump. The panel keeps running during the backup.",
+						ArgsUsage: "[ARCHIVE]",
+						Action:    panelbackup.Handle,
 						Flags: []cli.Flag{
 							panelScopeFlag(),
 						},
 					},
+					{
+						Name:  "restore",
+						Usage: "Restore a panel backup",
+						Description: "Restores a backup made by 'panel backup' onto an installed panel using the " +
+							"same database driver. The database and the files of the installation are replaced, " +
+							"the database connection settings of config.env are kept.",
+						ArgsUsage: "ARCHIVE",
+						Action:    panelbackup.Restore,
+						Flags: []cli.Flag{
+							panelScopeFlag(),
+						},
+					},
+					{
+						Name:  "database",
+						Usage: "Manage the panel database",
+						Subcommands: []*cli.Command{
+							{
+								Name:  "migrate",
+								Usage: "Move the panel to another database",
+								Description: "Stops the panel, creates the schema in the new database, copies all " +
+									"tables converting the values between SQLite, PostgreSQL and MySQL, verifies the " +
+									"row counts and switches DATABASE_DRIVER and DATABASE_URL in config.env. " +
+									"On failure the original config.env is restored. The old database is not changed.",
+								Action: paneldatabase.Migrate,
+								Flags: []cli.Flag{
+									panelScopeFlag(),
+									&cli.StringFlag{
+										Name:     "to",
+										Usage:    "Target database driver (sqlite|postgres|mysql)",
+										Required: true,
+									},
+									secretflag.String(&cli.StringFlag{
+										Name: "database-url",
+										Usage: "DATABASE_URL of the target database. " +
+											"Default for sqlite: database.sqlite in the data directory.",
+									}),
+									&cli.BoolFlag{
+										Name:  "force",
+										Usage: "Replace the data of a target database which is already used by a panel",
+									},
+								},
+							},
+							{
+								Name:  "rotate-password",
+								Usage: "Generate a new password for the panel database user",
+								Description: "Changes the password on the MySQL, MariaDB or PostgreSQL server, " +
+									"updates DATABASE_URL in config.env and the install state and restarts the panel. " +
+									"If the panel does not come up, the old password is restored.",
+								Action: paneldatabase.RotatePassword,
+								Flags: []cli.Flag{
+									panelScopeFlag(),
+									secretflag.String(&cli.StringFlag{
+										Name:  "root-password",
+										Usage: "MySQL root password. Default: the one saved at install.",
+									}),
+								},
+							},
+						},
+					},
 					{
 						Name:  "letsencrypt",
 						Usage: "Manage Let's Encrypt (ACME) certificates",
@@ -430,6 +790,7 @@ func Run(args []string) {
 								Action: panelletsencrypt.Setup,
 								Flags: []cli.Flag{
 									panelScopeFlag(),
+									answersFileFlag(),
 									&cli.StringFlag{
 										Name:  "challenge",
 										Usage: "Challenge type: http-01 (default) or dns-01",
@@ -450,10 +811,10 @@ func Run(args []string) {
 										Name:  "staging",
 										Usage: "Use Let's Encrypt staging directory",
 									},
-									&cli.StringSliceFlag{
+									secretflag.StringSlice(&cli.StringSliceFlag{
 										Name:  "env",
 										Usage: "Additional KEY=VALUE entries appended to config.env (DNS provider credentials)",
-									},
+									}),
 									&cli.BoolFlag{
 										Name:  "non-interactive",
 										Usage: "Fail instead of prompting when required flags are missing",
@@ -506,7 +867,7 @@ func Run(args []string) {
 				Name:        "self-update",
 				Description: "Update the gameapctl binary to the latest version",
 				Usage:       "Update the gameapctl binary to the latest version",
-				Action:      selfupdate.Handle,
+				Action:      bundle.FromBundle(selfupdate.Handle),
 				Flags:       selfUpdateFlags(),
 			},
 			{
@@ -514,18 +875,206 @@ func Run(args []string) {
 				Description: "Update the gameapctl binary to the latest version",
 				Usage:       "Update the gameapctl binary to the latest version",
 				Hidden:      true,
-				Action:      selfupdate.Handle,
+				Action:      bundle.FromBundle(selfupdate.Handle),
 				Flags:       selfUpdateFlags(),
 			},
 			{
-				Name:        "send-logs",
-				Description: "Send logs to GameAP support. You can specify log which you want to send.",
-				Usage:       "Send logs to GameAP support",
+				Name:  "bundle",
+				Usage: "Offline installation bundles",
+				Description: "A bundle carries the panel, daemon and gameapctl releases and the package archives " +
+					"gameapctl downloads itself. Install and upgrade commands take it with --from-bundle. " +
+					"Packages from the OS repositories are not bundled.",
+				Subcommands: []*cli.Command{
+					{
+						Name:      "create",
+						Usage:     "Download the releases and package archives for a system into a bundle",
+						ArgsUsage: "[bundle.tar.gz]",
+						Action:    bundle.Create,
+						Flags: []cli.Flag{
+							&cli.StringSliceFlag{
+								Name:  "components",
+								Usage: "Components to bundle: panel, daemon, gameapctl",
+								Value: cli.NewStringSlice("panel", "daemon", "gameapctl"),
+							},
+							&cli.StringFlag{
+								Name:     "os",
+								Usage:    "Target system as <distribution>[-<version>], e.g. debian-12 or windows",
+								Required: true,
+							},
+							&cli.StringFlag{
+								Name:  "arch",
+								Usage: "Target architecture, e.g. amd64 or arm64",
+								Value: runtime.GOARCH,
+							},
+						},
+					},
+				},
+			},
+			{
+				Name:  "cache",
+				Usage: "Manage the download cache",
+				Description: "Release archives and package files are kept in the download cache, " +
+					"/var/cache/gameapctl for root by default, so installs and upgrades do not download them again. " +
+					"The location and the size limit are set in ~/.gameapctl/config.yaml.",
+				Subcommands: []*cli.Command{
+					{
+						Name:   "list",
+						Usage:  "List the cached files",
+						Action: cache.List,
+					},
+					{
+						Name:   "prune",
+						Usage:  "Remove cached files",
+						Action: cache.Prune,
+						Flags: []cli.Flag{
+							&cli.DurationFlag{
+								Name:  "older-than",
+								Usage: "Remove only the files not used for the duration, e.g. 720h",
+							},
+						},
+					},
+				},
+			},
+			{
+				Name:  "channel",
+				Usage: "Switch the release channel of gameapctl, the panel and the daemon",
+				Description: "Panel upgrade, daemon upgrade and self-update follow the channel when no version is given: " +
+					"stable releases, beta releases including prereleases, or nightly builds from the GitHub source. " +
+					"The channel is kept in ~/.gameapctl/config.yaml and in the install states.",
+				Subcommands: []*cli.Command{
+					{
+						Name:   "show",
+						Usage:  "Print the release channels",
+						Action: channel.Show,
+					},
+					{
+						Name:      "set",
+						Usage:     "Switch gameapctl and the installations to a channel",
+						ArgsUsage: "stable|beta|nightly",
+						Action:    channel.Set,
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "branch",
+								Usage: "GitHub branch the nightly panel and daemon are built from",
+							},
+						},
+					},
+				},
+			},
+			{
+				Name:  "autoupdate",
+				Usage: "Upgrade the daemon, the panel and gameapctl on schedule",
+				Description: "A systemd timer, or cron without systemd, starts the updates inside the maintenance window. " +
+					"They follow the release channel, are limited by the policy and wait while game servers " +
+					"are being installed or updated. The daemon is not upgraded while game servers are running. " +
+					"The runs are logged in ~/.gameapctl/autoupdate.log.",
+				Subcommands: []*cli.Command{
+					{
+						Name:   "enable",
+						Usage:  "Schedule the automatic updates",
+						Action: autoupdate.Enable,
+						Flags: []cli.Flag{
+							&cli.StringSliceFlag{
+								Name:  "components",
+								Usage: "Components to update: panel, daemon, gameapctl",
+								Value: cli.NewStringSlice("daemon", "gameapctl"),
+							},
+							&cli.StringFlag{
+								Name:  "window",
+								Usage: "Maintenance window in the local time, e.g. \"Sun 03:00-05:00\" or \"Mon-Fri 02:00-04:00\"",
+								Value: "03:00-05:00",
+							},
+							&cli.StringFlag{
+								Name: "policy",
+								Usage: "How far the updates go: patch (e.g. 4.1.x), minor (e.g. 4.x) " +
+									"or any (the latest release)",
+								Value: "patch",
+							},
+						},
+					},
+					{
+						Name:   "disable",
+						Usage:  "Remove the scheduled automatic updates",
+						Action: autoupdate.Disable,
+					},
+					{
+						Name:   "status",
+						Usage:  "Print the settings and the recent runs",
+						Action: autoupdate.Status,
+					},
+					{
+						Name:   "run",
+						Usage:  "Run the updates when inside the window, started by the scheduler",
+						Hidden: true,
+						Action: autoupdate.Run,
+					},
+				},
+			},
+			{
+				Name:        "doctor",
+				Description: "Diagnose panel and daemon installations and suggest how to fix found problems",
+				Usage:       "Diagnose GameAP installation",
+				Action:      doctor.Handle,
+				Flags: []cli.Flag{
+					fixFlag(),
+				},
+			},
+			{
+				Name:  "state",
+				Usage: "Show what gameapctl remembers about the installations",
+				Description: "The install states are kept in ~/.gameapctl. Passwords and keys are " +
+					"encrypted there with a machine-local key.",
+				Subcommands: []*cli.Command{
+					{
+						Name:   "show",
+						Usage:  "Print the panel and daemon install states, secrets are masked",
+						Action: state.Show,
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "reveal",
+								Usage: "Print the decrypted passwords and keys",
+							},
+						},
+					},
+				},
+			},
+			{
+				Name: "send-logs",
+				Description: "Send logs to GameAP support. You can specify log which you want to send. " +
+					"Passwords, keys and tokens are redacted from every file before sending. " +
+					"The archive holds a manifest.json listing what was gathered by each collector. " +
+					"Use --save-to to save the archive locally instead of sending it.",
+				Usage: "Send logs to GameAP support",
 				Flags: []cli.Flag{
 					&cli.StringSliceFlag{
 						Name:  "include-logs",
 						Usage: "Send additional logs, for example: --include-logs=/var/log/nginx",
 					},
+					&cli.BoolFlag{
+						Name:  "preview",
+						Usage: "List the collected files and show their redacted content before sending",
+					},
+					&cli.StringFlag{
+						Name:  "save-to",
+						Usage: "Save the archive to this file instead of sending it, for example: --save-to=bundle.tar.gz",
+					},
+					&cli.StringFlag{
+						Name:  "endpoint",
+						Usage: "Send the archive to this URL instead of GameAP support",
+					},
+					&cli.StringSliceFlag{
+						Name:  "only",
+						Usage: "Run only these collectors: " + strings.Join(sendlogs.CollectorNames(), ", "),
+					},
+					&cli.StringSliceFlag{
+						Name:  "exclude",
+						Usage: "Skip these collectors: " + strings.Join(sendlogs.CollectorNames(), ", "),
+					},
+					&cli.Int64Flag{
+						Name:  "max-size",
+						Usage: "Maximum size of the files gathered by each collector in MB, 0 disables the limit",
+						Value: 50, //nolint:mnd
+					},
 				},
 				Action: sendlogs.Handle,
 			},
@@ -533,16 +1082,15 @@ func Run(args []string) {
 				Name:    "version",
 				Usage:   "Print version information",
 				Aliases: []string{"v"},
-				Action: func(_ *cli.Context) error {
-					fmt.Println("Version:", gameap.Version)
-					fmt.Println("Build Date:", gameap.BuildDate)
-
-					return nil
+				Action: func(cliCtx *cli.Context) error {
+					return output.Print(cliCtx, statuspkg.Version(cliCtx.Context))
 				},
 			},
 		},
 	}
 
+	secretflag.Wire(app.Commands)
+
 	ctx := shutdownContext(context.Background())
 
 	err := app.RunContext(ctx, args)
@@ -551,13 +1099,80 @@ func Run(args []string) {
 		os.Exit(130) //nolint:mnd
 	}
 	if err != nil {
-		fmt.Println(err)
+		w := infoWriter(outputFormat)
+
+		_, _ = fmt.Fprintln(w, redact.String(err.Error()))
 
 		if logfilepath != "" {
-			fmt.Println("See details in log file: " + logfilepath)
+			_, _ = fmt.Fprintln(w, "See details in log file: "+logfilepath)
 		}
 
-		log.Fatal(err)
+		log.Fatal(redact.String(err.Error()))
+	}
+}
+
+// infoWriter returns where diagnostics go. With a structured output format stdout
+// is reserved for the rendered result, so everything else is sent to stderr.
+func infoWriter(format output.Format) io.Writer {
+	if format.IsStructured() {
+		return os.Stderr
+	}
+
+	return os.Stdout
+}
+
+// applyConfig sets the release mirrors and the download cache from the config.
+// A broken config must not lock out the commands fixing it, so its errors are
+// printed as warnings and the defaults are used instead.
+func applyConfig(w io.Writer) {
+	config, err := gameapctl.LoadConfig()
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "Warning: using the default settings, %v\n", err)
+		config = &gameapctl.Config{}
+	}
+
+	if err := releasesource.SetMirrors(config.ReleaseMirrors); err != nil {
+		_, _ = fmt.Fprintf(w, "Warning: invalid release mirrors, using the default sources: %v\n", err)
+	}
+
+	cache, err := config.DownloadCache.Cache()
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "Warning: using the default download cache, %v\n", err)
+		cache, _ = gameapctl.DownloadCacheConfig{}.Cache()
+	}
+	downloadcache.SetDefault(cache)
+}
+
+func printDebugInfo(ctx context.Context, w io.Writer) {
+	osInfo := contextInternal.OSInfoFromContext(ctx)
+
+	_, _ = fmt.Fprintln(w, "---------------")
+	_, _ = fmt.Fprintln(w, "Information")
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintln(w, "Kernel:", osInfo.Kernel)
+	_, _ = fmt.Fprintln(w, "Core:", osInfo.Core)
+	_, _ = fmt.Fprintln(w, "Distribution:", osInfo.Distribution)
+	_, _ = fmt.Fprintln(w, "DistributionVersion:", osInfo.DistributionVersion)
+	_, _ = fmt.Fprintln(w, "DistributionCodename:", osInfo.DistributionCodename)
+	_, _ = fmt.Fprintln(w, "Platform:", osInfo.Platform)
+	_, _ = fmt.Fprintln(w, "OS:", osInfo.OS)
+	_, _ = fmt.Fprintln(w, "Hostname:", osInfo.Hostname)
+	_, _ = fmt.Fprintln(w, "CPUs:", osInfo.CPUs)
+	_, _ = fmt.Fprintln(w, "---------------")
+}
+
+func fixFlag() *cli.BoolFlag {
+	return &cli.BoolFlag{
+		Name:  "fix",
+		Usage: "Try to fix found problems automatically",
+	}
+}
+
+func answersFileFlag() *cli.StringFlag {
+	return &cli.StringFlag{
+		Name: answers.FlagName,
+		Usage: "Path to a YAML answers file for an unattended run. " +
+			"Flags set on the command line take precedence over the file.",
 	}
 }
 
@@ -570,6 +1185,13 @@ func panelScopeFlag() *cli.StringFlag {
 	}
 }
 
+func panelConfigRestartFlag() *cli.BoolFlag {
+	return &cli.BoolFlag{
+		Name:  "restart",
+		Usage: "Restart the panel to apply the changes",
+	}
+}
+
 func initLogFile(command string) string {
 	logname := fmt.Sprintf("%s_%s.log", command, time.Now().Format("2006-01-02_15-04-05.000"))
 
@@ -666,6 +1288,12 @@ func selfUpdateFlags() []cli.Flag {
 			Name:  "version",
 			Usage: "Update to specific gameapctl release tag (e.g. 0.26.0, v0.26.0). Empty = latest.",
 		},
+		&cli.BoolFlag{
+			Name: "insecure-skip-verify",
+			Usage: "Update even if the signed checksums of the release are missing or do not match. " +
+				"Not recommended.",
+		},
+		bundle.Flag(),
 	}
 }
 
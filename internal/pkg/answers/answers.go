@@ -0,0 +1,393 @@
+// Package answers loads the answers file of an unattended installation. The
+// file supplies every value the interactive installers would otherwise ask
+// for; values passed as command line flags take precedence over it.
+package answers
+
+import (
+	"fmt"
+	"net/mail"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gameap/gameapctl/internal/pkg/redact"
+	"github.com/gameap/gameapctl/pkg/gameap"
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+const FlagName = "answers-file"
+
+const (
+	ChallengeHTTP01 = "http-01"
+	ChallengeDNS01  = "dns-01"
+)
+
+var (
+	databaseTypes = []string{"postgres", "mysql", "sqlite", "none"}
+	webServers    = []string{"nginx", "apache", "none"}
+	challenges    = []string{ChallengeHTTP01, ChallengeDNS01}
+)
+
+type File struct {
+	Scope         string    `yaml:"scope"`
+	Host          string    `yaml:"host"`
+	Port          string    `yaml:"port"`
+	GRPCPort      string    `yaml:"grpc-port"`
+	WithDaemon    bool      `yaml:"with-daemon"`
+	AdminPassword string    `yaml:"admin-password"`
+	Version       string    `yaml:"version"`
+	Database      *Database `yaml:"database"`
+	ACME          *ACME     `yaml:"acme"`
+	Daemon        *Daemon   `yaml:"daemon"`
+
+	// Path and WebServer are only used by the GameAP v3 installer.
+	Path      string `yaml:"path"`
+	WebServer string `yaml:"web-server"`
+
+	path string
+	ast  *ast.File
+}
+
+type Database struct {
+	Type string `yaml:"type"`
+	// Existing connects to an already running server instead of installing one.
+	Existing bool   `yaml:"existing"`
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	Name     string `yaml:"name"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+type ACME struct {
+	Challenge   string            `yaml:"challenge"`
+	Domains     []string          `yaml:"domains"`
+	Email       string            `yaml:"email"`
+	DNSProvider string            `yaml:"dns-provider"`
+	Staging     bool              `yaml:"staging"`
+	Env         map[string]string `yaml:"env"`
+}
+
+type Daemon struct {
+	Host       string `yaml:"host"`
+	Token      string `yaml:"token"`
+	ConnectURL string `yaml:"connect-url"`
+}
+
+// ValidationError points at the key of the answers file with an invalid value.
+type ValidationError struct {
+	File    string
+	Line    int
+	Key     string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s: %s", e.File, e.Line, e.Key, e.Message)
+	}
+
+	return fmt.Sprintf("%s: %s: %s", e.File, e.Key, e.Message)
+}
+
+// Load reads and decodes the answers file. Unknown keys and values of a wrong
+// type are rejected, the error contains the position of the offending key.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read answers file %s", path)
+	}
+
+	f := &File{path: path}
+
+	if err = yaml.UnmarshalWithOptions(data, f, yaml.Strict()); err != nil {
+		return nil, errors.Errorf("invalid answers file %s:\n%s", path, yaml.FormatError(err, false, true))
+	}
+
+	f.ast, err = parser.ParseBytes(data, 0)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse answers file %s", path)
+	}
+
+	f.registerSecrets()
+
+	return f, nil
+}
+
+// registerSecrets masks the secrets of the file in the logged commands.
+func (f *File) registerSecrets() {
+	redact.Register(f.AdminPassword, f.DatabaseOrEmpty().Password, f.DaemonOrEmpty().Token, f.DaemonOrEmpty().ConnectURL)
+
+	if f.ACME != nil {
+		for key, value := range f.ACME.Env {
+			if redact.IsSecretKey(key) {
+				redact.Register(value)
+			}
+		}
+	}
+}
+
+// LoadFromFlag loads and validates the answers file given with --answers-file.
+// Without the flag it returns an empty file, so callers can read the answers
+// unconditionally and fall back to the flags.
+func LoadFromFlag(cliCtx *cli.Context, validate func(*File) error) (*File, error) {
+	path := cliCtx.String(FlagName)
+	if path == "" {
+		return &File{}, nil
+	}
+
+	f, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = validate(f); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Provided reports whether the answers were read from a file. An answers file
+// means an unattended run: nothing is asked interactively.
+func (f *File) Provided() bool {
+	return f.path != ""
+}
+
+// DatabaseOrEmpty returns the database section or an empty one when it is omitted.
+func (f *File) DatabaseOrEmpty() Database {
+	if f.Database == nil {
+		return Database{}
+	}
+
+	return *f.Database
+}
+
+// DaemonOrEmpty returns the daemon section or an empty one when it is omitted.
+func (f *File) DaemonOrEmpty() Daemon {
+	if f.Daemon == nil {
+		return Daemon{}
+	}
+
+	return *f.Daemon
+}
+
+// FlagOr returns the value of the flag when it is set explicitly on the command
+// line, the answer when there is one, and the flag default otherwise.
+func FlagOr(cliCtx *cli.Context, name, answer string) string {
+	if cliCtx.IsSet(name) || answer == "" {
+		return cliCtx.String(name)
+	}
+
+	return answer
+}
+
+// ValidatePanel checks the answers needed by 'panel install'.
+func (f *File) ValidatePanel() error {
+	v := f.validator()
+
+	v.required("host", f.Host)
+	v.validateCommon()
+
+	if f.Database != nil {
+		v.oneOf("database.type", f.Database.Type, databaseTypes)
+		if f.Database.Type == "" {
+			v.add("database.type", "is required")
+		}
+		if f.Database.Existing {
+			v.required("database.host", f.Database.Host)
+			v.required("database.password", f.Database.Password)
+		}
+		v.port("database.port", f.Database.Port)
+	} else if gameap.ScopeOrDefault(f.Scope) != gameap.ScopeUser {
+		v.add("database", "is required")
+	}
+
+	if f.ACME != nil {
+		v.validateACME(f.ACME)
+	}
+
+	if f.Daemon != nil {
+		v.add("daemon", "is not used by 'panel install', set with-daemon instead")
+	}
+
+	return v.err()
+}
+
+// ValidateDaemon checks the answers needed by 'daemon install'.
+func (f *File) ValidateDaemon() error {
+	v := f.validator()
+
+	v.validateCommon()
+
+	switch {
+	case f.Daemon == nil:
+		v.add("daemon", "is required")
+	case f.Daemon.ConnectURL != "" && (f.Daemon.Host != "" || f.Daemon.Token != ""):
+		v.add("daemon.connect-url", "is mutually exclusive with daemon.host and daemon.token")
+	case f.Daemon.ConnectURL == "":
+		v.required("daemon.host", f.Daemon.Host)
+		v.required("daemon.token", f.Daemon.Token)
+	}
+
+	return v.err()
+}
+
+// ValidateACME checks the answers needed by 'panel letsencrypt setup'.
+func (f *File) ValidateACME() error {
+	v := f.validator()
+
+	if f.ACME == nil {
+		v.add("acme", "is required")
+	} else {
+		v.validateACME(f.ACME)
+	}
+
+	return v.err()
+}
+
+func (f *File) validator() *validator {
+	return &validator{file: f}
+}
+
+type validator struct {
+	file   *File
+	errors []error
+}
+
+func (v *validator) add(key, format string, a ...any) {
+	v.errors = append(v.errors, &ValidationError{
+		File:    v.file.path,
+		Line:    v.file.line(key),
+		Key:     key,
+		Message: fmt.Sprintf(format, a...),
+	})
+}
+
+func (v *validator) required(key, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.add(key, "is required")
+	}
+}
+
+func (v *validator) oneOf(key, value string, allowed []string) {
+	if value == "" {
+		return
+	}
+
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+
+	v.add(key, "unsupported value %q, expected one of: %s", value, strings.Join(allowed, ", "))
+}
+
+func (v *validator) port(key, value string) {
+	if value == "" {
+		return
+	}
+
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		v.add(key, "must be a port number between 1 and 65535")
+	}
+}
+
+func (v *validator) validateCommon() {
+	f := v.file
+
+	v.oneOf("scope", f.Scope, []string{gameap.ScopeSystem, gameap.ScopeUser})
+	v.port("port", f.Port)
+	v.port("grpc-port", f.GRPCPort)
+	v.oneOf("web-server", f.WebServer, webServers)
+}
+
+func (v *validator) validateACME(a *ACME) {
+	v.oneOf("acme.challenge", a.Challenge, challenges)
+
+	if len(a.Domains) == 0 {
+		v.add("acme.domains", "at least one domain is required")
+	}
+
+	if a.Email == "" {
+		v.add("acme.email", "is required")
+	} else if _, err := mail.ParseAddress(a.Email); err != nil {
+		v.add("acme.email", "invalid email %q", a.Email)
+	}
+
+	if a.Challenge == ChallengeDNS01 && a.DNSProvider == "" {
+		v.add("acme.dns-provider", "is required for the dns-01 challenge")
+	}
+
+	if a.Challenge != ChallengeDNS01 {
+		for _, d := range a.Domains {
+			if strings.HasPrefix(d, "*") {
+				v.add("acme.domains", "wildcard domain %q requires the dns-01 challenge", d)
+			}
+		}
+	}
+}
+
+func (v *validator) err() error {
+	switch len(v.errors) {
+	case 0:
+		return nil
+	case 1:
+		return v.errors[0]
+	}
+
+	lines := make([]string, 0, len(v.errors))
+	for _, err := range v.errors {
+		lines = append(lines, "  "+err.Error())
+	}
+
+	return errors.Errorf("invalid answers file:\n%s", strings.Join(lines, "\n"))
+}
+
+// line returns the line of the key, or of its closest existing parent when the
+// key is missing, so that "is required" errors point at the right section.
+func (f *File) line(key string) int {
+	if f.ast == nil || len(f.ast.Docs) == 0 {
+		return 0
+	}
+
+	var line int
+
+	node := f.ast.Docs[0].Body
+	for _, part := range strings.Split(key, ".") {
+		value := mappingValue(node, part)
+		if value == nil {
+			break
+		}
+
+		line = value.Key.GetToken().Position.Line
+		node = value.Value
+	}
+
+	return line
+}
+
+func mappingValue(node ast.Node, key string) *ast.MappingValueNode {
+	var values []*ast.MappingValueNode
+
+	switch n := node.(type) {
+	case *ast.MappingNode:
+		values = n.Values
+	case *ast.MappingValueNode:
+		values = []*ast.MappingValueNode{n}
+	}
+
+	for _, value := range values {
+		if value.Key.GetToken().Value == key {
+			return value
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,203 @@
+package answers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeAnswers(t *testing.T, body string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "install.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(body), 0o600))
+
+	return path
+}
+
+func TestLoad_Panel(t *testing.T) {
+	path := writeAnswers(t, `scope: system
+host: panel.example.com
+port: "8080"
+with-daemon: true
+admin-password: secret
+database:
+  type: postgres
+  existing: true
+  host: db.example.com
+  name: gameap
+  username: gameap
+  password: dbpass
+acme:
+  challenge: dns-01
+  domains: ["*.example.com", example.com]
+  email: ops@example.com
+  dns-provider: cloudflare:cloudflare
+  env:
+    CF_DNS_API_TOKEN: token
+`)
+
+	f, err := Load(path)
+	require.NoError(t, err)
+	require.NoError(t, f.ValidatePanel())
+
+	assert.True(t, f.Provided())
+	assert.Equal(t, "panel.example.com", f.Host)
+	assert.Equal(t, "8080", f.Port)
+	assert.True(t, f.WithDaemon)
+	assert.Equal(t, "secret", f.AdminPassword)
+	assert.Equal(t, Database{
+		Type:     "postgres",
+		Existing: true,
+		Host:     "db.example.com",
+		Name:     "gameap",
+		Username: "gameap",
+		Password: "dbpass",
+	}, f.DatabaseOrEmpty())
+	require.NotNil(t, f.ACME)
+	assert.Equal(t, []string{"*.example.com", "example.com"}, f.ACME.Domains)
+	assert.Equal(t, map[string]string{"CF_DNS_API_TOKEN": "token"}, f.ACME.Env)
+}
+
+func TestLoad_UnknownKey(t *testing.T) {
+	path := writeAnswers(t, "host: panel.example.com\nhots: typo\n")
+
+	_, err := Load(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), path)
+	assert.Contains(t, err.Error(), `[2:1] unknown field "hots"`)
+}
+
+func TestLoad_WrongType(t *testing.T) {
+	path := writeAnswers(t, "host: panel.example.com\nwith-daemon: maybe\n")
+
+	_, err := Load(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "[2:")
+}
+
+func TestValidatePanel_PointsAtKey(t *testing.T) {
+	path := writeAnswers(t, `host: panel.example.com
+database:
+  type: postgre
+`)
+
+	f, err := Load(path)
+	require.NoError(t, err)
+
+	err = f.ValidatePanel()
+	require.Error(t, err)
+
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, path, validationErr.File)
+	assert.Equal(t, 3, validationErr.Line)
+	assert.Equal(t, "database.type", validationErr.Key)
+	assert.Equal(t, path+`:3: database.type: unsupported value "postgre", expected one of: `+
+		"postgres, mysql, sqlite, none", err.Error())
+}
+
+func TestValidatePanel_MissingKeys(t *testing.T) {
+	path := writeAnswers(t, `port: "70000"
+database:
+  type: mysql
+  existing: true
+`)
+
+	f, err := Load(path)
+	require.NoError(t, err)
+
+	err = f.ValidatePanel()
+	require.Error(t, err)
+	assert.Equal(t, "invalid answers file:\n"+
+		"  "+path+": host: is required\n"+
+		"  "+path+":1: port: must be a port number between 1 and 65535\n"+
+		"  "+path+":2: database.host: is required\n"+
+		"  "+path+":2: database.password: is required", err.Error())
+}
+
+func TestValidatePanel_UserScopeWithoutDatabase(t *testing.T) {
+	f, err := Load(writeAnswers(t, "scope: user\nhost: localhost\n"))
+	require.NoError(t, err)
+
+	assert.NoError(t, f.ValidatePanel())
+}
+
+func TestValidatePanel_ACME(t *testing.T) {
+	path := writeAnswers(t, `host: panel.example.com
+database:
+  type: sqlite
+acme:
+  domains: ["*.example.com"]
+  email: not-an-email
+`)
+
+	f, err := Load(path)
+	require.NoError(t, err)
+
+	err = f.ValidatePanel()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), path+`:6: acme.email: invalid email "not-an-email"`)
+	assert.Contains(t, err.Error(), path+`:5: acme.domains: wildcard domain "*.example.com" requires the dns-01 challenge`)
+}
+
+func TestValidateDaemon(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string
+	}{
+		{
+			name: "connect_url",
+			body: "daemon:\n  connect-url: grpc://panel.example.com:31718/key\n",
+		},
+		{
+			name: "host_and_token",
+			body: "daemon:\n  host: https://panel.example.com\n  token: token\n",
+		},
+		{
+			name:    "missing_section",
+			body:    "scope: system\n",
+			wantErr: "daemon: is required",
+		},
+		{
+			name:    "missing_token",
+			body:    "daemon:\n  host: https://panel.example.com\n",
+			wantErr: ":1: daemon.token: is required",
+		},
+		{
+			name:    "mutually_exclusive",
+			body:    "daemon:\n  connect-url: grpc://panel.example.com:31718/key\n  token: token\n",
+			wantErr: ":2: daemon.connect-url: is mutually exclusive with daemon.host and daemon.token",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f, err := Load(writeAnswers(t, test.body))
+			require.NoError(t, err)
+
+			err = f.ValidateDaemon()
+			if test.wantErr == "" {
+				assert.NoError(t, err)
+
+				return
+			}
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.wantErr)
+		})
+	}
+}
+
+func TestValidateACME_Required(t *testing.T) {
+	f, err := Load(writeAnswers(t, "host: panel.example.com\n"))
+	require.NoError(t, err)
+
+	err = f.ValidateACME()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "acme: is required")
+}
@@ -0,0 +1,136 @@
+package autoupdate
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/gameap/gameapctl/internal/pkg/daemon"
+	"github.com/gameap/gameapctl/internal/pkg/gameapctl"
+	"github.com/gameap/gameapctl/internal/pkg/panel"
+	"github.com/gameap/gameapctl/pkg/gameap"
+	"github.com/gameap/gameapctl/pkg/oscore"
+	"github.com/gameap/gameapctl/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// busyProcesses install or update game servers, an update restarting the
+// daemon would break them.
+var busyProcesses = []string{"steamcmd", "steamcmd.sh", "steamcmd.exe"}
+
+// maxListedServers limits the game servers named in the busy reason.
+const maxListedServers = 3
+
+// Busy returns why the game servers are busy, empty when they are not.
+func Busy(ctx context.Context) (string, error) {
+	for _, name := range busyProcesses {
+		p, err := oscore.FindProcessByName(ctx, name)
+		if err != nil {
+			return "", err
+		}
+
+		if p != nil {
+			return fmt.Sprintf("%s is running (pid %d), a game server is being installed or updated", name, p.Pid), nil
+		}
+	}
+
+	return "", nil
+}
+
+// ServersRunning returns why the daemon must not be restarted now, empty when
+// no game server runs. The panel installed on this host tells which game
+// servers of the node run. Without it the processes working in the servers
+// directory of the daemon are the running game servers.
+func ServersRunning(ctx context.Context) (string, error) {
+	daemonPaths, nodeID := daemonNode(ctx)
+
+	names, ok, err := panelRunningServers(ctx, nodeID)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		if len(names) == 0 {
+			return "", nil
+		}
+
+		listed := names[:min(len(names), maxListedServers)]
+		reason := fmt.Sprintf("%d game servers are running: %s", len(names), strings.Join(listed, ", "))
+		if len(names) > len(listed) {
+			reason += ", ..."
+		}
+
+		return reason, nil
+	}
+
+	serversDir := filepath.Join(daemonPaths.WorkPath, "servers")
+	if !utils.IsFileExists(serversDir) {
+		return "", nil
+	}
+
+	processes, err := oscore.FindProcessesInDir(ctx, serversDir)
+	if err != nil {
+		return "", err
+	}
+	if len(processes) > 0 {
+		return fmt.Sprintf("%d game server processes are running in %s", len(processes), serversDir), nil
+	}
+
+	return "", nil
+}
+
+// daemonNode returns the paths of the installed daemon with its work path
+// from the config, and the node ID it is registered with, zero when unknown.
+func daemonNode(ctx context.Context) (gameap.DaemonPaths, uint) {
+	scope := ""
+	if state, err := gameapctl.LoadDaemonInstallState(ctx); err == nil {
+		scope = state.Scope
+	}
+
+	paths, err := gameap.DaemonPathsForScope(scope)
+	if err != nil {
+		log.Printf("Failed to resolve daemon paths: %v\n", err)
+
+		return gameap.SystemDaemonPaths(), 0
+	}
+
+	cfg, err := daemon.LoadConfig(paths.DaemonConfigFilePath)
+	if err != nil {
+		return paths, 0
+	}
+
+	if workPath, ok, _ := cfg.ReadString("$.work_path"); ok && workPath != "" {
+		paths.WorkPath = workPath
+	}
+
+	nodeID, _, _ := cfg.ReadUint("$.ds_id")
+
+	return paths, nodeID
+}
+
+// panelRunningServers asks the panel database for the running game servers
+// of the node. It reports false when the panel is not installed on this host.
+func panelRunningServers(ctx context.Context, nodeID uint) ([]string, bool, error) {
+	paths, err := panel.ResolveScope(ctx, "")
+	if err != nil {
+		return nil, false, nil //nolint:nilerr
+	}
+
+	if !utils.IsFileExists(paths.ConfigFilePath) {
+		return nil, false, nil
+	}
+
+	db, driver, err := panel.OpenConfiguredDatabase(ctx, paths.ConfigFilePath)
+	if err != nil {
+		return nil, false, errors.WithMessage(err, "failed to open panel database")
+	}
+	defer func() { _ = db.Close() }()
+
+	names, err := panel.RunningServers(ctx, db, driver, nodeID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return names, true, nil
+}
@@ -0,0 +1,59 @@
+// Package autoupdate holds the building blocks of the scheduled automatic
+// updates: the update policy, the maintenance window, the busy check and the
+// installation of the systemd timer or the cron entry running the job.
+package autoupdate
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Policy limits how far an automatic update may go from the installed
+// version.
+type Policy string
+
+const (
+	// PolicyPatch updates within the installed minor version, e.g. 4.1.x.
+	PolicyPatch Policy = "patch"
+	// PolicyMinor updates within the installed major version, e.g. 4.x.
+	PolicyMinor Policy = "minor"
+	// PolicyAny updates to the latest release of the channel.
+	PolicyAny Policy = "any"
+)
+
+var installedVersionRegex = regexp.MustCompile(`^[vV]?(\d+)(?:\.(\d+))?`)
+
+func ParsePolicy(s string) (Policy, error) {
+	switch p := Policy(strings.ToLower(strings.TrimSpace(s))); p {
+	case PolicyPatch, PolicyMinor, PolicyAny:
+		return p, nil
+	default:
+		return "", errors.Errorf("unknown update policy %q, expected patch, minor or any", s)
+	}
+}
+
+// TagPrefix returns the tag prefix of the releases the policy allows from the
+// installed version, e.g. "v4.1." for the patch policy and version 4.1.2. It
+// is empty for PolicyAny.
+func (p Policy) TagPrefix(installed string) (string, error) {
+	if p == PolicyAny {
+		return "", nil
+	}
+
+	matches := installedVersionRegex.FindStringSubmatch(strings.TrimSpace(installed))
+	if matches == nil {
+		return "", errors.Errorf("the installed version %q is unknown, the %s policy needs it", installed, p)
+	}
+
+	if p == PolicyMinor {
+		return "v" + matches[1] + ".", nil
+	}
+
+	if matches[2] == "" {
+		return "", errors.Errorf("the installed version %q has no minor version, the %s policy needs it", installed, p)
+	}
+
+	return "v" + matches[1] + "." + matches[2] + ".", nil
+}
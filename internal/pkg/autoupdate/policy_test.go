@@ -0,0 +1,50 @@
+package autoupdate_test
+
+import (
+	"testing"
+
+	"github.com/gameap/gameapctl/internal/pkg/autoupdate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePolicy(t *testing.T) {
+	p, err := autoupdate.ParsePolicy(" Minor ")
+	require.NoError(t, err)
+	assert.Equal(t, autoupdate.PolicyMinor, p)
+
+	_, err = autoupdate.ParsePolicy("major")
+	require.ErrorContains(t, err, `unknown update policy "major"`)
+}
+
+func TestPolicy_TagPrefix(t *testing.T) {
+	tests := []struct {
+		policy    autoupdate.Policy
+		installed string
+		want      string
+		wantErr   bool
+	}{
+		{policy: autoupdate.PolicyPatch, installed: "4.1.2", want: "v4.1."},
+		{policy: autoupdate.PolicyPatch, installed: "v4.1.0-beta1", want: "v4.1."},
+		{policy: autoupdate.PolicyMinor, installed: "v4.1.2", want: "v4."},
+		{policy: autoupdate.PolicyMinor, installed: "4", want: "v4."},
+		{policy: autoupdate.PolicyAny, installed: "", want: ""},
+		{policy: autoupdate.PolicyPatch, installed: "4", wantErr: true},
+		{policy: autoupdate.PolicyMinor, installed: "", wantErr: true},
+		{policy: autoupdate.PolicyPatch, installed: "dev", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.policy)+"_"+test.installed, func(t *testing.T) {
+			got, err := test.policy.TagPrefix(test.installed)
+			if test.wantErr {
+				require.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
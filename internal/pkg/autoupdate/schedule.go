@@ -0,0 +1,40 @@
+package autoupdate
+
+import "strings"
+
+// Scheduler runs the update job.
+type Scheduler string
+
+const (
+	SchedulerSystemd Scheduler = "systemd"
+	SchedulerCron    Scheduler = "cron"
+)
+
+// jobName names the systemd units and marks the cron entry.
+const jobName = "gameapctl-autoupdate"
+
+// The job runs every 15 minutes and does the work only inside the window,
+// so a run skipped while the game servers are busy is retried.
+const (
+	systemdCalendar = "*:0/15"
+	cronSchedule    = "*/15 * * * *"
+)
+
+// RunArgs are the arguments of the gameapctl command the scheduler runs.
+var RunArgs = []string{"autoupdate", "run"}
+
+func shellQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n'\"\\$`;&|<>()*?[]#~%") {
+		return s
+	}
+
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func command(executable string) string {
+	parts := make([]string, 0, len(RunArgs)+1)
+	parts = append(parts, shellQuote(executable))
+	parts = append(parts, RunArgs...)
+
+	return strings.Join(parts, " ")
+}
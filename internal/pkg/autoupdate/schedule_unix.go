@@ -0,0 +1,253 @@
+//go:build linux || darwin
+
+package autoupdate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/gameap/gameapctl/pkg/dryrun"
+	"github.com/gameap/gameapctl/pkg/gameap"
+	"github.com/gameap/gameapctl/pkg/oscore"
+	"github.com/gameap/gameapctl/pkg/systemd"
+	"github.com/gameap/gameapctl/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+const cronDir = "/etc/cron.d"
+
+// Install schedules the update job of the gameapctl executable with a systemd
+// timer, or with cron on systems without systemd. Root schedules a system
+// job, other users a job of their own.
+func Install(ctx context.Context, executable string) (Scheduler, error) {
+	if utils.IsFileExists("/run/systemd/system") {
+		return SchedulerSystemd, installSystemd(ctx, executable)
+	}
+
+	return SchedulerCron, installCron(ctx, executable)
+}
+
+// Remove removes the job scheduled by Install.
+func Remove(ctx context.Context, scheduler Scheduler) error {
+	switch scheduler {
+	case SchedulerSystemd:
+		return removeSystemd(ctx)
+	case SchedulerCron:
+		return removeCron(ctx)
+	default:
+		return errors.Errorf("unknown scheduler %q", scheduler)
+	}
+}
+
+func scope() string {
+	if os.Geteuid() == 0 {
+		return gameap.ScopeSystem
+	}
+
+	return gameap.ScopeUser
+}
+
+func systemdUnitDir() (string, error) {
+	if scope() == gameap.ScopeSystem {
+		return "/etc/systemd/system", nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to detect user home directory")
+	}
+
+	return filepath.Join(homeDir, ".config", "systemd", "user"), nil
+}
+
+func installSystemd(ctx context.Context, executable string) error {
+	unitDir, err := systemdUnitDir()
+	if err != nil {
+		return err
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return errors.Wrap(err, "failed to detect user home directory")
+	}
+
+	// The job keeps its settings and log in ~/.gameapctl, system services
+	// run without HOME. A % of the path would start a systemd specifier.
+	service := fmt.Sprintf(`[Unit]
+Description=GameAP automatic updates
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+Type=oneshot
+Environment=HOME=%s
+ExecStart=%s
+`, homeDir, strings.ReplaceAll(command(executable), "%", "%%"))
+
+	timer := fmt.Sprintf(`[Unit]
+Description=GameAP automatic updates
+
+[Timer]
+OnCalendar=%s
+RandomizedDelaySec=60
+
+[Install]
+WantedBy=timers.target
+`, systemdCalendar)
+
+	if !dryrun.Enabled(ctx) {
+		if err := os.MkdirAll(unitDir, 0o755); err != nil {
+			return errors.Wrap(err, "failed to create systemd unit directory")
+		}
+	}
+
+	err = oscore.WriteFile(ctx, filepath.Join(unitDir, jobName+".service"), []byte(service), 0o644)
+	if err != nil {
+		return err
+	}
+
+	timerPath := filepath.Join(unitDir, jobName+".timer")
+	if err := systemd.InstallUnit(ctx, scope(), timerPath, []byte(timer)); err != nil {
+		return err
+	}
+
+	return errors.WithMessage(
+		systemd.Run(ctx, scope(), "start", jobName+".timer"),
+		"failed to start timer",
+	)
+}
+
+func removeSystemd(ctx context.Context) error {
+	unitDir, err := systemdUnitDir()
+	if err != nil {
+		return err
+	}
+
+	if err := systemd.Run(ctx, scope(), "disable", "--now", jobName+".timer"); err != nil {
+		log.Printf("Failed to disable %s timer: %v\n", jobName, err)
+	}
+
+	for _, unit := range []string{jobName + ".timer", jobName + ".service"} {
+		if err := oscore.RemoveAll(ctx, filepath.Join(unitDir, unit)); err != nil {
+			return err
+		}
+	}
+
+	return errors.WithMessage(systemd.Run(ctx, scope(), "daemon-reload"), "failed to reload systemctl")
+}
+
+// cronLine runs the job quietly, the results are kept in the update log. Cron
+// turns an unescaped % of the command into a newline, even a quoted one.
+func cronLine(executable, user string) string {
+	if user != "" {
+		user += " "
+	}
+
+	cmd := strings.ReplaceAll(command(executable), "%", `\%`)
+
+	return cronSchedule + " " + user + cmd + " >/dev/null 2>&1 # " + jobName + "\n"
+}
+
+func systemCron() bool {
+	return scope() == gameap.ScopeSystem && utils.IsFileExists(cronDir)
+}
+
+func installCron(ctx context.Context, executable string) error {
+	if systemCron() {
+		content := "# GameAP automatic updates, managed by 'gameapctl autoupdate'\n" + cronLine(executable, "root")
+
+		return oscore.WriteFile(ctx, filepath.Join(cronDir, jobName), []byte(content), 0o644)
+	}
+
+	current, err := readCrontab(ctx)
+	if err != nil {
+		return err
+	}
+
+	return writeCrontab(ctx, withoutJob(current)+cronLine(executable, ""))
+}
+
+func removeCron(ctx context.Context) error {
+	if systemCron() {
+		return oscore.RemoveAll(ctx, filepath.Join(cronDir, jobName))
+	}
+
+	current, err := readCrontab(ctx)
+	if err != nil {
+		return err
+	}
+
+	return writeCrontab(ctx, withoutJob(current))
+}
+
+func readCrontab(ctx context.Context) (string, error) {
+	if _, err := exec.LookPath("crontab"); err != nil {
+		return "", errors.New("neither systemd nor crontab is available to schedule the updates")
+	}
+
+	// crontab -l fails when the user has no crontab yet. Any other failure
+	// is returned: the crontab written next would replace the user's jobs.
+	cmd := exec.CommandContext(ctx, "crontab", "-l")
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	log.Println("\n" + cmd.String())
+
+	current, err := cmd.Output()
+	if err != nil {
+		if noCrontab(stderr.String()) {
+			return "", nil
+		}
+
+		return "", errors.Wrapf(err, "failed to read crontab: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	return string(current), nil
+}
+
+// noCrontab reports whether crontab -l failed because the user has no crontab.
+func noCrontab(stderr string) bool {
+	return strings.Contains(strings.ToLower(stderr), "no crontab for ")
+}
+
+func withoutJob(crontab string) string {
+	var b strings.Builder
+
+	for _, line := range strings.SplitAfter(crontab, "\n") {
+		if line == "" || strings.HasSuffix(strings.TrimSpace(line), "# "+jobName) {
+			continue
+		}
+
+		b.WriteString(line)
+		if !strings.HasSuffix(line, "\n") {
+			b.WriteString("\n")
+		}
+	}
+
+	return b.String()
+}
+
+func writeCrontab(ctx context.Context, content string) error {
+	f, err := os.CreateTemp("", "gameapctl-crontab")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temporary file")
+	}
+	defer func() { _ = os.Remove(f.Name()) }()
+
+	if _, err := f.WriteString(content); err != nil {
+		_ = f.Close()
+
+		return errors.Wrap(err, "failed to write crontab")
+	}
+
+	if err := f.Close(); err != nil {
+		return errors.Wrap(err, "failed to write crontab")
+	}
+
+	return errors.WithMessage(oscore.ExecCommand(ctx, "crontab", f.Name()), "failed to install crontab")
+}
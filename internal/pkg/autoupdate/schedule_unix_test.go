@@ -0,0 +1,40 @@
+//go:build linux || darwin
+
+package autoupdate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCronLine(t *testing.T) {
+	assert.Equal(t,
+		"*/15 * * * * root '/opt/game ap/gameapctl' autoupdate run >/dev/null 2>&1 # gameapctl-autoupdate\n",
+		cronLine("/opt/game ap/gameapctl", "root"),
+	)
+	assert.Equal(t,
+		`*/15 * * * * '/opt/100\%/gameapctl' autoupdate run >/dev/null 2>&1 # gameapctl-autoupdate`+"\n",
+		cronLine("/opt/100%/gameapctl", ""),
+	)
+}
+
+func TestWithoutJob(t *testing.T) {
+	crontab := "MAILTO=admin\n" +
+		"0 * * * * /usr/local/bin/backup\n" +
+		"*/15 * * * * /usr/local/bin/gameapctl autoupdate run >/dev/null 2>&1 # gameapctl-autoupdate\n" +
+		"30 4 * * * /usr/local/bin/cleanup"
+
+	assert.Equal(t,
+		"MAILTO=admin\n0 * * * * /usr/local/bin/backup\n30 4 * * * /usr/local/bin/cleanup\n",
+		withoutJob(crontab),
+	)
+	assert.Empty(t, withoutJob(""))
+}
+
+func TestNoCrontab(t *testing.T) {
+	assert.True(t, noCrontab("no crontab for root\n"))
+	assert.True(t, noCrontab("crontab: no crontab for gameap"))
+	assert.False(t, noCrontab("crontab: your UID isn't in the passwd file.\n"))
+	assert.False(t, noCrontab(""))
+}
@@ -0,0 +1,17 @@
+package autoupdate
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+var errWindowsUnsupported = errors.New("automatic updates are not supported on Windows yet")
+
+func Install(_ context.Context, _ string) (Scheduler, error) {
+	return "", errWindowsUnsupported
+}
+
+func Remove(_ context.Context, _ Scheduler) error {
+	return errWindowsUnsupported
+}
@@ -0,0 +1,139 @@
+package autoupdate
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const day = 24 * time.Hour
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Window is a maintenance window in the local time, e.g. "Sun 03:00-05:00",
+// "Mon-Fri 02:00-04:00", "Sat,Sun 23:00-01:00" or "03:00-05:00" for every
+// day. A window ending before its start ends on the next day.
+type Window struct {
+	days  [7]bool
+	start time.Duration
+	end   time.Duration
+	text  string
+}
+
+func ParseWindow(s string) (Window, error) {
+	w := Window{text: strings.Join(strings.Fields(s), " ")}
+
+	fields := strings.Fields(s)
+	switch len(fields) {
+	case 1:
+		for i := range w.days {
+			w.days[i] = true
+		}
+	case 2: //nolint:mnd
+		if err := w.parseDays(fields[0]); err != nil {
+			return Window{}, err
+		}
+		fields = fields[1:]
+	default:
+		return Window{}, errors.Errorf("invalid window %q, expected e.g. \"Sun 03:00-05:00\"", s)
+	}
+
+	from, to, ok := strings.Cut(fields[0], "-")
+	if !ok {
+		return Window{}, errors.Errorf("invalid window time %q, expected e.g. 03:00-05:00", fields[0])
+	}
+
+	var err error
+	if w.start, err = parseClock(from); err != nil {
+		return Window{}, err
+	}
+	if w.end, err = parseClock(to); err != nil {
+		return Window{}, err
+	}
+
+	if w.start == w.end {
+		return Window{}, errors.Errorf("the window %q is empty", s)
+	}
+
+	return w, nil
+}
+
+func (w *Window) parseDays(s string) error {
+	for _, part := range strings.Split(strings.ToLower(s), ",") {
+		from, to, isRange := strings.Cut(part, "-")
+
+		first, ok := weekdays[from]
+		if !ok {
+			return errors.Errorf("unknown day %q, expected e.g. Sun, Mon-Fri or Sat,Sun", from)
+		}
+
+		last := first
+		if isRange {
+			if last, ok = weekdays[to]; !ok {
+				return errors.Errorf("unknown day %q, expected e.g. Sun, Mon-Fri or Sat,Sun", to)
+			}
+		}
+
+		// A range may wrap around the end of the week, e.g. Fri-Mon.
+		for d := first; ; d = (d + 1) % 7 {
+			w.days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+func parseClock(s string) (time.Duration, error) {
+	var hours, minutes int
+	if _, err := fmt.Sscanf(s, "%d:%d", &hours, &minutes); err != nil ||
+		hours < 0 || hours > 23 || minutes < 0 || minutes > 59 || len(s) > len("00:00") {
+		return 0, errors.Errorf("invalid time %q, expected HH:MM", s)
+	}
+
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute, nil
+}
+
+func (w Window) String() string {
+	return w.text
+}
+
+func (w Window) duration() time.Duration {
+	if w.end > w.start {
+		return w.end - w.start
+	}
+
+	return w.end + day - w.start
+}
+
+// Start returns the start of the window occurrence t falls into, false when t
+// is outside the window.
+func (w Window) Start(t time.Time) (time.Time, bool) {
+	// An occurrence started on the previous day may still last.
+	for _, daysBack := range []int{0, 1} {
+		d := t.AddDate(0, 0, -daysBack)
+		midnight := time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, t.Location())
+		if !w.days[midnight.Weekday()] {
+			continue
+		}
+
+		start := midnight.Add(w.start)
+		if !t.Before(start) && t.Before(start.Add(w.duration())) {
+			return start, true
+		}
+	}
+
+	return time.Time{}, false
+}
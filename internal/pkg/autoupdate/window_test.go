@@ -0,0 +1,61 @@
+package autoupdate_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gameap/gameapctl/internal/pkg/autoupdate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWindow_invalid(t *testing.T) {
+	for _, s := range []string{"", "Sun", "Sun 03:00", "Funday 03:00-05:00", "Sun 3am-5am", "24:00-01:00",
+		"03:00-03:00", "Sun 03:00-05:00 UTC"} {
+		_, err := autoupdate.ParseWindow(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestWindow_Start(t *testing.T) {
+	// 2026-10-04 is a Sunday.
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 10, day, hour, minute, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		window string
+		t      time.Time
+		want   time.Time
+		ok     bool
+	}{
+		{window: "Sun 03:00-05:00", t: at(4, 3, 0), want: at(4, 3, 0), ok: true},
+		{window: "Sun 03:00-05:00", t: at(4, 4, 59), want: at(4, 3, 0), ok: true},
+		{window: "Sun 03:00-05:00", t: at(4, 5, 0)},
+		{window: "Sun 03:00-05:00", t: at(5, 3, 30)},
+		{window: "Mon-Fri 02:00-04:00", t: at(7, 3, 0), want: at(7, 2, 0), ok: true},
+		{window: "Mon-Fri 02:00-04:00", t: at(10, 3, 0)},
+		{window: "Fri-Mon 02:00-04:00", t: at(5, 2, 0), want: at(5, 2, 0), ok: true},
+		{window: "sat,sun 23:00-01:00", t: at(4, 23, 30), want: at(4, 23, 0), ok: true},
+		{window: "sat,sun 23:00-01:00", t: at(5, 0, 30), want: at(4, 23, 0), ok: true},
+		{window: "sat,sun 23:00-01:00", t: at(6, 0, 30)},
+		{window: "03:00-05:00", t: at(8, 4, 0), want: at(8, 3, 0), ok: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.window+" "+test.t.Format(time.DateTime), func(t *testing.T) {
+			w, err := autoupdate.ParseWindow(test.window)
+			require.NoError(t, err)
+
+			got, ok := w.Start(test.t)
+			assert.Equal(t, test.ok, ok)
+			assert.True(t, test.want.Equal(got), "got %s", got)
+		})
+	}
+}
+
+func TestWindow_String(t *testing.T) {
+	w, err := autoupdate.ParseWindow("  Sun   03:00-05:00 ")
+	require.NoError(t, err)
+	assert.Equal(t, "Sun 03:00-05:00", w.String())
+}
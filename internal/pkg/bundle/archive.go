@@ -0,0 +1,171 @@
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// WriteManifest writes the manifest into the staging directory of a bundle.
+func WriteManifest(dir string, m Manifest) error {
+	m.Version = manifestVersion
+
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal manifest")
+	}
+
+	return errors.Wrap(
+		os.WriteFile(filepath.Join(dir, ManifestEntry), b, 0o600),
+		"failed to write manifest",
+	)
+}
+
+// Pack writes the staging directory into the bundle archive. The manifest
+// goes first, so a reader can tell a bundle early.
+func Pack(dir, archivePath string) (err error) {
+	f, err := os.OpenFile(archivePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create archive %s", archivePath)
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil && closeErr != nil {
+			err = errors.Wrap(closeErr, "failed to close archive")
+		}
+	}()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	if err := addFile(tw, dir, filepath.Join(dir, ManifestEntry)); err != nil {
+		return err
+	}
+
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s", p)
+		}
+
+		if !d.Type().IsRegular() || p == filepath.Join(dir, ManifestEntry) {
+			return nil
+		}
+
+		return addFile(tw, dir, p)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return errors.Wrap(err, "failed to finish tar")
+	}
+
+	return errors.Wrap(gz.Close(), "failed to finish gzip")
+}
+
+func addFile(tw *tar.Writer, dir, p string) error {
+	rel, err := filepath.Rel(dir, p)
+	if err != nil {
+		return errors.Wrapf(err, "failed to resolve %s", p)
+	}
+
+	info, err := os.Stat(p)
+	if err != nil {
+		return errors.Wrapf(err, "failed to stat %s", p)
+	}
+
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return errors.Wrapf(err, "failed to create header for %s", p)
+	}
+	header.Name = filepath.ToSlash(rel)
+	header.Format = tar.FormatPAX
+
+	if err := tw.WriteHeader(header); err != nil {
+		return errors.Wrapf(err, "failed to write %s header", header.Name)
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %s", p)
+	}
+	defer func() { _ = f.Close() }()
+
+	if _, err := io.CopyN(tw, f, header.Size); err != nil {
+		return errors.Wrapf(err, "failed to archive %s", p)
+	}
+
+	return nil
+}
+
+// extractArchive unpacks the archive into dir and returns its manifest. Only
+// regular files are extracted.
+func extractArchive(archivePath, dir string) (Manifest, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return Manifest{}, errors.Wrapf(err, "failed to open bundle %s", archivePath)
+	}
+	defer func() { _ = f.Close() }()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return Manifest{}, errors.Wrap(err, "failed to read bundle")
+	}
+	defer func() { _ = gz.Close() }()
+
+	tr := tar.NewReader(gz)
+
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Manifest{}, errors.Wrap(err, "failed to read bundle")
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		if err := extractFile(tr, header.Name, dir); err != nil {
+			return Manifest{}, err
+		}
+	}
+
+	return readManifest(filepath.Join(dir, ManifestEntry))
+}
+
+func extractFile(r io.Reader, entry, dir string) error {
+	name := path.Clean(entry)
+	if name == "." || path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+		return errors.Errorf("unsafe path %q in bundle", entry)
+	}
+
+	target := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+		return errors.Wrapf(err, "failed to create %s", filepath.Dir(target))
+	}
+
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create %s", target)
+	}
+
+	// The content is verified against the manifest checksums after extraction.
+	if _, err := io.Copy(f, r); err != nil { //nolint:gosec
+		_ = f.Close()
+
+		return errors.Wrapf(err, "failed to extract %s", target)
+	}
+
+	return errors.Wrapf(f.Close(), "failed to close %s", target)
+}
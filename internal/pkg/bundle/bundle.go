@@ -0,0 +1,270 @@
+// Package bundle reads and writes offline installation bundles. A bundle is a
+// tar.gz archive with the releases of the GameAP components in the CDN mirror
+// layout and the package archives gameapctl downloads itself, described by a
+// manifest.
+package bundle
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	osinfo "github.com/gameap/gameapctl/pkg/os_info"
+	"github.com/gameap/gameapctl/pkg/releasesource"
+	"github.com/gameap/gameapctl/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// Entries of the bundle archive.
+const (
+	ManifestEntry = "manifest.json"
+	// ReleasesPrefix holds <component>/releases.json and
+	// <component>/<tag>/<asset>, it is used as a release mirror.
+	ReleasesPrefix = "releases"
+	// FilesPrefix holds the package archives by the path of their URL.
+	FilesPrefix = "files"
+)
+
+// manifestVersion is increased on incompatible changes of the archive layout.
+const manifestVersion = 1
+
+// mirrorName is the release source name of an opened bundle.
+const mirrorName = "bundle"
+
+type Manifest struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	// OS is the distribution the bundle is created for with an optional
+	// version, e.g. "debian-12" or "windows".
+	OS       string    `json:"os"`
+	Arch     string    `json:"arch"`
+	Releases []Release `json:"releases"`
+	Files    []File    `json:"files"`
+}
+
+type Release struct {
+	Component releasesource.Component `json:"component"`
+	Tag       string                  `json:"tag"`
+	Asset     string                  `json:"asset"`
+	SHA256    string                  `json:"sha256"`
+}
+
+// Path returns the entry of the release asset in the archive.
+func (r Release) Path() string {
+	return path.Join(ReleasesPrefix, string(r.Component), r.Tag, r.Asset)
+}
+
+// File is a package archive downloaded from URL.
+type File struct {
+	Package string `json:"package"`
+	URL     string `json:"url"`
+	Path    string `json:"path"`
+	SHA256  string `json:"sha256"`
+}
+
+// FilePath returns the entry of a file downloaded from the URL. Files are
+// looked up by the URL path, so the same file from another mirror of a
+// package repository is found too.
+func FilePath(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid URL")
+	}
+
+	p := path.Clean("/" + u.Path)
+	if p == "/" {
+		return "", errors.Errorf("URL %s has no path", rawURL)
+	}
+
+	return path.Join(FilesPrefix, p), nil
+}
+
+// ParseOS splits an OS of the manifest into the distribution and version.
+func ParseOS(s string) (osinfo.Distribution, string, error) {
+	distribution, version, _ := strings.Cut(strings.ToLower(s), "-")
+	if distribution == "" {
+		return "", "", errors.Errorf("invalid OS %q, expected <distribution>[-<version>], e.g. debian-12", s)
+	}
+
+	return osinfo.Distribution(distribution), version, nil
+}
+
+// CheckTarget returns an error when the bundle is created for another system.
+func (m Manifest) CheckTarget(info osinfo.Info, arch string) error {
+	distribution, version, err := ParseOS(m.OS)
+	if err != nil {
+		return err
+	}
+
+	if distribution != info.Distribution ||
+		(version != "" && version != info.DistributionVersion) ||
+		m.Arch != arch {
+		return errors.Errorf(
+			"the bundle is created for %s/%s, this system is %s-%s/%s",
+			m.OS, m.Arch, info.Distribution, info.DistributionVersion, arch,
+		)
+	}
+
+	return nil
+}
+
+// Bundle is an opened bundle extracted into a temporary directory.
+type Bundle struct {
+	dir      string
+	manifest Manifest
+	files    map[string]File
+}
+
+// Open extracts the bundle archive and verifies the checksums of its files.
+func Open(archivePath string) (*Bundle, error) {
+	dir, err := os.MkdirTemp("", "gameapctl-bundle")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create temporary directory")
+	}
+
+	b, err := open(archivePath, dir)
+	if err != nil {
+		if removeErr := os.RemoveAll(dir); removeErr != nil {
+			log.Printf("Failed to remove temporary directory: %v\n", removeErr)
+		}
+
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func open(archivePath, dir string) (*Bundle, error) {
+	manifest, err := extractArchive(archivePath, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Bundle{dir: dir, manifest: manifest, files: make(map[string]File, len(manifest.Files))}
+
+	for _, r := range manifest.Releases {
+		if err := verifyFile(filepath.Join(dir, filepath.FromSlash(r.Path())), r.SHA256); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, f := range manifest.Files {
+		if err := verifyFile(filepath.Join(dir, filepath.FromSlash(f.Path)), f.SHA256); err != nil {
+			return nil, err
+		}
+
+		b.files[f.Path] = f
+	}
+
+	return b, nil
+}
+
+func (b *Bundle) Manifest() Manifest {
+	return b.manifest
+}
+
+// Mirror returns the release mirror serving the releases of the bundle.
+func (b *Bundle) Mirror() releasesource.Mirror {
+	return releasesource.Mirror{
+		Name: mirrorName,
+		URL:  utils.FileURL(filepath.Join(b.dir, ReleasesPrefix)),
+	}
+}
+
+// Use makes the releases and the package archives come only from the bundle:
+// it replaces the release sources and fails every download of a file that is
+// not in the bundle.
+func (b *Bundle) Use(ctx context.Context) (context.Context, error) {
+	if err := releasesource.UseOnly(b.Mirror()); err != nil {
+		return ctx, err
+	}
+
+	return utils.WithSourceResolver(ctx, b.Resolve), nil
+}
+
+// Resolve returns the local copy of a file downloaded from the source URL.
+// Local files are returned as they are.
+func (b *Bundle) Resolve(source string) (string, error) {
+	u, err := url.Parse(source)
+	if err != nil || u.Scheme == "" || u.Scheme == "file" || filepath.VolumeName(source) != "" {
+		return source, nil //nolint:nilerr
+	}
+
+	entry, err := FilePath(source)
+	if err != nil {
+		return "", err
+	}
+
+	if _, ok := b.files[entry]; !ok {
+		return "", errors.Errorf("%s is not in the offline bundle", u.Redacted())
+	}
+
+	resolved := utils.FileURL(filepath.Join(b.dir, filepath.FromSlash(entry)))
+	if u.RawQuery != "" {
+		resolved += "?" + u.RawQuery
+	}
+
+	return resolved, nil
+}
+
+// Close removes the extracted bundle.
+func (b *Bundle) Close() error {
+	return errors.Wrap(os.RemoveAll(b.dir), "failed to remove extracted bundle")
+}
+
+func readManifest(manifestPath string) (Manifest, error) {
+	var m Manifest
+
+	b, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return m, errors.Wrap(err, "failed to read manifest, the file is not a gameapctl bundle")
+	}
+
+	if err := json.Unmarshal(b, &m); err != nil {
+		return m, errors.Wrap(err, "failed to parse manifest")
+	}
+
+	if m.Version != manifestVersion {
+		return m, errors.Errorf("unsupported bundle version %d, expected %d", m.Version, manifestVersion)
+	}
+
+	return m, nil
+}
+
+// FileChecksum returns the hex SHA-256 of the file.
+func FileChecksum(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to open %s", p)
+	}
+	defer func() { _ = f.Close() }()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", errors.Wrapf(err, "failed to read %s", p)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func verifyFile(p, want string) error {
+	got, err := FileChecksum(p)
+	if err != nil {
+		return err
+	}
+
+	if got != want {
+		return errors.Errorf("checksum mismatch for %s in the bundle", filepath.Base(p))
+	}
+
+	return nil
+}
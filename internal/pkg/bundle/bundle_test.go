@@ -0,0 +1,184 @@
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	osinfo "github.com/gameap/gameapctl/pkg/os_info"
+	"github.com/gameap/gameapctl/pkg/releasefinder"
+	"github.com/gameap/gameapctl/pkg/releasesource"
+	"github.com/gameap/gameapctl/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testAsset      = "gameap-daemon-v4.1.0-linux-amd64.tar.gz"
+	testPackageURL = "https://packages.gameap.com/chroots/php/php8.1-fpm.service"
+)
+
+func stageBundle(t *testing.T) string {
+	t.Helper()
+
+	staging := t.TempDir()
+
+	release := Release{Component: releasesource.ComponentDaemon, Tag: "v4.1.0", Asset: testAsset}
+	writeTarGz(t, filepath.Join(staging, filepath.FromSlash(release.Path())), "gameap-daemon", "daemon-binary")
+	release.SHA256 = checksum(t, filepath.Join(staging, filepath.FromSlash(release.Path())))
+
+	writeFile(t,
+		filepath.Join(staging, ReleasesPrefix, string(release.Component), "releases.json"),
+		`[{"tag_name": "v4.1.0", "assets": [{"name": "`+testAsset+`", "digest": "sha256:`+release.SHA256+`"}]}]`,
+	)
+
+	entry, err := FilePath(testPackageURL)
+	require.NoError(t, err)
+	assert.Equal(t, "files/chroots/php/php8.1-fpm.service", entry)
+
+	writeFile(t, filepath.Join(staging, filepath.FromSlash(entry)), "[Service]")
+
+	require.NoError(t, WriteManifest(staging, Manifest{
+		OS:       "debian-12",
+		Arch:     "amd64",
+		Releases: []Release{release},
+		Files: []File{{
+			Package: "php",
+			URL:     testPackageURL,
+			Path:    entry,
+			SHA256:  checksum(t, filepath.Join(staging, filepath.FromSlash(entry))),
+		}},
+	}))
+
+	archivePath := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	require.NoError(t, Pack(staging, archivePath))
+
+	return archivePath
+}
+
+func writeFile(t *testing.T, p, content string) {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+	require.NoError(t, os.WriteFile(p, []byte(content), 0o600))
+}
+
+func writeTarGz(t *testing.T, p, name, content string) {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+
+	f, err := os.Create(p)
+	require.NoError(t, err)
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, tw.WriteHeader(&tar.Header{
+		Name: name, Mode: 0o600, Size: int64(len(content)), Typeflag: tar.TypeReg,
+	}))
+	_, err = tw.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	require.NoError(t, f.Close())
+}
+
+func checksum(t *testing.T, p string) string {
+	t.Helper()
+
+	sum, err := FileChecksum(p)
+	require.NoError(t, err)
+
+	return sum
+}
+
+func TestOpen(t *testing.T) {
+	b, err := Open(stageBundle(t))
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, b.Close()) })
+
+	assert.Equal(t, "debian-12", b.Manifest().OS)
+
+	// Another mirror of the package repository resolves to the same file.
+	resolved, err := b.Resolve("https://packages.gameap.ru/chroots/php/php8.1-fpm.service")
+	require.NoError(t, err)
+	assert.Equal(t,
+		utils.FileURL(filepath.Join(b.dir, "files", "chroots", "php", "php8.1-fpm.service")),
+		resolved,
+	)
+
+	_, err = b.Resolve("https://packages.sury.org/php/apt.gpg")
+	require.EqualError(t, err, "https://packages.sury.org/php/apt.gpg is not in the offline bundle")
+
+	local := filepath.Join(t.TempDir(), "archive.tar.gz")
+	resolved, err = b.Resolve(local)
+	require.NoError(t, err)
+	assert.Equal(t, local, resolved)
+}
+
+func TestBundle_Use(t *testing.T) {
+	b, err := Open(stageBundle(t))
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, b.Close()) })
+
+	ctx, err := b.Use(context.Background())
+	require.NoError(t, err)
+
+	release, err := releasesource.FindRelease(
+		ctx, releasesource.ComponentDaemon, "linux", "amd64", releasefinder.FindOptions{},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "v4.1.0", release.Tag)
+	assert.Equal(t, b.Manifest().Releases[0].SHA256, release.SHA256)
+
+	dst := t.TempDir()
+	require.NoError(t, releasesource.Download(ctx, release, dst))
+
+	content, err := os.ReadFile(filepath.Join(dst, "gameap-daemon"))
+	require.NoError(t, err)
+	assert.Equal(t, "daemon-binary", string(content))
+
+	dst = filepath.Join(t.TempDir(), "php8.1-fpm.service")
+	require.NoError(t, utils.DownloadFile(ctx, testPackageURL, dst))
+	require.Error(t, utils.DownloadFile(ctx, "https://packages.sury.org/php/apt.gpg", dst))
+}
+
+func TestOpen_ChecksumMismatch(t *testing.T) {
+	archivePath := stageBundle(t)
+
+	dir := t.TempDir()
+	manifest, err := extractArchive(archivePath, dir)
+	require.NoError(t, err)
+	writeFile(t, filepath.Join(dir, filepath.FromSlash(manifest.Files[0].Path)), "tampered")
+
+	tampered := filepath.Join(t.TempDir(), "tampered.tar.gz")
+	require.NoError(t, Pack(dir, tampered))
+
+	_, err = Open(tampered)
+	require.EqualError(t, err, "checksum mismatch for php8.1-fpm.service in the bundle")
+}
+
+func TestOpen_UnsafePath(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "evil.tar.gz")
+	writeTarGz(t, archivePath, "../evil", "evil")
+
+	_, err := Open(archivePath)
+	require.EqualError(t, err, `unsafe path "../evil" in bundle`)
+}
+
+func TestManifest_CheckTarget(t *testing.T) {
+	debian12 := osinfo.Info{Distribution: osinfo.DistributionDebian, DistributionVersion: "12"}
+
+	require.NoError(t, Manifest{OS: "debian-12", Arch: "amd64"}.CheckTarget(debian12, "amd64"))
+	require.NoError(t, Manifest{OS: "debian", Arch: "amd64"}.CheckTarget(debian12, "amd64"))
+
+	require.EqualError(t,
+		Manifest{OS: "debian-11", Arch: "amd64"}.CheckTarget(debian12, "amd64"),
+		"the bundle is created for debian-11/amd64, this system is debian-12/amd64",
+	)
+	require.Error(t, Manifest{OS: "debian-12", Arch: "arm64"}.CheckTarget(debian12, "amd64"))
+	require.Error(t, Manifest{OS: "windows", Arch: "amd64"}.CheckTarget(debian12, "amd64"))
+}
@@ -0,0 +1,222 @@
+package daemon
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gameap/gameapctl/internal/pkg/doctor"
+	daemonsvc "github.com/gameap/gameapctl/pkg/daemon"
+	"github.com/gameap/gameapctl/pkg/fixer"
+	"github.com/gameap/gameapctl/pkg/gameap"
+	"github.com/gameap/gameapctl/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+const (
+	tlsProbeTimeout = 5 * time.Second
+
+	// certExpiryWarning is how long before the expiry a certificate is reported.
+	certExpiryWarning = 30 * 24 * time.Hour
+)
+
+// Checks returns the diagnostics for the daemon installation at the given paths.
+func Checks(scope string, paths gameap.DaemonPaths) []doctor.Check {
+	cfg := &daemonConfig{path: paths.DaemonConfigFilePath}
+
+	return []doctor.Check{
+		{
+			ID:        "daemon.binary",
+			Component: doctor.ComponentDaemon,
+			Title:     "Daemon binary",
+			Hint:      "Install the daemon with 'gameapctl daemon install'.",
+			Run: func(_ context.Context) doctor.Outcome {
+				if !utils.IsFileExists(paths.DaemonFilePath) {
+					return doctor.Fail("gameap-daemon binary not found at %s", paths.DaemonFilePath)
+				}
+
+				return doctor.Pass("found at %s", paths.DaemonFilePath)
+			},
+		},
+		{
+			ID:        "daemon.config",
+			Component: doctor.ComponentDaemon,
+			Title:     "Daemon config",
+			Hint:      "Reinstall the daemon to register it in the panel again.",
+			Run:       cfg.check,
+		},
+		{
+			ID:        "daemon.process",
+			Component: doctor.ComponentDaemon,
+			Title:     "Daemon process",
+			Hint:      "Start the daemon with 'gameapctl daemon start'.",
+			Run:       checkProcess,
+			Fix: &fixer.Item{
+				Name:      "Start daemon",
+				Condition: func(_ context.Context) (bool, error) { return true, nil },
+				FixFunc: func(ctx context.Context) error {
+					return daemonsvc.Start(ctx, daemonsvc.Options{Scope: scope})
+				},
+			},
+		},
+		{
+			ID:        "daemon.certificates",
+			Component: doctor.ComponentDaemon,
+			Title:     "Daemon certificates",
+			Hint:      "Reinstall the daemon to issue new certificates.",
+			Run:       cfg.checkCertificates,
+		},
+		{
+			ID:        "daemon.grpc",
+			Component: doctor.ComponentDaemon,
+			Title:     "Panel gRPC connectivity",
+			Hint:      "Make sure the panel gRPC port is reachable from this host and grpc.address is correct.",
+			Run:       cfg.checkGRPC,
+		},
+	}
+}
+
+type daemonConfig struct {
+	path   string
+	file   *ConfigFile
+	err    error
+	loaded bool
+}
+
+func (c *daemonConfig) load() (*ConfigFile, error) {
+	if !c.loaded {
+		c.file, c.err = LoadConfig(c.path)
+		c.loaded = true
+	}
+
+	return c.file, c.err
+}
+
+func (c *daemonConfig) check(_ context.Context) doctor.Outcome {
+	cfg, err := c.load()
+	if err != nil {
+		return doctor.Fail("%s", err.Error())
+	}
+
+	if apiKey, ok, _ := cfg.ReadString("$.api_key"); !ok || apiKey == "" {
+		return doctor.Fail("api_key is missing, the daemon is not registered in the panel")
+	}
+	if nodeID, ok, _ := cfg.ReadUint("$.ds_id"); !ok || nodeID == 0 {
+		return doctor.Fail("ds_id is missing, the daemon is not registered in the panel")
+	}
+
+	return doctor.Pass("%s", c.path)
+}
+
+func checkProcess(ctx context.Context) doctor.Outcome {
+	pr, err := daemonsvc.FindProcess(ctx)
+	if err != nil {
+		return doctor.Fail("failed to find daemon process: %s", err.Error())
+	}
+	if pr == nil {
+		return doctor.Fail("gameap-daemon process is not running")
+	}
+
+	return doctor.Pass("running (pid %d)", pr.Pid)
+}
+
+func (c *daemonConfig) checkCertificates(_ context.Context) doctor.Outcome {
+	cfg, err := c.load()
+	if err != nil {
+		return doctor.Skip("config file is not readable")
+	}
+
+	cfgDir := filepath.Dir(c.path)
+
+	for _, key := range []string{"ca_certificate_file", "certificate_chain_file", "private_key_file"} {
+		path, _, _ := cfg.ReadString("$." + key)
+		if path == "" {
+			return doctor.Fail("%s is not set", key)
+		}
+		if !utils.IsFileExists(resolveConfigPath(cfgDir, path)) {
+			return doctor.Fail("%s not found at %s", key, resolveConfigPath(cfgDir, path))
+		}
+	}
+
+	chainPath, _, _ := cfg.ReadString("$.certificate_chain_file")
+
+	leaf, err := readCertificate(resolveConfigPath(cfgDir, chainPath))
+	if err != nil {
+		return doctor.Fail("%s", err.Error())
+	}
+
+	return certificateOutcome("daemon certificate", leaf)
+}
+
+func (c *daemonConfig) checkGRPC(ctx context.Context) doctor.Outcome {
+	cfg, err := c.load()
+	if err != nil {
+		return doctor.Skip("config file is not readable")
+	}
+
+	if enabled, _, _ := cfg.ReadString("$.grpc.enabled"); enabled != "true" {
+		return doctor.Skip("gRPC is disabled, the daemon uses the legacy API")
+	}
+
+	addr, _, _ := cfg.ReadString("$.grpc.address")
+	if addr == "" {
+		return doctor.Fail("grpc.address is not set")
+	}
+
+	if err = CheckGRPCConnectivity(addr); err != nil {
+		return doctor.Fail("%s", err.Error())
+	}
+
+	probe, err := ProbeTLSLeaf(ctx, addr, tlsProbeTimeout)
+	if err != nil {
+		return doctor.Fail("%s", err.Error())
+	}
+	if probe.Leaf == nil {
+		return doctor.Pass("%s is reachable", addr)
+	}
+
+	return certificateOutcome("panel certificate at "+addr, probe.Leaf)
+}
+
+func certificateOutcome(name string, cert *x509.Certificate) doctor.Outcome {
+	left := time.Until(cert.NotAfter)
+
+	switch {
+	case left <= 0:
+		return doctor.Fail("%s expired on %s", name, cert.NotAfter.Format(time.DateOnly))
+	case left < certExpiryWarning:
+		return doctor.Warn("%s expires on %s", name, cert.NotAfter.Format(time.DateOnly))
+	default:
+		return doctor.Pass("%s is valid until %s", name, cert.NotAfter.Format(time.DateOnly))
+	}
+}
+
+func readCertificate(path string) (*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read certificate %s", path)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.Errorf("no PEM data found in %s", path)
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse certificate %s", path)
+	}
+
+	return cert, nil
+}
+
+func resolveConfigPath(cfgDir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(cfgDir, path)
+}
@@ -112,33 +112,114 @@ func (c *ConfigFile) EnsureGRPCEnabled(address string) error {
 }
 
 func (c *ConfigFile) setUnderGRPC(key, scalarSrc string) error {
-	keyPath, err := yaml.PathString("$.grpc." + key)
+	return c.SetValue("$.grpc."+key, scalarSrc)
+}
+
+// Get returns the value at the given YAMLPath decoded into a Go value, reflecting
+// the edits that are not saved yet. Returns (nil, false, nil) if path is not found.
+func (c *ConfigFile) Get(yamlPath string) (any, bool, error) {
+	p, err := yaml.PathString(yamlPath)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "invalid yaml path %q", yamlPath)
+	}
+
+	node, err := p.FilterFile(c.ast)
+	if err != nil {
+		if errors.Is(err, yaml.ErrNotFoundNode) {
+			return nil, false, nil
+		}
+
+		return nil, false, errors.Wrapf(err, "failed to read yaml path %q", yamlPath)
+	}
+
+	var v any
+	if err := yaml.NodeToValue(node, &v); err != nil {
+		return nil, false, errors.Wrapf(err, "failed to decode yaml path %q", yamlPath)
+	}
+
+	return v, true, nil
+}
+
+// SetValue replaces the value at the given YAMLPath with the single-line YAML
+// source, keeping the comments around it. A missing key is created together with
+// its missing parents, which requires a path of the form "$.a.b".
+func (c *ConfigFile) SetValue(yamlPath, src string) error {
+	p, err := yaml.PathString(yamlPath)
 	if err != nil {
-		return errors.Wrapf(err, "failed to build yaml path $.grpc.%s", key)
+		return errors.Wrapf(err, "invalid yaml path %q", yamlPath)
 	}
 
-	if _, filterErr := keyPath.FilterFile(c.ast); filterErr == nil {
-		if err := keyPath.ReplaceWithReader(c.ast, strings.NewReader(scalarSrc)); err != nil {
-			return errors.Wrapf(err, "failed to replace $.grpc.%s", key)
+	if node, filterErr := p.FilterFile(c.ast); filterErr == nil {
+		// The inline comment belongs to the replaced value node.
+		comment := node.GetComment()
+
+		if err := p.ReplaceWithReader(c.ast, strings.NewReader(src)); err != nil {
+			return errors.Wrapf(err, "failed to replace %s", yamlPath)
+		}
+
+		if replaced, err := p.FilterFile(c.ast); err == nil && comment != nil {
+			_ = replaced.SetComment(comment)
 		}
 
 		return nil
 	} else if !errors.Is(filterErr, yaml.ErrNotFoundNode) {
-		return errors.Wrapf(filterErr, "failed to probe $.grpc.%s", key)
+		return errors.Wrapf(filterErr, "failed to probe %s", yamlPath)
 	}
 
-	grpcPath, err := yaml.PathString("$.grpc")
+	keys, err := splitKeyPath(yamlPath)
 	if err != nil {
-		return errors.Wrap(err, "failed to build yaml path $.grpc")
+		return err
+	}
+
+	parentPath, missing := "$", keys
+	for i := len(keys) - 1; i > 0; i-- {
+		candidate := "$." + strings.Join(keys[:i], ".")
+
+		pp, err := yaml.PathString(candidate)
+		if err != nil {
+			return errors.Wrapf(err, "failed to build yaml path %s", candidate)
+		}
+
+		if _, err := pp.FilterFile(c.ast); err == nil {
+			parentPath, missing = candidate, keys[i:]
+
+			break
+		}
+	}
+
+	var b strings.Builder
+	for i, key := range missing {
+		b.WriteString(strings.Repeat("  ", i))
+		if i == len(missing)-1 {
+			fmt.Fprintf(&b, "%s: %s\n", key, src)
+		} else {
+			fmt.Fprintf(&b, "%s:\n", key)
+		}
+	}
+
+	pp, err := yaml.PathString(parentPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to build yaml path %s", parentPath)
 	}
-	snippet := fmt.Sprintf("%s: %s\n", key, scalarSrc)
-	if err := grpcPath.MergeFromReader(c.ast, strings.NewReader(snippet)); err != nil {
-		return errors.Wrapf(err, "failed to merge %s into $.grpc", key)
+	if err := pp.MergeFromReader(c.ast, strings.NewReader(b.String())); err != nil {
+		return errors.Wrapf(err, "failed to merge %s into %s", strings.Join(missing, "."), parentPath)
 	}
 
 	return nil
 }
 
+func splitKeyPath(yamlPath string) ([]string, error) {
+	keys := strings.Split(strings.TrimPrefix(yamlPath, "$."), ".")
+
+	for _, key := range keys {
+		if key == "" || strings.ContainsAny(key, "[]*'\" ") || !strings.HasPrefix(yamlPath, "$.") {
+			return nil, errors.Errorf("cannot create %q, only paths of the form $.key.subkey can be created", yamlPath)
+		}
+	}
+
+	return keys, nil
+}
+
 func (c *ConfigFile) appendGRPCBlock(address string) error {
 	var b strings.Builder
 	b.WriteString("grpc:\n  enabled: true\n")
@@ -194,15 +275,22 @@ func (c *ConfigFile) DeleteKey(yamlPath string) error {
 	return nil
 }
 
-func (c *ConfigFile) Save() error {
+// Bytes returns the document with the edits that are not saved yet.
+func (c *ConfigFile) Bytes() []byte {
 	out := c.ast.String()
 	if !strings.HasSuffix(out, "\n") {
 		out += "\n"
 	}
-	if err := os.WriteFile(c.path, []byte(out), configFileMode); err != nil {
+
+	return []byte(out)
+}
+
+func (c *ConfigFile) Save() error {
+	out := c.Bytes()
+	if err := os.WriteFile(c.path, out, configFileMode); err != nil {
 		return errors.Wrapf(err, "failed to write daemon config %s", c.path)
 	}
-	c.data = []byte(out)
+	c.data = out
 
 	return nil
 }
@@ -254,3 +254,64 @@ func TestLoadConfig_MissingFile(t *testing.T) {
 	_, err := LoadConfig(filepath.Join(t.TempDir(), "nope.yaml"))
 	require.Error(t, err)
 }
+
+func TestConfigFile_SetValue_ReplacesKeepingComments(t *testing.T) {
+	p := writeTempConfig(t, `# daemon config
+listen_port: 31717 # port for the panel
+work_path: /srv/gameap
+`)
+	cfg, err := LoadConfig(p)
+	require.NoError(t, err)
+
+	require.NoError(t, cfg.SetValue("$.listen_port", "31718"))
+	require.NoError(t, cfg.Save())
+
+	out, err := os.ReadFile(p)
+	require.NoError(t, err)
+	assert.Equal(t, `# daemon config
+listen_port: 31718 # port for the panel
+work_path: /srv/gameap
+`, string(out))
+}
+
+func TestConfigFile_SetValue_CreatesMissingKeys(t *testing.T) {
+	p := writeTempConfig(t, `work_path: /srv/gameap
+process_manager:
+  name: tmux
+`)
+	cfg, err := LoadConfig(p)
+	require.NoError(t, err)
+
+	require.NoError(t, cfg.SetValue("$.process_manager.config.scope", "user"))
+	require.NoError(t, cfg.SetValue("$.steam_config.login", "anonymous"))
+	require.NoError(t, cfg.SetValue("$.if_list", "[eth0, eth1]"))
+
+	value, ok, err := cfg.Get("$.process_manager")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, map[string]any{"name": "tmux", "config": map[string]any{"scope": "user"}}, value)
+
+	value, ok, err = cfg.Get("$.steam_config.login")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "anonymous", value)
+
+	value, ok, err = cfg.Get("$.if_list")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []any{"eth0", "eth1"}, value)
+
+	_, ok, err = cfg.Get("$.not_here")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestConfigFile_SetValue_RejectsComplexPathForNewKey(t *testing.T) {
+	p := writeTempConfig(t, "if_list: [eth0]\n")
+	cfg, err := LoadConfig(p)
+	require.NoError(t, err)
+
+	err = cfg.SetValue("$.drives_list[0]", "/")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "only paths of the form")
+}
@@ -6,6 +6,7 @@ import (
 	"log"
 	"strings"
 
+	"github.com/gameap/gameapctl/pkg/dryrun"
 	"github.com/gameap/gameapctl/pkg/releasesource"
 	"github.com/goccy/go-yaml"
 	"github.com/goccy/go-yaml/ast"
@@ -60,6 +61,12 @@ func SetupCDNReplacements(ctx context.Context, configPath string) {
 		return
 	}
 
+	// The daemon config is not written in a dry run, so there is nothing to load yet.
+	if len(planCDNReplacements(availability)) > 0 &&
+		dryrun.Record(ctx, dryrun.KindFile, "add %s entries to %s", remoteRepositoryReplacementsKey, configPath) {
+		return
+	}
+
 	if err := EnsureRepositoryReplacements(configPath, availability); err != nil {
 		log.Printf("Warning: failed to setup remote repository replacements in %s: %v\n", configPath, err)
 	}
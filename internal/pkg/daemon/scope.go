@@ -0,0 +1,29 @@
+package daemon
+
+import (
+	"context"
+
+	"github.com/gameap/gameapctl/internal/pkg/gameapctl"
+	"github.com/gameap/gameapctl/pkg/gameap"
+	"github.com/gameap/gameapctl/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// ResolvePaths returns the scope and paths of the daemon installation recorded in
+// the install state. A missing state file means a system scope installation.
+func ResolvePaths(ctx context.Context) (string, gameap.DaemonPaths, error) {
+	state, _ := gameapctl.LoadDaemonInstallState(ctx)
+	scope := gameap.ScopeOrDefault(state.Scope)
+
+	paths, err := gameap.DaemonPathsForScope(scope)
+	if err != nil {
+		return scope, paths, errors.WithMessage(err, "failed to resolve daemon paths")
+	}
+
+	return scope, paths, nil
+}
+
+// IsInstalled reports whether there is a daemon installation to diagnose at the given paths.
+func IsInstalled(paths gameap.DaemonPaths) bool {
+	return utils.IsFileExists(paths.DaemonFilePath) || utils.IsFileExists(paths.DaemonConfigFilePath)
+}
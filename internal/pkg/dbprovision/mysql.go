@@ -0,0 +1,242 @@
+package dbprovision
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// mysqlProvisioner holds what MySQL and MariaDB share.
+type mysqlProvisioner struct {
+	q Querier
+}
+
+// MySQLProvisioner creates the user for any host, MySQL 8 with the
+// mysql_native_password plugin supported by the panel, the other versions for
+// localhost too.
+type MySQLProvisioner struct {
+	*mysqlProvisioner
+}
+
+// MariaDBProvisioner creates the user for any host and for localhost: the
+// anonymous localhost account of a fresh MariaDB would shadow the '%' one.
+type MariaDBProvisioner struct {
+	*mysqlProvisioner
+}
+
+func (p *mysqlProvisioner) Version(ctx context.Context) (string, error) {
+	return queryString(ctx, p.q, "SELECT VERSION()")
+}
+
+func (p *mysqlProvisioner) DatabaseExists(ctx context.Context, database string) (bool, error) {
+	return queryBool(ctx, p.q,
+		"SELECT EXISTS(SELECT 1 FROM INFORMATION_SCHEMA.SCHEMATA WHERE SCHEMA_NAME = ?)", database,
+	)
+}
+
+func (p *mysqlProvisioner) CreateDatabase(ctx context.Context, database string) error {
+	if err := ValidateIdentifier(database); err != nil {
+		return err
+	}
+
+	_, err := p.q.ExecContext(ctx, "CREATE DATABASE IF NOT EXISTS "+mysqlIdent(database))
+
+	return errors.Wrap(err, "failed to create database")
+}
+
+func (p *mysqlProvisioner) UserExists(ctx context.Context, username string) (bool, error) {
+	return queryBool(ctx, p.q, "SELECT EXISTS(SELECT 1 FROM mysql.user WHERE user = ?)", username)
+}
+
+// AlterUserPassword changes the password of the user for every host it exists for.
+func (p *mysqlProvisioner) AlterUserPassword(ctx context.Context, username, password string) error {
+	accounts, err := p.accounts(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	for _, account := range accounts {
+		_, err := p.q.ExecContext(ctx, "ALTER USER "+account+" IDENTIFIED BY "+mysqlString(password))
+		if err != nil {
+			// MySQL before 5.7.6 and MariaDB before 10.2 have no ALTER USER.
+			_, err = p.q.ExecContext(ctx, "SET PASSWORD FOR "+account+" = PASSWORD("+mysqlString(password)+")")
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to change the password of %s", account)
+		}
+	}
+
+	return p.flush(ctx)
+}
+
+func (p *mysqlProvisioner) Grant(ctx context.Context, username, database string) error {
+	if err := validateIdentifiers(username, database); err != nil {
+		return err
+	}
+
+	accounts, err := p.accounts(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	for _, account := range accounts {
+		if _, err := p.q.ExecContext(ctx, "GRANT SELECT ON *.* TO "+account); err != nil {
+			return errors.Wrap(err, "failed to grant select privileges")
+		}
+
+		_, err := p.q.ExecContext(ctx, "GRANT ALL PRIVILEGES ON "+mysqlIdent(database)+".* TO "+account)
+		if err != nil {
+			return errors.Wrap(err, "failed to grant all privileges")
+		}
+	}
+
+	return p.flush(ctx)
+}
+
+func (p *mysqlProvisioner) IsDatabaseEmpty(ctx context.Context, database string) (bool, error) {
+	if err := ValidateIdentifier(database); err != nil {
+		return false, err
+	}
+
+	tableExists, err := queryBool(ctx, p.q, `SELECT EXISTS (
+		SELECT 1
+		FROM INFORMATION_SCHEMA.TABLES
+		WHERE TABLE_SCHEMA = ?
+		AND TABLE_NAME = ?
+	)`, database, "games")
+	if err != nil || !tableExists {
+		return true, err
+	}
+
+	recordsExist, err := queryBool(ctx, p.q,
+		"SELECT EXISTS(SELECT 1 FROM "+mysqlIdent(database)+".`games` LIMIT 1)",
+	)
+
+	return !recordsExist, err
+}
+
+// accounts returns the quoted 'user'@'host' accounts of the user.
+func (p *mysqlProvisioner) accounts(ctx context.Context, username string) ([]string, error) {
+	if err := ValidateIdentifier(username); err != nil {
+		return nil, err
+	}
+
+	rows, err := p.q.QueryContext(ctx, "SELECT host FROM mysql.user WHERE user = ?", username)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query accounts")
+	}
+	defer func() { _ = rows.Close() }()
+
+	var accounts []string
+
+	for rows.Next() {
+		var host string
+		if err := rows.Scan(&host); err != nil {
+			return nil, errors.Wrap(err, "failed to scan accounts")
+		}
+
+		accounts = append(accounts, mysqlAccount(username, host))
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to query accounts")
+	}
+
+	if len(accounts) == 0 {
+		return nil, errors.Errorf("user %s not found", username)
+	}
+
+	return accounts, nil
+}
+
+func (p *mysqlProvisioner) flush(ctx context.Context) error {
+	_, err := p.q.ExecContext(ctx, "FLUSH PRIVILEGES")
+
+	return errors.Wrap(err, "failed to flush privileges")
+}
+
+func (p *MySQLProvisioner) CreateUser(ctx context.Context, username, password string) error {
+	if err := ValidateIdentifier(username); err != nil {
+		return err
+	}
+
+	version, err := p.Version(ctx)
+	if err != nil {
+		return errors.WithMessage(err, "failed to get mysql version")
+	}
+
+	for _, query := range mysqlCreateUserQueries(version, username, password) {
+		if _, err := p.q.ExecContext(ctx, query); err != nil {
+			return errors.Wrap(err, "failed to create user")
+		}
+	}
+
+	return nil
+}
+
+// mysqlCreateUserQueries creates the user for MySQL 8 with the
+// mysql_native_password plugin, which MySQL 9 no longer has. The other
+// versions get the default plugin and a localhost account too: the anonymous
+// localhost account of MySQL before 8 would shadow the '%' one.
+func mysqlCreateUserQueries(version, username, password string) []string {
+	if major, _ := majorMinor(version); major == 8 { //nolint:mnd
+		return []string{
+			"CREATE USER " + mysqlAccount(username, "%") +
+				" IDENTIFIED WITH mysql_native_password BY " + mysqlString(password),
+		}
+	}
+
+	queries := make([]string, 0, 2) //nolint:mnd
+	for _, host := range []string{"%", "localhost"} {
+		queries = append(queries, "CREATE USER "+mysqlAccount(username, host)+" IDENTIFIED BY "+mysqlString(password))
+	}
+
+	return queries
+}
+
+func (p *MariaDBProvisioner) CreateUser(ctx context.Context, username, password string) error {
+	if err := ValidateIdentifier(username); err != nil {
+		return err
+	}
+
+	for _, host := range []string{"%", "localhost"} {
+		_, err := p.q.ExecContext(ctx,
+			"CREATE USER "+mysqlAccount(username, host)+" IDENTIFIED BY "+mysqlString(password),
+		)
+		if err != nil {
+			return errors.Wrap(err, "failed to create user")
+		}
+	}
+
+	return nil
+}
+
+func mysqlIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func mysqlAccount(username, host string) string {
+	return mysqlString(username) + "@" + mysqlString(host)
+}
+
+// mysqlString quotes a string literal for the default sql_mode, where the
+// backslash is an escape character.
+func mysqlString(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `''`, "\x00", `\0`).Replace(s) + "'"
+}
+
+// majorMinor parses versions such as "8.0.36" and "10.11.6-MariaDB-0+deb12u1".
+func majorMinor(version string) (int, int) {
+	parts := strings.SplitN(version, ".", 3) //nolint:mnd
+
+	major, _ := strconv.Atoi(parts[0])
+
+	minor := 0
+	if len(parts) > 1 {
+		minor, _ = strconv.Atoi(parts[1])
+	}
+
+	return major, minor
+}
@@ -0,0 +1,119 @@
+package dbprovision
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// PostgresProvisioner works on the database of its connection, IsDatabaseEmpty
+// looks at the public schema of that database.
+type PostgresProvisioner struct {
+	q Querier
+}
+
+func (p *PostgresProvisioner) Version(ctx context.Context) (string, error) {
+	return queryString(ctx, p.q, "SHOW server_version")
+}
+
+func (p *PostgresProvisioner) DatabaseExists(ctx context.Context, database string) (bool, error) {
+	return queryBool(ctx, p.q, "SELECT EXISTS(SELECT 1 FROM pg_database WHERE datname = $1)", database)
+}
+
+func (p *PostgresProvisioner) CreateDatabase(ctx context.Context, database string) error {
+	if err := ValidateIdentifier(database); err != nil {
+		return err
+	}
+
+	_, err := p.q.ExecContext(ctx, "CREATE DATABASE "+postgresIdent(database))
+
+	return errors.Wrap(err, "failed to create database")
+}
+
+func (p *PostgresProvisioner) UserExists(ctx context.Context, username string) (bool, error) {
+	return queryBool(ctx, p.q, "SELECT EXISTS(SELECT 1 FROM pg_roles WHERE rolname = $1)", username)
+}
+
+func (p *PostgresProvisioner) CreateUser(ctx context.Context, username, password string) error {
+	if err := ValidateIdentifier(username); err != nil {
+		return err
+	}
+
+	_, err := p.q.ExecContext(ctx,
+		"CREATE ROLE "+postgresIdent(username)+" LOGIN PASSWORD "+postgresString(password),
+	)
+
+	return errors.Wrap(err, "failed to create user")
+}
+
+// AlterUserPassword needs no superuser when the user changes its own password.
+func (p *PostgresProvisioner) AlterUserPassword(ctx context.Context, username, password string) error {
+	query, err := PostgresAlterPasswordQuery(username, password)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.q.ExecContext(ctx, query)
+
+	return errors.Wrapf(err, "failed to change the password of %s", username)
+}
+
+// PostgresAlterPasswordQuery returns the statement changing the password of the
+// role, for the servers reachable only through psql, such as a fresh install
+// accepting the postgres superuser over peer authentication alone.
+func PostgresAlterPasswordQuery(username, password string) (string, error) {
+	if err := ValidateIdentifier(username); err != nil {
+		return "", err
+	}
+
+	return "ALTER ROLE " + postgresIdent(username) + " PASSWORD " + postgresString(password), nil
+}
+
+// Grant makes the user the owner of the database: since PostgreSQL 15 the
+// privileges on the database no longer allow to create tables in its public
+// schema.
+func (p *PostgresProvisioner) Grant(ctx context.Context, username, database string) error {
+	if err := validateIdentifiers(username, database); err != nil {
+		return err
+	}
+
+	_, err := p.q.ExecContext(ctx,
+		"GRANT ALL PRIVILEGES ON DATABASE "+postgresIdent(database)+" TO "+postgresIdent(username),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to grant privileges")
+	}
+
+	_, err = p.q.ExecContext(ctx,
+		"ALTER DATABASE "+postgresIdent(database)+" OWNER TO "+postgresIdent(username),
+	)
+
+	return errors.Wrap(err, "failed to change the database owner")
+}
+
+func (p *PostgresProvisioner) IsDatabaseEmpty(ctx context.Context, _ string) (bool, error) {
+	tableExists, err := queryBool(ctx, p.q, `SELECT EXISTS (
+		SELECT 1
+		FROM information_schema.tables
+		WHERE table_schema = 'public'
+		AND table_name = $1
+	)`, "games")
+	if err != nil || !tableExists {
+		return true, err
+	}
+
+	recordsExist, err := queryBool(ctx, p.q, "SELECT EXISTS(SELECT 1 FROM public.games LIMIT 1)")
+
+	return !recordsExist, err
+}
+
+func postgresIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// postgresString quotes a string literal, standard_conforming_strings is on
+// since PostgreSQL 9.1 so only the quote is escaped.
+func postgresString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,125 @@
+// Package dbprovision creates the panel database and its user on MySQL,
+// MariaDB, PostgreSQL and SQLite. Names are validated and quoted, values are
+// passed as query parameters wherever the server accepts them. Account
+// statements (CREATE USER, ALTER USER, GRANT) take no parameters, so the
+// passwords are escaped as string literals there.
+package dbprovision
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	MySQL    = "mysql"
+	Postgres = "postgres"
+	SQLite   = "sqlite"
+)
+
+// maxIdentifierLen is the shortest limit of the supported servers, PostgreSQL
+// truncates longer names.
+const maxIdentifierLen = 63
+
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$-]*$`)
+
+// Provisioner manages the database and the user of the panel on one server.
+type Provisioner interface {
+	// Version returns the server version.
+	Version(ctx context.Context) (string, error)
+	DatabaseExists(ctx context.Context, database string) (bool, error)
+	CreateDatabase(ctx context.Context, database string) error
+	UserExists(ctx context.Context, username string) (bool, error)
+	CreateUser(ctx context.Context, username, password string) error
+	AlterUserPassword(ctx context.Context, username, password string) error
+	// Grant gives the user all privileges on the database.
+	Grant(ctx context.Context, username, database string) error
+	// IsDatabaseEmpty reports whether the panel has no data in the database yet.
+	IsDatabaseEmpty(ctx context.Context, database string) (bool, error)
+}
+
+// Querier is implemented by *sql.DB and *sql.Conn.
+type Querier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// New returns the provisioner for the server behind q. For MySQL the server is
+// asked for its version to tell MariaDB apart.
+func New(ctx context.Context, driver string, q Querier) (Provisioner, error) {
+	switch driver {
+	case MySQL:
+		p := &mysqlProvisioner{q: q}
+
+		version, err := p.Version(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if strings.Contains(strings.ToLower(version), "mariadb") {
+			return &MariaDBProvisioner{mysqlProvisioner: p}, nil
+		}
+
+		return &MySQLProvisioner{mysqlProvisioner: p}, nil
+	case Postgres:
+		return &PostgresProvisioner{q: q}, nil
+	case SQLite:
+		return &SQLiteProvisioner{q: q}, nil
+	default:
+		return nil, errors.Errorf("unsupported database driver: %s", driver)
+	}
+}
+
+// ValidateIdentifier accepts the database and user names that are safe on all
+// the supported servers.
+func ValidateIdentifier(name string) error {
+	if name == "" {
+		return errors.New("name is empty")
+	}
+
+	if len(name) > maxIdentifierLen {
+		return errors.Errorf("name %q is longer than %d characters", name, maxIdentifierLen)
+	}
+
+	if !identifierPattern.MatchString(name) {
+		return errors.Errorf(
+			"invalid name %q, use letters, digits, '_', '$' and '-', starting with a letter or '_'", name,
+		)
+	}
+
+	return nil
+}
+
+func validateIdentifiers(names ...string) error {
+	for _, name := range names {
+		if err := ValidateIdentifier(name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func queryBool(ctx context.Context, q Querier, query string, args ...any) (bool, error) {
+	var result bool
+
+	if err := q.QueryRowContext(ctx, query, args...).Scan(&result); err != nil {
+		return false, errors.Wrap(err, "failed to execute query")
+	}
+
+	return result, nil
+}
+
+func queryString(ctx context.Context, q Querier, query string, args ...any) (string, error) {
+	var result string
+
+	if err := q.QueryRowContext(ctx, query, args...).Scan(&result); err != nil {
+		return "", errors.Wrap(err, "failed to execute query")
+	}
+
+	return result, nil
+}
@@ -0,0 +1,117 @@
+package dbprovision
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func TestValidateIdentifier(t *testing.T) {
+	for _, name := range []string{"gameap", "gameap_db", "_gameap", "game-ap", "user$1"} {
+		require.NoError(t, ValidateIdentifier(name), name)
+	}
+
+	for _, name := range []string{
+		"", "1gameap", "game ap", "gameap'; DROP DATABASE mysql; --", "game`ap", `game"ap`, "gameap@localhost",
+		"a123456789012345678901234567890123456789012345678901234567890123",
+	} {
+		require.Error(t, ValidateIdentifier(name), name)
+	}
+}
+
+func TestQuote(t *testing.T) {
+	assert.Equal(t, "`game``ap`", mysqlIdent("game`ap"))
+	assert.Equal(t, `'gameap'@'%'`, mysqlAccount("gameap", "%"))
+	assert.Equal(t, `'it''s a \\ test\0'`, mysqlString("it's a \\ test\x00"))
+	assert.Equal(t, `"game""ap"`, postgresIdent(`game"ap`))
+	assert.Equal(t, `'it''s a \ test'`, postgresString(`it's a \ test`))
+}
+
+func TestPostgresAlterPasswordQuery(t *testing.T) {
+	query, err := PostgresAlterPasswordQuery("postgres", `it's "secret"`)
+	require.NoError(t, err)
+	assert.Equal(t, `ALTER ROLE "postgres" PASSWORD 'it''s "secret"'`, query)
+
+	_, err = PostgresAlterPasswordQuery("postgres; DROP ROLE gameap", "secret")
+	require.Error(t, err)
+}
+
+func TestMajorMinor(t *testing.T) {
+	for version, expected := range map[string][2]int{
+		"8.0.36":                    {8, 0},
+		"10.11.6-MariaDB-0+deb12u1": {10, 11},
+		"5.7":                       {5, 7},
+		"":                          {0, 0},
+	} {
+		major, minor := majorMinor(version)
+		assert.Equal(t, expected, [2]int{major, minor}, version)
+	}
+}
+
+func TestMySQLCreateUserQueries(t *testing.T) {
+	assert.Equal(t, []string{
+		`CREATE USER 'gameap'@'%' IDENTIFIED WITH mysql_native_password BY 'secret'`,
+	}, mysqlCreateUserQueries("8.0.36", "gameap", "secret"))
+
+	for _, version := range []string{"5.7.44", "9.1.0"} {
+		assert.Equal(t, []string{
+			`CREATE USER 'gameap'@'%' IDENTIFIED BY 'secret'`,
+			`CREATE USER 'gameap'@'localhost' IDENTIFIED BY 'secret'`,
+		}, mysqlCreateUserQueries(version, "gameap", "secret"), version)
+	}
+}
+
+func TestSQLiteProvisioner(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "database.sqlite"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	p, err := New(ctx, SQLite, db)
+	require.NoError(t, err)
+
+	version, err := p.Version(ctx)
+	require.NoError(t, err)
+	assert.NotEmpty(t, version)
+
+	empty, err := p.IsDatabaseEmpty(ctx, "gameap")
+	require.NoError(t, err)
+	assert.True(t, empty)
+
+	_, err = db.ExecContext(ctx, "CREATE TABLE games (code TEXT PRIMARY KEY)")
+	require.NoError(t, err)
+
+	empty, err = p.IsDatabaseEmpty(ctx, "gameap")
+	require.NoError(t, err)
+	assert.True(t, empty)
+
+	_, err = db.ExecContext(ctx, "INSERT INTO games (code) VALUES ('cs')")
+	require.NoError(t, err)
+
+	empty, err = p.IsDatabaseEmpty(ctx, "gameap")
+	require.NoError(t, err)
+	assert.False(t, empty)
+
+	require.NoError(t, p.CreateUser(ctx, "gameap", "secret"))
+	require.NoError(t, p.Grant(ctx, "gameap", "gameap"))
+}
+
+func TestNew_UnsupportedDriver(t *testing.T) {
+	_, err := New(context.Background(), "oracle", nil)
+	require.EqualError(t, err, "unsupported database driver: oracle")
+}
+
+func TestMySQLProvisioner_RejectsInvalidNames(t *testing.T) {
+	ctx := context.Background()
+	p := &MySQLProvisioner{mysqlProvisioner: &mysqlProvisioner{}}
+
+	require.Error(t, p.CreateDatabase(ctx, "gameap; DROP DATABASE mysql"))
+	require.Error(t, p.CreateUser(ctx, "gameap'@'%", "secret"))
+	require.Error(t, p.Grant(ctx, "gameap", "gameap`.* TO x"))
+}
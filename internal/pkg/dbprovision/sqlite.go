@@ -0,0 +1,53 @@
+package dbprovision
+
+import (
+	"context"
+)
+
+// SQLiteProvisioner works on the database file of its connection. SQLite has
+// no users, so the user methods do nothing.
+type SQLiteProvisioner struct {
+	q Querier
+}
+
+func (p *SQLiteProvisioner) Version(ctx context.Context) (string, error) {
+	return queryString(ctx, p.q, "SELECT sqlite_version()")
+}
+
+// DatabaseExists is always true, the file is created by opening it.
+func (p *SQLiteProvisioner) DatabaseExists(context.Context, string) (bool, error) {
+	return true, nil
+}
+
+func (p *SQLiteProvisioner) CreateDatabase(context.Context, string) error {
+	return nil
+}
+
+func (p *SQLiteProvisioner) UserExists(context.Context, string) (bool, error) {
+	return false, nil
+}
+
+func (p *SQLiteProvisioner) CreateUser(context.Context, string, string) error {
+	return nil
+}
+
+func (p *SQLiteProvisioner) AlterUserPassword(context.Context, string, string) error {
+	return nil
+}
+
+func (p *SQLiteProvisioner) Grant(context.Context, string, string) error {
+	return nil
+}
+
+func (p *SQLiteProvisioner) IsDatabaseEmpty(ctx context.Context, _ string) (bool, error) {
+	tableExists, err := queryBool(ctx, p.q,
+		"SELECT EXISTS(SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = ?)", "games",
+	)
+	if err != nil || !tableExists {
+		return true, err
+	}
+
+	recordsExist, err := queryBool(ctx, p.q, `SELECT EXISTS(SELECT 1 FROM "games" LIMIT 1)`)
+
+	return !recordsExist, err
+}
@@ -0,0 +1,217 @@
+// Package doctor runs diagnostic checks against an existing installation. Every
+// check reports pass/warn/fail with a remediation hint; checks that have a
+// fixer.Item can be repaired automatically.
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/gameap/gameapctl/pkg/fixer"
+	"github.com/pkg/errors"
+)
+
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+	StatusSkip Status = "skip"
+)
+
+const (
+	ComponentPanel  = "panel"
+	ComponentDaemon = "daemon"
+	ComponentSystem = "system"
+)
+
+type Outcome struct {
+	Status  Status
+	Message string
+}
+
+func Pass(format string, a ...any) Outcome {
+	return Outcome{Status: StatusPass, Message: fmt.Sprintf(format, a...)}
+}
+
+func Warn(format string, a ...any) Outcome {
+	return Outcome{Status: StatusWarn, Message: fmt.Sprintf(format, a...)}
+}
+
+func Fail(format string, a ...any) Outcome {
+	return Outcome{Status: StatusFail, Message: fmt.Sprintf(format, a...)}
+}
+
+func Skip(format string, a ...any) Outcome {
+	return Outcome{Status: StatusSkip, Message: fmt.Sprintf(format, a...)}
+}
+
+func (o Outcome) problem() bool {
+	return o.Status == StatusWarn || o.Status == StatusFail
+}
+
+type Check struct {
+	ID        string
+	Component string
+	Title     string
+	// Hint tells the operator how to resolve a warning or a failure.
+	Hint string
+	Run  func(ctx context.Context) Outcome
+	// Fix, when set, is applied with --fix to a check that did not pass.
+	Fix *fixer.Item
+}
+
+type Result struct {
+	ID        string `json:"id"`
+	Component string `json:"component"`
+	Title     string `json:"title"`
+	Status    Status `json:"status"`
+	Message   string `json:"message,omitempty"`
+	Hint      string `json:"hint,omitempty"`
+	Fixable   bool   `json:"fixable,omitempty"`
+	Fixed     bool   `json:"fixed,omitempty"`
+}
+
+type Summary struct {
+	Pass  int `json:"pass"`
+	Warn  int `json:"warn"`
+	Fail  int `json:"fail"`
+	Skip  int `json:"skip"`
+	Fixed int `json:"fixed"`
+}
+
+type Report struct {
+	Results []Result `json:"results"`
+	Summary Summary  `json:"summary"`
+}
+
+type Options struct {
+	Fix bool
+}
+
+type Registry struct {
+	checks []Check
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) Register(checks ...Check) {
+	r.checks = append(r.checks, checks...)
+}
+
+func (r *Registry) Checks() []Check {
+	return r.checks
+}
+
+// Run executes the registered checks in registration order.
+func (r *Registry) Run(ctx context.Context, opts Options) Report {
+	report := Report{Results: make([]Result, 0, len(r.checks))}
+
+	for _, check := range r.checks {
+		if ctx.Err() != nil {
+			break
+		}
+
+		result := runCheck(ctx, check, opts)
+
+		switch result.Status {
+		case StatusPass:
+			report.Summary.Pass++
+		case StatusWarn:
+			report.Summary.Warn++
+		case StatusFail:
+			report.Summary.Fail++
+		case StatusSkip:
+			report.Summary.Skip++
+		}
+		if result.Fixed {
+			report.Summary.Fixed++
+		}
+
+		report.Results = append(report.Results, result)
+	}
+
+	return report
+}
+
+func runCheck(ctx context.Context, check Check, opts Options) Result {
+	outcome := check.Run(ctx)
+
+	result := Result{
+		ID:        check.ID,
+		Component: check.Component,
+		Title:     check.Title,
+		Fixable:   check.Fix != nil,
+	}
+
+	if outcome.problem() && opts.Fix && check.Fix != nil {
+		// RunFixer re-runs the check after the fix, so the outcome reported is
+		// the state after the fix attempt.
+		err := fixer.RunFixer(ctx, func(ctx context.Context) error {
+			outcome = check.Run(ctx)
+			if outcome.problem() {
+				return errors.New(outcome.Message)
+			}
+
+			return nil
+		}, []fixer.Item{*check.Fix})
+		if err != nil {
+			log.Println(errors.WithMessagef(err, "failed to fix '%s'", check.ID))
+		} else {
+			result.Fixed = true
+		}
+	}
+
+	result.Status = outcome.Status
+	result.Message = outcome.Message
+
+	if outcome.problem() {
+		result.Hint = check.Hint
+	}
+
+	return result
+}
+
+// Failed reports whether at least one check failed. Warnings do not fail a run.
+func (r Report) Failed() bool {
+	return r.Summary.Fail > 0
+}
+
+func (r Report) WriteText(w io.Writer) error {
+	for _, result := range r.Results {
+		line := fmt.Sprintf("[%s] %s: %s", strings.ToUpper(string(result.Status)), result.Title, result.Message)
+		if result.Fixed {
+			line += " (fixed)"
+		}
+
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return errors.Wrap(err, "failed to write report")
+		}
+
+		if result.Hint == "" {
+			continue
+		}
+
+		hint := "       Hint: " + result.Hint
+		if result.Fixable {
+			hint += " Run with --fix to try an automatic fix."
+		}
+
+		if _, err := fmt.Fprintln(w, hint); err != nil {
+			return errors.Wrap(err, "failed to write report")
+		}
+	}
+
+	_, err := fmt.Fprintf(
+		w, "\nSummary: %d passed, %d warnings, %d failed, %d skipped, %d fixed\n",
+		r.Summary.Pass, r.Summary.Warn, r.Summary.Fail, r.Summary.Skip, r.Summary.Fixed,
+	)
+
+	return errors.Wrap(err, "failed to write report")
+}
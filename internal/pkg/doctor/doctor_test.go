@@ -0,0 +1,128 @@
+package doctor
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/gameap/gameapctl/pkg/fixer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_Run(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(
+		Check{
+			ID:    "ok",
+			Title: "Ok",
+			Hint:  "never shown",
+			Run:   func(_ context.Context) Outcome { return Pass("fine") },
+		},
+		Check{
+			ID:    "warn",
+			Title: "Warn",
+			Hint:  "look at it",
+			Run:   func(_ context.Context) Outcome { return Warn("not great") },
+		},
+		Check{
+			ID:    "skip",
+			Title: "Skip",
+			Run:   func(_ context.Context) Outcome { return Skip("not applicable") },
+		},
+	)
+
+	report := registry.Run(context.Background(), Options{})
+
+	require.Len(t, report.Results, 3)
+	assert.Equal(t, Summary{Pass: 1, Warn: 1, Skip: 1}, report.Summary)
+	assert.False(t, report.Failed())
+	assert.Empty(t, report.Results[0].Hint)
+	assert.Equal(t, "look at it", report.Results[1].Hint)
+}
+
+func TestRegistry_Run_FailWithoutFix(t *testing.T) {
+	fixed := false
+
+	registry := NewRegistry()
+	registry.Register(brokenCheck(&fixed))
+
+	report := registry.Run(context.Background(), Options{})
+
+	assert.True(t, report.Failed())
+	assert.False(t, fixed, "fix must not run without --fix")
+	assert.True(t, report.Results[0].Fixable)
+	assert.False(t, report.Results[0].Fixed)
+}
+
+func TestRegistry_Run_Fix(t *testing.T) {
+	fixed := false
+
+	registry := NewRegistry()
+	registry.Register(brokenCheck(&fixed))
+
+	report := registry.Run(context.Background(), Options{Fix: true})
+
+	assert.False(t, report.Failed())
+	assert.Equal(t, StatusPass, report.Results[0].Status)
+	assert.True(t, report.Results[0].Fixed)
+	assert.Equal(t, 1, report.Summary.Fixed)
+}
+
+func TestRegistry_Run_FixDidNotHelp(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(Check{
+		ID:  "broken",
+		Run: func(_ context.Context) Outcome { return Fail("still broken") },
+		Fix: &fixer.Item{
+			Name:      "noop",
+			Condition: func(_ context.Context) (bool, error) { return true, nil },
+			FixFunc:   func(_ context.Context) error { return nil },
+		},
+	})
+
+	report := registry.Run(context.Background(), Options{Fix: true})
+
+	assert.True(t, report.Failed())
+	assert.False(t, report.Results[0].Fixed)
+}
+
+func TestReport_WriteText(t *testing.T) {
+	report := Report{
+		Results: []Result{
+			{ID: "a", Title: "Panel process", Status: StatusFail, Message: "not running", Hint: "Start it.", Fixable: true},
+		},
+		Summary: Summary{Fail: 1},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteText(&buf))
+
+	assert.Contains(t, buf.String(), "[FAIL] Panel process: not running")
+	assert.Contains(t, buf.String(), "Hint: Start it. Run with --fix")
+	assert.Contains(t, buf.String(), "Summary: 0 passed, 0 warnings, 1 failed")
+}
+
+func brokenCheck(fixed *bool) Check {
+	return Check{
+		ID:    "broken",
+		Title: "Broken",
+		Hint:  "fix it",
+		Run: func(_ context.Context) Outcome {
+			if *fixed {
+				return Pass("repaired")
+			}
+
+			return Fail("broken")
+		},
+		Fix: &fixer.Item{
+			Name:      "repair",
+			Condition: func(_ context.Context) (bool, error) { return true, nil },
+			FixFunc: func(_ context.Context) error {
+				*fixed = true
+
+				return nil
+			},
+		},
+	}
+}
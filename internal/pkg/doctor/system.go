@@ -0,0 +1,53 @@
+package doctor
+
+import (
+	"context"
+	"runtime"
+
+	"github.com/gameap/gameapctl/pkg/fixer"
+	"github.com/gameap/gameapctl/pkg/gameap"
+)
+
+// SystemChecks returns the host checks shared by the panel and the daemon.
+func SystemChecks(scope string) []Check {
+	return []Check{
+		selinuxCheck(scope),
+	}
+}
+
+// selinuxCheck warns about an enforcing SELinux, which blocks GameAP from
+// binding its ports and reading files outside of the labelled directories.
+func selinuxCheck(scope string) Check {
+	check := Check{
+		ID:        "system.selinux",
+		Component: ComponentSystem,
+		Title:     "SELinux",
+		Hint:      "Switch SELinux to permissive mode or add policies for GameAP.",
+		Run: func(ctx context.Context) Outcome {
+			if runtime.GOOS != "linux" {
+				return Skip("not applicable on %s", runtime.GOOS)
+			}
+
+			enabled, err := fixer.IsSELinuxEnabled(ctx)
+			if err != nil {
+				return Warn("failed to get SELinux status: %s", err.Error())
+			}
+			if enabled {
+				return Warn("SELinux is enforcing")
+			}
+
+			return Pass("not enforcing")
+		},
+	}
+
+	// Changing the SELinux mode requires root, a rootless installation cannot fix it.
+	if gameap.ScopeOrDefault(scope) == gameap.ScopeSystem {
+		check.Fix = &fixer.Item{
+			Name:      "Disable SELinux",
+			Condition: fixer.IsSELinuxEnabled,
+			FixFunc:   fixer.DisableSELinux,
+		}
+	}
+
+	return check
+}
@@ -0,0 +1,131 @@
+package gameapctl
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/fs"
+	"time"
+
+	"github.com/gameap/gameapctl/pkg/dryrun"
+	"github.com/pkg/errors"
+)
+
+const (
+	autoUpdateSettingsFile = "autoupdate.json"
+	autoUpdateLogFile      = "autoupdate.log"
+
+	// autoUpdateLogSize is the number of runs the log keeps.
+	autoUpdateLogSize = 200
+)
+
+type AutoUpdateSettings struct {
+	Enabled    bool      `json:"enabled"`
+	Components []string  `json:"components"`
+	Window     string    `json:"window"`
+	Policy     string    `json:"policy"`
+	Scheduler  string    `json:"scheduler,omitempty"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+func SaveAutoUpdateSettings(ctx context.Context, settings AutoUpdateSettings) error {
+	if dryrun.Enabled(ctx) {
+		return nil
+	}
+
+	b, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return errors.WithMessage(err, "failed to marshal json")
+	}
+
+	return writeStateFile(autoUpdateSettingsFile, b)
+}
+
+func LoadAutoUpdateSettings() (AutoUpdateSettings, error) {
+	var settings AutoUpdateSettings
+
+	b, err := readStateFile(autoUpdateSettingsFile)
+	if err != nil {
+		return settings, err
+	}
+
+	if err = json.Unmarshal(b, &settings); err != nil {
+		return settings, errors.WithMessage(err, "failed to unmarshal json")
+	}
+
+	return settings, nil
+}
+
+// Results of an automatic update run of a component.
+const (
+	AutoUpdateUpdated  = "updated"
+	AutoUpdateUpToDate = "up-to-date"
+	AutoUpdateSkipped  = "skipped"
+	AutoUpdateFailed   = "failed"
+	// AutoUpdateBusy runs waited for the game servers and are retried on the
+	// next start within the window.
+	AutoUpdateBusy = "busy"
+)
+
+// AutoUpdateRun is an entry of the automatic update log. A run skipped as a
+// whole has no component.
+type AutoUpdateRun struct {
+	StartedAt time.Time `json:"startedAt"`
+	Component string    `json:"component,omitempty"`
+	From      string    `json:"from,omitempty"`
+	To        string    `json:"to,omitempty"`
+	Result    string    `json:"result"`
+	Message   string    `json:"message,omitempty"`
+}
+
+// AppendAutoUpdateRuns appends the runs to the log, one JSON object per line.
+// The oldest runs are dropped to keep the log short.
+func AppendAutoUpdateRuns(ctx context.Context, runs ...AutoUpdateRun) error {
+	if dryrun.Enabled(ctx) || len(runs) == 0 {
+		return nil
+	}
+
+	all, err := LoadAutoUpdateRuns()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return errors.WithMessage(err, "failed to load autoupdate log")
+	}
+
+	all = append(all, runs...)
+	if len(all) > autoUpdateLogSize {
+		all = all[len(all)-autoUpdateLogSize:]
+	}
+
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	for _, run := range all {
+		if err := enc.Encode(run); err != nil {
+			return errors.WithMessage(err, "failed to marshal json")
+		}
+	}
+
+	return writeStateFile(autoUpdateLogFile, b.Bytes())
+}
+
+// LoadAutoUpdateRuns returns the logged runs, the oldest first. Lines which
+// fail to parse are skipped.
+func LoadAutoUpdateRuns() ([]AutoUpdateRun, error) {
+	b, err := readStateFile(autoUpdateLogFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []AutoUpdateRun
+
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		var run AutoUpdateRun
+		if err := json.Unmarshal(scanner.Bytes(), &run); err != nil {
+			continue
+		}
+
+		runs = append(runs, run)
+	}
+
+	return runs, errors.Wrap(scanner.Err(), "failed to read autoupdate log")
+}
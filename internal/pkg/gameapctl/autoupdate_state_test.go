@@ -0,0 +1,69 @@
+package gameapctl
+
+import (
+	"context"
+	"io/fs"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoUpdateSettings(t *testing.T) {
+	setupStateHome(t)
+
+	_, err := LoadAutoUpdateSettings()
+	require.ErrorIs(t, err, fs.ErrNotExist)
+
+	settings := AutoUpdateSettings{
+		Enabled:    true,
+		Components: []string{"daemon", "gameapctl"},
+		Window:     "Sun 03:00-05:00",
+		Policy:     "patch",
+		Scheduler:  "systemd",
+		UpdatedAt:  time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+	}
+	require.NoError(t, SaveAutoUpdateSettings(context.Background(), settings))
+
+	loaded, err := LoadAutoUpdateSettings()
+	require.NoError(t, err)
+	assert.Equal(t, settings, loaded)
+}
+
+func TestAppendAutoUpdateRuns(t *testing.T) {
+	setupStateHome(t)
+	ctx := context.Background()
+
+	_, err := LoadAutoUpdateRuns()
+	require.ErrorIs(t, err, fs.ErrNotExist)
+
+	start := time.Date(2026, 10, 4, 3, 0, 0, 0, time.UTC)
+	for i := range autoUpdateLogSize + 5 {
+		require.NoError(t, AppendAutoUpdateRuns(ctx, AutoUpdateRun{
+			StartedAt: start.Add(time.Duration(i) * time.Minute),
+			Component: "daemon",
+			Result:    AutoUpdateUpToDate,
+		}))
+	}
+
+	require.NoError(t, AppendAutoUpdateRuns(ctx, AutoUpdateRun{
+		StartedAt: start.Add(time.Hour * 24),
+		Component: "gameapctl",
+		From:      "v1.2.0",
+		To:        "v1.2.1",
+		Result:    AutoUpdateUpdated,
+	}))
+
+	runs, err := LoadAutoUpdateRuns()
+	require.NoError(t, err)
+	require.Len(t, runs, autoUpdateLogSize)
+	assert.Equal(t, start.Add(6*time.Minute), runs[0].StartedAt.UTC())
+	assert.Equal(t, AutoUpdateRun{
+		StartedAt: start.Add(time.Hour * 24),
+		Component: "gameapctl",
+		From:      "v1.2.0",
+		To:        "v1.2.1",
+		Result:    AutoUpdateUpdated,
+	}, runs[len(runs)-1])
+}
@@ -0,0 +1,48 @@
+package gameapctl
+
+import (
+	"log"
+
+	"github.com/gameap/gameapctl/pkg/releasesource"
+)
+
+// ReleaseChannel returns the channel of an installation: its own channel,
+// otherwise the one of the config or stable.
+func ReleaseChannel(own releasesource.Channel) releasesource.Channel {
+	if own != "" {
+		return own
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		log.Println("Warning: failed to load config, using the stable channel:", err)
+
+		return releasesource.ChannelStable
+	}
+
+	if config.Channel != "" {
+		return config.Channel
+	}
+
+	return releasesource.ChannelStable
+}
+
+// ReleaseChannel returns the channel the panel follows. A panel built from
+// the GitHub source follows the nightly channel.
+func (s PanelInstallState) ReleaseChannel() releasesource.Channel {
+	if s.FromGithub {
+		return releasesource.ChannelNightly
+	}
+
+	return ReleaseChannel(releasesource.Channel(s.Channel))
+}
+
+// ReleaseChannel returns the channel the daemon follows. A daemon built from
+// the GitHub source follows the nightly channel.
+func (s DaemonInstallState) ReleaseChannel() releasesource.Channel {
+	if s.FromGithub {
+		return releasesource.ChannelNightly
+	}
+
+	return ReleaseChannel(releasesource.Channel(s.Channel))
+}
@@ -0,0 +1,154 @@
+package gameapctl
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gameap/gameapctl/pkg/downloadcache"
+	"github.com/gameap/gameapctl/pkg/releasesource"
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/parser"
+	"github.com/pkg/errors"
+)
+
+const configFile = "config.yaml"
+
+// Config holds the gameapctl settings kept in ~/.gameapctl/config.yaml.
+type Config struct {
+	// ReleaseMirrors are self-hosted release sources used next to GitHub
+	// and the GameAP CDNs.
+	ReleaseMirrors []releasesource.Mirror `yaml:"release-mirrors"`
+	DownloadCache  DownloadCacheConfig    `yaml:"download-cache"`
+	// Channel is the release channel of gameapctl and of the installations
+	// without a channel of their own.
+	Channel  releasesource.Channel `yaml:"channel"`
+	Rollback RollbackConfig        `yaml:"rollback"`
+}
+
+// RollbackConfig configures the releases upgrades keep for the rollback.
+type RollbackConfig struct {
+	// Keep is the number of releases kept per component. Zero is
+	// releasehistory.DefaultKeep.
+	Keep int `yaml:"keep"`
+}
+
+// DownloadCacheConfig configures the cache of downloaded archives.
+type DownloadCacheConfig struct {
+	// Dir defaults to downloadcache.DefaultDir.
+	Dir string `yaml:"dir"`
+	// MaxSizeMB limits the cache size in megabytes, the oldest used files are
+	// removed first. Zero is downloadcache.DefaultMaxSize.
+	MaxSizeMB int64 `yaml:"max-size-mb"`
+	Disabled  bool  `yaml:"disabled"`
+}
+
+// Cache returns the configured download cache, nil when it is disabled.
+func (c DownloadCacheConfig) Cache() (*downloadcache.Cache, error) {
+	if c.Disabled {
+		return nil, nil //nolint:nilnil
+	}
+
+	if c.MaxSizeMB < 0 {
+		return nil, errors.Errorf("invalid download cache size %d MB", c.MaxSizeMB)
+	}
+
+	dir := c.Dir
+	if dir == "" {
+		dir = downloadcache.DefaultDir()
+	}
+
+	return downloadcache.New(dir, c.MaxSizeMB<<20), nil //nolint:mnd
+}
+
+// LoadConfig reads the config file. A missing file or home directory is an
+// empty config.
+func LoadConfig() (*Config, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return &Config{}, nil //nolint:nilerr
+	}
+
+	path := filepath.Join(homeDir, ".gameapctl", configFile)
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to read config")
+	}
+
+	// Unknown keys are ignored, an older gameapctl still reads the file
+	// written by a newer one.
+	config := &Config{}
+	if err := yaml.Unmarshal(b, config); err != nil {
+		return nil, errors.WithMessagef(err, "invalid config %s", path)
+	}
+
+	if config.Channel != "" {
+		if config.Channel, err = releasesource.ParseChannel(string(config.Channel)); err != nil {
+			return nil, errors.WithMessagef(err, "invalid config %s", path)
+		}
+	}
+
+	if config.Rollback.Keep < 0 {
+		return nil, errors.Errorf("invalid config %s: rollback keep must not be negative", path)
+	}
+
+	return config, nil
+}
+
+// SetConfigValue sets a top-level key of the config file. The rest of the
+// file is kept as it is, comments included.
+func SetConfigValue(key string, value any) error {
+	b, err := readStateFile(configFile)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return errors.WithMessage(err, "failed to read config")
+	}
+
+	node, err := yaml.Marshal(value)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal config value")
+	}
+
+	entry, err := yaml.Marshal(map[string]any{key: value})
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal config value")
+	}
+
+	file, err := parser.ParseBytes(b, parser.ParseComments)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse config")
+	}
+
+	keyPath, err := yaml.PathString("$." + key)
+	if err != nil {
+		return errors.Wrap(err, "invalid config key")
+	}
+
+	if _, readErr := keyPath.ReadNode(file); readErr == nil {
+		err = keyPath.ReplaceWithReader(file, bytes.NewReader(node))
+	} else {
+		root, _ := yaml.PathString("$")
+		err = root.MergeFromReader(file, bytes.NewReader(entry))
+	}
+
+	content := file.String()
+	if err != nil {
+		// A file without a mapping, e.g. an empty one, gets the key appended.
+		content = string(b)
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		content += string(entry)
+	}
+
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+
+	return writeStateFile(configFile, []byte(content))
+}
@@ -0,0 +1,126 @@
+package gameapctl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gameap/gameapctl/pkg/downloadcache"
+	"github.com/gameap/gameapctl/pkg/releasesource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := setupStateHome(t)
+
+	config, err := LoadConfig()
+	require.NoError(t, err)
+	assert.Empty(t, config.ReleaseMirrors)
+
+	require.NoError(t, os.MkdirAll(dir, 0700))
+	require.NoError(t, writeStateFile(configFile, []byte(
+		"release-mirrors:\n"+
+			"  - name: intranet\n"+
+			"    url: https://mirror.example.com/gameap\n"+
+			"  - url: file:///srv/gameap\n"+
+			"future-setting: true\n",
+	)))
+
+	config, err = LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, []releasesource.Mirror{
+		{Name: "intranet", URL: "https://mirror.example.com/gameap"},
+		{URL: "file:///srv/gameap"},
+	}, config.ReleaseMirrors)
+}
+
+func TestDownloadCacheConfig_Cache(t *testing.T) {
+	cache, err := DownloadCacheConfig{Disabled: true}.Cache()
+	require.NoError(t, err)
+	assert.Nil(t, cache)
+
+	_, err = DownloadCacheConfig{MaxSizeMB: -1}.Cache()
+	require.Error(t, err)
+
+	cache, err = DownloadCacheConfig{Dir: "/srv/cache", MaxSizeMB: 512}.Cache()
+	require.NoError(t, err)
+	assert.Equal(t, "/srv/cache", cache.Dir())
+	assert.Equal(t, int64(512<<20), cache.MaxSize())
+
+	cache, err = DownloadCacheConfig{}.Cache()
+	require.NoError(t, err)
+	assert.Equal(t, downloadcache.DefaultDir(), cache.Dir())
+	assert.Equal(t, downloadcache.DefaultMaxSize, cache.MaxSize())
+}
+
+func TestSetConfigValue(t *testing.T) {
+	setupStateHome(t)
+
+	require.NoError(t, SetConfigValue("channel", "beta"))
+
+	config, err := LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, releasesource.ChannelBeta, config.Channel)
+
+	require.NoError(t, writeStateFile(configFile, []byte(
+		"# Local mirror\n"+
+			"release-mirrors:\n"+
+			"  - url: file:///srv/gameap # NFS share\n"+
+			"channel: beta\n"+
+			"future-setting: true\n",
+	)))
+
+	require.NoError(t, SetConfigValue("channel", "nightly"))
+
+	b, err := readStateFile(configFile)
+	require.NoError(t, err)
+	assert.Equal(t,
+		"# Local mirror\n"+
+			"release-mirrors:\n"+
+			"  - url: file:///srv/gameap # NFS share\n"+
+			"channel: nightly\n"+
+			"future-setting: true\n",
+		string(b),
+	)
+}
+
+func TestLoadConfig_invalidChannel(t *testing.T) {
+	setupStateHome(t)
+
+	require.NoError(t, SetConfigValue("channel", "alpha"))
+
+	_, err := LoadConfig()
+	require.ErrorContains(t, err, `unknown release channel "alpha"`)
+}
+
+func TestReleaseHistory(t *testing.T) {
+	dir := setupStateHome(t)
+
+	history, err := ReleaseHistory("daemon", "")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "releases", "daemon", "system"), history.Dir())
+
+	history, err = ReleaseHistory("daemon", "user")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "releases", "daemon", "user"), history.Dir())
+
+	require.NoError(t, SetConfigValue("rollback", map[string]int{"keep": -1}))
+
+	_, err = ReleaseHistory("daemon", "")
+	require.ErrorContains(t, err, "rollback keep must not be negative")
+}
+
+func TestReleaseChannel(t *testing.T) {
+	setupStateHome(t)
+
+	assert.Equal(t, releasesource.ChannelStable, ReleaseChannel(""))
+	assert.Equal(t, releasesource.ChannelStable, DaemonInstallState{}.ReleaseChannel())
+
+	require.NoError(t, SetConfigValue("channel", "beta"))
+
+	assert.Equal(t, releasesource.ChannelBeta, ReleaseChannel(""))
+	assert.Equal(t, releasesource.ChannelBeta, PanelInstallState{}.ReleaseChannel())
+	assert.Equal(t, releasesource.ChannelStable, PanelInstallState{Channel: "stable"}.ReleaseChannel())
+	assert.Equal(t, releasesource.ChannelNightly, DaemonInstallState{FromGithub: true}.ReleaseChannel())
+}
@@ -3,9 +3,9 @@ package gameapctl
 import (
 	"context"
 	"encoding/json"
-	"os"
-	"path/filepath"
+	"log"
 
+	"github.com/gameap/gameapctl/pkg/dryrun"
 	"github.com/pkg/errors"
 )
 
@@ -23,51 +23,43 @@ type DaemonInstallState struct {
 	CertsPath      string `json:"certsPath"`
 	FromGithub     bool   `json:"fromGithub"`
 	Branch         string `json:"branch"`
+	Channel        string `json:"channel,omitempty"`
 	ProcessManager string `json:"processManager"`
 	GRPCEnabled    bool   `json:"grpcEnabled,omitempty"`
 }
 
-func SaveDaemonInstallState(_ context.Context, state DaemonInstallState) error {
-	b, err := json.MarshalIndent(state, "", "  ")
-	if err != nil {
-		return errors.WithMessage(err, "failed to marshal json")
+func SaveDaemonInstallState(ctx context.Context, state DaemonInstallState) error {
+	// The state describes a completed installation step, nothing was installed in a dry run.
+	if dryrun.Enabled(ctx) {
+		return nil
 	}
 
-	dir, err := stateDirectory()
+	store, err := NewSecretStore()
 	if err != nil {
-		return errors.WithMessage(err, "failed to get state directory")
+		return errors.WithMessage(err, "failed to open secret store")
 	}
 
-	finalPath := filepath.Join(dir, daemonInstallStateFile)
-	tmpPath := finalPath + ".tmp"
-
-	err = os.WriteFile(tmpPath, b, 0600)
-	if err != nil {
-		return errors.WithMessage(err, "failed to write temp file")
+	if err = encryptFields(store, state.secrets()); err != nil {
+		return errors.WithMessage(err, "failed to encrypt secrets")
 	}
 
-	if err = os.Rename(tmpPath, finalPath); err != nil {
-		if writeErr := os.WriteFile(finalPath, b, 0600); writeErr != nil {
-			return errors.WithMessage(writeErr, "failed to write file")
-		}
-
-		_ = os.Remove(tmpPath)
+	b, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return errors.WithMessage(err, "failed to marshal json")
 	}
 
-	return nil
+	return writeStateFile(daemonInstallStateFile, b)
 }
 
-func LoadDaemonInstallState(_ context.Context) (DaemonInstallState, error) {
+// LoadDaemonInstallState returns the state with the secrets decrypted. A state written
+// before the secrets were encrypted is rewritten encrypted. Secrets which cannot be
+// decrypted are cleared instead of failing the load.
+func LoadDaemonInstallState(ctx context.Context) (DaemonInstallState, error) {
 	var state DaemonInstallState
 
-	dir, err := stateDirectory()
-	if err != nil {
-		return state, errors.WithMessage(err, "failed to get state directory")
-	}
-
-	b, err := os.ReadFile(filepath.Join(dir, daemonInstallStateFile))
+	b, err := readStateFile(daemonInstallStateFile)
 	if err != nil {
-		return state, errors.WithMessage(err, "failed to read file")
+		return state, err
 	}
 
 	err = json.Unmarshal(b, &state)
@@ -75,5 +67,25 @@ func LoadDaemonInstallState(_ context.Context) (DaemonInstallState, error) {
 		return state, errors.WithMessage(err, "failed to unmarshal json")
 	}
 
+	plaintext := decryptSecrets(daemonInstallStateFile, state.secrets(), nil)
+
+	if plaintext {
+		if err := SaveDaemonInstallState(ctx, state); err != nil {
+			log.Println(errors.WithMessage(err, "failed to encrypt secrets in the state file"))
+		}
+	}
+
 	return state, nil
 }
+
+// secrets lists the fields encrypted at rest, the connect URL holds the setup key.
+func (s *DaemonInstallState) secrets() []*string {
+	return []*string{&s.ConnectURL}
+}
+
+// Masked returns a copy of the state with the secrets hidden.
+func (s DaemonInstallState) Masked() DaemonInstallState {
+	maskFields(s.secrets())
+
+	return s
+}
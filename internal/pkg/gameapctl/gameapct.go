@@ -24,3 +24,43 @@ func stateDirectory() (string, error) {
 
 	return dir, nil
 }
+
+func writeStateFile(name string, b []byte) error {
+	dir, err := stateDirectory()
+	if err != nil {
+		return errors.WithMessage(err, "failed to get state directory")
+	}
+
+	finalPath := filepath.Join(dir, name)
+	tmpPath := finalPath + ".tmp"
+
+	err = os.WriteFile(tmpPath, b, 0600)
+	if err != nil {
+		return errors.WithMessage(err, "failed to write temp file")
+	}
+
+	if err = os.Rename(tmpPath, finalPath); err != nil {
+		// Fallback to direct write if rename fails (e.g. file locked on Windows)
+		if writeErr := os.WriteFile(finalPath, b, 0600); writeErr != nil {
+			return errors.WithMessage(writeErr, "failed to write file")
+		}
+
+		_ = os.Remove(tmpPath)
+	}
+
+	return nil
+}
+
+func readStateFile(name string) ([]byte, error) {
+	dir, err := stateDirectory()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get state directory")
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to read file")
+	}
+
+	return b, nil
+}
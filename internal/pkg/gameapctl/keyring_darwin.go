@@ -0,0 +1,76 @@
+//go:build darwin
+
+package gameapctl
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os/exec"
+	"os/user"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	keychainService = "gameapctl"
+	// keychainItemNotFound is the exit code of 'security' for a missing item.
+	keychainItemNotFound = 44
+)
+
+func keyringSources() []keySource {
+	if _, err := exec.LookPath("security"); err != nil {
+		return nil
+	}
+
+	u, err := user.Current()
+	if err != nil {
+		return nil
+	}
+
+	return []keySource{keychainKeySource{account: u.Username}}
+}
+
+// keychainKeySource keeps the key in the macOS login keychain.
+type keychainKeySource struct {
+	account string
+}
+
+func (s keychainKeySource) Name() string {
+	return "macOS keychain"
+}
+
+func (s keychainKeySource) Load() ([]byte, error) {
+	out, err := exec.Command( //nolint:gosec
+		"security", "find-generic-password", "-a", s.account, "-s", keychainService, "-w",
+	).Output()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == keychainItemNotFound {
+		return nil, errKeyNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read keychain")
+	}
+
+	key, err := hex.DecodeString(strings.TrimSpace(string(out)))
+	if err != nil || len(key) != keyLength {
+		return nil, errors.New("invalid key in keychain")
+	}
+
+	return key, nil
+}
+
+func (s keychainKeySource) Store(key []byte) error {
+	// The command is passed on stdin so the key never shows up in the process list.
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf(
+		"add-generic-password -U -a %q -s %q -w %q\n", s.account, keychainService, hex.EncodeToString(key),
+	))
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "failed to write keychain: %s", strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
@@ -0,0 +1,9 @@
+//go:build !darwin
+
+package gameapctl
+
+// keyringSources is empty here, servers rarely run a keyring service the
+// root user can reach, so the key is kept in the key file.
+func keyringSources() []keySource {
+	return nil
+}
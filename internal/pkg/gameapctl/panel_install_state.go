@@ -3,9 +3,10 @@ package gameapctl
 import (
 	"context"
 	"encoding/json"
-	"os"
-	"path/filepath"
+	"log"
+	"maps"
 
+	"github.com/gameap/gameapctl/pkg/dryrun"
 	"github.com/pkg/errors"
 )
 
@@ -28,6 +29,7 @@ type PanelInstallState struct {
 	Develop              bool   `json:"develop"`
 	FromGithub           bool   `json:"fromGithub"`
 	Branch               string `json:"branch"`
+	Channel              string `json:"channel,omitempty"`
 
 	DBHost         string `json:"dbHost,omitempty"`
 	DBPort         string `json:"dbPort,omitempty"`
@@ -36,50 +38,79 @@ type PanelInstallState struct {
 	DBPassword     string `json:"dbPassword,omitempty"`
 	DBRootPassword string `json:"dbRootPassword,omitempty"`
 	AdminPassword  string `json:"adminPassword,omitempty"`
+
+	ExistingDatabase bool   `json:"existingDatabase,omitempty"`
+	WithDaemon       bool   `json:"withDaemon,omitempty"`
+	BinaryPath       string `json:"binaryPath,omitempty"`
+	Tag              string `json:"tag,omitempty"`
+	TagPrefix        string `json:"tagPrefix,omitempty"`
+	GRPCEnabled      bool   `json:"grpcEnabled,omitempty"`
+	GRPCPort         string `json:"grpcPort,omitempty"`
+	HTTPS            bool   `json:"https,omitempty"`
+
+	// ACME keeps the certificate settings of the installation, so a resumed
+	// run configures them without the original answers file.
+	ACME *PanelACMEState `json:"acme,omitempty"`
+
+	// CompletedSteps lists the installation steps finished so far,
+	// 'panel install --resume' continues after them.
+	CompletedSteps []string `json:"completedSteps,omitempty"`
 }
 
-func SavePanelInstallState(_ context.Context, state PanelInstallState) error {
-	b, err := json.MarshalIndent(state, "", "  ")
-	if err != nil {
-		return errors.WithMessage(err, "failed to marshal json")
+// PanelACMEState mirrors the ACME section of the answers file. Env holds the DNS
+// provider credentials and is encrypted like the other secrets.
+type PanelACMEState struct {
+	Challenge   string            `json:"challenge,omitempty"`
+	Domains     []string          `json:"domains,omitempty"`
+	Email       string            `json:"email,omitempty"`
+	DNSProvider string            `json:"dnsProvider,omitempty"`
+	Staging     bool              `json:"staging,omitempty"`
+	Env         map[string]string `json:"env,omitempty"`
+}
+
+func SavePanelInstallState(ctx context.Context, state PanelInstallState) error {
+	// The state describes a completed installation step, nothing was installed in a dry run.
+	if dryrun.Enabled(ctx) {
+		return nil
 	}
 
-	dir, err := stateDirectory()
+	store, err := NewSecretStore()
 	if err != nil {
-		return errors.WithMessage(err, "failed to get state directory")
+		return errors.WithMessage(err, "failed to open secret store")
 	}
 
-	finalPath := filepath.Join(dir, panelInstallStateFile)
-	tmpPath := finalPath + ".tmp"
+	// The map is shared with the caller, the encrypted values go to a copy.
+	if state.ACME != nil {
+		acme := *state.ACME
+		acme.Env = maps.Clone(acme.Env)
+		state.ACME = &acme
+	}
 
-	err = os.WriteFile(tmpPath, b, 0600)
-	if err != nil {
-		return errors.WithMessage(err, "failed to write temp file")
+	if err = encryptFields(store, state.secrets()); err != nil {
+		return errors.WithMessage(err, "failed to encrypt secrets")
 	}
 
-	if err = os.Rename(tmpPath, finalPath); err != nil {
-		// Fallback to direct write if rename fails (e.g. file locked on Windows)
-		if writeErr := os.WriteFile(finalPath, b, 0600); writeErr != nil {
-			return errors.WithMessage(writeErr, "failed to write file")
-		}
+	if err = encryptMaps(store, state.secretMaps()); err != nil {
+		return errors.WithMessage(err, "failed to encrypt secrets")
+	}
 
-		_ = os.Remove(tmpPath)
+	b, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return errors.WithMessage(err, "failed to marshal json")
 	}
 
-	return nil
+	return writeStateFile(panelInstallStateFile, b)
 }
 
-func LoadPanelInstallState(_ context.Context) (PanelInstallState, error) {
+// LoadPanelInstallState returns the state with the secrets decrypted. A state written
+// before the secrets were encrypted is rewritten encrypted. Secrets which cannot be
+// decrypted are cleared instead of failing the load.
+func LoadPanelInstallState(ctx context.Context) (PanelInstallState, error) {
 	var state PanelInstallState
 
-	dir, err := stateDirectory()
+	b, err := readStateFile(panelInstallStateFile)
 	if err != nil {
-		return state, errors.WithMessage(err, "failed to get state directory")
-	}
-
-	b, err := os.ReadFile(filepath.Join(dir, panelInstallStateFile))
-	if err != nil {
-		return state, errors.WithMessage(err, "failed to read file")
+		return state, err
 	}
 
 	err = json.Unmarshal(b, &state)
@@ -87,5 +118,56 @@ func LoadPanelInstallState(_ context.Context) (PanelInstallState, error) {
 		return state, errors.WithMessage(err, "failed to unmarshal json")
 	}
 
+	plaintext := decryptSecrets(panelInstallStateFile, state.secrets(), state.secretMaps())
+
+	if plaintext {
+		if err := SavePanelInstallState(ctx, state); err != nil {
+			log.Println(errors.WithMessage(err, "failed to encrypt secrets in the state file"))
+		}
+	}
+
 	return state, nil
 }
+
+func (s *PanelInstallState) secrets() []*string {
+	return []*string{&s.DBPassword, &s.DBRootPassword, &s.AdminPassword}
+}
+
+// secretMaps returns the maps whose values are secrets.
+func (s *PanelInstallState) secretMaps() []map[string]string {
+	if s.ACME == nil {
+		return nil
+	}
+
+	return []map[string]string{s.ACME.Env}
+}
+
+// Masked returns a copy of the state with the secrets hidden.
+func (s PanelInstallState) Masked() PanelInstallState {
+	if s.ACME != nil {
+		acme := *s.ACME
+		acme.Env = maps.Clone(acme.Env)
+		s.ACME = &acme
+	}
+
+	maskFields(s.secrets())
+	maskMaps(s.secretMaps())
+
+	return s
+}
+
+// WithoutSecrets returns a copy of the state with the secrets cleared, for the
+// copies of the state leaving the state directory.
+func (s PanelInstallState) WithoutSecrets() PanelInstallState {
+	if s.ACME != nil {
+		acme := *s.ACME
+		acme.Env = nil
+		s.ACME = &acme
+	}
+
+	for _, field := range s.secrets() {
+		*field = ""
+	}
+
+	return s
+}
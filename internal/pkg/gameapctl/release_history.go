@@ -0,0 +1,31 @@
+package gameapctl
+
+import (
+	"path/filepath"
+
+	"github.com/gameap/gameapctl/internal/pkg/releasehistory"
+	"github.com/gameap/gameapctl/pkg/gameap"
+	"github.com/pkg/errors"
+)
+
+const releaseHistoryDir = "releases"
+
+// ReleaseHistory returns the releases of the component installed in the scope
+// the upgrades keep in ~/.gameapctl/releases. The system and the user
+// installations have separate histories.
+func ReleaseHistory(component, scope string) (*releasehistory.History, error) {
+	config, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := stateDirectory()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get state directory")
+	}
+
+	return releasehistory.New(
+		filepath.Join(dir, releaseHistoryDir, component, gameap.ScopeOrDefault(scope)),
+		config.Rollback.Keep,
+	), nil
+}
@@ -0,0 +1,408 @@
+package gameapctl
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io/fs"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gameap/gameapctl/internal/pkg/redact"
+	"github.com/pkg/errors"
+)
+
+const (
+	// encryptedPrefix marks the encrypted values in the state files.
+	encryptedPrefix = "enc:v1:"
+
+	keyFile   = "state.key"
+	keyLength = 32
+	keyInfo   = "gameapctl state secrets"
+)
+
+var errKeyNotFound = errors.New("encryption key not found")
+
+// SecretStore encrypts the sensitive fields of the state files at rest.
+type SecretStore interface {
+	Encrypt(plaintext string) (string, error)
+	Decrypt(value string) (string, error)
+}
+
+// IsEncrypted reports whether the value was written by a SecretStore.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+// keySource keeps the machine-local key the state secrets are derived from.
+type keySource interface {
+	Name() string
+	Load() ([]byte, error)
+	Store(key []byte) error
+}
+
+// keySources lists the sources in the order they are tried. The key is read
+// from the first source holding it, a new key goes to the first source
+// accepting it. It is a variable to be replaced in tests.
+var keySources = func(dir string) []keySource {
+	return append(keyringSources(), fileKeySource{path: filepath.Join(dir, keyFile)})
+}
+
+// fileKeySource keeps the key in the state directory, readable by the owner only.
+type fileKeySource struct {
+	path string
+}
+
+func (s fileKeySource) Name() string {
+	return s.path
+}
+
+func (s fileKeySource) Load() ([]byte, error) {
+	b, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, errKeyNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read key file")
+	}
+
+	key, err := hex.DecodeString(strings.TrimSpace(string(b)))
+	if err != nil || len(key) != keyLength {
+		return nil, errors.Errorf("invalid key file %s", s.path)
+	}
+
+	return key, nil
+}
+
+func (s fileKeySource) Store(key []byte) error {
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Wrap(err, "failed to create key file")
+	}
+
+	_, err = f.WriteString(hex.EncodeToString(key) + "\n")
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	return errors.Wrap(err, "failed to write key file")
+}
+
+// NewSecretStore returns the store for the state files of the current user.
+// The key is created on the first use, so only the writers of the state call it.
+func NewSecretStore() (SecretStore, error) {
+	dir, err := stateDirectory()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get state directory")
+	}
+
+	material, err := loadKey(keySources(dir))
+	if errors.Is(err, errKeyNotFound) {
+		material, err = createKey(keySources(dir))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return newAESStore(material)
+}
+
+// openSecretStore returns the store for reading the state, without creating a key.
+func openSecretStore() (SecretStore, error) {
+	dir, err := stateDirectory()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get state directory")
+	}
+
+	material, err := loadKey(keySources(dir))
+	if err != nil {
+		return nil, err
+	}
+
+	return newAESStore(material)
+}
+
+func loadKey(sources []keySource) ([]byte, error) {
+	for _, source := range sources {
+		key, err := source.Load()
+		if errors.Is(err, errKeyNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed to load key from %s", source.Name())
+		}
+
+		return key, nil
+	}
+
+	return nil, errKeyNotFound
+}
+
+func createKey(sources []keySource) ([]byte, error) {
+	key := make([]byte, keyLength)
+	if _, err := rand.Read(key); err != nil {
+		return nil, errors.Wrap(err, "failed to generate key")
+	}
+
+	var storeErr error
+	for _, source := range sources {
+		if storeErr = source.Store(key); storeErr == nil {
+			return key, nil
+		}
+	}
+
+	return nil, errors.WithMessage(storeErr, "failed to store key")
+}
+
+type aesStore struct {
+	aead cipher.AEAD
+}
+
+func newAESStore(material []byte) (*aesStore, error) {
+	key, err := hkdf.Key(sha256.New, material, nil, keyInfo, keyLength)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to derive key")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create cipher")
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create cipher")
+	}
+
+	return &aesStore{aead: aead}, nil
+}
+
+func (s *aesStore) Encrypt(plaintext string) (string, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", errors.Wrap(err, "failed to generate nonce")
+	}
+
+	sealed := s.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (s *aesStore) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil || len(sealed) < s.aead.NonceSize() {
+		return "", errors.New("malformed encrypted value")
+	}
+
+	nonce, ciphertext := sealed[:s.aead.NonceSize()], sealed[s.aead.NonceSize():]
+
+	plaintext, err := s.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", errors.New("failed to decrypt value, the encryption key has changed")
+	}
+
+	return string(plaintext), nil
+}
+
+// encryptFields replaces the plaintext values with their encrypted form.
+func encryptFields(store SecretStore, fields []*string) error {
+	for _, field := range fields {
+		if *field == "" || IsEncrypted(*field) {
+			continue
+		}
+
+		encrypted, err := store.Encrypt(*field)
+		if err != nil {
+			return err
+		}
+
+		*field = encrypted
+	}
+
+	return nil
+}
+
+// decryptFields decrypts the values in place. It reports whether some of the
+// values were still stored as plaintext, so the file needs to be rewritten.
+func decryptFields(store SecretStore, fields []*string) (bool, error) {
+	plaintext := false
+
+	for _, field := range fields {
+		if *field == "" {
+			continue
+		}
+
+		if !IsEncrypted(*field) {
+			plaintext = true
+
+			continue
+		}
+
+		decrypted, err := store.Decrypt(*field)
+		if err != nil {
+			return false, err
+		}
+
+		*field = decrypted
+	}
+
+	return plaintext, nil
+}
+
+// decryptSecrets decrypts the secrets of a loaded state file in place and
+// reports whether some of them were still stored as plaintext. The values which
+// cannot be decrypted, because the key was lost or replaced, are cleared with a
+// warning: the rest of the state stays usable and the next save starts over
+// with the current key. The loaded values are registered for redaction.
+func decryptSecrets(file string, fields []*string, secretMaps []map[string]string) bool {
+	defer registerSecrets(fields, secretMaps)
+
+	if !hasEncrypted(fields, secretMaps) {
+		return hasPlaintext(fields, secretMaps)
+	}
+
+	store, err := openSecretStore()
+	if err == nil {
+		var plaintext, plaintextMaps bool
+
+		plaintext, err = decryptFields(store, fields)
+		if err == nil {
+			plaintextMaps, err = decryptMaps(store, secretMaps)
+		}
+
+		if err == nil {
+			return plaintext || plaintextMaps
+		}
+	}
+
+	log.Printf(
+		"Warning: failed to decrypt the secrets in %s, they are ignored: %v\n",
+		file, err,
+	)
+
+	clearEncrypted(fields, secretMaps)
+
+	return false
+}
+
+func hasEncrypted(fields []*string, secretMaps []map[string]string) bool {
+	for _, field := range fields {
+		if IsEncrypted(*field) {
+			return true
+		}
+	}
+
+	for _, m := range secretMaps {
+		for _, value := range m {
+			if IsEncrypted(value) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func hasPlaintext(fields []*string, secretMaps []map[string]string) bool {
+	for _, field := range fields {
+		if *field != "" {
+			return true
+		}
+	}
+
+	for _, m := range secretMaps {
+		if len(m) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func clearEncrypted(fields []*string, secretMaps []map[string]string) {
+	for _, field := range fields {
+		if IsEncrypted(*field) {
+			*field = ""
+		}
+	}
+
+	for _, m := range secretMaps {
+		maps.DeleteFunc(m, func(_, value string) bool {
+			return IsEncrypted(value)
+		})
+	}
+}
+
+// encryptMaps encrypts the map values in place.
+func encryptMaps(store SecretStore, secretMaps []map[string]string) error {
+	for _, m := range secretMaps {
+		for name, value := range m {
+			if err := encryptFields(store, []*string{&value}); err != nil {
+				return err
+			}
+
+			m[name] = value
+		}
+	}
+
+	return nil
+}
+
+// decryptMaps is decryptFields for the map values.
+func decryptMaps(store SecretStore, secretMaps []map[string]string) (bool, error) {
+	plaintext := false
+
+	for _, m := range secretMaps {
+		for name, value := range m {
+			p, err := decryptFields(store, []*string{&value})
+			if err != nil {
+				return false, err
+			}
+
+			plaintext = plaintext || p
+			m[name] = value
+		}
+	}
+
+	return plaintext, nil
+}
+
+func maskMaps(secretMaps []map[string]string) {
+	for _, m := range secretMaps {
+		for name, value := range m {
+			maskFields([]*string{&value})
+			m[name] = value
+		}
+	}
+}
+
+func maskFields(fields []*string) {
+	for _, field := range fields {
+		if *field != "" {
+			*field = redact.Mask
+		}
+	}
+}
+
+func registerSecrets(fields []*string, secretMaps []map[string]string) {
+	for _, field := range fields {
+		redact.Register(*field)
+	}
+
+	for _, m := range secretMaps {
+		for _, value := range m {
+			redact.Register(value)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package gameapctl
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/gameap/gameapctl/internal/pkg/redact"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupStateHome(t *testing.T) string {
+	t.Helper()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	original := keySources
+	keySources = func(dir string) []keySource {
+		return []keySource{fileKeySource{path: filepath.Join(dir, keyFile)}}
+	}
+	t.Cleanup(func() { keySources = original })
+
+	return filepath.Join(home, ".gameapctl")
+}
+
+func TestPanelInstallState_SecretsEncryptedAtRest(t *testing.T) {
+	dir := setupStateHome(t)
+	ctx := context.Background()
+
+	state := PanelInstallState{Host: "example.com", DBPassword: "db-secret", AdminPassword: "admin-secret"}
+	require.NoError(t, SavePanelInstallState(ctx, state))
+
+	raw, err := os.ReadFile(filepath.Join(dir, panelInstallStateFile))
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), "db-secret")
+	assert.NotContains(t, string(raw), "admin-secret")
+
+	var stored PanelInstallState
+	require.NoError(t, json.Unmarshal(raw, &stored))
+	assert.True(t, IsEncrypted(stored.DBPassword))
+	assert.Empty(t, stored.DBRootPassword)
+	assert.Equal(t, "example.com", stored.Host)
+
+	loaded, err := LoadPanelInstallState(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, state, loaded)
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(filepath.Join(dir, keyFile))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+}
+
+func TestPanelInstallState_ACMEEnvEncryptedAtRest(t *testing.T) {
+	dir := setupStateHome(t)
+	ctx := context.Background()
+
+	env := map[string]string{"CF_DNS_API_TOKEN": "cf-secret"}
+	state := PanelInstallState{
+		Host: "example.com",
+		ACME: &PanelACMEState{Challenge: "dns-01", DNSProvider: "cloudflare", Env: env},
+	}
+	require.NoError(t, SavePanelInstallState(ctx, state))
+
+	assert.Equal(t, "cf-secret", env["CF_DNS_API_TOKEN"], "the caller's map must not be encrypted")
+
+	raw, err := os.ReadFile(filepath.Join(dir, panelInstallStateFile))
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), "cf-secret")
+	assert.Contains(t, string(raw), "cloudflare")
+
+	loaded, err := LoadPanelInstallState(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, state, loaded)
+
+	assert.Equal(t, redact.Mask, loaded.Masked().ACME.Env["CF_DNS_API_TOKEN"])
+	assert.Equal(t, "cf-secret", loaded.ACME.Env["CF_DNS_API_TOKEN"], "Masked must not change the original")
+}
+
+func TestLoadDaemonInstallState_MigratesPlaintext(t *testing.T) {
+	dir := setupStateHome(t)
+	ctx := context.Background()
+
+	require.NoError(t, os.MkdirAll(dir, 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, daemonInstallStateFile),
+		[]byte(`{"host": "node1", "connectUrl": "grpc://panel:31718/setup-key"}`), 0600))
+
+	loaded, err := LoadDaemonInstallState(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "grpc://panel:31718/setup-key", loaded.ConnectURL)
+
+	raw, err := os.ReadFile(filepath.Join(dir, daemonInstallStateFile))
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), "setup-key")
+
+	loaded, err = LoadDaemonInstallState(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "grpc://panel:31718/setup-key", loaded.ConnectURL)
+}
+
+func TestLoadPanelInstallState_DoesNotCreateKey(t *testing.T) {
+	dir := setupStateHome(t)
+	ctx := context.Background()
+
+	require.NoError(t, os.MkdirAll(dir, 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, panelInstallStateFile),
+		[]byte(`{"version": "v4", "host": "example.com"}`), 0600))
+
+	loaded, err := LoadPanelInstallState(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "example.com", loaded.Host)
+
+	assert.NoFileExists(t, filepath.Join(dir, keyFile))
+}
+
+func TestLoadPanelInstallState_LostKey(t *testing.T) {
+	dir := setupStateHome(t)
+	ctx := context.Background()
+
+	require.NoError(t, SavePanelInstallState(ctx, PanelInstallState{
+		Host:       "example.com",
+		DBPassword: "db-secret",
+		ACME:       &PanelACMEState{Challenge: "dns-01", Env: map[string]string{"TOKEN": "token"}},
+	}))
+	require.NoError(t, os.Remove(filepath.Join(dir, keyFile)))
+
+	loaded, err := LoadPanelInstallState(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "example.com", loaded.Host)
+	assert.Empty(t, loaded.DBPassword)
+	assert.Empty(t, loaded.ACME.Env)
+	assert.NoFileExists(t, filepath.Join(dir, keyFile), "loading must not replace the lost key")
+
+	// The next save starts over with a new key.
+	loaded.DBPassword = "new-secret"
+	require.NoError(t, SavePanelInstallState(ctx, loaded))
+
+	loaded, err = LoadPanelInstallState(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "new-secret", loaded.DBPassword)
+}
+
+func TestLoadDaemonInstallState_ReplacedKey(t *testing.T) {
+	dir := setupStateHome(t)
+	ctx := context.Background()
+
+	require.NoError(t, SaveDaemonInstallState(ctx, DaemonInstallState{
+		Host:       "node1",
+		ConnectURL: "grpc://panel:31718/setup-key",
+	}))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, keyFile),
+		[]byte(strings.Repeat("ab", keyLength)+"\n"), 0600))
+
+	loaded, err := LoadDaemonInstallState(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "node1", loaded.Host)
+	assert.Empty(t, loaded.ConnectURL)
+}
+
+func TestAESStore_WrongKey(t *testing.T) {
+	store, err := newAESStore([]byte("first key material"))
+	require.NoError(t, err)
+
+	encrypted, err := store.Encrypt("secret")
+	require.NoError(t, err)
+
+	other, err := newAESStore([]byte("second key material"))
+	require.NoError(t, err)
+
+	_, err = other.Decrypt(encrypted)
+	require.Error(t, err)
+
+	plain, err := other.Decrypt("not encrypted")
+	require.NoError(t, err)
+	assert.Equal(t, "not encrypted", plain)
+}
+
+func TestMasked(t *testing.T) {
+	state := PanelInstallState{Host: "example.com", DBPassword: "secret"}
+
+	masked := state.Masked()
+
+	assert.Equal(t, "[REDACTED]", masked.DBPassword)
+	assert.Empty(t, masked.AdminPassword)
+	assert.Equal(t, "secret", state.DBPassword)
+}
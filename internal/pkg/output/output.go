@@ -0,0 +1,109 @@
+// Package output renders command results in the format selected by the global
+// --output flag. Structured formats are derived from the json tags of the
+// result types, so JSON and YAML always share the same schema.
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+type Format string
+
+const (
+	FormatText Format = "text"
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+)
+
+// FlagName is the name of the global flag selecting the output format.
+const FlagName = "output"
+
+// TextWriter is implemented by results that have a human-readable representation.
+// Results without it are printed as their structured fields in text mode.
+type TextWriter interface {
+	WriteText(w io.Writer) error
+}
+
+func ParseFormat(s string) (Format, error) {
+	switch Format(strings.ToLower(strings.TrimSpace(s))) {
+	case "", FormatText:
+		return FormatText, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	case FormatYAML, "yml":
+		return FormatYAML, nil
+	default:
+		return "", errors.Errorf(
+			"unknown output format %q (expected %q, %q or %q)", s, FormatText, FormatJSON, FormatYAML,
+		)
+	}
+}
+
+// FormatFromContext returns the format selected by the global flag. An invalid
+// value is rejected when the application starts, so it falls back to text here.
+func FormatFromContext(cliCtx *cli.Context) Format {
+	format, err := ParseFormat(cliCtx.String(FlagName))
+	if err != nil {
+		return FormatText
+	}
+
+	return format
+}
+
+// IsStructured reports whether the output is meant for machines. Commands must
+// keep stdout free of anything but the rendered result in that case.
+func (f Format) IsStructured() bool {
+	return f == FormatJSON || f == FormatYAML
+}
+
+// Print renders the result to stdout in the format selected by the global flag.
+func Print(cliCtx *cli.Context, result any) error {
+	return Write(os.Stdout, FormatFromContext(cliCtx), result)
+}
+
+func Write(w io.Writer, format Format, result any) error {
+	switch format {
+	case FormatJSON:
+		b, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal json")
+		}
+
+		_, err = fmt.Fprintln(w, string(b))
+
+		return errors.Wrap(err, "failed to write output")
+	case FormatYAML:
+		b, err := json.Marshal(result)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal json")
+		}
+
+		// Converting from JSON keeps key names and order identical in both formats.
+		y, err := yaml.JSONToYAML(b)
+		if err != nil {
+			return errors.Wrap(err, "failed to convert json to yaml")
+		}
+
+		_, err = w.Write(y)
+
+		return errors.Wrap(err, "failed to write output")
+	case FormatText:
+		if tw, ok := result.(TextWriter); ok {
+			return tw.WriteText(w)
+		}
+
+		_, err := fmt.Fprintf(w, "%+v\n", result)
+
+		return errors.Wrap(err, "failed to write output")
+	default:
+		return errors.Errorf("unknown output format %q", format)
+	}
+}
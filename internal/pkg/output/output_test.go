@@ -0,0 +1,77 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testResult struct {
+	State   string `json:"state"`
+	PID     int32  `json:"pid,omitempty"`
+	Version string `json:"version"`
+}
+
+func (r testResult) WriteText(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "state is %s\n", r.State)
+
+	return err
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Format
+	}{
+		{"", FormatText},
+		{"text", FormatText},
+		{"JSON", FormatJSON},
+		{"yaml", FormatYAML},
+		{"yml", FormatYAML},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			format, err := ParseFormat(test.input)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, format)
+		})
+	}
+}
+
+func TestParseFormat_Unknown(t *testing.T) {
+	_, err := ParseFormat("xml")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown output format")
+}
+
+func TestWrite_JSON(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := Write(&buf, FormatJSON, testResult{State: "running", PID: 42, Version: "v4.2.0"})
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{"state":"running","pid":42,"version":"v4.2.0"}`, buf.String())
+}
+
+func TestWrite_YAMLUsesJSONKeys(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := Write(&buf, FormatYAML, testResult{State: "stopped", Version: "v4.2.0"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "state: stopped\nversion: v4.2.0\n", buf.String())
+}
+
+func TestWrite_TextUsesTextWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := Write(&buf, FormatText, testResult{State: "running"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "state is running\n", buf.String())
+}
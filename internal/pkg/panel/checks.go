@@ -0,0 +1,217 @@
+package panel
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	daemonpkg "github.com/gameap/gameapctl/internal/pkg/daemon"
+	"github.com/gameap/gameapctl/internal/pkg/doctor"
+	"github.com/gameap/gameapctl/pkg/fixer"
+	"github.com/gameap/gameapctl/pkg/gameap"
+	"github.com/gameap/gameapctl/pkg/oscore"
+	panelsvc "github.com/gameap/gameapctl/pkg/panel"
+	"github.com/gameap/gameapctl/pkg/utils"
+)
+
+const (
+	panelProcessName   = "gameap"
+	healthCheckTimeout = 10 * time.Second
+)
+
+// Checks returns the diagnostics for the panel installation at the given paths.
+// Checks that need the config file report skip when it cannot be read, the
+// config check itself reports the failure.
+func Checks(paths gameap.PanelPaths) []doctor.Check {
+	cfg := &panelConfig{paths: paths}
+
+	return []doctor.Check{
+		{
+			ID:        "panel.binary",
+			Component: doctor.ComponentPanel,
+			Title:     "Panel binary",
+			Hint:      "Install the panel with 'gameapctl panel install'.",
+			Run: func(_ context.Context) doctor.Outcome {
+				if err := CheckBinaryInstalled(paths); err != nil {
+					return doctor.Fail("%s", err.Error())
+				}
+
+				return doctor.Pass("found at %s", paths.BinaryPath)
+			},
+		},
+		{
+			ID:        "panel.config",
+			Component: doctor.ComponentPanel,
+			Title:     "Panel config",
+			Hint:      "Reinstall the panel or restore config.env from a backup.",
+			Run:       cfg.check,
+		},
+		{
+			ID:        "panel.process",
+			Component: doctor.ComponentPanel,
+			Title:     "Panel process",
+			Hint:      "Start the panel with 'gameapctl panel start'.",
+			Run:       checkProcess,
+			Fix: &fixer.Item{
+				Name:      "Start panel",
+				Condition: func(_ context.Context) (bool, error) { return true, nil },
+				FixFunc: func(ctx context.Context) error {
+					if err := panelsvc.Start(ctx, panelsvc.Options{Scope: paths.Scope}); err != nil {
+						return err
+					}
+
+					_, err := oscore.WaitForProcessByName(ctx, panelProcessName)
+
+					return err
+				},
+			},
+		},
+		{
+			ID:        "panel.http-port",
+			Component: doctor.ComponentPanel,
+			Title:     "HTTP port",
+			Hint:      "Stop the process holding the port or change HTTP_PORT in config.env.",
+			Run:       cfg.checkHTTPPort,
+		},
+		{
+			ID:        "panel.health",
+			Component: doctor.ComponentPanel,
+			Title:     "Panel health",
+			Hint:      "Check the panel logs, then restart it with 'gameapctl panel restart'.",
+			Run:       cfg.checkHealth,
+		},
+		{
+			ID:        "panel.grpc",
+			Component: doctor.ComponentPanel,
+			Title:     "Panel gRPC",
+			Hint:      "Make sure GRPC_PORT is not blocked and restart the panel.",
+			Run:       cfg.checkGRPC,
+		},
+	}
+}
+
+type panelConfig struct {
+	paths  gameap.PanelPaths
+	values map[string]string
+	err    error
+	loaded bool
+}
+
+func (c *panelConfig) load() (map[string]string, error) {
+	if !c.loaded {
+		c.values, c.err = panelsvc.ReadConfigValues(c.paths.ConfigFilePath)
+		c.loaded = true
+	}
+
+	return c.values, c.err
+}
+
+func (c *panelConfig) value(key string) string {
+	values, _ := c.load()
+
+	return strings.TrimSpace(values[key])
+}
+
+func (c *panelConfig) httpPort() string {
+	values, _ := c.load()
+
+	return HTTPPort(c.paths, values)
+}
+
+func (c *panelConfig) check(_ context.Context) doctor.Outcome {
+	values, err := c.load()
+	if err != nil {
+		return doctor.Fail("%s", err.Error())
+	}
+
+	var missing []string
+	for _, key := range []string{"DATABASE_DRIVER", "DATABASE_URL", "ENCRYPTION_KEY", "AUTH_SECRET"} {
+		if strings.TrimSpace(values[key]) == "" {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return doctor.Fail("%s is missing %s", c.paths.ConfigFilePath, strings.Join(missing, ", "))
+	}
+
+	return doctor.Pass("%s", c.paths.ConfigFilePath)
+}
+
+func checkProcess(ctx context.Context) doctor.Outcome {
+	pr, err := oscore.FindProcessByName(ctx, panelProcessName)
+	if err != nil {
+		return doctor.Fail("failed to find gameap process: %s", err.Error())
+	}
+	if pr == nil {
+		return doctor.Fail("gameap process is not running")
+	}
+
+	return doctor.Pass("running (pid %d)", pr.Pid)
+}
+
+// checkHTTPPort mirrors the installer check: a port held by another process
+// prevents the panel from starting. A running panel holds the port itself.
+func (c *panelConfig) checkHTTPPort(ctx context.Context) doctor.Outcome {
+	if _, err := c.load(); err != nil {
+		return doctor.Skip("config file is not readable")
+	}
+
+	port := c.httpPort()
+
+	pr, err := oscore.FindProcessByName(ctx, panelProcessName)
+	if err == nil && pr != nil {
+		return doctor.Pass("port %s is used by the panel", port)
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(c.value("HTTP_HOST"), port))
+	if err != nil {
+		return doctor.Fail("port %s is already in use by another process", port)
+	}
+	_ = listener.Close()
+
+	return doctor.Pass("port %s is available", port)
+}
+
+func (c *panelConfig) checkHealth(ctx context.Context) doctor.Outcome {
+	if _, err := c.load(); err != nil {
+		return doctor.Skip("config file is not readable")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	port := c.httpPort()
+
+	if err := CheckInstallationV4(ctx, "127.0.0.1", port, false); err != nil {
+		return doctor.Fail("health check on port %s failed: %s", port, err.Error())
+	}
+
+	return doctor.Pass("responding on port %s", port)
+}
+
+func (c *panelConfig) checkGRPC(_ context.Context) doctor.Outcome {
+	if _, err := c.load(); err != nil {
+		return doctor.Skip("config file is not readable")
+	}
+
+	if c.value("GRPC_ENABLED") != "true" {
+		return doctor.Skip("gRPC is disabled")
+	}
+
+	port := c.value("GRPC_PORT")
+	if port == "" {
+		port = gameap.DefaultGRPCPort
+	}
+
+	if err := daemonpkg.CheckGRPCConnectivity(net.JoinHostPort("127.0.0.1", port)); err != nil {
+		return doctor.Fail("%s", err.Error())
+	}
+
+	return doctor.Pass("listening on port %s", port)
+}
+
+// IsInstalled reports whether there is a panel installation to diagnose at the given paths.
+func IsInstalled(paths gameap.PanelPaths) bool {
+	return utils.IsFileExists(paths.BinaryPath) || utils.IsFileExists(paths.ConfigFilePath)
+}
@@ -0,0 +1,117 @@
+package panel
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"strings"
+
+	_ "github.com/go-sql-driver/mysql" // DATABASE_DRIVER=mysql
+	_ "github.com/jackc/pgx/v5/stdlib" // DATABASE_DRIVER=postgres
+	"github.com/pkg/errors"
+	_ "modernc.org/sqlite" // DATABASE_DRIVER=sqlite
+)
+
+const (
+	DatabaseMySQL    = "mysql"
+	DatabasePostgres = "postgres"
+	DatabaseSQLite   = "sqlite"
+)
+
+// DatabaseDriver normalizes DATABASE_DRIVER of config.env, the panel accepts
+// the aliases of PostgreSQL.
+func DatabaseDriver(driver string) (string, error) {
+	switch driver {
+	case DatabaseMySQL:
+		return DatabaseMySQL, nil
+	case DatabasePostgres, "postgresql", "pgsql":
+		return DatabasePostgres, nil
+	case DatabaseSQLite:
+		return DatabaseSQLite, nil
+	default:
+		return "", errors.Errorf("unsupported database driver: %s", driver)
+	}
+}
+
+// OpenDatabase connects to the panel database described by DATABASE_DRIVER and
+// DATABASE_URL of config.env.
+func OpenDatabase(ctx context.Context, driver, dsn string) (*sql.DB, error) {
+	driver, err := DatabaseDriver(driver)
+	if err != nil {
+		return nil, err
+	}
+
+	var driverName string
+
+	switch driver {
+	case DatabaseMySQL:
+		driverName = "mysql"
+		if !strings.Contains(dsn, "parseTime=") {
+			if strings.Contains(dsn, "?") {
+				dsn += "&parseTime=true"
+			} else {
+				dsn += "?parseTime=true"
+			}
+		}
+	case DatabasePostgres:
+		driverName = "pgx"
+	case DatabaseSQLite:
+		driverName = "sqlite"
+	}
+
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to open database connection")
+	}
+
+	// Test the connection
+	err = db.PingContext(ctx)
+	if err != nil {
+		closeErr := db.Close()
+		if closeErr != nil {
+			log.Printf("Failed to close database connection: %v\n", closeErr)
+		}
+
+		return nil, errors.WithMessage(err, "failed to ping database")
+	}
+
+	return db, nil
+}
+
+// OpenConfiguredDatabase connects to the database config.env at configPath
+// points at. It returns the normalized driver with the connection.
+func OpenConfiguredDatabase(ctx context.Context, configPath string) (*sql.DB, string, error) {
+	_, values, err := ReadConfigEnv(configPath)
+	if err != nil {
+		return nil, "", errors.WithMessage(err, "failed to parse config file")
+	}
+
+	if values["DATABASE_DRIVER"] == "" {
+		return nil, "", errors.New("DATABASE_DRIVER not found in config.env")
+	}
+	if values["DATABASE_URL"] == "" {
+		return nil, "", errors.New("DATABASE_URL not found in config.env")
+	}
+
+	driver, err := DatabaseDriver(values["DATABASE_DRIVER"])
+	if err != nil {
+		return nil, "", err
+	}
+
+	log.Printf("Connecting to database (driver: %s)...\n", driver)
+
+	db, err := OpenDatabase(ctx, driver, values["DATABASE_URL"])
+	if err != nil {
+		return nil, "", errors.WithMessage(err, "failed to connect to database")
+	}
+
+	return db, driver, nil
+}
+
+// SQLitePath returns the database file of a SQLite DATABASE_URL such as
+// "file:/var/lib/gameap/database.sqlite?_busy_timeout=5000".
+func SQLitePath(dsn string) string {
+	path, _, _ := strings.Cut(strings.TrimPrefix(dsn, "file:"), "?")
+
+	return path
+}
@@ -0,0 +1,54 @@
+package panel
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gameap/gameapctl/pkg/gameap"
+	"github.com/pkg/errors"
+)
+
+const (
+	healthCheckRetries = 10
+	healthCheckDelay   = 3 * time.Second
+)
+
+// HTTPPort returns the port the panel listens on according to config.env.
+func HTTPPort(paths gameap.PanelPaths, values map[string]string) string {
+	if port := strings.TrimSpace(values["HTTP_PORT"]); port != "" {
+		return port
+	}
+
+	if gameap.ScopeOrDefault(paths.Scope) == gameap.ScopeUser {
+		return "8025"
+	}
+
+	return "80"
+}
+
+// WaitHealthy polls the local panel until it responds, a freshly started panel
+// needs a few seconds to run its migrations.
+func WaitHealthy(ctx context.Context, port string) error {
+	for i := 0; i < healthCheckRetries; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(healthCheckDelay):
+			}
+		}
+
+		err := CheckInstallationV4(ctx, "127.0.0.1", port, false)
+		if err == nil {
+			log.Println("Health check passed!")
+
+			return nil
+		}
+
+		log.Printf("Health check attempt %d/%d failed: %v\n", i+1, healthCheckRetries, err)
+	}
+
+	return errors.Errorf("the panel does not respond on port %s, check the panel logs", port)
+}
@@ -10,6 +10,7 @@ import (
 	"net/url"
 	"os"
 
+	"github.com/gameap/gameapctl/pkg/dryrun"
 	"github.com/gameap/gameapctl/pkg/gameap"
 	"github.com/gameap/gameapctl/pkg/oscore"
 	packagemanager "github.com/gameap/gameapctl/pkg/package_manager"
@@ -82,6 +83,13 @@ func SetupGameAPFromGithubV4(
 		return err
 	}
 
+	if dryrun.Record(
+		ctx, dryrun.KindCommand, "git clone -b %s %s, build styles and build gameap to %s",
+		branch, gameap.GithubRepositoryPanelV4, outputPath,
+	) {
+		return nil
+	}
+
 	path, err := os.MkdirTemp("", "gameapctl")
 	if err != nil {
 		return errors.WithMessage(err, "failed to create temp dir")
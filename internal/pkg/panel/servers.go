@@ -0,0 +1,27 @@
+package panel
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// RunningServers returns the names of the game servers the panel reports as
+// running, the daemons keep their process state up to date. A non-zero nodeID
+// limits them to the servers of the node.
+func RunningServers(ctx context.Context, q Querier, driver string, nodeID uint) ([]string, error) {
+	query := "SELECT name FROM servers WHERE process_active = TRUE"
+	args := []any{}
+
+	if nodeID != 0 {
+		query += " AND ds_id = " + Placeholder(driver, 1)
+		args = append(args, nodeID)
+	}
+
+	names, err := queryStrings(ctx, q, query, args...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to list running game servers")
+	}
+
+	return names, nil
+}
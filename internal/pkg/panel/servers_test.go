@@ -0,0 +1,33 @@
+package panel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunningServers_SQLite(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := OpenDatabase(ctx, DatabaseSQLite, "file:"+t.TempDir()+"/database.sqlite")
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	for _, query := range []string{
+		"CREATE TABLE servers (id INTEGER PRIMARY KEY, ds_id INTEGER, name TEXT, process_active BOOLEAN)",
+		"INSERT INTO servers (ds_id, name, process_active) VALUES (1, 'cs', 1), (1, 'rust', 0), (2, 'minecraft', 1)",
+	} {
+		_, err := db.ExecContext(ctx, query)
+		require.NoError(t, err)
+	}
+
+	names, err := RunningServers(ctx, db, DatabaseSQLite, 0)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"cs", "minecraft"}, names)
+
+	names, err = RunningServers(ctx, db, DatabaseSQLite, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cs"}, names)
+}
@@ -0,0 +1,254 @@
+package panel
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Querier is implemented by *sql.DB, *sql.Conn and *sql.Tx.
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// ListTables returns the tables of the panel database, ordered so that
+// referenced tables come before the tables referencing them.
+func ListTables(ctx context.Context, q Querier, driver string) ([]string, error) {
+	var tablesQuery, referencesQuery string
+
+	switch driver {
+	case DatabaseMySQL:
+		tablesQuery = "SELECT table_name FROM information_schema.tables " +
+			"WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE'"
+		referencesQuery = "SELECT table_name, referenced_table_name FROM information_schema.referential_constraints " +
+			"WHERE constraint_schema = DATABASE()"
+	case DatabasePostgres:
+		tablesQuery = "SELECT tablename FROM pg_tables WHERE schemaname = current_schema()"
+		referencesQuery = "SELECT c.relname, r.relname FROM pg_constraint f " +
+			"JOIN pg_class c ON c.oid = f.conrelid " +
+			"JOIN pg_class r ON r.oid = f.confrelid " +
+			"JOIN pg_namespace n ON n.oid = c.relnamespace " +
+			"WHERE f.contype = 'f' AND n.nspname = current_schema()"
+	case DatabaseSQLite:
+		tablesQuery = "SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'"
+		referencesQuery = "SELECT m.name, p.\"table\" FROM sqlite_master m " +
+			"JOIN pragma_foreign_key_list(m.name) p WHERE m.type = 'table'"
+	default:
+		return nil, errors.Errorf("unsupported database driver: %s", driver)
+	}
+
+	tables, err := queryStrings(ctx, q, tablesQuery)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to list tables")
+	}
+
+	rows, err := q.QueryContext(ctx, referencesQuery)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list foreign keys")
+	}
+	defer func() { _ = rows.Close() }()
+
+	references := make(map[string][]string)
+
+	for rows.Next() {
+		var table, referenced string
+		if err := rows.Scan(&table, &referenced); err != nil {
+			return nil, errors.Wrap(err, "failed to scan foreign key")
+		}
+
+		references[table] = append(references[table], referenced)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to list foreign keys")
+	}
+
+	return sortTables(tables, references), nil
+}
+
+// sortTables orders the tables topologically by their references. Tables in
+// a reference cycle keep the alphabetical order.
+func sortTables(tables []string, references map[string][]string) []string {
+	slices.Sort(tables)
+
+	sorted := make([]string, 0, len(tables))
+	state := make(map[string]int, len(tables))
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+
+	var visit func(table string)
+	visit = func(table string) {
+		if state[table] != 0 {
+			return
+		}
+		state[table] = visiting
+
+		referenced := slices.Clone(references[table])
+		slices.Sort(referenced)
+
+		for _, r := range referenced {
+			if r != table && slices.Contains(tables, r) {
+				visit(r)
+			}
+		}
+
+		state[table] = visited
+		sorted = append(sorted, table)
+	}
+
+	for _, table := range tables {
+		visit(table)
+	}
+
+	return sorted
+}
+
+// DeferForeignKeys relaxes the foreign key checks for the rest of the
+// transaction, tables referencing each other in a cycle cannot be ordered.
+// PostgreSQL checks stay, the tables are loaded in the ListTables order.
+func DeferForeignKeys(ctx context.Context, tx *sql.Tx, driver string) error {
+	var query string
+
+	switch driver {
+	case DatabaseMySQL:
+		query = "SET FOREIGN_KEY_CHECKS=0"
+	case DatabaseSQLite:
+		query = "PRAGMA defer_foreign_keys = ON"
+	default:
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, query)
+
+	return errors.Wrap(err, "failed to disable foreign key checks")
+}
+
+// RestoreForeignKeys enables the checks disabled by DeferForeignKeys, the
+// MySQL setting outlives the transaction on the pooled connection.
+func RestoreForeignKeys(ctx context.Context, tx *sql.Tx, driver string) error {
+	if driver != DatabaseMySQL {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS=1")
+
+	return errors.Wrap(err, "failed to enable foreign key checks")
+}
+
+// ResetSequences moves the PostgreSQL serial sequences past the inserted ids,
+// otherwise the next insert collides with a copied row. MySQL and SQLite move
+// their counters on inserts with explicit ids.
+func ResetSequences(ctx context.Context, q Querier, driver string) error {
+	if driver != DatabasePostgres {
+		return nil
+	}
+
+	rows, err := q.QueryContext(ctx,
+		"SELECT table_name, column_name, pg_get_serial_sequence(quote_ident(table_name), column_name) "+
+			"FROM information_schema.columns "+
+			"WHERE table_schema = current_schema() "+
+			"AND pg_get_serial_sequence(quote_ident(table_name), column_name) IS NOT NULL",
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to list sequences")
+	}
+
+	type sequence struct {
+		table, column, name string
+	}
+
+	var sequences []sequence
+
+	for rows.Next() {
+		var s sequence
+		if err := rows.Scan(&s.table, &s.column, &s.name); err != nil {
+			_ = rows.Close()
+
+			return errors.Wrap(err, "failed to scan sequence")
+		}
+
+		sequences = append(sequences, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+
+		return errors.Wrap(err, "failed to list sequences")
+	}
+	_ = rows.Close()
+
+	for _, s := range sequences {
+		_, err := q.ExecContext(ctx, fmt.Sprintf( //nolint:gosec
+			"SELECT setval($1, COALESCE((SELECT MAX(%s) FROM %s), 0) + 1, false)",
+			QuoteIdent(driver, s.column), QuoteIdent(driver, s.table),
+		), s.name)
+		if err != nil {
+			return errors.Wrapf(err, "failed to reset sequence %s", s.name)
+		}
+	}
+
+	return nil
+}
+
+// QuoteIdent quotes a table or column name for the driver.
+func QuoteIdent(driver, name string) string {
+	if driver == DatabaseMySQL {
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	}
+
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// Placeholder returns the n-th (1-based) query parameter for the driver.
+func Placeholder(driver string, n int) string {
+	if driver == DatabasePostgres {
+		return fmt.Sprintf("$%d", n)
+	}
+
+	return "?"
+}
+
+// InsertQuery builds an INSERT of one row into the columns of the table.
+func InsertQuery(driver, table string, columns []string) string {
+	quoted := make([]string, 0, len(columns))
+	placeholders := make([]string, 0, len(columns))
+
+	for i, column := range columns {
+		quoted = append(quoted, QuoteIdent(driver, column))
+		placeholders = append(placeholders, Placeholder(driver, i+1))
+	}
+
+	return fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES (%s)",
+		QuoteIdent(driver, table), strings.Join(quoted, ", "), strings.Join(placeholders, ", "),
+	)
+}
+
+func queryStrings(ctx context.Context, q Querier, query string, args ...any) ([]string, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query")
+	}
+	defer func() { _ = rows.Close() }()
+
+	var result []string
+
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, errors.Wrap(err, "failed to scan")
+		}
+
+		result = append(result, s)
+	}
+
+	return result, errors.Wrap(rows.Err(), "failed to read rows")
+}
@@ -0,0 +1,52 @@
+package panel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortTables(t *testing.T) {
+	tables := []string{"servers", "games", "users", "server_user", "nodes", "a", "b"}
+	references := map[string][]string{
+		"servers":     {"games", "nodes"},
+		"server_user": {"servers", "users"},
+		"nodes":       {"nodes"},
+		"a":           {"b"},
+		"b":           {"a"},
+	}
+
+	got := sortTables(tables, references)
+
+	assert.Equal(t, []string{"b", "a", "games", "nodes", "servers", "users", "server_user"}, got)
+}
+
+func TestListTables_SQLite(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := OpenDatabase(ctx, DatabaseSQLite, "file:"+t.TempDir()+"/database.sqlite")
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	for _, query := range []string{
+		"CREATE TABLE servers (id INTEGER PRIMARY KEY AUTOINCREMENT, game_id INTEGER REFERENCES games (id))",
+		"CREATE TABLE games (id INTEGER PRIMARY KEY)",
+		"CREATE TABLE users (id INTEGER PRIMARY KEY)",
+	} {
+		_, err := db.ExecContext(ctx, query)
+		require.NoError(t, err)
+	}
+
+	tables, err := ListTables(ctx, db, DatabaseSQLite)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"games", "servers", "users"}, tables)
+}
+
+func TestInsertQuery(t *testing.T) {
+	assert.Equal(t, "INSERT INTO `users` (`id`, `login`) VALUES (?, ?)",
+		InsertQuery(DatabaseMySQL, "users", []string{"id", "login"}))
+	assert.Equal(t, `INSERT INTO "users" ("id", "login") VALUES ($1, $2)`,
+		InsertQuery(DatabasePostgres, "users", []string{"id", "login"}))
+}
@@ -0,0 +1,12 @@
+package panel
+
+import "strings"
+
+// LockedPasswordPrefix makes the bcrypt hash of a disabled user match no
+// password, like '!' in /etc/shadow. Removing it restores the old password.
+const LockedPasswordPrefix = "!"
+
+// IsPasswordLocked reports whether the password hash was locked by 'panel user disable'.
+func IsPasswordLocked(hash string) bool {
+	return strings.HasPrefix(hash, LockedPasswordPrefix)
+}
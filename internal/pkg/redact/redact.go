@@ -0,0 +1,93 @@
+// Package redact removes credentials from configs and logs before they leave
+// the host. Rules are chosen by the file name: key based rules understand the
+// env, JSON and YAML formats, pattern rules apply to every text file.
+package redact
+
+import (
+	"bytes"
+	"path"
+	"strings"
+)
+
+// Mask replaces the redacted values.
+const Mask = "[REDACTED]"
+
+// Rule redacts secrets in the content of one file.
+type Rule interface {
+	// Applies reports whether the rule handles the file with the given name.
+	Applies(name string) bool
+	// Redact returns the content with the secrets replaced by Mask and the
+	// number of replaced values.
+	Redact(content []byte) ([]byte, int)
+}
+
+type Redactor struct {
+	rules []Rule
+}
+
+func New(rules ...Rule) *Redactor {
+	return &Redactor{rules: rules}
+}
+
+// Default returns the redactor for the files collected from a GameAP host.
+func Default() *Redactor {
+	return New(EnvRule{}, JSONRule{}, YAMLRule{}, URLRule{})
+}
+
+// Redact applies the rules matching the file name. Binary content is returned
+// unchanged.
+func (r *Redactor) Redact(name string, content []byte) ([]byte, int) {
+	if bytes.IndexByte(content, 0) >= 0 {
+		return content, 0
+	}
+
+	total := 0
+
+	for _, rule := range r.rules {
+		if !rule.Applies(name) {
+			continue
+		}
+
+		var n int
+		content, n = rule.Redact(content)
+		total += n
+	}
+
+	return content, total
+}
+
+// secretMarkers are matched against the key lowercased and without
+// separators, so DBPassword, db_password and db-password are all secrets.
+var secretMarkers = []string{
+	"password", "passwd", "secret", "token", "apikey", "privatekey", "encryptionkey", "credential",
+}
+
+// IsSecretKey reports whether the key names a credential. Keys naming a file
+// or a path to a secret (private_key_file) are not secrets themselves.
+func IsSecretKey(key string) bool {
+	normalized := strings.ToLower(strings.NewReplacer("_", "", "-", "", ".", "", " ", "").Replace(key))
+
+	if strings.HasSuffix(normalized, "file") || strings.HasSuffix(normalized, "path") {
+		return false
+	}
+
+	for _, marker := range secretMarkers {
+		if strings.Contains(normalized, marker) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasExt(name string, exts ...string) bool {
+	ext := strings.ToLower(path.Ext(strings.ReplaceAll(name, `\`, "/")))
+
+	for _, e := range exts {
+		if ext == e {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,59 @@
+package redact
+
+import (
+	"cmp"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// minValueLength keeps very short values, which would mask unrelated text,
+// out of the known secrets.
+const minValueLength = 4
+
+var (
+	valuesMu sync.RWMutex
+	values   []string
+)
+
+// Register adds secrets known to the process, such as the values of secret
+// flags. String masks them wherever they appear.
+func Register(secrets ...string) {
+	valuesMu.Lock()
+	defer valuesMu.Unlock()
+
+	for _, secret := range secrets {
+		if len(secret) >= minValueLength && !slices.Contains(values, secret) {
+			values = append(values, secret)
+		}
+	}
+
+	// Longer values first, so a secret containing another one is masked whole.
+	slices.SortFunc(values, func(a, b string) int { return cmp.Compare(len(b), len(a)) })
+}
+
+var flagValue = regexp.MustCompile(`(--?([A-Za-z][A-Za-z0-9_-]*)=)("[^"]*"|'[^']*'|\S+)`)
+
+// String masks the registered secrets, the values of secret flags passed as
+// --name=value and the passwords of URLs in a command line or a log message.
+func String(s string) string {
+	valuesMu.RLock()
+	for _, v := range values {
+		s = strings.ReplaceAll(s, v, Mask)
+	}
+	valuesMu.RUnlock()
+
+	s = flagValue.ReplaceAllStringFunc(s, func(flag string) string {
+		m := flagValue.FindStringSubmatch(flag)
+		if !IsSecretKey(m[2]) || !maskable(m[3]) {
+			return flag
+		}
+
+		return m[1] + Mask
+	})
+
+	content, _ := URLRule{}.Redact([]byte(s))
+
+	return string(content)
+}
@@ -0,0 +1,320 @@
+// Package releasehistory keeps the binaries and config files replaced by
+// upgrades, indexed by release tag, so an installation can be rolled back to
+// an earlier release long after the upgrade.
+package releasehistory
+
+import (
+	"context"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gameap/gameapctl/pkg/dryrun"
+	"github.com/gameap/gameapctl/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// DefaultKeep is the number of releases kept by default.
+const DefaultKeep = 3
+
+const (
+	snapshotFile = "snapshot.json"
+	unknownTag   = "unknown"
+)
+
+var ErrNotFound = errors.New("release snapshot not found")
+
+var unsafeNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// File is a file of a snapshot.
+type File struct {
+	// Name is the file name in the snapshot directory.
+	Name string `json:"name"`
+	// Path is where the file is restored to.
+	Path string `json:"path"`
+}
+
+// Snapshot is a release binary with the config files it ran with.
+type Snapshot struct {
+	Tag       string    `json:"tag"`
+	CreatedAt time.Time `json:"createdAt"`
+	Binary    File      `json:"binary"`
+	Configs   []File    `json:"configs,omitempty"`
+
+	dir string
+}
+
+// History keeps the snapshots of a component in a directory, one
+// subdirectory per release tag.
+type History struct {
+	dir  string
+	keep int
+}
+
+// New returns the history kept in dir. Saving a snapshot removes the oldest
+// ones beyond keep, DefaultKeep when keep is zero.
+func New(dir string, keep int) *History {
+	if keep <= 0 {
+		keep = DefaultKeep
+	}
+
+	return &History{dir: dir, keep: keep}
+}
+
+func (h *History) Dir() string {
+	return h.dir
+}
+
+// Name returns the tag of the snapshot, "unknown" when the installed version
+// was not known.
+func (s Snapshot) Name() string {
+	if s.Tag == "" {
+		return unknownTag
+	}
+
+	return s.Tag
+}
+
+// Save copies the binary and the existing config files to the snapshot of the
+// tag, replacing an older snapshot of the same tag.
+func (h *History) Save(ctx context.Context, tag, binaryPath string, configPaths ...string) error {
+	if err := h.save(ctx, tag, binaryPath, configPaths...); err != nil {
+		return err
+	}
+
+	return h.prune(ctx)
+}
+
+// Rollback keeps the installed release as currentTag, so the rollback can be
+// undone, and restores the snapshot. The config files of the snapshot are
+// restored only with withConfigs, the installed ones are kept either way.
+func (h *History) Rollback(ctx context.Context, snapshot Snapshot, currentTag string, withConfigs bool) error {
+	if dirName(snapshot.Name()) == dirName((Snapshot{Tag: currentTag}).Name()) {
+		return errors.Errorf("release %s is installed already", snapshot.Name())
+	}
+
+	configPaths := make([]string, 0, len(snapshot.Configs))
+	for _, f := range snapshot.Configs {
+		configPaths = append(configPaths, f.Path)
+	}
+
+	// The snapshot may be the oldest one, it is pruned only after the restore.
+	if err := h.save(ctx, currentTag, snapshot.Binary.Path, configPaths...); err != nil {
+		return errors.WithMessage(err, "failed to keep the installed release")
+	}
+
+	if !withConfigs {
+		snapshot.Configs = nil
+	}
+
+	if err := h.Restore(ctx, snapshot); err != nil {
+		return err
+	}
+
+	return h.prune(ctx)
+}
+
+func (h *History) save(ctx context.Context, tag, binaryPath string, configPaths ...string) error {
+	snapshot := Snapshot{
+		Tag:       tag,
+		CreatedAt: time.Now(),
+		Binary:    File{Name: filepath.Base(binaryPath), Path: binaryPath},
+	}
+
+	dir := filepath.Join(h.dir, dirName(snapshot.Name()))
+
+	if dryrun.Record(ctx, dryrun.KindFile, "keep %s of release %s in %s", binaryPath, snapshot.Name(), dir) {
+		return nil
+	}
+
+	tmpDir := dir + ".tmp"
+	if err := os.RemoveAll(tmpDir); err != nil {
+		return errors.Wrap(err, "failed to remove temporary snapshot")
+	}
+	if err := os.MkdirAll(tmpDir, 0o700); err != nil {
+		return errors.Wrap(err, "failed to create snapshot directory")
+	}
+
+	if err := utils.Copy(binaryPath, filepath.Join(tmpDir, snapshot.Binary.Name)); err != nil {
+		return errors.Wrap(err, "failed to copy binary")
+	}
+
+	for _, p := range configPaths {
+		if p == "" || !utils.IsFileExists(p) {
+			continue
+		}
+
+		f := File{Name: "config-" + filepath.Base(p), Path: p}
+		if err := utils.Copy(p, filepath.Join(tmpDir, f.Name)); err != nil {
+			return errors.Wrapf(err, "failed to copy config %s", p)
+		}
+		snapshot.Configs = append(snapshot.Configs, f)
+	}
+
+	b, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal json")
+	}
+
+	if err := os.WriteFile(filepath.Join(tmpDir, snapshotFile), b, 0o600); err != nil {
+		return errors.Wrap(err, "failed to write snapshot")
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return errors.Wrap(err, "failed to remove previous snapshot")
+	}
+	if err := os.Rename(tmpDir, dir); err != nil {
+		return errors.Wrap(err, "failed to save snapshot")
+	}
+
+	return nil
+}
+
+// List returns the snapshots, the newest first.
+func (h *History) List() ([]Snapshot, error) {
+	entries, err := os.ReadDir(h.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read release history")
+	}
+
+	snapshots := make([]Snapshot, 0, len(entries))
+
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasSuffix(entry.Name(), ".tmp") {
+			continue
+		}
+
+		dir := filepath.Join(h.dir, entry.Name())
+
+		b, err := os.ReadFile(filepath.Join(dir, snapshotFile))
+		if err != nil {
+			continue
+		}
+
+		var snapshot Snapshot
+		if err := json.Unmarshal(b, &snapshot); err != nil {
+			continue
+		}
+		snapshot.dir = dir
+
+		snapshots = append(snapshots, snapshot)
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.After(snapshots[j].CreatedAt)
+	})
+
+	return snapshots, nil
+}
+
+// Find returns the snapshot of the tag, the newest one when the tag is empty.
+// Tags match with or without the "v" prefix.
+func (h *History) Find(tag string) (Snapshot, error) {
+	snapshots, err := h.List()
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	if len(snapshots) == 0 {
+		return Snapshot{}, errors.WithMessage(ErrNotFound, "no releases were kept by upgrades yet")
+	}
+
+	if tag == "" {
+		return snapshots[0], nil
+	}
+
+	tags := make([]string, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		if SameTag(snapshot.Tag, tag) {
+			return snapshot, nil
+		}
+		tags = append(tags, snapshot.Name())
+	}
+
+	return Snapshot{}, errors.WithMessagef(
+		ErrNotFound, "no release %s, kept releases: %s", tag, strings.Join(tags, ", "),
+	)
+}
+
+// Restore copies the binary and the config files of the snapshot back to
+// their paths.
+func (h *History) Restore(ctx context.Context, snapshot Snapshot) error {
+	if dryrun.Record(ctx, dryrun.KindFile, "restore %s of release %s", snapshot.Binary.Path, snapshot.Name()) {
+		return nil
+	}
+
+	for _, f := range append([]File{snapshot.Binary}, snapshot.Configs...) {
+		if err := restoreFile(filepath.Join(snapshot.dir, f.Name), f.Path); err != nil {
+			return errors.Wrapf(err, "failed to restore %s", f.Path)
+		}
+	}
+
+	return nil
+}
+
+// restoreFile replaces the file at path with the kept copy. The restored file
+// gets the mode and the owner of the replaced one, so the service user can
+// still read a config owned by it.
+func restoreFile(src, path string) error {
+	info, statErr := os.Stat(path)
+	if statErr != nil && !errors.Is(statErr, fs.ErrNotExist) {
+		return errors.Wrap(statErr, "failed to stat file")
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return errors.Wrap(err, "failed to remove file")
+	}
+
+	if err := utils.Copy(src, path); err != nil {
+		return errors.Wrap(err, "failed to copy file")
+	}
+
+	if statErr != nil {
+		return nil
+	}
+
+	if err := os.Chmod(path, info.Mode().Perm()); err != nil {
+		return errors.Wrap(err, "failed to restore file mode")
+	}
+
+	return chownLike(path, info)
+}
+
+func (h *History) prune(ctx context.Context) error {
+	if dryrun.Enabled(ctx) {
+		return nil
+	}
+
+	snapshots, err := h.List()
+	if err != nil {
+		return err
+	}
+
+	for _, snapshot := range snapshots[min(h.keep, len(snapshots)):] {
+		if err := os.RemoveAll(snapshot.dir); err != nil {
+			return errors.Wrapf(err, "failed to remove snapshot of release %s", snapshot.Name())
+		}
+	}
+
+	return nil
+}
+
+// SameTag reports whether the tags name the same release, e.g. "4.1.2" and
+// "v4.1.2".
+func SameTag(a, b string) bool {
+	return strings.TrimPrefix(a, "v") == strings.TrimPrefix(b, "v")
+}
+
+// dirName names the snapshot directory of the tag, "4.1.2" and "v4.1.2"
+// share one.
+func dirName(tag string) string {
+	return unsafeNameChars.ReplaceAllString(strings.TrimPrefix(tag, "v"), "_")
+}
@@ -0,0 +1,186 @@
+package releasehistory_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gameap/gameapctl/internal/pkg/releasehistory"
+	"github.com/gameap/gameapctl/pkg/dryrun"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type installation struct {
+	binary string
+	config string
+}
+
+func setupInstallation(t *testing.T) installation {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	return installation{
+		binary: filepath.Join(dir, "gameap"),
+		config: filepath.Join(dir, "config.env"),
+	}
+}
+
+func (i installation) install(t *testing.T, version string) {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(i.binary, []byte("binary "+version), 0o755))
+	require.NoError(t, os.WriteFile(i.config, []byte("config "+version), 0o600))
+}
+
+func (i installation) assertInstalled(t *testing.T, version string) {
+	t.Helper()
+
+	b, err := os.ReadFile(i.binary)
+	require.NoError(t, err)
+	assert.Equal(t, "binary "+version, string(b))
+
+	b, err = os.ReadFile(i.config)
+	require.NoError(t, err)
+	assert.Equal(t, "config "+version, string(b))
+
+	info, err := os.Stat(i.binary)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+}
+
+func tags(t *testing.T, h *releasehistory.History) []string {
+	t.Helper()
+
+	snapshots, err := h.List()
+	require.NoError(t, err)
+
+	result := make([]string, 0, len(snapshots))
+	for _, s := range snapshots {
+		result = append(result, s.Name())
+	}
+
+	return result
+}
+
+func TestHistory_Save(t *testing.T) {
+	ctx := context.Background()
+	inst := setupInstallation(t)
+	h := releasehistory.New(t.TempDir(), 2)
+
+	for _, version := range []string{"v4.1.0", "v4.1.1", "v4.1.2"} {
+		inst.install(t, version)
+		require.NoError(t, h.Save(ctx, version, inst.binary, inst.config, filepath.Join(t.TempDir(), "missing")))
+	}
+
+	assert.Equal(t, []string{"v4.1.2", "v4.1.1"}, tags(t, h))
+
+	inst.install(t, "v4.1.1")
+	require.NoError(t, h.Save(ctx, "4.1.1", inst.binary, inst.config))
+	assert.Equal(t, []string{"4.1.1", "v4.1.2"}, tags(t, h))
+
+	snapshot, err := h.Find("")
+	require.NoError(t, err)
+	assert.Equal(t, "4.1.1", snapshot.Tag)
+	assert.Len(t, snapshot.Configs, 1)
+
+	snapshot, err = h.Find("4.1.2")
+	require.NoError(t, err)
+	assert.Equal(t, "v4.1.2", snapshot.Tag)
+
+	_, err = h.Find("v4.1.0")
+	require.ErrorIs(t, err, releasehistory.ErrNotFound)
+	assert.ErrorContains(t, err, "kept releases: 4.1.1, v4.1.2")
+}
+
+func TestHistory_Rollback(t *testing.T) {
+	ctx := context.Background()
+	inst := setupInstallation(t)
+	h := releasehistory.New(t.TempDir(), 2)
+
+	inst.install(t, "v4.1.0")
+	require.NoError(t, h.Save(ctx, "v4.1.0", inst.binary, inst.config))
+	inst.install(t, "v4.1.1")
+	require.NoError(t, h.Save(ctx, "v4.1.1", inst.binary, inst.config))
+	inst.install(t, "v4.1.2")
+
+	snapshot, err := h.Find("v4.1.0")
+	require.NoError(t, err)
+
+	require.NoError(t, h.Rollback(ctx, snapshot, "v4.1.2", true))
+	inst.assertInstalled(t, "v4.1.0")
+	assert.Equal(t, []string{"v4.1.2", "v4.1.1"}, tags(t, h))
+
+	// The rollback is undone with the release it kept.
+	snapshot, err = h.Find("")
+	require.NoError(t, err)
+	require.NoError(t, h.Rollback(ctx, snapshot, "v4.1.0", true))
+	inst.assertInstalled(t, "v4.1.2")
+
+	snapshot, err = h.Find("v4.1.0")
+	require.NoError(t, err)
+	assert.ErrorContains(t, h.Rollback(ctx, snapshot, "4.1.0", true), "installed already")
+}
+
+func TestHistory_Rollback_binaryOnly(t *testing.T) {
+	ctx := context.Background()
+	inst := setupInstallation(t)
+	h := releasehistory.New(t.TempDir(), 2)
+
+	inst.install(t, "v4.1.0")
+	require.NoError(t, h.Save(ctx, "v4.1.0", inst.binary, inst.config))
+	inst.install(t, "v4.1.1")
+
+	snapshot, err := h.Find("v4.1.0")
+	require.NoError(t, err)
+
+	require.NoError(t, h.Rollback(ctx, snapshot, "v4.1.1", false))
+
+	b, err := os.ReadFile(inst.binary)
+	require.NoError(t, err)
+	assert.Equal(t, "binary v4.1.0", string(b))
+
+	b, err = os.ReadFile(inst.config)
+	require.NoError(t, err)
+	assert.Equal(t, "config v4.1.1", string(b))
+
+	// The installed config is kept with the release it belongs to.
+	snapshot, err = h.Find("v4.1.1")
+	require.NoError(t, err)
+	require.Len(t, snapshot.Configs, 1)
+}
+
+func TestHistory_Rollback_keepsModeOfReplacedFiles(t *testing.T) {
+	ctx := context.Background()
+	inst := setupInstallation(t)
+	h := releasehistory.New(t.TempDir(), 2)
+
+	inst.install(t, "v4.1.0")
+	require.NoError(t, h.Save(ctx, "v4.1.0", inst.binary, inst.config))
+	inst.install(t, "v4.1.1")
+	require.NoError(t, os.Chmod(inst.config, 0o640))
+
+	snapshot, err := h.Find("v4.1.0")
+	require.NoError(t, err)
+
+	require.NoError(t, h.Rollback(ctx, snapshot, "v4.1.1", true))
+	inst.assertInstalled(t, "v4.1.0")
+
+	info, err := os.Stat(inst.config)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o640), info.Mode().Perm())
+}
+
+func TestHistory_dryRun(t *testing.T) {
+	ctx := dryrun.WithPlan(context.Background(), dryrun.NewPlan())
+	inst := setupInstallation(t)
+	inst.install(t, "v4.1.0")
+
+	h := releasehistory.New(t.TempDir(), 0)
+	require.NoError(t, h.Save(ctx, "v4.1.0", inst.binary, inst.config))
+
+	_, err := h.Find("")
+	require.ErrorIs(t, err, releasehistory.ErrNotFound)
+}
@@ -0,0 +1,21 @@
+//go:build linux || darwin
+
+package releasehistory
+
+import (
+	"io/fs"
+	"os"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// chownLike gives the file at path the owner of the file described by info.
+func chownLike(path string, info fs.FileInfo) error {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+
+	return errors.Wrap(os.Lchown(path, int(stat.Uid), int(stat.Gid)), "failed to restore file owner")
+}
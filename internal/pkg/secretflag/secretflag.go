@@ -0,0 +1,227 @@
+// Package secretflag lets every sensitive flag be read from a file, from stdin
+// or from the environment, so the secret stays out of the shell history and
+// of the process list. A secret flag --x comes with --x-file, --x-stdin and the
+// GAMEAPCTL_X environment variable.
+package secretflag
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gameap/gameapctl/internal/pkg/redact"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	EnvPrefix = "GAMEAPCTL_"
+
+	fileSuffix  = "-file"
+	stdinSuffix = "-stdin"
+)
+
+// stdin is a variable to be replaced in tests.
+var stdin io.Reader = os.Stdin
+
+// EnvVar returns the environment variable of the secret flag.
+func EnvVar(name string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// marked holds the secret flags. The flags are marked while the commands are
+// declared, before the app runs.
+var marked = make(map[cli.Flag]bool)
+
+// String marks the flag as a secret and adds its environment variable. Wire
+// adds the -file and -stdin variants.
+func String(flag *cli.StringFlag) *cli.StringFlag {
+	flag.EnvVars = append(flag.EnvVars, EnvVar(flag.Name))
+	marked[flag] = true
+
+	return flag
+}
+
+// StringSlice marks the flag as a secret and adds its environment variable.
+// The -file and -stdin variants hold one value per line.
+func StringSlice(flag *cli.StringSliceFlag) *cli.StringSliceFlag {
+	flag.EnvVars = append(flag.EnvVars, EnvVar(flag.Name))
+	marked[flag] = true
+
+	return flag
+}
+
+func sources(name string) []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  name + fileSuffix,
+			Usage: "Read --" + name + " from the file",
+		},
+		&cli.BoolFlag{
+			Name:  name + stdinSuffix,
+			Usage: "Read --" + name + " from stdin",
+		},
+	}
+}
+
+// Wire adds the -file and -stdin variants of the secret flags to the commands,
+// and to their subcommands, and resolves the secrets before the command runs.
+// The handlers read the secrets with the usual cliCtx.String and cliCtx.IsSet.
+func Wire(commands []*cli.Command) {
+	for _, cmd := range commands {
+		Wire(cmd.Subcommands)
+
+		secrets := secretFlags(cmd.Flags)
+		if len(secrets) == 0 {
+			continue
+		}
+
+		for _, f := range secrets {
+			cmd.Flags = append(cmd.Flags, sources(f.Names()[0])...)
+		}
+
+		before := cmd.Before
+		cmd.Before = func(cliCtx *cli.Context) error {
+			if err := Resolve(cliCtx); err != nil {
+				return err
+			}
+
+			if before != nil {
+				return before(cliCtx)
+			}
+
+			return nil
+		}
+	}
+}
+
+func secretFlags(flags []cli.Flag) []cli.Flag {
+	var secrets []cli.Flag
+
+	for _, f := range flags {
+		if marked[f] {
+			secrets = append(secrets, f)
+		}
+	}
+
+	return secrets
+}
+
+// Resolve sets the secret flags given with -file or -stdin and registers every
+// secret value for redaction. A value from the file or stdin takes precedence
+// over the environment, passing it also on the command line is an error.
+func Resolve(cliCtx *cli.Context) error {
+	stdinUsed := ""
+
+	for _, f := range secretFlags(cliCtx.Command.Flags) {
+		name := f.Names()[0]
+		path := cliCtx.String(name + fileSuffix)
+		fromStdin := cliCtx.Bool(name + stdinSuffix)
+
+		if path != "" && fromStdin {
+			return errors.Errorf("--%s%s and --%s%s are mutually exclusive", name, fileSuffix, name, stdinSuffix)
+		}
+
+		if path == "" && !fromStdin {
+			register(cliCtx, f)
+
+			continue
+		}
+
+		if cliCtx.IsSet(name) && !fromEnv(cliCtx, f) {
+			return errors.Errorf("--%s is already set, remove it or the file and stdin options", name)
+		}
+
+		var (
+			content []byte
+			err     error
+		)
+
+		if fromStdin {
+			if stdinUsed != "" {
+				return errors.Errorf("--%s%s and --%s%s cannot both read stdin", stdinUsed, stdinSuffix, name, stdinSuffix)
+			}
+			stdinUsed = name
+
+			content, err = io.ReadAll(stdin)
+		} else {
+			content, err = os.ReadFile(path)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to read --%s", name)
+		}
+
+		if err := set(cliCtx, f, string(content)); err != nil {
+			return err
+		}
+
+		register(cliCtx, f)
+	}
+
+	return nil
+}
+
+// fromEnv reports whether the value of the flag comes from its environment
+// variable rather than from the command line.
+func fromEnv(cliCtx *cli.Context, f cli.Flag) bool {
+	envFlag, ok := f.(interface{ GetEnvVars() []string })
+	if !ok {
+		return false
+	}
+
+	for _, env := range envFlag.GetEnvVars() {
+		value, ok := os.LookupEnv(env)
+		if !ok {
+			continue
+		}
+
+		if _, isSlice := f.(*cli.StringSliceFlag); isSlice || value == cliCtx.String(f.Names()[0]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func set(cliCtx *cli.Context, f cli.Flag, content string) error {
+	name := f.Names()[0]
+
+	if _, ok := f.(*cli.StringSliceFlag); !ok {
+		return errors.WithMessagef(cliCtx.Set(name, strings.TrimRight(content, "\r\n")), "failed to set --%s", name)
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if err := cliCtx.Set(name, line); err != nil {
+			return errors.WithMessagef(err, "failed to set --%s", name)
+		}
+	}
+
+	return nil
+}
+
+// register adds the values of the flag to the redacted secrets. Only the value
+// part of KEY=VALUE entries is a secret.
+func register(cliCtx *cli.Context, f cli.Flag) {
+	name := f.Names()[0]
+
+	if _, ok := f.(*cli.StringSliceFlag); !ok {
+		redact.Register(cliCtx.String(name))
+
+		return
+	}
+
+	for _, v := range cliCtx.StringSlice(name) {
+		if _, value, ok := strings.Cut(v, "="); ok {
+			v = value
+		}
+
+		redact.Register(v)
+	}
+}
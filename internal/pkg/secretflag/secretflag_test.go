@@ -0,0 +1,101 @@
+package secretflag
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gameap/gameapctl/internal/pkg/redact"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+type result struct {
+	token string
+	env   []string
+}
+
+func run(t *testing.T, args ...string) (result, error) {
+	t.Helper()
+
+	var r result
+
+	commands := []*cli.Command{{
+		Name: "install",
+		Flags: []cli.Flag{
+			String(&cli.StringFlag{Name: "token"}),
+			StringSlice(&cli.StringSliceFlag{Name: "env"}),
+		},
+		Action: func(cliCtx *cli.Context) error {
+			r = result{token: cliCtx.String("token"), env: cliCtx.StringSlice("env")}
+
+			return nil
+		},
+	}}
+	Wire(commands)
+
+	app := &cli.App{Commands: commands}
+
+	err := app.Run(append([]string{"gameapctl", "install"}, args...))
+
+	return r, err
+}
+
+func TestResolve_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("file-token\n"), 0o600))
+
+	r, err := run(t, "--token-file", path)
+	require.NoError(t, err)
+	assert.Equal(t, "file-token", r.token)
+	assert.Equal(t, "--token="+redact.Mask, redact.String("--token=file-token"))
+	assert.Equal(t, "token "+redact.Mask, redact.String("token file-token"))
+}
+
+func TestResolve_Stdin(t *testing.T) {
+	stdin = strings.NewReader("# DNS credentials\nCF_DNS_API_TOKEN=stdin-token\n\nCF_ZONE_API_TOKEN=zone-token\n")
+	t.Cleanup(func() { stdin = os.Stdin })
+
+	r, err := run(t, "--env-stdin")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"CF_DNS_API_TOKEN=stdin-token", "CF_ZONE_API_TOKEN=zone-token"}, r.env)
+	assert.Equal(t, "CF_DNS_API_TOKEN="+redact.Mask, redact.String("CF_DNS_API_TOKEN=stdin-token"))
+}
+
+func TestResolve_Env(t *testing.T) {
+	t.Setenv("GAMEAPCTL_TOKEN", "env-token")
+
+	r, err := run(t)
+	require.NoError(t, err)
+	assert.Equal(t, "env-token", r.token)
+
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("file-token"), 0o600))
+
+	r, err = run(t, "--token-file", path)
+	require.NoError(t, err)
+	assert.Equal(t, "file-token", r.token)
+}
+
+func TestResolve_Conflicts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("file-token"), 0o600))
+
+	_, err := run(t, "--token", "flag-token", "--token-file", path)
+	require.EqualError(t, err, "--token is already set, remove it or the file and stdin options")
+
+	_, err = run(t, "--token-file", path, "--token-stdin")
+	require.EqualError(t, err, "--token-file and --token-stdin are mutually exclusive")
+
+	_, err = run(t, "--token-stdin", "--env-stdin")
+	require.EqualError(t, err, "--token-stdin and --env-stdin cannot both read stdin")
+
+	_, err = run(t, "--token-file", filepath.Join(t.TempDir(), "missing"))
+	require.ErrorContains(t, err, "failed to read --token")
+}
+
+func TestEnvVar(t *testing.T) {
+	assert.Equal(t, "GAMEAPCTL_DATABASE_PASSWORD", EnvVar("database-password"))
+}
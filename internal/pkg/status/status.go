@@ -0,0 +1,253 @@
+// Package status collects the state of GameAP components into typed results
+// rendered by the output package. Field names are part of the public schema
+// consumed by scripts: rename or remove them only with a major release.
+package status
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"runtime"
+
+	contextInternal "github.com/gameap/gameapctl/internal/context"
+	"github.com/gameap/gameapctl/internal/pkg/gameapctl"
+	"github.com/gameap/gameapctl/pkg/daemon"
+	"github.com/gameap/gameapctl/pkg/gameap"
+	osinfo "github.com/gameap/gameapctl/pkg/os_info"
+	"github.com/gameap/gameapctl/pkg/oscore"
+	"github.com/pkg/errors"
+)
+
+type State string
+
+const (
+	StateRunning State = "running"
+	StateStopped State = "stopped"
+)
+
+const (
+	ComponentPanel  = "panel"
+	ComponentDaemon = "daemon"
+
+	panelProcessName = "gameap"
+)
+
+type OSInfo struct {
+	Kernel               string `json:"kernel"`
+	Core                 string `json:"core"`
+	Distribution         string `json:"distribution"`
+	DistributionVersion  string `json:"distributionVersion"`
+	DistributionCodename string `json:"distributionCodename"`
+	Platform             string `json:"platform"`
+	OS                   string `json:"os"`
+	Hostname             string `json:"hostname"`
+	CPUs                 int    `json:"cpus"`
+}
+
+func NewOSInfo(info osinfo.Info) OSInfo {
+	return OSInfo{
+		Kernel:               info.Kernel,
+		Core:                 info.Core,
+		Distribution:         info.Distribution.String(),
+		DistributionVersion:  info.DistributionVersion,
+		DistributionCodename: info.DistributionCodename,
+		Platform:             info.Platform.String(),
+		OS:                   info.OS,
+		Hostname:             info.Hostname,
+		CPUs:                 info.CPUs,
+	}
+}
+
+type PanelPaths struct {
+	ConfigDir       string `json:"configDir"`
+	ConfigFilePath  string `json:"configFilePath"`
+	DataDir         string `json:"dataDir"`
+	FilesBasePath   string `json:"filesBasePath"`
+	BinaryPath      string `json:"binaryPath"`
+	SystemdUnitPath string `json:"systemdUnitPath,omitempty"`
+}
+
+type DaemonPaths struct {
+	WorkPath             string `json:"workPath"`
+	SteamCMDPath         string `json:"steamCmdPath"`
+	ToolsPath            string `json:"toolsPath"`
+	CertsPath            string `json:"certsPath"`
+	DaemonFilePath       string `json:"daemonFilePath"`
+	DaemonConfigFilePath string `json:"daemonConfigFilePath"`
+	OutputLogPath        string `json:"outputLogPath"`
+	SystemdUnitPath      string `json:"systemdUnitPath,omitempty"`
+}
+
+type PanelStatus struct {
+	Component string     `json:"component"`
+	State     State      `json:"state"`
+	PID       int32      `json:"pid,omitempty"`
+	Scope     string     `json:"scope"`
+	Version   string     `json:"version,omitempty"`
+	Paths     PanelPaths `json:"paths"`
+	OS        OSInfo     `json:"os"`
+}
+
+type DaemonStatus struct {
+	Component string      `json:"component"`
+	State     State       `json:"state"`
+	PID       int32       `json:"pid,omitempty"`
+	Scope     string      `json:"scope"`
+	Version   string      `json:"version,omitempty"`
+	Paths     DaemonPaths `json:"paths"`
+	OS        OSInfo      `json:"os"`
+}
+
+type VersionInfo struct {
+	Version   string `json:"version"`
+	BuildDate string `json:"buildDate"`
+	GoVersion string `json:"goVersion"`
+	OS        OSInfo `json:"os"`
+}
+
+// Panel reports the panel process state for the installation at the given paths.
+// The version is taken from the install state only when it belongs to the same scope.
+func Panel(ctx context.Context, paths gameap.PanelPaths) (PanelStatus, error) {
+	result := PanelStatus{
+		Component: ComponentPanel,
+		State:     StateStopped,
+		Scope:     paths.Scope,
+		Paths: PanelPaths{
+			ConfigDir:       paths.ConfigDir,
+			ConfigFilePath:  paths.ConfigFilePath,
+			DataDir:         paths.DataDir,
+			FilesBasePath:   paths.FilesBasePath,
+			BinaryPath:      paths.BinaryPath,
+			SystemdUnitPath: paths.SystemdUnitPath,
+		},
+		OS: NewOSInfo(contextInternal.OSInfoFromContext(ctx)),
+	}
+
+	if state, err := gameapctl.LoadPanelInstallState(ctx); err == nil &&
+		gameap.ScopeOrDefault(state.Scope) == gameap.ScopeOrDefault(paths.Scope) {
+		result.Version = state.Version
+	}
+
+	pr, err := oscore.FindProcessByName(ctx, panelProcessName)
+	if err != nil {
+		return result, errors.WithMessage(err, "failed to find gameap process")
+	}
+	if pr != nil {
+		result.State = StateRunning
+		result.PID = pr.Pid
+	}
+
+	return result, nil
+}
+
+// Daemon reports the daemon process state for the installation described by the
+// daemon install state. A missing state file means a system scope installation.
+func Daemon(ctx context.Context) (DaemonStatus, error) {
+	result := DaemonStatus{
+		Component: ComponentDaemon,
+		State:     StateStopped,
+		OS:        NewOSInfo(contextInternal.OSInfoFromContext(ctx)),
+	}
+
+	state, stateErr := gameapctl.LoadDaemonInstallState(ctx)
+	if stateErr == nil {
+		result.Version = state.Version
+	}
+
+	result.Scope = gameap.ScopeOrDefault(state.Scope)
+
+	paths, err := gameap.DaemonPathsForScope(result.Scope)
+	if err != nil {
+		return result, errors.WithMessage(err, "failed to resolve daemon paths")
+	}
+
+	result.Paths = DaemonPaths{
+		WorkPath:             paths.WorkPath,
+		SteamCMDPath:         paths.SteamCMDPath,
+		ToolsPath:            paths.ToolsPath,
+		CertsPath:            paths.CertsPath,
+		DaemonFilePath:       paths.DaemonFilePath,
+		DaemonConfigFilePath: paths.DaemonConfigFilePath,
+		OutputLogPath:        paths.OutputLogPath,
+		SystemdUnitPath:      paths.SystemdUnitPath,
+	}
+
+	if stateErr == nil && state.WorkPath != "" {
+		result.Paths.WorkPath = state.WorkPath
+	}
+
+	pr, err := daemon.FindProcess(ctx)
+	if err != nil {
+		return result, errors.WithMessage(err, "failed to find daemon process")
+	}
+	if pr != nil {
+		result.State = StateRunning
+		result.PID = pr.Pid
+	}
+
+	return result, nil
+}
+
+func Version(ctx context.Context) VersionInfo {
+	return VersionInfo{
+		Version:   gameap.Version,
+		BuildDate: gameap.BuildDate,
+		GoVersion: runtime.Version(),
+		OS:        NewOSInfo(contextInternal.OSInfoFromContext(ctx)),
+	}
+}
+
+func (s PanelStatus) WriteText(w io.Writer) error {
+	return writeLines(w,
+		"GameAP panel: "+stateText(s.State, s.PID),
+		"Installation scope: "+s.Scope,
+		"Version: "+valueOrUnknown(s.Version),
+		"Config file: "+s.Paths.ConfigFilePath,
+		"Data directory: "+s.Paths.DataDir,
+		"Binary: "+s.Paths.BinaryPath,
+	)
+}
+
+func (s DaemonStatus) WriteText(w io.Writer) error {
+	return writeLines(w,
+		"GameAP daemon: "+stateText(s.State, s.PID),
+		"Installation scope: "+s.Scope,
+		"Version: "+valueOrUnknown(s.Version),
+		"Config file: "+s.Paths.DaemonConfigFilePath,
+		"Work directory: "+s.Paths.WorkPath,
+		"Binary: "+s.Paths.DaemonFilePath,
+	)
+}
+
+func (v VersionInfo) WriteText(w io.Writer) error {
+	return writeLines(w,
+		"Version: "+v.Version,
+		"Build Date: "+v.BuildDate,
+	)
+}
+
+func stateText(state State, pid int32) string {
+	if state == StateRunning && pid != 0 {
+		return fmt.Sprintf("%s (pid %d)", state, pid)
+	}
+
+	return string(state)
+}
+
+func valueOrUnknown(v string) string {
+	if v == "" {
+		return "unknown"
+	}
+
+	return v
+}
+
+func writeLines(w io.Writer, lines ...string) error {
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return errors.Wrap(err, "failed to write output")
+		}
+	}
+
+	return nil
+}
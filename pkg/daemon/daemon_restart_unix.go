@@ -8,6 +8,7 @@ import (
 	"log"
 	"os"
 
+	"github.com/gameap/gameapctl/pkg/dryrun"
 	"github.com/gameap/gameapctl/pkg/gameap"
 	"github.com/gameap/gameapctl/pkg/oscore"
 	"github.com/gameap/gameapctl/pkg/runhelper"
@@ -16,6 +17,10 @@ import (
 )
 
 func Restart(ctx context.Context, opts ...Options) error {
+	if dryrun.Record(ctx, dryrun.KindService, "restart gameap-daemon service") {
+		return nil
+	}
+
 	o := firstOptions(opts)
 
 	if o.scope() == gameap.ScopeUser {
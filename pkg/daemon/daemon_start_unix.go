@@ -12,6 +12,7 @@ import (
 	"strings"
 	"syscall"
 
+	"github.com/gameap/gameapctl/pkg/dryrun"
 	"github.com/gameap/gameapctl/pkg/gameap"
 	"github.com/gameap/gameapctl/pkg/runhelper"
 	"github.com/gameap/gameapctl/pkg/systemd"
@@ -21,6 +22,10 @@ import (
 const daemonServiceName = "gameap-daemon"
 
 func Start(ctx context.Context, opts ...Options) error {
+	if dryrun.Record(ctx, dryrun.KindService, "start gameap-daemon service") {
+		return nil
+	}
+
 	o := firstOptions(opts)
 
 	if o.scope() == gameap.ScopeUser {
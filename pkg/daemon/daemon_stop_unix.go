@@ -9,6 +9,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/gameap/gameapctl/pkg/dryrun"
 	"github.com/gameap/gameapctl/pkg/gameap"
 	"github.com/gameap/gameapctl/pkg/oscore"
 	"github.com/gameap/gameapctl/pkg/runhelper"
@@ -21,6 +22,10 @@ const (
 )
 
 func Stop(ctx context.Context, opts ...Options) error {
+	if dryrun.Record(ctx, dryrun.KindService, "stop gameap-daemon service") {
+		return nil
+	}
+
 	o := firstOptions(opts)
 
 	if o.scope() == gameap.ScopeUser {
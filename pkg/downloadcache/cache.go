@@ -0,0 +1,662 @@
+// Package downloadcache keeps downloaded files between gameapctl runs, so an
+// install or upgrade does not download the same archives again. A file is
+// keyed by the SHA-256 of its content when it is known, the copy is then
+// shared by all mirrors of the file, and by its URL otherwise. An interrupted
+// download is resumed with an HTTP Range request.
+package downloadcache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultMaxSize limits the cache size when no limit is configured.
+const DefaultMaxSize int64 = 4 << 30
+
+const (
+	entryFile  = "entry.json"
+	partSuffix = ".part"
+	lockSuffix = ".lock"
+)
+
+const (
+	lockPollInterval    = 200 * time.Millisecond
+	lockRefreshInterval = 30 * time.Second
+	// lockStaleAfter is the age of a lock which is no longer refreshed, the
+	// process holding it was killed.
+	lockStaleAfter = 2 * time.Minute
+)
+
+// ErrUnavailable is returned when the cache directory cannot be created. The
+// file is then downloaded without the cache.
+var ErrUnavailable = errors.New("download cache is unavailable")
+
+var (
+	sha256Pattern   = regexp.MustCompile(`^[0-9a-f]{64}$`)
+	unsafeNameChars = regexp.MustCompile(`[^A-Za-z0-9._+-]`)
+)
+
+// ProgressTracker reports the progress of a download, it matches the
+// progress listener of go-getter.
+type ProgressTracker interface {
+	TrackProgress(src string, currentSize, totalSize int64, stream io.ReadCloser) io.ReadCloser
+}
+
+// Entry is a cached file in <dir>/<key>/<name>, the name is taken from the
+// URL, so the archive type is still recognized by the extension.
+type Entry struct {
+	Key          string `json:"key"`
+	Name         string `json:"name"`
+	URL          string `json:"url"`
+	SHA256       string `json:"sha256,omitempty"`
+	Size         int64  `json:"size"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	// Complete is false while the file is downloaded, the partial file is
+	// then resumed by the next download.
+	Complete  bool      `json:"complete"`
+	CreatedAt time.Time `json:"createdAt"`
+	UsedAt    time.Time `json:"usedAt"`
+}
+
+type Cache struct {
+	dir     string
+	maxSize int64
+	client  *http.Client
+
+	initOnce sync.Once
+	initErr  error
+}
+
+// New returns a cache in dir limited to maxSize bytes, a non-positive
+// maxSize is DefaultMaxSize. The directory is created on the first download.
+func New(dir string, maxSize int64) *Cache {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+
+	return &Cache{
+		dir:     dir,
+		maxSize: maxSize,
+		client:  &http.Client{},
+	}
+}
+
+// DefaultDir returns /var/cache/gameapctl for root on Unix-like systems and
+// the gameapctl directory in the user cache directory otherwise.
+func DefaultDir() string {
+	if runtime.GOOS != "windows" && os.Geteuid() == 0 {
+		return "/var/cache/gameapctl"
+	}
+
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "gameapctl-cache")
+	}
+
+	return filepath.Join(dir, "gameapctl")
+}
+
+var defaultCache *Cache
+
+// SetDefault sets the cache used by the downloads, nil disables caching.
+func SetDefault(c *Cache) {
+	defaultCache = c
+}
+
+// Default returns the cache used by the downloads, nil when caching is
+// disabled.
+func Default() *Cache {
+	return defaultCache
+}
+
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+func (c *Cache) MaxSize() int64 {
+	return c.maxSize
+}
+
+func (c *Cache) init() error {
+	c.initOnce.Do(func() {
+		if err := os.MkdirAll(c.dir, 0o755); err != nil {
+			log.Printf("Download cache %s is unavailable: %v\n", c.dir, err)
+			c.initErr = errors.Wrap(ErrUnavailable, err.Error())
+		}
+	})
+
+	return c.initErr
+}
+
+// Fetch returns the path of the cached copy of the file at the URL and
+// downloads the file first when it is not cached. A non-empty sha256
+// verifies the content. A file cached by URL is revalidated with the server
+// and is still used when the server cannot be reached.
+func (c *Cache) Fetch(ctx context.Context, rawURL, sha256 string, tracker ProgressTracker) (string, error) {
+	if err := c.init(); err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid URL")
+	}
+
+	key := cacheKey(rawURL, sha256)
+
+	// The entry is read under the lock, a concurrent download of the same
+	// file is finished by then and is not repeated.
+	unlock, err := c.lock(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	entry, err := c.readEntry(key)
+	if err != nil || entry.URL == "" {
+		entry = Entry{
+			Key:       key,
+			Name:      entryName(u),
+			SHA256:    sha256,
+			CreatedAt: time.Now().UTC(),
+		}
+	}
+
+	if _, err := os.Stat(c.filePath(entry)); err != nil {
+		entry.Complete = false
+	}
+
+	// A file with a known checksum never changes.
+	if !entry.Complete || sha256 == "" {
+		entry.URL = u.Redacted()
+		if err := c.download(ctx, rawURL, &entry, tracker); err != nil {
+			return "", err
+		}
+	}
+
+	entry.UsedAt = time.Now().UTC()
+	if err := c.writeEntry(entry); err != nil {
+		return "", err
+	}
+
+	c.evict(key)
+
+	return c.filePath(entry), nil
+}
+
+// Lookup returns the path of a complete cached copy of the file with the
+// checksum without contacting any server. A file cached by URL may be
+// outdated, it is only returned by Fetch after revalidation.
+func (c *Cache) Lookup(sha256 string) (string, bool) {
+	if c == nil || !sha256Pattern.MatchString(sha256) {
+		return "", false
+	}
+
+	entry, err := c.readEntry(cacheKey("", sha256))
+	if err != nil || !entry.Complete {
+		return "", false
+	}
+
+	if _, err := os.Stat(c.filePath(entry)); err != nil {
+		return "", false
+	}
+
+	entry.UsedAt = time.Now().UTC()
+	if err := c.writeEntry(entry); err != nil {
+		log.Println(err)
+	}
+
+	return c.filePath(entry), true
+}
+
+// download fetches the file into the entry. A partial file is resumed, and a
+// complete file cached by URL is only replaced when the server has a newer
+// one.
+//
+//nolint:funlen
+func (c *Cache) download(ctx context.Context, rawURL string, entry *Entry, tracker ProgressTracker) error {
+	partPath := c.filePath(*entry) + partSuffix
+
+	var offset int64
+	if info, err := os.Stat(partPath); err == nil && !entry.Complete && c.resumable(*entry) {
+		offset = info.Size()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+
+	switch {
+	case entry.Complete:
+		setValidators(req, "If-None-Match", "If-Modified-Since", *entry)
+	case offset > 0:
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		// Content with a known checksum is the same on every mirror, the
+		// final verification catches a mismatch.
+		if entry.SHA256 == "" {
+			setValidators(req, "If-Range", "If-Range", *entry)
+		}
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		if entry.Complete && ctx.Err() == nil {
+			log.Printf("Failed to revalidate %s, using the cached copy: %v\n", entry.URL, err)
+
+			return nil
+		}
+
+		return errors.Wrapf(err, "failed to download %s", entry.URL)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		offset = 0
+	case resp.StatusCode == http.StatusPartialContent && offset > 0 &&
+		strings.HasPrefix(resp.Header.Get("Content-Range"), fmt.Sprintf("bytes %d-", offset)):
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// The partial file does not match the one on the server any more.
+		if err := os.Remove(partPath); err != nil {
+			return errors.Wrap(err, "failed to remove partial download")
+		}
+
+		return c.download(ctx, rawURL, entry, tracker)
+	case entry.Complete && resp.StatusCode == http.StatusNotModified:
+		return nil
+	case entry.Complete:
+		log.Printf("Failed to revalidate %s, using the cached copy: %s\n", entry.URL, resp.Status)
+
+		return nil
+	default:
+		return errors.Errorf("failed to download %s: %s", entry.URL, resp.Status)
+	}
+
+	if err := os.MkdirAll(filepath.Join(c.dir, entry.Key), 0o755); err != nil {
+		return errors.Wrap(err, "failed to create cache entry")
+	}
+
+	entry.Complete = false
+	entry.ETag = resp.Header.Get("ETag")
+	entry.LastModified = resp.Header.Get("Last-Modified")
+	if err := c.writeEntry(*entry); err != nil {
+		return err
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if offset > 0 {
+		flags = os.O_WRONLY | os.O_APPEND
+		log.Printf("Resuming download of %s from %d bytes\n", entry.URL, offset)
+	}
+
+	f, err := os.OpenFile(partPath, flags, 0o644)
+	if err != nil {
+		return errors.Wrap(err, "failed to open partial download")
+	}
+
+	var body io.ReadCloser = resp.Body
+	if tracker != nil {
+		total := int64(0)
+		if resp.ContentLength > 0 {
+			total = offset + resp.ContentLength
+		}
+		body = tracker.TrackProgress(entry.URL, offset, total, resp.Body)
+	}
+
+	_, err = io.Copy(f, body)
+	_ = body.Close()
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to download %s", entry.URL)
+	}
+
+	return c.complete(entry, partPath)
+}
+
+// lock takes the lock of the entry with the key, so processes downloading the
+// same file do not write the partial file and the entry at the same time. The
+// lock is a file created exclusively next to the entry directory, the holder
+// refreshes it and a lock left by a killed process is taken over once stale.
+func (c *Cache) lock(ctx context.Context, key string) (func(), error) {
+	p := filepath.Join(c.dir, key+lockSuffix)
+
+	for {
+		f, err := os.OpenFile(p, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			_, _ = fmt.Fprintf(f, "%d\n", os.Getpid())
+			_ = f.Close()
+
+			return holdLock(p), nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, errors.Wrap(err, "failed to lock cache entry")
+		}
+
+		if info, err := os.Stat(p); err == nil && time.Since(info.ModTime()) > lockStaleAfter {
+			log.Printf("Removing stale download cache lock %s\n", p)
+			if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return nil, errors.Wrap(err, "failed to remove stale lock")
+			}
+
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+// locked reports whether the entry with the key is being downloaded.
+func (c *Cache) locked(key string) bool {
+	_, err := os.Stat(filepath.Join(c.dir, key+lockSuffix))
+
+	return err == nil
+}
+
+// holdLock refreshes the lock file until the returned function releases it.
+func holdLock(p string) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(lockRefreshInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				now := time.Now()
+				if err := os.Chtimes(p, now, now); err != nil {
+					log.Printf("Failed to refresh download cache lock: %v\n", err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+
+		if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("Failed to remove download cache lock: %v\n", err)
+		}
+	}
+}
+
+func (c *Cache) resumable(entry Entry) bool {
+	return entry.SHA256 != "" || entry.ETag != "" || entry.LastModified != ""
+}
+
+func setValidators(req *http.Request, etagHeader, dateHeader string, entry Entry) {
+	if entry.ETag != "" {
+		req.Header.Set(etagHeader, entry.ETag)
+
+		return
+	}
+
+	if entry.LastModified != "" {
+		req.Header.Set(dateHeader, entry.LastModified)
+	}
+}
+
+// complete verifies the downloaded file and moves it in place of the cached
+// copy. A file with a wrong checksum is removed, so it is not resumed.
+func (c *Cache) complete(entry *Entry, partPath string) error {
+	if entry.SHA256 != "" {
+		got, err := fileChecksum(partPath)
+		if err != nil {
+			return err
+		}
+
+		if got != entry.SHA256 {
+			if err := os.Remove(partPath); err != nil {
+				log.Printf("Failed to remove corrupted download: %v\n", err)
+			}
+
+			return errors.Errorf("checksum mismatch for %s: expected %s, got %s", entry.URL, entry.SHA256, got)
+		}
+	}
+
+	info, err := os.Stat(partPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to stat download")
+	}
+
+	if err := os.Rename(partPath, c.filePath(*entry)); err != nil {
+		return errors.Wrap(err, "failed to store download")
+	}
+
+	entry.Size = info.Size()
+	entry.Complete = true
+
+	return nil
+}
+
+func (c *Cache) filePath(entry Entry) string {
+	return filepath.Join(c.dir, entry.Key, entry.Name)
+}
+
+func (c *Cache) readEntry(key string) (Entry, error) {
+	var entry Entry
+
+	b, err := os.ReadFile(filepath.Join(c.dir, key, entryFile))
+	if err != nil {
+		return entry, errors.Wrap(err, "failed to read cache entry")
+	}
+
+	if err := json.Unmarshal(b, &entry); err != nil {
+		return entry, errors.Wrap(err, "failed to parse cache entry")
+	}
+
+	if entry.Key != key || entry.Name == "" || strings.ContainsAny(entry.Name, `/\`) {
+		return entry, errors.Errorf("invalid cache entry %s", key)
+	}
+
+	return entry, nil
+}
+
+func (c *Cache) writeEntry(entry Entry) error {
+	b, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal cache entry")
+	}
+
+	return errors.Wrap(
+		os.WriteFile(filepath.Join(c.dir, entry.Key, entryFile), b, 0o644),
+		"failed to write cache entry",
+	)
+}
+
+// List returns the cached files, the most recently used first. The size of a
+// partial download is the downloaded part.
+func (c *Cache) List() ([]Entry, error) {
+	dirs, err := os.ReadDir(c.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read cache directory")
+	}
+
+	entries := make([]Entry, 0, len(dirs))
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+
+		entry, err := c.readEntry(d.Name())
+		if err != nil {
+			continue
+		}
+
+		if !entry.Complete {
+			if info, err := os.Stat(c.filePath(entry) + partSuffix); err == nil {
+				entry.Size = info.Size()
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	slices.SortFunc(entries, func(a, b Entry) int {
+		return b.UsedAt.Compare(a.UsedAt)
+	})
+
+	return entries, nil
+}
+
+// Prune removes the entries not used for olderThan, all entries when it is
+// zero, and the leftovers of broken entries. It returns the number of
+// removed entries and the freed bytes. Entries being downloaded are kept.
+func (c *Cache) Prune(olderThan time.Duration) (int, int64, error) {
+	dirs, err := os.ReadDir(c.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "failed to read cache directory")
+	}
+
+	removed, freed := 0, int64(0)
+	threshold := time.Now().Add(-olderThan)
+
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+
+		entry, err := c.readEntry(d.Name())
+		if err == nil && olderThan > 0 && entry.UsedAt.After(threshold) {
+			continue
+		}
+
+		if c.locked(d.Name()) {
+			continue
+		}
+
+		size := dirSize(filepath.Join(c.dir, d.Name()))
+		if err := os.RemoveAll(filepath.Join(c.dir, d.Name())); err != nil {
+			return removed, freed, errors.Wrap(err, "failed to remove cache entry")
+		}
+
+		removed++
+		freed += size
+	}
+
+	return removed, freed, nil
+}
+
+// evict removes the least recently used entries until the cache fits into
+// its size limit. The entry with the keep key and the entries being
+// downloaded are never removed.
+func (c *Cache) evict(keep string) {
+	entries, err := c.List()
+	if err != nil {
+		log.Println(err)
+
+		return
+	}
+
+	var total int64
+	for _, entry := range entries {
+		total += entry.Size
+	}
+
+	for i := len(entries) - 1; i >= 0 && total > c.maxSize; i-- {
+		if entries[i].Key == keep || c.locked(entries[i].Key) {
+			continue
+		}
+
+		if err := os.RemoveAll(filepath.Join(c.dir, entries[i].Key)); err != nil {
+			log.Printf("Failed to remove cache entry %s: %v\n", entries[i].Key, err)
+
+			continue
+		}
+
+		total -= entries[i].Size
+	}
+}
+
+// cacheKey keys the content by its checksum and by the URL when the checksum
+// is unknown.
+func cacheKey(rawURL, sha256Hex string) string {
+	if sha256Pattern.MatchString(sha256Hex) {
+		return "sha256-" + sha256Hex
+	}
+
+	sum := sha256.Sum256([]byte(rawURL))
+
+	return "url-" + hex.EncodeToString(sum[:])
+}
+
+func entryName(u *url.URL) string {
+	name := unsafeNameChars.ReplaceAllString(path.Base(u.Path), "_")
+	if name == "" || name == "." || name == "_" || strings.HasPrefix(name, "..") {
+		return "download"
+	}
+
+	return name
+}
+
+func fileChecksum(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to open download")
+	}
+	defer func() { _ = f.Close() }()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", errors.Wrap(err, "failed to read download")
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func dirSize(dir string) int64 {
+	var size int64
+
+	_ = filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil //nolint:nilerr
+		}
+
+		if info, err := d.Info(); err == nil {
+			size += info.Size()
+		}
+
+		return nil
+	})
+
+	return size
+}
@@ -0,0 +1,305 @@
+package downloadcache_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gameap/gameapctl/pkg/downloadcache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fileServer serves content with Range and conditional request support and
+// records the requests.
+type fileServer struct {
+	mu       sync.Mutex
+	content  []byte
+	etag     string
+	requests []*http.Request
+	// interrupt aborts the next response after the given number of bytes.
+	interrupt int
+}
+
+func (s *fileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Clone(context.Background()))
+	content, etag, interrupt := s.content, s.etag, s.interrupt
+	s.interrupt = 0
+	s.mu.Unlock()
+
+	if interrupt > 0 {
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		_, _ = w.Write(content[:interrupt])
+		w.(http.Flusher).Flush() //nolint:forcetypeassert
+
+		panic(http.ErrAbortHandler)
+	}
+
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	http.ServeContent(w, r, "archive.tar.gz", time.Time{}, bytes.NewReader(content))
+}
+
+func (s *fileServer) set(content []byte, etag string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.content, s.etag = content, etag
+}
+
+func (s *fileServer) requestCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.requests)
+}
+
+func (s *fileServer) lastRequest() *http.Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests[len(s.requests)-1]
+}
+
+func checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+
+	return hex.EncodeToString(sum[:])
+}
+
+func readFile(t *testing.T, p string) string {
+	t.Helper()
+
+	b, err := os.ReadFile(p)
+	require.NoError(t, err)
+
+	return string(b)
+}
+
+func TestCache_Fetch_byChecksum(t *testing.T) {
+	content := []byte("release archive")
+	server := &fileServer{content: content}
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+
+	c := downloadcache.New(t.TempDir(), 0)
+	sum := checksum(content)
+
+	p, err := c.Fetch(context.Background(), ts.URL+"/v4.1.0/gameap-daemon.tar.gz", sum, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "gameap-daemon.tar.gz", filepath.Base(p))
+	assert.Equal(t, string(content), readFile(t, p))
+
+	// Another mirror of the same file is served from the cache.
+	p, err = c.Fetch(context.Background(), "https://mirror.invalid/gameap-daemon.tar.gz", sum, nil)
+	require.NoError(t, err)
+	assert.Equal(t, string(content), readFile(t, p))
+	assert.Equal(t, 1, server.requestCount())
+
+	cached, ok := c.Lookup(sum)
+	require.True(t, ok)
+	assert.Equal(t, p, cached)
+
+	entries, err := c.List()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, sum, entries[0].SHA256)
+	assert.Equal(t, int64(len(content)), entries[0].Size)
+	assert.True(t, entries[0].Complete)
+}
+
+func TestCache_Fetch_concurrentDownloadsOfSameFile(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 1000)
+	server := &fileServer{content: content}
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+
+	dir := t.TempDir()
+	sum := checksum(content)
+
+	// Every cache stands for a separate gameapctl process.
+	var wg sync.WaitGroup
+	paths := make([]string, 4)
+	errs := make([]error, len(paths))
+	for i := range paths {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			paths[i], errs[i] = downloadcache.New(dir, 0).Fetch(context.Background(), ts.URL+"/archive.tar.gz", sum, nil)
+		}()
+	}
+	wg.Wait()
+
+	for i := range paths {
+		require.NoError(t, errs[i])
+		assert.Equal(t, string(content), readFile(t, paths[i]))
+	}
+	assert.Equal(t, 1, server.requestCount())
+}
+
+func TestCache_Fetch_takesOverStaleLock(t *testing.T) {
+	content := []byte("release archive")
+	server := &fileServer{content: content}
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+
+	dir := t.TempDir()
+	sum := checksum(content)
+
+	lock := filepath.Join(dir, "sha256-"+sum+".lock")
+	require.NoError(t, os.WriteFile(lock, []byte("1\n"), 0o644))
+	old := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(lock, old, old))
+
+	p, err := downloadcache.New(dir, 0).Fetch(context.Background(), ts.URL+"/archive.tar.gz", sum, nil)
+	require.NoError(t, err)
+	assert.Equal(t, string(content), readFile(t, p))
+	assert.NoFileExists(t, lock)
+}
+
+func TestCache_Fetch_resumesInterruptedDownload(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 1000)
+	server := &fileServer{content: content, etag: `"v1"`, interrupt: 4000}
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+
+	c := downloadcache.New(t.TempDir(), 0)
+
+	_, err := c.Fetch(context.Background(), ts.URL+"/steamcmd.tar.gz", "", nil)
+	require.Error(t, err)
+
+	entries, err := c.List()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.False(t, entries[0].Complete)
+	assert.Equal(t, int64(4000), entries[0].Size)
+
+	p, err := c.Fetch(context.Background(), ts.URL+"/steamcmd.tar.gz", "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, string(content), readFile(t, p))
+
+	assert.Equal(t, "bytes=4000-", server.lastRequest().Header.Get("Range"))
+	assert.Equal(t, `"v1"`, server.lastRequest().Header.Get("If-Range"))
+}
+
+func TestCache_Fetch_checksumMismatch(t *testing.T) {
+	server := &fileServer{content: []byte("tampered")}
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+
+	c := downloadcache.New(t.TempDir(), 0)
+	sum := checksum([]byte("release archive"))
+
+	_, err := c.Fetch(context.Background(), ts.URL+"/gameap.tar.gz", sum, nil)
+	require.ErrorContains(t, err, "checksum mismatch")
+
+	_, ok := c.Lookup(sum)
+	assert.False(t, ok)
+
+	// The rejected file is not resumed.
+	server.set([]byte("release archive"), "")
+	p, err := c.Fetch(context.Background(), ts.URL+"/gameap.tar.gz", sum, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "release archive", readFile(t, p))
+	assert.Empty(t, server.lastRequest().Header.Get("Range"))
+}
+
+func TestCache_Fetch_revalidatesByURL(t *testing.T) {
+	server := &fileServer{content: []byte("key v1"), etag: `"v1"`}
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+
+	c := downloadcache.New(t.TempDir(), 0)
+	u := ts.URL + "/apt.gpg"
+
+	_, err := c.Fetch(context.Background(), u, "", nil)
+	require.NoError(t, err)
+
+	p, err := c.Fetch(context.Background(), u, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "key v1", readFile(t, p))
+	assert.Equal(t, `"v1"`, server.lastRequest().Header.Get("If-None-Match"))
+
+	server.set([]byte("key v2"), `"v2"`)
+
+	p, err = c.Fetch(context.Background(), u, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "key v2", readFile(t, p))
+
+	// The cached copy is used when the server is unreachable.
+	ts.Close()
+
+	p, err = c.Fetch(context.Background(), u, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "key v2", readFile(t, p))
+
+	// A copy cached by URL is not served without revalidation.
+	_, ok := c.Lookup(checksum([]byte("key v2")))
+	assert.False(t, ok)
+}
+
+func TestCache_Fetch_evictsLeastRecentlyUsed(t *testing.T) {
+	server := &fileServer{content: bytes.Repeat([]byte("x"), 600)}
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+
+	c := downloadcache.New(t.TempDir(), 1000)
+
+	_, err := c.Fetch(context.Background(), ts.URL+"/first.zip", "", nil)
+	require.NoError(t, err)
+
+	_, err = c.Fetch(context.Background(), ts.URL+"/second.zip", "", nil)
+	require.NoError(t, err)
+
+	entries, err := c.List()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, ts.URL+"/second.zip", entries[0].URL)
+}
+
+func TestCache_Prune(t *testing.T) {
+	server := &fileServer{content: []byte("archive")}
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+
+	c := downloadcache.New(t.TempDir(), 0)
+
+	_, err := c.Fetch(context.Background(), ts.URL+"/gameap.tar.gz", "", nil)
+	require.NoError(t, err)
+
+	removed, freed, err := c.Prune(time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 0, removed)
+	assert.Zero(t, freed)
+
+	removed, freed, err = c.Prune(0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	assert.Positive(t, freed)
+
+	entries, err := c.List()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestCache_unavailable(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(file, nil, 0o600))
+
+	c := downloadcache.New(filepath.Join(file, "cache"), 0)
+
+	_, err := c.Fetch(context.Background(), "https://example.invalid/file", "", nil)
+	require.ErrorIs(t, err, downloadcache.ErrUnavailable)
+}
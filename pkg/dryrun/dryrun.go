@@ -0,0 +1,162 @@
+// Package dryrun records the changes a command would make to the system instead
+// of applying them. Side-effecting primitives (command execution, package
+// installation, unit files, users, directories and files) check the context and
+// add an action to the plan when dry-run mode is enabled.
+package dryrun
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/gameap/gameapctl/internal/pkg/redact"
+	"github.com/pkg/errors"
+)
+
+type Kind string
+
+const (
+	KindPackage   Kind = "package"
+	KindCommand   Kind = "command"
+	KindService   Kind = "service"
+	KindUser      Kind = "user"
+	KindDirectory Kind = "directory"
+	KindFile      Kind = "file"
+	KindDownload  Kind = "download"
+	KindDatabase  Kind = "database"
+)
+
+// kindOrder is the order of sections in the text report.
+var kindOrder = []Kind{
+	KindPackage,
+	KindDownload,
+	KindUser,
+	KindDirectory,
+	KindFile,
+	KindService,
+	KindDatabase,
+	KindCommand,
+}
+
+var kindTitles = map[Kind]string{
+	KindPackage:   "Packages to install or remove",
+	KindDownload:  "Files to download",
+	KindUser:      "Users and groups to create",
+	KindDirectory: "Directories to create",
+	KindFile:      "Files to write or remove",
+	KindService:   "Services to configure",
+	KindDatabase:  "Database changes",
+	KindCommand:   "Commands to run",
+}
+
+type Action struct {
+	Kind        Kind   `json:"kind"`
+	Description string `json:"description"`
+}
+
+// Plan collects the actions in the order they would have been executed.
+type Plan struct {
+	mu      sync.Mutex
+	actions []Action
+}
+
+func NewPlan() *Plan {
+	return &Plan{}
+}
+
+func (p *Plan) Add(kind Kind, description string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.actions = append(p.actions, Action{Kind: kind, Description: description})
+}
+
+func (p *Plan) Actions() []Action {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	actions := make([]Action, len(p.actions))
+	copy(actions, p.actions)
+
+	return actions
+}
+
+// Report is the rendered form of a plan for the output package.
+type Report struct {
+	DryRun  bool     `json:"dryRun"`
+	Actions []Action `json:"actions"`
+}
+
+func (p *Plan) Report() Report {
+	return Report{DryRun: true, Actions: p.Actions()}
+}
+
+// WriteText prints the actions grouped by kind, keeping the execution order
+// inside every group.
+func (r Report) WriteText(w io.Writer) error {
+	lines := []string{"", "Dry run: no changes were made. The following changes would be applied:"}
+
+	if len(r.Actions) == 0 {
+		lines = append(lines, "  nothing to do")
+	}
+
+	for _, kind := range kindOrder {
+		var section []string
+		for _, action := range r.Actions {
+			if action.Kind == kind {
+				section = append(section, "  - "+action.Description)
+			}
+		}
+
+		if len(section) == 0 {
+			continue
+		}
+
+		lines = append(lines, "", kindTitles[kind]+":")
+		lines = append(lines, section...)
+	}
+
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return errors.Wrap(err, "failed to write dry run report")
+		}
+	}
+
+	return nil
+}
+
+type contextKey struct{}
+
+func WithPlan(ctx context.Context, plan *Plan) context.Context {
+	return context.WithValue(ctx, contextKey{}, plan)
+}
+
+// FromContext returns the plan of a dry run or nil when changes must be applied.
+func FromContext(ctx context.Context) *Plan {
+	plan, _ := ctx.Value(contextKey{}).(*Plan)
+
+	return plan
+}
+
+func Enabled(ctx context.Context) bool {
+	return FromContext(ctx) != nil
+}
+
+// Record adds an action to the plan when dry-run mode is enabled. It reports
+// whether the caller must skip the change. Known secrets are masked in the
+// description:
+//
+//	if dryrun.Record(ctx, dryrun.KindFile, "write %s", path) {
+//		return nil
+//	}
+func Record(ctx context.Context, kind Kind, format string, a ...any) bool {
+	plan := FromContext(ctx)
+	if plan == nil {
+		return false
+	}
+
+	plan.Add(kind, redact.String(fmt.Sprintf(format, a...)))
+
+	return true
+}
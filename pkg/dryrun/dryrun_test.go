@@ -0,0 +1,63 @@
+package dryrun
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecord_DisabledWithoutPlan(t *testing.T) {
+	ctx := context.Background()
+
+	assert.False(t, Enabled(ctx))
+	assert.False(t, Record(ctx, KindFile, "write %s", "/etc/gameap/config.env"))
+}
+
+func TestRecord_AddsActionToPlan(t *testing.T) {
+	plan := NewPlan()
+	ctx := WithPlan(context.Background(), plan)
+
+	assert.True(t, Enabled(ctx))
+	assert.True(t, Record(ctx, KindPackage, "install %s", "nginx"))
+	assert.True(t, Record(ctx, KindCommand, "systemctl daemon-reload"))
+
+	assert.Equal(t, []Action{
+		{Kind: KindPackage, Description: "install nginx"},
+		{Kind: KindCommand, Description: "systemctl daemon-reload"},
+	}, plan.Actions())
+}
+
+func TestReport_WriteTextGroupsByKind(t *testing.T) {
+	plan := NewPlan()
+	plan.Add(KindCommand, "systemctl daemon-reload")
+	plan.Add(KindDirectory, "/var/lib/gameap (0755)")
+	plan.Add(KindPackage, "install curl")
+	plan.Add(KindDirectory, "/etc/gameap (0755)")
+
+	var buf bytes.Buffer
+	require.NoError(t, plan.Report().WriteText(&buf))
+
+	assert.Equal(t, `
+Dry run: no changes were made. The following changes would be applied:
+
+Packages to install or remove:
+  - install curl
+
+Directories to create:
+  - /var/lib/gameap (0755)
+  - /etc/gameap (0755)
+
+Commands to run:
+  - systemctl daemon-reload
+`, buf.String())
+}
+
+func TestReport_WriteTextEmptyPlan(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, NewPlan().Report().WriteText(&buf))
+
+	assert.Contains(t, buf.String(), "nothing to do")
+}
@@ -9,10 +9,15 @@ import (
 	"path/filepath"
 	"strconv"
 
+	"github.com/gameap/gameapctl/pkg/dryrun"
 	"github.com/pkg/errors"
 )
 
 func ChownRecursive(ctx context.Context, path string, userName string, groupName string) error {
+	if dryrun.Record(ctx, dryrun.KindFile, "chown -R %s:%s %s", userName, groupName, path) {
+		return nil
+	}
+
 	u, err := user.Lookup(userName)
 	if err != nil {
 		return errors.WithMessage(err, "failed to lookup user")
@@ -44,6 +49,10 @@ func ChownRecursive(ctx context.Context, path string, userName string, groupName
 // ChownR recursively changes the ownership of all files and directories under path.
 // Based on https://github.com/gutengo/fil/blob/6109b2e0b5cfdefdef3a254cc1a3eaa35bc89284/file.go#L27
 func ChownR(ctx context.Context, path string, uid, gid int) error {
+	if dryrun.Record(ctx, dryrun.KindFile, "chown -R %d:%d %s", uid, gid, path) {
+		return nil
+	}
+
 	return filepath.Walk(path, func(name string, info os.FileInfo, err error) error {
 		// Check if context is cancelled
 		select {
@@ -6,6 +6,7 @@ import (
 	"log"
 	"strings"
 
+	"github.com/gameap/gameapctl/pkg/dryrun"
 	"github.com/pkg/errors"
 )
 
@@ -49,6 +50,10 @@ func Grant(ctx context.Context, path string, userName string, permission GrantFl
 	userIdentifier := resolveUserIdentifier(userName)
 	grantParam := fmt.Sprintf("%s:(OI)(CI)%s", userIdentifier, string(permission))
 
+	if dryrun.Record(ctx, dryrun.KindFile, "icacls %s /grant %s /T", path, grantParam) {
+		return nil
+	}
+
 	output, err := ExecCommandWithOutput(ctx, "icacls", path, "/grant", grantParam, "/T")
 	if err != nil {
 		log.Printf(
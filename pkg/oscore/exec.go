@@ -6,19 +6,27 @@ import (
 	"log"
 	"os/exec"
 
+	"github.com/gameap/gameapctl/internal/pkg/redact"
+	"github.com/gameap/gameapctl/pkg/dryrun"
 	"github.com/pkg/errors"
 )
 
 func ExecCommand(ctx context.Context, command string, args ...string) error {
 	cmd := exec.CommandContext(ctx, command, args...)
 
+	if dryrun.Record(ctx, dryrun.KindCommand, "%s", redact.String(cmd.String())) {
+		return nil
+	}
+
 	cmd.Stdout = log.Writer()
 	cmd.Stderr = log.Writer()
-	log.Println("\n" + cmd.String())
+	log.Println("\n" + redact.String(cmd.String()))
 
 	return cmd.Run()
 }
 
+// ExecCommandWithOutput runs the command even in dry-run mode: it is meant for
+// commands that only query the system.
 func ExecCommandWithOutput(ctx context.Context, command string, args ...string) (string, error) {
 	cmd := exec.CommandContext(ctx, command, args...)
 
@@ -26,7 +34,7 @@ func ExecCommandWithOutput(ctx context.Context, command string, args ...string)
 	buf.Grow(1024) //nolint:mnd
 	cmd.Stdout = buf
 	cmd.Stderr = log.Writer()
-	log.Println("\n" + cmd.String())
+	log.Println("\n" + redact.String(cmd.String()))
 	err := cmd.Run()
 	if err != nil {
 		return "", errors.Wrapf(err, "failed to run command %s", command)
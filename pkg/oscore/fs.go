@@ -0,0 +1,46 @@
+package oscore
+
+import (
+	"context"
+	"os"
+
+	"github.com/gameap/gameapctl/pkg/dryrun"
+	"github.com/pkg/errors"
+)
+
+// MkdirAll creates the directory with its parents. In dry-run mode the directory
+// is recorded in the plan unless it already exists.
+func MkdirAll(ctx context.Context, path string, perm os.FileMode) error {
+	if dryrun.Enabled(ctx) {
+		if _, err := os.Stat(path); err != nil {
+			dryrun.Record(ctx, dryrun.KindDirectory, "%s (%04o)", path, perm)
+		}
+
+		return nil
+	}
+
+	return errors.Wrapf(os.MkdirAll(path, perm), "failed to create directory %s", path)
+}
+
+// WriteFile writes the file, in dry-run mode it is only recorded in the plan.
+func WriteFile(ctx context.Context, path string, data []byte, perm os.FileMode) error {
+	if dryrun.Record(ctx, dryrun.KindFile, "write %s (%04o, %d bytes)", path, perm, len(data)) {
+		return nil
+	}
+
+	return errors.Wrapf(os.WriteFile(path, data, perm), "failed to write file %s", path)
+}
+
+// RemoveAll removes the path with its children. In dry-run mode an existing path
+// is recorded in the plan.
+func RemoveAll(ctx context.Context, path string) error {
+	if dryrun.Enabled(ctx) {
+		if _, err := os.Lstat(path); err == nil {
+			dryrun.Record(ctx, dryrun.KindFile, "remove %s", path)
+		}
+
+		return nil
+	}
+
+	return errors.Wrapf(os.RemoveAll(path), "failed to remove %s", path)
+}
@@ -0,0 +1,68 @@
+package oscore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gameap/gameapctl/pkg/dryrun"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMkdirAll_DryRunRecordsMissingDirectoryOnly(t *testing.T) {
+	existing := t.TempDir()
+	missing := filepath.Join(existing, "data")
+
+	plan := dryrun.NewPlan()
+	ctx := dryrun.WithPlan(context.Background(), plan)
+
+	require.NoError(t, MkdirAll(ctx, existing, 0755))
+	require.NoError(t, MkdirAll(ctx, missing, 0755))
+
+	assert.NoDirExists(t, missing)
+	assert.Equal(t, []dryrun.Action{
+		{Kind: dryrun.KindDirectory, Description: missing + " (0755)"},
+	}, plan.Actions())
+}
+
+func TestWriteFile_DryRunDoesNotWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.env")
+
+	plan := dryrun.NewPlan()
+	ctx := dryrun.WithPlan(context.Background(), plan)
+
+	require.NoError(t, WriteFile(ctx, path, []byte("HTTP_PORT=80\n"), 0600))
+
+	assert.NoFileExists(t, path)
+	assert.Equal(t, []dryrun.Action{
+		{Kind: dryrun.KindFile, Description: "write " + path + " (0600, 13 bytes)"},
+	}, plan.Actions())
+}
+
+func TestRemoveAll_DryRunKeepsFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gameap")
+	require.NoError(t, os.WriteFile(path, []byte("binary"), 0600))
+
+	plan := dryrun.NewPlan()
+	ctx := dryrun.WithPlan(context.Background(), plan)
+
+	require.NoError(t, RemoveAll(ctx, path))
+	require.NoError(t, RemoveAll(ctx, path+".backup"))
+
+	assert.FileExists(t, path)
+	assert.Equal(t, []dryrun.Action{
+		{Kind: dryrun.KindFile, Description: "remove " + path},
+	}, plan.Actions())
+}
+
+func TestWriteFile_WritesWithoutPlan(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.env")
+
+	require.NoError(t, WriteFile(context.Background(), path, []byte("HTTP_PORT=80\n"), 0600))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "HTTP_PORT=80\n", string(data))
+}
@@ -3,6 +3,8 @@ package oscore
 import (
 	"context"
 	"log"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -36,6 +38,32 @@ func FindProcessByName(ctx context.Context, processName string) (*process.Proces
 	return nil, nil //nolint:nilnil
 }
 
+// FindProcessesInDir returns the processes whose working directory is dir or
+// is inside it. Processes whose working directory cannot be read are skipped.
+func FindProcessesInDir(ctx context.Context, dir string) ([]*process.Process, error) {
+	processes, err := process.ProcessesWithContext(ctx)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to load all processes")
+	}
+
+	dir = filepath.Clean(dir)
+
+	var result []*process.Process
+	for _, p := range processes {
+		cwd, err := p.CwdWithContext(ctx)
+		if err != nil || cwd == "" {
+			continue
+		}
+
+		cwd = filepath.Clean(cwd)
+		if cwd == dir || strings.HasPrefix(cwd, dir+string(filepath.Separator)) {
+			result = append(result, p)
+		}
+	}
+
+	return result, nil
+}
+
 func WaitForProcessByName(ctx context.Context, processName string) (*process.Process, error) {
 	ticker := time.NewTicker(defaultWaitInterval)
 	defer ticker.Stop()
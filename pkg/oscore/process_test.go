@@ -3,6 +3,7 @@ package oscore
 import (
 	"context"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/shirou/gopsutil/v3/process"
@@ -21,3 +22,22 @@ func TestFindProcessByName_FindsOwnProcess(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, found)
 }
+
+func TestFindProcessesInDir_FindsOwnProcess(t *testing.T) {
+	ctx := context.Background()
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+
+	found, err := FindProcessesInDir(ctx, filepath.Dir(wd))
+	require.NoError(t, err)
+
+	pids := make([]int32, 0, len(found))
+	for _, p := range found {
+		pids = append(pids, p.Pid)
+	}
+	require.Contains(t, pids, int32(os.Getpid()))
+
+	found, err = FindProcessesInDir(ctx, filepath.Join(wd, "missing"))
+	require.NoError(t, err)
+	require.Empty(t, found)
+}
@@ -10,6 +10,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/gameap/gameapctl/pkg/dryrun"
 	"github.com/pkg/errors"
 )
 
@@ -23,6 +24,10 @@ func CreateGroup(ctx context.Context, groupname string, opts ...CreateGroupOptio
 		return fmt.Errorf("group %s already exists", groupname) //nolint:err113
 	}
 
+	if dryrun.Record(ctx, dryrun.KindUser, "create group %s", groupname) {
+		return nil
+	}
+
 	// Find the next available GID if not provided
 	gid := options.gid
 	if gid == "" {
@@ -95,6 +100,10 @@ func CreateUser(
 		return fmt.Errorf("user %s already exists", username) //nolint:err113
 	}
 
+	if dryrun.Record(ctx, dryrun.KindUser, "create user %s", username) {
+		return nil
+	}
+
 	// Find the next available UID
 	uid, err := findNextAvailableUID(ctx)
 	if err != nil {
@@ -9,12 +9,23 @@ import (
 	"os/user"
 	"strings"
 
+	"github.com/gameap/gameapctl/pkg/dryrun"
 	"github.com/pkg/errors"
 )
 
 func CreateGroup(ctx context.Context, groupname string, opts ...CreateGroupOption) error {
 	options := applyCreateGroupOptions(opts...)
 
+	if dryrun.Enabled(ctx) {
+		if _, err := user.LookupGroup(groupname); err == nil {
+			return NewGroupAlreadyExistsError(groupname)
+		}
+
+		dryrun.Record(ctx, dryrun.KindUser, "create group %s", groupname)
+
+		return nil
+	}
+
 	// Check if group already exists
 	err := ExecCommand(ctx, "getent", "group", groupname)
 	if err == nil {
@@ -50,6 +61,10 @@ func CreateUser(
 		return NewUserAlreadyExistsError(username)
 	}
 
+	if dryrun.Record(ctx, dryrun.KindUser, "create user %s", username) {
+		return nil
+	}
+
 	// Build useradd arguments
 	args := []string{"-m"}
 
@@ -7,10 +7,21 @@ import (
 	"os/exec"
 	"os/user"
 
+	"github.com/gameap/gameapctl/pkg/dryrun"
 	"github.com/pkg/errors"
 )
 
 func CreateGroup(ctx context.Context, groupname string, opts ...CreateGroupOption) error {
+	if dryrun.Enabled(ctx) {
+		if _, err := user.LookupGroup(groupname); err == nil {
+			return NewGroupAlreadyExistsError(groupname)
+		}
+
+		dryrun.Record(ctx, dryrun.KindUser, "create group %s", groupname)
+
+		return nil
+	}
+
 	// Create group with net localgroup command
 	// Note: We attempt to create the group directly because checking for existence
 	// with "net localgroup <name>" is unreliable on Windows (it may fail to find
@@ -43,6 +54,10 @@ func CreateUser(
 		return NewUserAlreadyExistsError(username)
 	}
 
+	if dryrun.Record(ctx, dryrun.KindUser, "create user %s", username) {
+		return nil
+	}
+
 	// Create user with net user command
 	args := []string{"user", username}
 
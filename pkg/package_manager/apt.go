@@ -228,12 +228,14 @@ func newExtendedAPT(osInfo osinfo.Info, apt *apt) (*extended, error) {
 		return nil, errors.WithMessage(err, "failed to load package configurations")
 	}
 
+	underlined := newDryRunAware(apt)
+
 	return &extended{
 		packages:   packages,
-		underlined: apt,
+		underlined: underlined,
 		strategy: &aptStrategy{
 			packages: packages,
-			apt:      apt,
+			apt:      underlined,
 		},
 	}, nil
 }
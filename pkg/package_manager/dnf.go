@@ -83,7 +83,7 @@ func newExtendedDNF(osInfo osinfo.Info, underlined PackageManager) (*extended, e
 
 	return &extended{
 		packages:   packages,
-		underlined: underlined,
+		underlined: newDryRunAware(underlined),
 		strategy:   noopStrategy{},
 	}, nil
 }
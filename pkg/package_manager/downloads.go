@@ -0,0 +1,58 @@
+package packagemanager
+
+import (
+	"maps"
+	"slices"
+
+	osinfo "github.com/gameap/gameapctl/pkg/os_info"
+	"github.com/gameap/gameapctl/pkg/package_manager/windows"
+	"github.com/pkg/errors"
+)
+
+// https://curl.se/docs/caextract.html
+const caCertURL = "https://curl.se/ca/cacert.pem"
+
+// PackageDownload is a file a package manager downloads itself instead of
+// installing it from the OS repositories. URLs are alternative locations of
+// the same file.
+type PackageDownload struct {
+	Package string
+	URLs    []string
+}
+
+// PackageDownloads lists the files the package managers of the system
+// download themselves: the chroot packages on Debian and Ubuntu and the
+// Windows packages. Packages from the OS repositories are not included.
+func PackageDownloads(info osinfo.Info) ([]PackageDownload, error) {
+	var downloads []PackageDownload
+
+	switch {
+	case info.Distribution.IsDebianLike():
+		for _, name := range slices.Sorted(maps.Keys(chrootPackages)) {
+			p, ok := chrootPackages[name][info.Platform]
+			if !ok {
+				continue
+			}
+
+			downloads = append(downloads,
+				PackageDownload{Package: name, URLs: []string{p.ArchiveURL}},
+				PackageDownload{Package: name, URLs: []string{p.SystemdUnitURL}},
+			)
+		}
+	case info.Distribution.IsWindows():
+		packages, err := windows.LoadPackages(info)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to load windows packages")
+		}
+
+		for _, name := range slices.Sorted(maps.Keys(packages)) {
+			if urls := packages[name].DownloadURLs; len(urls) > 0 {
+				downloads = append(downloads, PackageDownload{Package: name, URLs: urls})
+			}
+		}
+
+		downloads = append(downloads, PackageDownload{Package: CACertificatesPackage, URLs: []string{caCertURL}})
+	}
+
+	return downloads, nil
+}
@@ -0,0 +1,40 @@
+package packagemanager
+
+import (
+	"testing"
+
+	osinfo "github.com/gameap/gameapctl/pkg/os_info"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackageDownloads(t *testing.T) {
+	downloads, err := PackageDownloads(osinfo.Info{
+		Distribution: osinfo.DistributionDebian, DistributionVersion: "13", Platform: osinfo.PlatformAmd64,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []PackageDownload{
+		{Package: PHPPackage, URLs: []string{chrootPackages[PHPPackage][ArchAMD64].ArchiveURL}},
+		{Package: PHPPackage, URLs: []string{chrootPackages[PHPPackage][ArchAMD64].SystemdUnitURL}},
+	}, downloads)
+
+	downloads, err = PackageDownloads(osinfo.Info{
+		Distribution: osinfo.DistributionDebian, DistributionVersion: "13", Platform: osinfo.PlatformArm64,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, downloads)
+
+	downloads, err = PackageDownloads(osinfo.Info{
+		Distribution: osinfo.DistributionWindows, Platform: osinfo.PlatformAmd64,
+	})
+	require.NoError(t, err)
+
+	byPackage := lo.SliceToMap(downloads, func(d PackageDownload) (string, []string) { return d.Package, d.URLs })
+	assert.NotEmpty(t, byPackage[NginxPackage])
+	assert.Equal(t, []string{caCertURL}, byPackage[CACertificatesPackage])
+
+	downloads, err = PackageDownloads(osinfo.Info{Distribution: osinfo.DistributionCentOS})
+	require.NoError(t, err)
+	assert.Empty(t, downloads)
+}
@@ -0,0 +1,55 @@
+package packagemanager
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gameap/gameapctl/pkg/dryrun"
+)
+
+// dryRunAware wraps a system package manager. In dry-run mode it records the
+// packages that would be installed or removed instead of calling the underlying
+// manager. Searches are read-only and always reach the underlying manager.
+type dryRunAware struct {
+	underlined PackageManager
+}
+
+func newDryRunAware(underlined PackageManager) *dryRunAware {
+	return &dryRunAware{underlined: underlined}
+}
+
+func (d *dryRunAware) Search(ctx context.Context, name string) ([]PackageInfo, error) {
+	return d.underlined.Search(ctx, name)
+}
+
+func (d *dryRunAware) Install(ctx context.Context, pack string, opts ...InstallOptions) error {
+	if dryrun.Record(ctx, dryrun.KindPackage, "install %s", pack) {
+		return nil
+	}
+
+	return d.underlined.Install(ctx, pack, opts...)
+}
+
+func (d *dryRunAware) CheckForUpdates(ctx context.Context) error {
+	if dryrun.Record(ctx, dryrun.KindPackage, "update package lists") {
+		return nil
+	}
+
+	return d.underlined.CheckForUpdates(ctx)
+}
+
+func (d *dryRunAware) Remove(ctx context.Context, packs ...string) error {
+	if dryrun.Record(ctx, dryrun.KindPackage, "remove %s", strings.Join(packs, ", ")) {
+		return nil
+	}
+
+	return d.underlined.Remove(ctx, packs...)
+}
+
+func (d *dryRunAware) Purge(ctx context.Context, packs ...string) error {
+	if dryrun.Record(ctx, dryrun.KindPackage, "purge %s", strings.Join(packs, ", ")) {
+		return nil
+	}
+
+	return d.underlined.Purge(ctx, packs...)
+}
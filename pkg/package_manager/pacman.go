@@ -142,7 +142,7 @@ func newExtendedPacman(osInfo osinfo.Info, underlined PackageManager) (*extended
 
 	return &extended{
 		packages:   packages,
-		underlined: underlined,
+		underlined: newDryRunAware(underlined),
 		strategy:   noopStrategy{},
 	}, nil
 }
@@ -19,7 +19,7 @@ func TestLoadPackages_APT_Default(t *testing.T) {
 		assert.Equal(t, "postgresql", pkg.Name)
 		assert.Equal(t, []string{"postgresql", "postgresql-contrib"}, pkg.ReplaceWith)
 		assert.Empty(t, pkg.PreInstall)
-		require.Len(t, pkg.PostInstall, 2)
+		require.Len(t, pkg.PostInstall, 1)
 		assert.Empty(t, pkg.Install)
 	})
 
@@ -216,7 +216,7 @@ func TestLoadPackages_DNF_Default(t *testing.T) {
 		require.True(t, exists, "postgresql should exist in default.yaml")
 		assert.Equal(t, "postgresql", pkg.Name)
 		assert.Equal(t, []string{"postgresql-server", "postgresql-contrib"}, pkg.ReplaceWith)
-		require.Len(t, pkg.PostInstall, 2)
+		require.Len(t, pkg.PostInstall, 1)
 		require.Len(t, pkg.PostInstall[0].RunCommands, 3)
 		assert.Equal(t, "postgresql-setup --initdb", pkg.PostInstall[0].RunCommands[0])
 		assert.Equal(t, "systemctl enable postgresql", pkg.PostInstall[0].RunCommands[1])
@@ -282,14 +282,12 @@ func TestLoadPackages_Pacman_Default(t *testing.T) {
 		pkg, exists := packages["postgresql"]
 		require.True(t, exists, "postgresql should exist in default.yaml")
 		assert.Equal(t, []string{"postgresql"}, pkg.ReplaceWith)
-		require.Len(t, pkg.PostInstall, 2)
+		require.Len(t, pkg.PostInstall, 1)
 		require.Len(t, pkg.PostInstall[0].RunCommands, 3)
 		assert.Contains(t, pkg.PostInstall[0].RunCommands[0], "PG_VERSION")
 		assert.Contains(t, pkg.PostInstall[0].RunCommands[0], "initdb")
 		assert.Equal(t, "systemctl enable postgresql", pkg.PostInstall[0].RunCommands[1])
 		assert.Equal(t, "systemctl start postgresql", pkg.PostInstall[0].RunCommands[2])
-		require.Len(t, pkg.PostInstall[1].RunCommands, 3)
-		assert.Contains(t, pkg.PostInstall[1].RunCommands[0], `configValue "db-root-password"`)
 	})
 
 	t.Run("redis-server maps to valkey", func(t *testing.T) {
@@ -403,7 +401,7 @@ func TestLoadPackages_DNF_CentOS10(t *testing.T) {
 		pkg, exists := packages["postgresql"]
 		require.True(t, exists, "postgresql should exist")
 		assert.Equal(t, []string{"postgresql-server", "postgresql-contrib"}, pkg.ReplaceWith)
-		require.Len(t, pkg.PostInstall, 2)
+		require.Len(t, pkg.PostInstall, 1)
 		require.Len(t, pkg.PostInstall[0].RunCommands, 3)
 		assert.Equal(t, "postgresql-setup --initdb", pkg.PostInstall[0].RunCommands[0])
 	})
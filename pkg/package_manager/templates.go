@@ -3,6 +3,7 @@ package packagemanager
 import (
 	"text/template"
 
+	"github.com/gameap/gameapctl/internal/pkg/redact"
 	pkgstings "github.com/gameap/gameapctl/pkg/strings"
 )
 
@@ -14,5 +15,10 @@ var runtimeTemplateFuncMap = template.FuncMap{
 
 		return value
 	},
-	"generatePassword": pkgstings.GeneratePassword,
+	"generatePassword": func(length int) (string, error) {
+		password, err := pkgstings.GeneratePassword(length)
+		redact.Register(password)
+
+		return password, err
+	},
 }
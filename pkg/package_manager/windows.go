@@ -18,6 +18,7 @@ import (
 	"text/template"
 	"time"
 
+	"github.com/gameap/gameapctl/pkg/dryrun"
 	osinfo "github.com/gameap/gameapctl/pkg/os_info"
 	"github.com/gameap/gameapctl/pkg/oscore"
 	"github.com/gameap/gameapctl/pkg/package_manager/windows"
@@ -33,9 +34,6 @@ const servicesConfigPath = "C:\\gameap\\services"
 
 const defaultServiceUser = "NT AUTHORITY\\NETWORK SERVICE"
 
-// https://curl.se/docs/caextract.html
-const caCertURL = "https://curl.se/ca/cacert.pem"
-
 type WindowsPackageManager struct {
 	packages map[string]windows.Package
 }
@@ -73,6 +71,10 @@ func (pm *WindowsPackageManager) Install(ctx context.Context, pack string, opts
 		return nil
 	}
 
+	if dryrun.Record(ctx, dryrun.KindPackage, "install %s", pack) {
+		return nil
+	}
+
 	err = pm.installPackage(ctx, p, options)
 	if err != nil {
 		return err
@@ -446,6 +448,10 @@ func (pm *WindowsPackageManager) Remove(ctx context.Context, packs ...string) er
 			continue
 		}
 
+		if dryrun.Record(ctx, dryrun.KindPackage, "remove %s", packName) {
+			continue
+		}
+
 		err := pm.removePackage(ctx, p)
 		if err != nil {
 			return errors.WithMessagef(err, "failed to remove package '%s'", packName)
@@ -13,6 +13,7 @@ import (
 	"strings"
 	"text/template"
 
+	"github.com/gameap/gameapctl/pkg/dryrun"
 	"github.com/gameap/gameapctl/pkg/gameap"
 	"github.com/gameap/gameapctl/pkg/oscore"
 	"github.com/gameap/gameapctl/pkg/releasefinder"
@@ -298,7 +299,7 @@ func createConfigEnv(ctx context.Context, config InstallConfig) error {
 	}
 
 	configPath := filepath.Join(config.ConfigDirectory, "config.env")
-	if err := os.WriteFile(configPath, rendered, 0600); err != nil {
+	if err := oscore.WriteFile(ctx, configPath, rendered, 0600); err != nil {
 		return errors.WithMessage(err, "failed to write config.env file")
 	}
 
@@ -325,8 +326,8 @@ func createDirectories(ctx context.Context, config InstallConfig) error {
 	}
 
 	for _, dir := range directories {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return errors.WithMessagef(err, "failed to create directory %s", dir)
+		if err := oscore.MkdirAll(ctx, dir, 0755); err != nil {
+			return err
 		}
 	}
 
@@ -390,10 +391,7 @@ func ResolveRelease(ctx context.Context, config InstallConfig) (*releasesource.R
 }
 
 func downloadBinaries(ctx context.Context, config InstallConfig) (string, error) {
-	tmpDir, err := os.MkdirTemp("", "gameap")
-	if err != nil {
-		return "", errors.WithMessage(err, "failed to make temp dir")
-	}
+	var err error
 
 	release := config.PreResolvedRelease
 	if release == nil {
@@ -403,6 +401,17 @@ func downloadBinaries(ctx context.Context, config InstallConfig) (string, error)
 		}
 	}
 
+	if dryrun.Record(ctx, dryrun.KindDownload, "gameap %s from %s", release.Tag, release.PrimaryURL()) {
+		dryrun.Record(ctx, dryrun.KindFile, "install gameap binary to %s (0755)", config.BinaryPath)
+
+		return release.Tag, nil
+	}
+
+	tmpDir, err := os.MkdirTemp("", "gameap")
+	if err != nil {
+		return "", errors.WithMessage(err, "failed to make temp dir")
+	}
+
 	fmt.Println("Downloading binaries ...")
 	fmt.Println("Release Tag:", release.Tag)
 
@@ -472,6 +481,16 @@ func preserveExistingSecrets(config InstallConfig) InstallConfig {
 	return config
 }
 
+// ReadConfigValues returns the key/value pairs of a panel config.env file.
+func ReadConfigValues(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read config file")
+	}
+
+	return parseConfigEnvValues(data), nil
+}
+
 func parseConfigEnvValues(data []byte) map[string]string {
 	result := make(map[string]string)
 	for _, line := range strings.Split(string(data), "\n") {
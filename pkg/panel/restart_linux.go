@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 
+	"github.com/gameap/gameapctl/pkg/dryrun"
 	"github.com/gameap/gameapctl/pkg/gameap"
 	"github.com/gameap/gameapctl/pkg/oscore"
 	"github.com/gameap/gameapctl/pkg/runhelper"
@@ -14,6 +15,10 @@ import (
 )
 
 func Restart(ctx context.Context, opts ...Options) error {
+	if dryrun.Record(ctx, dryrun.KindService, "restart gameap service") {
+		return nil
+	}
+
 	o := firstOptions(opts)
 
 	if o.scope() == gameap.ScopeUser {
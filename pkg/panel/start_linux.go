@@ -12,6 +12,7 @@ import (
 	"strings"
 	"syscall"
 
+	"github.com/gameap/gameapctl/pkg/dryrun"
 	"github.com/gameap/gameapctl/pkg/gameap"
 	"github.com/gameap/gameapctl/pkg/oscore"
 	"github.com/gameap/gameapctl/pkg/runhelper"
@@ -23,6 +24,10 @@ import (
 const panelServiceName = "gameap"
 
 func Start(ctx context.Context, opts ...Options) error {
+	if dryrun.Record(ctx, dryrun.KindService, "start gameap service") {
+		return nil
+	}
+
 	o := firstOptions(opts)
 
 	// DetectInit reads /proc/1/exe, which an unprivileged user cannot do, so in user
@@ -7,6 +7,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/gameap/gameapctl/pkg/dryrun"
 	"github.com/gameap/gameapctl/pkg/gameap"
 	"github.com/gameap/gameapctl/pkg/oscore"
 	"github.com/gameap/gameapctl/pkg/runhelper"
@@ -19,6 +20,10 @@ const (
 )
 
 func Stop(ctx context.Context, opts ...Options) error {
+	if dryrun.Record(ctx, dryrun.KindService, "stop gameap service") {
+		return nil
+	}
+
 	o := firstOptions(opts)
 
 	if o.scope() == gameap.ScopeUser {
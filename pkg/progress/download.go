@@ -0,0 +1,103 @@
+package progress
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// downloadStep is the number of bytes between two events of a download with an
+// unknown size.
+const downloadStep = 1 << 20
+
+// DownloadTracker reports the downloaded bytes. It implements the progress
+// tracker interface of go-getter.
+type DownloadTracker struct {
+	bus *Bus
+}
+
+// NewDownloadTracker returns nil when there is no bus in the context, a nil
+// tracker passes the stream through.
+func NewDownloadTracker(ctx context.Context) *DownloadTracker {
+	bus := FromContext(ctx)
+	if bus == nil {
+		return nil
+	}
+
+	return &DownloadTracker{bus: bus}
+}
+
+func (t *DownloadTracker) TrackProgress(
+	src string, currentSize, totalSize int64, stream io.ReadCloser,
+) io.ReadCloser {
+	if t == nil {
+		return stream
+	}
+
+	return &trackingReader{
+		ReadCloser: stream,
+		bus:        t.bus,
+		source:     src,
+		bytes:      currentSize,
+		total:      totalSize,
+	}
+}
+
+type trackingReader struct {
+	io.ReadCloser
+
+	bus    *Bus
+	source string
+
+	mu       sync.Mutex
+	bytes    int64
+	total    int64
+	reported int64
+	closed   bool
+}
+
+func (r *trackingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+
+	r.mu.Lock()
+	r.bytes += int64(n)
+	report := r.bytes-r.reported >= r.reportStep()
+	if report {
+		r.reported = r.bytes
+	}
+	bytes := r.bytes
+	r.mu.Unlock()
+
+	if report {
+		r.send(bytes)
+	}
+
+	return n, err
+}
+
+func (r *trackingReader) Close() error {
+	r.mu.Lock()
+	first := !r.closed
+	r.closed = true
+	bytes := r.bytes
+	r.mu.Unlock()
+
+	if first {
+		r.send(bytes)
+	}
+
+	return r.ReadCloser.Close()
+}
+
+// reportStep limits the events to one per percent.
+func (r *trackingReader) reportStep() int64 {
+	if r.total <= 0 {
+		return downloadStep
+	}
+
+	return max(r.total/100, 1) //nolint:mnd
+}
+
+func (r *trackingReader) send(bytes int64) {
+	r.bus.emit(Event{Type: EventDownload, Source: r.source, Bytes: bytes, Total: r.total})
+}
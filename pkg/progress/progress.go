@@ -0,0 +1,225 @@
+// Package progress reports the progress of long running operations (install,
+// upgrade, self-update) as typed events. Operations emit events to the bus in
+// the context, subscribers render them: the CLI as a progress display, the web
+// UI as websocket messages.
+package progress
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventStepStarted  EventType = "step_started"
+	EventStepFinished EventType = "step_finished"
+	EventStepFailed   EventType = "step_failed"
+	EventStepSkipped  EventType = "step_skipped"
+	EventDownload     EventType = "download"
+)
+
+type Event struct {
+	Type  EventType `json:"type"`
+	Step  string    `json:"step,omitempty"`
+	Title string    `json:"title,omitempty"`
+	// Percent is the progress of the whole operation, 0-100.
+	Percent int `json:"percent"`
+	// Message is the error of a failed step or the reason a step was skipped.
+	Message string `json:"message,omitempty"`
+	// Source, Bytes and Total describe a download, Total is 0 when the size is unknown.
+	Source  string        `json:"source,omitempty"`
+	Bytes   int64         `json:"bytes,omitempty"`
+	Total   int64         `json:"total,omitempty"`
+	Elapsed time.Duration `json:"-"`
+}
+
+type Handler func(Event)
+
+// Bus delivers events to the subscribers and keeps the overall percentage. Only
+// the steps of the outermost sequence are counted: a daemon install started as
+// a step of a panel install reports its steps without moving the percentage.
+type Bus struct {
+	mu       sync.Mutex
+	handlers []Handler
+
+	depth int
+	total int
+	done  int
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+func (b *Bus) Subscribe(h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers = append(b.handlers, h)
+}
+
+func (b *Bus) emit(e Event) {
+	b.mu.Lock()
+	e.Percent = b.percent()
+	handlers := b.handlers
+	b.mu.Unlock()
+
+	for _, h := range handlers {
+		h(e)
+	}
+}
+
+func (b *Bus) percent() int {
+	if b.total == 0 {
+		return 0
+	}
+
+	return min(b.done*100/b.total, 100) //nolint:mnd
+}
+
+type contextKey struct{}
+
+func WithBus(ctx context.Context, bus *Bus) context.Context {
+	return context.WithValue(ctx, contextKey{}, bus)
+}
+
+// FromContext returns the bus or nil when nobody listens for progress.
+func FromContext(ctx context.Context) *Bus {
+	bus, _ := ctx.Value(contextKey{}).(*Bus)
+
+	return bus
+}
+
+// Sequence reports the consecutive steps of one operation. Starting a step
+// finishes the previous one, End finishes or fails the last one:
+//
+//	steps := progress.Steps(ctx, 3)
+//	defer func() { steps.End(err) }()
+//
+//	steps.Next("database", "database setup")
+type Sequence struct {
+	bus   *Bus
+	outer bool
+
+	step    string
+	title   string
+	started time.Time
+	ended   bool
+}
+
+// Steps starts a sequence of total steps. It is safe to use without a bus in
+// the context, events are dropped then.
+func Steps(ctx context.Context, total int) *Sequence {
+	bus := FromContext(ctx)
+	if bus == nil {
+		return &Sequence{}
+	}
+
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	s := &Sequence{bus: bus, outer: bus.depth == 0}
+	if s.outer {
+		bus.total = total
+		bus.done = 0
+	}
+	bus.depth++
+
+	return s
+}
+
+func (s *Sequence) Next(step, title string) {
+	s.finish()
+
+	s.step = step
+	s.title = title
+	s.started = time.Now()
+
+	s.send(Event{Type: EventStepStarted, Step: step, Title: title})
+}
+
+// Skip reports a step that is not executed, it counts as done.
+func (s *Sequence) Skip(step, title, reason string) {
+	s.finish()
+	s.count()
+
+	s.send(Event{Type: EventStepSkipped, Step: step, Title: title, Message: reason})
+}
+
+// End finishes the current step, or fails it when err is not nil, and closes
+// the sequence. Only the first call has an effect.
+func (s *Sequence) End(err error) {
+	if s.ended {
+		return
+	}
+	s.ended = true
+
+	if err != nil {
+		s.Fail(err)
+	}
+
+	s.finish()
+
+	if s.bus == nil {
+		return
+	}
+
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+
+	s.bus.depth--
+	if s.outer && err == nil {
+		s.bus.done = s.bus.total
+	}
+}
+
+// Fail reports the current step as failed without ending the sequence, for
+// steps whose failure does not stop the operation.
+func (s *Sequence) Fail(err error) {
+	if s.step == "" {
+		return
+	}
+
+	s.send(Event{
+		Type:    EventStepFailed,
+		Step:    s.step,
+		Title:   s.title,
+		Message: err.Error(),
+		Elapsed: time.Since(s.started),
+	})
+
+	s.count()
+	s.step = ""
+}
+
+func (s *Sequence) finish() {
+	if s.step == "" {
+		return
+	}
+
+	s.count()
+	s.send(Event{Type: EventStepFinished, Step: s.step, Title: s.title, Elapsed: time.Since(s.started)})
+
+	s.step = ""
+}
+
+func (s *Sequence) count() {
+	if s.bus == nil || !s.outer {
+		return
+	}
+
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+
+	s.bus.done++
+}
+
+func (s *Sequence) send(e Event) {
+	if s.bus == nil {
+		return
+	}
+
+	s.bus.emit(e)
+}
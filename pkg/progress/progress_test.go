@@ -0,0 +1,184 @@
+package progress
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func collect(ctx context.Context) (context.Context, *[]Event) {
+	events := &[]Event{}
+
+	bus := NewBus()
+	bus.Subscribe(func(e Event) {
+		*events = append(*events, e)
+	})
+
+	return WithBus(ctx, bus), events
+}
+
+func summary(events []Event) []string {
+	result := make([]string, 0, len(events))
+	for _, e := range events {
+		result = append(result, fmt.Sprintf("%s %s %d", e.Type, e.Step, e.Percent))
+	}
+
+	return result
+}
+
+func TestSteps_Percent(t *testing.T) {
+	ctx, events := collect(context.Background())
+
+	steps := Steps(ctx, 4)
+	steps.Next("one", "first")
+	steps.Skip("two", "second", "not needed")
+	steps.Next("three", "third")
+	steps.Next("four", "fourth")
+	steps.End(nil)
+
+	assert.Equal(t, []string{
+		"step_started one 0",
+		"step_finished one 25",
+		"step_skipped two 50",
+		"step_started three 50",
+		"step_finished three 75",
+		"step_started four 75",
+		"step_finished four 100",
+	}, summary(*events))
+}
+
+func TestSteps_Failed(t *testing.T) {
+	ctx, events := collect(context.Background())
+
+	steps := Steps(ctx, 2)
+	steps.Next("one", "first")
+	steps.End(errors.New("boom"))
+	steps.End(nil)
+
+	require.Len(t, *events, 2)
+	assert.Equal(t, EventStepFailed, (*events)[1].Type)
+	assert.Equal(t, "first", (*events)[1].Title)
+	assert.Equal(t, "boom", (*events)[1].Message)
+	assert.Equal(t, 0, (*events)[1].Percent)
+}
+
+func TestSteps_NestedDoNotMovePercent(t *testing.T) {
+	ctx, events := collect(context.Background())
+
+	outer := Steps(ctx, 2)
+	outer.Next("panel", "panel")
+	outer.Next("daemon", "daemon")
+
+	inner := Steps(ctx, 5)
+	inner.Next("daemon.binaries", "binaries")
+	inner.End(nil)
+
+	outer.End(nil)
+
+	assert.Equal(t, []string{
+		"step_started panel 0",
+		"step_finished panel 50",
+		"step_started daemon 50",
+		"step_started daemon.binaries 50",
+		"step_finished daemon.binaries 50",
+		"step_finished daemon 100",
+	}, summary(*events))
+
+	// The next operation starts from zero.
+	next := Steps(ctx, 1)
+	next.Next("check", "check")
+	assert.Equal(t, 0, (*events)[len(*events)-1].Percent)
+}
+
+func TestSteps_WithoutBus(t *testing.T) {
+	steps := Steps(context.Background(), 2)
+
+	assert.NotPanics(t, func() {
+		steps.Next("one", "first")
+		steps.Skip("two", "second", "reason")
+		steps.End(errors.New("boom"))
+	})
+}
+
+func TestJSONRenderer_ParseLine(t *testing.T) {
+	buf := &bytes.Buffer{}
+	render := NewJSONRenderer(buf)
+
+	render(Event{Type: EventStepFailed, Step: "database", Title: "database setup", Percent: 40, Message: "boom"})
+	render(Event{Type: EventDownload, Source: "https://example.com/a.tar.gz", Bytes: 10, Total: 20})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], LinePrefix))
+
+	e, ok := ParseLine(lines[0])
+	require.True(t, ok)
+	assert.Equal(t, Event{
+		Type: EventStepFailed, Step: "database", Title: "database setup", Percent: 40, Message: "boom",
+	}, e)
+
+	e, ok = ParseLine(lines[1])
+	require.True(t, ok)
+	assert.Equal(t, int64(20), e.Total)
+
+	_, ok = ParseLine("Installing dependencies ...")
+	assert.False(t, ok)
+	_, ok = ParseLine(LinePrefix + "{broken")
+	assert.False(t, ok)
+}
+
+func TestTextRenderer(t *testing.T) {
+	buf := &bytes.Buffer{}
+	render := NewTextRenderer(buf)
+
+	render(Event{Type: EventStepStarted, Title: "database setup", Percent: 5})
+	render(Event{Type: EventDownload, Source: "a.tar.gz", Bytes: 1 << 20, Total: 100 << 20})
+	render(Event{Type: EventDownload, Source: "a.tar.gz", Bytes: 15 << 20, Total: 100 << 20})
+	render(Event{Type: EventDownload, Source: "a.tar.gz", Bytes: 100 << 20, Total: 100 << 20})
+	render(Event{Type: EventStepSkipped, Title: "daemon", Percent: 60, Message: "not needed"})
+	render(Event{Type: EventStepFailed, Title: "panel", Percent: 80, Message: "boom"})
+
+	assert.Equal(t, "[  5%] database setup ...\n"+
+		"       downloading a.tar.gz: 15% (15.0 MiB of 100.0 MiB)\n"+
+		"       downloading a.tar.gz: 100% (100.0 MiB of 100.0 MiB)\n"+
+		"[ 60%] daemon: skipped, not needed\n"+
+		"[ 80%] panel: failed: boom\n", buf.String())
+}
+
+func TestNewRenderer_UnknownMode(t *testing.T) {
+	_, err := NewRenderer("fancy", io.Discard)
+
+	assert.Error(t, err)
+}
+
+func TestDownloadTracker(t *testing.T) {
+	ctx, events := collect(context.Background())
+
+	data := strings.Repeat("x", 1000)
+	stream := NewDownloadTracker(ctx).TrackProgress("src", 0, int64(len(data)), io.NopCloser(strings.NewReader(data)))
+
+	read, err := io.ReadAll(stream)
+	require.NoError(t, err)
+	require.NoError(t, stream.Close())
+
+	assert.Len(t, read, len(data))
+	require.NotEmpty(t, *events)
+
+	last := (*events)[len(*events)-1]
+	assert.Equal(t, EventDownload, last.Type)
+	assert.Equal(t, int64(1000), last.Bytes)
+	assert.Equal(t, int64(1000), last.Total)
+}
+
+func TestDownloadTracker_WithoutBus(t *testing.T) {
+	stream := io.NopCloser(strings.NewReader("data"))
+
+	assert.Equal(t, stream, NewDownloadTracker(context.Background()).TrackProgress("src", 0, 4, stream))
+}
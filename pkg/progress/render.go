@@ -0,0 +1,143 @@
+package progress
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// LinePrefix marks the lines of the JSON renderer in a mixed output stream, the
+// web UI reads events from the output of the gameapctl process it runs.
+const LinePrefix = "::progress::"
+
+// FlagName is the name of the global flag selecting the progress display.
+const FlagName = "progress"
+
+const (
+	ModeText = "text"
+	ModeJSON = "json"
+	ModeNone = "none"
+)
+
+// downloadReportStep is the number of bytes between two text lines of a download
+// with an unknown size.
+const downloadReportStep = 10 << 20
+
+// NewRenderer returns the handler for the mode, ModeNone drops the events.
+func NewRenderer(mode string, w io.Writer) (Handler, error) {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", ModeText:
+		return NewTextRenderer(w), nil
+	case ModeJSON:
+		return NewJSONRenderer(w), nil
+	case ModeNone:
+		return func(Event) {}, nil
+	default:
+		return nil, errors.Errorf(
+			"unknown progress mode %q (expected %q, %q or %q)", mode, ModeText, ModeJSON, ModeNone,
+		)
+	}
+}
+
+// NewTextRenderer prints the events as lines, downloads every 10 percent.
+func NewTextRenderer(w io.Writer) Handler {
+	var mu sync.Mutex
+	reported := make(map[string]int64)
+
+	return func(e Event) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var line string
+
+		switch e.Type {
+		case EventStepStarted:
+			line = fmt.Sprintf("[%3d%%] %s ...", e.Percent, e.Title)
+		case EventStepFinished:
+			line = fmt.Sprintf("[%3d%%] %s: done in %s", e.Percent, e.Title, e.Elapsed.Round(time.Second))
+		case EventStepFailed:
+			line = fmt.Sprintf("[%3d%%] %s: failed: %s", e.Percent, e.Title, e.Message)
+		case EventStepSkipped:
+			line = fmt.Sprintf("[%3d%%] %s: skipped, %s", e.Percent, e.Title, e.Message)
+		case EventDownload:
+			step := int64(downloadReportStep)
+			if e.Total > 0 {
+				step = max(e.Total/10, 1) //nolint:mnd
+			}
+
+			if e.Bytes < reported[e.Source]+step && e.Bytes != e.Total {
+				return
+			}
+			reported[e.Source] = e.Bytes
+
+			line = "       downloading " + e.Source + ": " + formatDownload(e.Bytes, e.Total)
+		default:
+			return
+		}
+
+		_, _ = fmt.Fprintln(w, line)
+	}
+}
+
+func formatDownload(bytes, total int64) string {
+	if total <= 0 {
+		return FormatBytes(bytes)
+	}
+
+	return fmt.Sprintf("%d%% (%s of %s)", bytes*100/total, FormatBytes(bytes), FormatBytes(total)) //nolint:mnd
+}
+
+// FormatBytes formats a size in binary units, e.g. "1.5 MiB".
+func FormatBytes(n int64) string {
+	const unit = 1024
+
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// NewJSONRenderer writes every event as a JSON line marked with LinePrefix.
+func NewJSONRenderer(w io.Writer) Handler {
+	var mu sync.Mutex
+
+	return func(e Event) {
+		b, err := json.Marshal(e)
+		if err != nil {
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		_, _ = fmt.Fprintln(w, LinePrefix+string(b))
+	}
+}
+
+// ParseLine decodes a line written by the JSON renderer. It reports false for
+// any other line.
+func ParseLine(line string) (Event, bool) {
+	data, ok := strings.CutPrefix(strings.TrimSpace(line), LinePrefix)
+	if !ok {
+		return Event{}, false
+	}
+
+	var e Event
+	if err := json.Unmarshal([]byte(data), &e); err != nil {
+		return Event{}, false
+	}
+
+	return e, true
+}
@@ -13,6 +13,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/gameap/gameapctl/pkg/utils"
 	"github.com/pkg/errors"
 )
 
@@ -20,9 +21,13 @@ type Release struct {
 	URL       string
 	Tag       string
 	AssetName string
+	// Digest is the digest GitHub reports for the asset, such as "sha256:<hex>".
+	// It is empty for assets uploaded before GitHub started reporting them.
+	Digest string
 }
 
-var httpClient = &http.Client{}
+// httpClient also serves file:// release lists of local mirrors.
+var httpClient = &http.Client{Transport: utils.NewHTTPTransport()}
 
 // FindOptions controls how Find selects a release.
 type FindOptions struct {
@@ -297,6 +302,7 @@ type asset struct {
 	URL                string `json:"url"`
 	Name               string `json:"name"`
 	BrowserDownloadURL string `json:"browser_download_url"` //nolint:tagliatelle
+	Digest             string `json:"digest"`
 }
 
 func findReleaseFromList(bodyBytes []byte, os, arch string, opts FindOptions) (*Release, error) {
@@ -366,6 +372,7 @@ func matchAsset(release releases, os, arch string) *Release {
 				URL:       asset.BrowserDownloadURL,
 				Tag:       release.TagName,
 				AssetName: asset.Name,
+				Digest:    asset.Digest,
 			}
 		}
 	}
@@ -688,6 +688,18 @@ func Test_FindFromStaticList(t *testing.T) {
 		assert.Equal(t, "gameap-daemon-v3.2.0-linux-amd64.tar.gz", release.AssetName)
 	})
 
+	t.Run("asset_digest", func(t *testing.T) {
+		srv := serve(t, `[{"tag_name": "v4.1.0", "assets": [{
+			"name": "gameap-v4.1.0-linux-amd64.tar.gz",
+			"browser_download_url": "https://example.com/gameap-v4.1.0-linux-amd64.tar.gz",
+			"digest": "sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+		}]}]`)
+
+		release, err := FindFromStaticList(context.Background(), srv.URL, "linux", "amd64", FindOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", release.Digest)
+	})
+
 	t.Run("exact_tag_found", func(t *testing.T) {
 		srv := serve(t, releasesWithPrerelease)
 
@@ -0,0 +1,43 @@
+package releasesource
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Channel selects which releases an installation follows when no version is
+// requested.
+type Channel string
+
+const (
+	// ChannelStable follows the latest stable release.
+	ChannelStable Channel = "stable"
+	// ChannelBeta follows the latest release including prereleases.
+	ChannelBeta Channel = "beta"
+	// ChannelNightly builds the development branch from the GitHub source.
+	ChannelNightly Channel = "nightly"
+)
+
+// ParseChannel parses a channel name, an empty name is ChannelStable.
+func ParseChannel(s string) (Channel, error) {
+	switch c := Channel(strings.ToLower(strings.TrimSpace(s))); c {
+	case "":
+		return ChannelStable, nil
+	case ChannelStable, ChannelBeta, ChannelNightly:
+		return c, nil
+	default:
+		return "", errors.Errorf("unknown release channel %q, expected stable, beta or nightly", s)
+	}
+}
+
+// AllowPrerelease reports whether the channel includes prereleases.
+func (c Channel) AllowPrerelease() bool {
+	return c == ChannelBeta
+}
+
+// FromSource reports whether the channel builds from the GitHub source
+// instead of using releases.
+func (c Channel) FromSource() bool {
+	return c == ChannelNightly
+}
@@ -0,0 +1,28 @@
+package releasesource
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseChannel(t *testing.T) {
+	for input, want := range map[string]Channel{
+		"":        ChannelStable,
+		"stable":  ChannelStable,
+		" Beta ":  ChannelBeta,
+		"nightly": ChannelNightly,
+	} {
+		got, err := ParseChannel(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, got, input)
+	}
+
+	_, err := ParseChannel("alpha")
+	require.EqualError(t, err, `unknown release channel "alpha", expected stable, beta or nightly`)
+
+	assert.True(t, ChannelBeta.AllowPrerelease())
+	assert.False(t, ChannelStable.AllowPrerelease())
+	assert.True(t, ChannelNightly.FromSource())
+}
@@ -0,0 +1,202 @@
+package releasesource
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"aead.dev/minisign"
+	"github.com/gameap/gameapctl/pkg/dryrun"
+	"github.com/gameap/gameapctl/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// Release metadata assets published next to the release archives.
+const (
+	// ChecksumsAsset lists the SHA-256 of every asset in the sha256sum format.
+	ChecksumsAsset = "checksums.txt"
+	// SignatureAsset is the minisign signature of ChecksumsAsset.
+	SignatureAsset = ChecksumsAsset + ".minisig"
+)
+
+const (
+	sha256Size      = 32
+	maxMetadataSize = 1 << 20
+	metadataTimeout = time.Minute
+)
+
+var metadataClient = &http.Client{Timeout: metadataTimeout, Transport: utils.NewHTTPTransport()}
+
+// ParseChecksums parses a checksums file in the sha256sum format and returns
+// the lowercase hex digests by asset name.
+func ParseChecksums(content []byte) (map[string]string, error) {
+	sums := make(map[string]string)
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		digest, name, ok := strings.Cut(text, " ")
+		name = strings.TrimPrefix(strings.TrimSpace(name), "*")
+
+		if b, err := hex.DecodeString(digest); !ok || err != nil || len(b) != sha256Size || name == "" {
+			return nil, errors.Errorf("invalid checksums line %d", line)
+		}
+
+		sums[name] = strings.ToLower(digest)
+	}
+
+	return sums, errors.Wrap(scanner.Err(), "failed to read checksums")
+}
+
+// VerifySignature checks the minisign signature of the content against the
+// public key given in the minisign text format.
+func VerifySignature(publicKey string, content, signature []byte) error {
+	var key minisign.PublicKey
+	if err := key.UnmarshalText([]byte(publicKey)); err != nil {
+		return errors.Wrap(err, "invalid public key")
+	}
+
+	if !minisign.Verify(key, content, signature) {
+		return errors.New("signature verification failed")
+	}
+
+	return nil
+}
+
+// sha256Digest returns the hex digest of a "sha256:<hex>" asset digest, other
+// algorithms are ignored.
+func sha256Digest(digest string) string {
+	algorithm, value, ok := strings.Cut(digest, ":")
+	if !ok || algorithm != "sha256" {
+		return ""
+	}
+
+	if b, err := hex.DecodeString(value); err != nil || len(b) != sha256Size {
+		return ""
+	}
+
+	return strings.ToLower(value)
+}
+
+// loadChecksum sets the expected digest of a release whose metadata has none
+// from the checksums file the project published with it, on GitHub or the
+// project CDN, never from a mirror serving the asset. The checksums are not
+// signed: they catch a tampered mirror, not a tampered GitHub release or CDN.
+// Only the gameapctl self-update checks the signature. A release without
+// checksums is downloaded unverified.
+func loadChecksum(ctx context.Context, release *Release) {
+	if release == nil || release.SHA256 != "" || release.AssetName == "" || dryrun.Enabled(ctx) {
+		return
+	}
+
+	for _, u := range release.checksumURLs {
+		digest, err := fetchChecksum(ctx, u, release.AssetName)
+		if err == nil {
+			release.SHA256 = digest
+
+			return
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		log.Printf("No checksum of %s at %s: %v", release.AssetName, u, err)
+	}
+
+	log.Printf("No checksum published for %s, the download is not verified", release.AssetName)
+}
+
+func fetchChecksum(ctx context.Context, checksumsURL, assetName string) (string, error) {
+	content, err := fetch(ctx, checksumsURL)
+	if err != nil {
+		return "", err
+	}
+
+	sums, err := ParseChecksums(content)
+	if err != nil {
+		return "", err
+	}
+
+	digest, ok := sums[assetName]
+	if !ok {
+		return "", errors.Errorf("%s is not listed", assetName)
+	}
+
+	return digest, nil
+}
+
+// FetchAsset downloads a small asset published in the same release, such as
+// ChecksumsAsset, trying the locations of the release asset in order.
+func FetchAsset(ctx context.Context, release *Release, name string) ([]byte, error) {
+	if release == nil || len(release.URLs) == 0 {
+		return nil, errors.New("release has no download URLs")
+	}
+
+	var lastErr error
+	for _, u := range release.URLs {
+		assetURL, err := siblingURL(u, name)
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := fetch(ctx, assetURL)
+		if err == nil {
+			return content, nil
+		}
+
+		lastErr = err
+		if ctx.Err() != nil {
+			return nil, lastErr
+		}
+	}
+
+	return nil, errors.WithMessagef(lastErr, "failed to fetch %s", name)
+}
+
+// siblingURL replaces the asset name at the end of the download URL.
+func siblingURL(assetURL, name string) (string, error) {
+	u, err := url.Parse(assetURL)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid download URL")
+	}
+
+	u.Path = path.Join(path.Dir(u.Path), name)
+	u.RawPath = ""
+	u.RawQuery = ""
+
+	return u.String(), nil
+}
+
+func fetch(ctx context.Context, u string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create request")
+	}
+
+	resp, err := metadataClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get %s", u)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("%s responded with status code %d", u, resp.StatusCode)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxMetadataSize))
+
+	return content, errors.Wrapf(err, "failed to read %s", u)
+}
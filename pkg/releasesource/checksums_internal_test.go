@@ -0,0 +1,217 @@
+package releasesource
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"aead.dev/minisign"
+	"github.com/gameap/gameapctl/pkg/releasefinder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDigest = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+func Test_ParseChecksums(t *testing.T) {
+	sums, err := ParseChecksums([]byte(
+		testDigest + "  gameapctl-v1.0.0-linux-amd64.tar.gz\n\n" +
+			"9F86D081884C7D659A2FEAA0C55AD015A3BF4F1B2B0B822CD15D6C15B0F00A08 *gameapctl-v1.0.0-windows-amd64.zip\n",
+	))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"gameapctl-v1.0.0-linux-amd64.tar.gz": testDigest,
+		"gameapctl-v1.0.0-windows-amd64.zip":  testDigest,
+	}, sums)
+
+	_, err = ParseChecksums([]byte(testDigest + "\n"))
+	require.EqualError(t, err, "invalid checksums line 1")
+
+	_, err = ParseChecksums([]byte("abc  gameapctl.tar.gz\n"))
+	require.EqualError(t, err, "invalid checksums line 1")
+}
+
+func Test_VerifySignature(t *testing.T) {
+	publicKey, privateKey, err := minisign.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	publicKeyText, err := publicKey.MarshalText()
+	require.NoError(t, err)
+
+	content := []byte(testDigest + "  gameapctl-v1.0.0-linux-amd64.tar.gz\n")
+	signature := minisign.Sign(privateKey, content)
+
+	require.NoError(t, VerifySignature(string(publicKeyText), content, signature))
+	require.EqualError(t,
+		VerifySignature(string(publicKeyText), append(content, '\n'), signature),
+		"signature verification failed",
+	)
+
+	otherKey, _, err := minisign.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherKeyText, err := otherKey.MarshalText()
+	require.NoError(t, err)
+	require.Error(t, VerifySignature(string(otherKeyText), content, signature))
+}
+
+func Test_FetchAsset_triesSiblingURLs(t *testing.T) {
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(notFound.Close)
+
+	var requested string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.Path
+		_, _ = w.Write([]byte("checksums"))
+	}))
+	t.Cleanup(srv.Close)
+
+	release := &Release{URLs: []string{
+		notFound.URL + "/gameapctl/v1.0.0/gameapctl-v1.0.0-linux-amd64.tar.gz",
+		srv.URL + "/gameapctl/v1.0.0/gameapctl-v1.0.0-linux-amd64.tar.gz",
+	}}
+
+	content, err := FetchAsset(context.Background(), release, ChecksumsAsset)
+	require.NoError(t, err)
+	assert.Equal(t, "checksums", string(content))
+	assert.Equal(t, "/gameapctl/v1.0.0/checksums.txt", requested)
+}
+
+func Test_DownloadFile_skipsMismatchingChecksum(t *testing.T) {
+	tampered := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("tampered-content"))
+	}))
+	t.Cleanup(tampered.Close)
+
+	genuine := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("binary-content"))
+	}))
+	t.Cleanup(genuine.Close)
+
+	digest := sha256.Sum256([]byte("binary-content"))
+
+	dst := filepath.Join(t.TempDir(), "out.bin")
+	release := &Release{
+		URLs:   []string{tampered.URL + "/file.bin", genuine.URL + "/file.bin"},
+		SHA256: hex.EncodeToString(digest[:]),
+	}
+
+	require.NoError(t, DownloadFile(context.Background(), release, dst))
+
+	content, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, "binary-content", string(content))
+
+	release.URLs = release.URLs[:1]
+	require.Error(t, DownloadFile(context.Background(), release, filepath.Join(t.TempDir(), "out.bin")))
+}
+
+func Test_sha256Digest(t *testing.T) {
+	assert.Equal(t, testDigest, sha256Digest("sha256:"+testDigest))
+	assert.Equal(t, testDigest, sha256Digest("sha256:"+strings.ToUpper(testDigest)))
+	assert.Empty(t, sha256Digest(""))
+	assert.Empty(t, sha256Digest(testDigest))
+	assert.Empty(t, sha256Digest("sha512:"+testDigest))
+	assert.Empty(t, sha256Digest("sha256:abc"))
+}
+
+func Test_Download_verifiesPublishedChecksums(t *testing.T) {
+	const asset = "gameap-daemon-v3.2.0-linux-amd64"
+
+	digest := sha256.Sum256([]byte("binary-content"))
+	tamperedDigest := sha256.Sum256([]byte("tampered-content"))
+
+	// The mirror serves a tampered asset with checksums matching it, they are
+	// not trusted.
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if path.Base(r.URL.Path) == ChecksumsAsset {
+			_, _ = w.Write([]byte(hex.EncodeToString(tamperedDigest[:]) + "  " + asset + "\n"))
+
+			return
+		}
+		_, _ = w.Write([]byte("tampered-content"))
+	}))
+	t.Cleanup(mirror.Close)
+
+	// The first project location publishes a broken checksums file, the
+	// second one the digest of the genuine content.
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("not a checksums file\n"))
+	}))
+	t.Cleanup(broken.Close)
+
+	genuine := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if path.Base(r.URL.Path) == ChecksumsAsset {
+			_, _ = w.Write([]byte(hex.EncodeToString(digest[:]) + "  " + asset + "\n"))
+
+			return
+		}
+		_, _ = w.Write([]byte("binary-content"))
+	}))
+	t.Cleanup(genuine.Close)
+
+	release := &Release{
+		AssetName: asset,
+		URLs:      []string{mirror.URL + "/v3.2.0/" + asset, genuine.URL + "/v3.2.0/" + asset},
+		checksumURLs: []string{
+			broken.URL + "/v3.2.0/" + ChecksumsAsset,
+			genuine.URL + "/v3.2.0/" + ChecksumsAsset,
+		},
+	}
+
+	dst := filepath.Join(t.TempDir(), "out.bin")
+	require.NoError(t, DownloadFile(context.Background(), release, dst))
+	assert.Equal(t, hex.EncodeToString(digest[:]), release.SHA256)
+
+	content, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, "binary-content", string(content))
+}
+
+func Test_buildRelease_ignoresMirrorChecksums(t *testing.T) {
+	github := source{name: sourceNameGitHub, kind: kindGitHub, baseURL: "https://api.github.com"}
+	cdn := source{name: CDNGameAPCom, kind: kindCDN, baseURL: "https://" + CDNGameAPCom}
+	mirror := source{name: "mirror", kind: kindMirror, baseURL: "https://mirror.example.com"}
+
+	rel := &releasefinder.Release{
+		Tag:       "v3.2.0",
+		AssetName: "gameap-daemon-v3.2.0-linux-amd64.tar.gz",
+		URL:       "https://mirror.example.com/evil.tar.gz",
+		Digest:    "sha256:" + testDigest,
+	}
+
+	release := buildRelease(mirror, []source{mirror, github, cdn}, ComponentDaemon, rel)
+	assert.Empty(t, release.SHA256)
+	assert.Equal(t, []string{
+		"https://github.com/gameap/daemon/releases/download/v3.2.0/checksums.txt",
+		"https://cdn.gameap.com/gameap-daemon/v3.2.0/checksums.txt",
+	}, release.checksumURLs)
+
+	release = buildRelease(github, []source{github, cdn, mirror}, ComponentDaemon, rel)
+	assert.Equal(t, testDigest, release.SHA256)
+
+	local := source{name: "bundle", kind: kindMirror, baseURL: "file:///opt/bundle/releases"}
+	release = buildRelease(local, []source{local}, ComponentDaemon, rel)
+	assert.Equal(t, testDigest, release.SHA256)
+}
+
+func Test_loadChecksum_unpublished(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(srv.Close)
+
+	release := &Release{
+		AssetName: "file.bin", URLs: []string{srv.URL + "/file.bin"}, checksumURLs: []string{srv.URL + "/checksums.txt"},
+	}
+	loadChecksum(context.Background(), release)
+	assert.Empty(t, release.SHA256)
+
+	release = &Release{AssetName: "file.bin", URLs: []string{srv.URL + "/file.bin"}, SHA256: testDigest}
+	loadChecksum(context.Background(), release)
+	assert.Equal(t, testDigest, release.SHA256)
+}
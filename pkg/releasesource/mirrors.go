@@ -0,0 +1,121 @@
+package releasesource
+
+import (
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// EnvMirrors lists self-hosted release mirrors added to the built-in sources,
+// separated by commas or spaces. An entry is a base URL or a name=URL pair,
+// e.g. "intranet=https://mirror.example.com/gameap,file:///srv/gameap".
+const EnvMirrors = "GAMEAP_RELEASE_MIRRORS"
+
+// Mirror is a self-hosted release source in the CDN layout:
+// <component>/releases.json and <component>/<tag>/<asset>. URL is an
+// http(s) base URL or a file:// directory.
+type Mirror struct {
+	// Name identifies the mirror in the logs and in GAMEAP_RELEASE_SOURCE.
+	// It defaults to the host and path of the URL.
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+}
+
+// ParseMirrors parses a mirror list in the EnvMirrors format.
+func ParseMirrors(value string) []Mirror {
+	fields := strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+
+	mirrors := make([]Mirror, 0, len(fields))
+	for _, field := range fields {
+		// The equals sign of a query string is not a name separator.
+		if i := strings.Index(field, "="); i > 0 && !strings.ContainsAny(field[:i], ":/?") {
+			mirrors = append(mirrors, Mirror{Name: field[:i], URL: field[i+1:]})
+
+			continue
+		}
+
+		mirrors = append(mirrors, Mirror{URL: field})
+	}
+
+	return mirrors
+}
+
+// SetMirrors adds the mirrors and the ones listed in EnvMirrors to the
+// built-in release sources. All of them are probed and ordered together on
+// the first release lookup.
+func SetMirrors(mirrors []Mirror) error {
+	mirrors = append(slices.Clone(mirrors), ParseMirrors(os.Getenv(EnvMirrors))...)
+	if len(mirrors) == 0 {
+		return nil
+	}
+
+	sources, err := withMirrors(defaultSources(), mirrors)
+	if err != nil {
+		return err
+	}
+
+	defaultSelector = newSelector(sources)
+
+	return nil
+}
+
+// UseOnly replaces all release sources with the mirror, releases are then
+// neither resolved nor downloaded from anywhere else.
+func UseOnly(m Mirror) error {
+	src, err := m.source()
+	if err != nil {
+		return err
+	}
+
+	defaultSelector = newSelector([]source{src})
+
+	return nil
+}
+
+func withMirrors(sources []source, mirrors []Mirror) ([]source, error) {
+	for _, m := range mirrors {
+		src, err := m.source()
+		if err != nil {
+			return nil, err
+		}
+
+		if slices.ContainsFunc(sources, func(s source) bool { return s.name == src.name }) {
+			return nil, errors.Errorf("duplicate release source name %q", src.name)
+		}
+
+		sources = append(sources, src)
+	}
+
+	return sources, nil
+}
+
+func (m Mirror) source() (source, error) {
+	u, err := url.Parse(m.URL)
+	if err != nil {
+		return source{}, errors.Wrap(err, "invalid release mirror URL")
+	}
+
+	switch {
+	case u.RawQuery != "" || u.Fragment != "":
+		return source{}, errors.Errorf("release mirror URL %s must not have a query", u.Redacted())
+	case (u.Scheme == "http" || u.Scheme == "https") && u.Host != "":
+	case u.Scheme == "file" && u.Path != "":
+	default:
+		return source{}, errors.Errorf(
+			"release mirror URL %s must be an http(s) URL or a file:// directory", u.Redacted(),
+		)
+	}
+
+	name := m.Name
+	if name == "" {
+		name = strings.TrimSuffix(u.Host+u.Path, "/")
+	}
+
+	return source{name: name, kind: kindMirror, baseURL: strings.TrimSuffix(m.URL, "/")}, nil
+}
@@ -0,0 +1,145 @@
+package releasesource
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gameap/gameapctl/pkg/releasefinder"
+	"github.com/gameap/gameapctl/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseMirrors(t *testing.T) {
+	mirrors := ParseMirrors(
+		"intranet=https://mirror.example.com/gameap, file:///srv/gameap\n" +
+			"https://mirror.example.com/?token=secret,,",
+	)
+
+	assert.Equal(t, []Mirror{
+		{Name: "intranet", URL: "https://mirror.example.com/gameap"},
+		{URL: "file:///srv/gameap"},
+		{URL: "https://mirror.example.com/?token=secret"},
+	}, mirrors)
+	assert.Empty(t, ParseMirrors(" "))
+}
+
+func Test_withMirrors(t *testing.T) {
+	sources, err := withMirrors(defaultSources(), []Mirror{
+		{Name: "intranet", URL: "https://mirror.example.com/gameap/"},
+		{URL: "file:///srv/gameap"},
+	})
+	require.NoError(t, err)
+	require.Len(t, sources, 5)
+	assert.Equal(t, source{name: "intranet", kind: kindMirror, baseURL: "https://mirror.example.com/gameap"}, sources[3])
+	assert.Equal(t, source{name: "/srv/gameap", kind: kindMirror, baseURL: "file:///srv/gameap"}, sources[4])
+
+	tests := []struct {
+		name    string
+		mirror  Mirror
+		wantErr string
+	}{
+		{
+			name:    "duplicate_name",
+			mirror:  Mirror{Name: CDNGameAPCom, URL: "https://mirror.example.com"},
+			wantErr: `duplicate release source name "cdn.gameap.com"`,
+		},
+		{
+			name:    "unsupported_scheme",
+			mirror:  Mirror{URL: "ftp://mirror.example.com"},
+			wantErr: "release mirror URL ftp://mirror.example.com must be an http(s) URL or a file:// directory",
+		},
+		{
+			name:    "no_scheme",
+			mirror:  Mirror{URL: "mirror.example.com"},
+			wantErr: "release mirror URL mirror.example.com must be an http(s) URL or a file:// directory",
+		},
+		{
+			name:    "query",
+			mirror:  Mirror{URL: "https://mirror.example.com/?token=secret"},
+			wantErr: "release mirror URL https://mirror.example.com/?token=secret must not have a query",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := withMirrors(defaultSources(), []Mirror{tt.mirror})
+			require.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func Test_SetMirrors(t *testing.T) {
+	original := defaultSelector
+	t.Cleanup(func() { defaultSelector = original })
+
+	t.Setenv(EnvMirrors, "env=https://env.example.com")
+
+	require.NoError(t, SetMirrors([]Mirror{{Name: "config", URL: "https://config.example.com"}}))
+	assert.Equal(t,
+		[]string{sourceNameGitHub, CDNGameAPCom, CDNGameAPRu, "config", "env"},
+		sourceNames(defaultSelector.sources),
+	)
+
+	t.Setenv(EnvMirrors, "ftp://env.example.com")
+	require.Error(t, SetMirrors(nil))
+}
+
+func Test_fileMirror(t *testing.T) {
+	dir := t.TempDir()
+	writeMirrorRelease(t, dir)
+
+	sources, err := withMirrors(nil, []Mirror{{Name: "local", URL: utils.FileURL(dir)}})
+	require.NoError(t, err)
+
+	sel := newSelector(sources)
+	assert.True(t, sel.probe(context.Background(), sources[0]).available)
+
+	release, err := sel.findRelease(
+		context.Background(), ComponentDaemon, "linux", "amd64", releasefinder.FindOptions{},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "v3.2.0", release.Tag)
+	assert.Equal(t, []string{
+		utils.FileURL(dir) + "/gameap-daemon/v3.2.0/gameap-daemon-v3.2.0-linux-amd64.tar.gz",
+	}, release.URLs)
+
+	dst := t.TempDir()
+	require.NoError(t, Download(context.Background(), release, dst))
+
+	content, err := os.ReadFile(filepath.Join(dst, "gameap-daemon"))
+	require.NoError(t, err)
+	assert.Equal(t, "daemon-binary", string(content))
+
+	// The mirror keeps its archive, it is copied rather than linked.
+	assert.FileExists(t, filepath.Join(dir, "gameap-daemon", "v3.2.0", "gameap-daemon-v3.2.0-linux-amd64.tar.gz"))
+}
+
+// writeMirrorRelease lays out a daemon release in the CDN layout.
+func writeMirrorRelease(t *testing.T, dir string) {
+	t.Helper()
+
+	releaseDir := filepath.Join(dir, "gameap-daemon", "v3.2.0")
+	require.NoError(t, os.MkdirAll(releaseDir, 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "gameapctl"), 0755))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "gameap-daemon", "releases.json"), []byte(testReleasesJSON), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "gameapctl", "releases.json"), []byte("[]"), 0600))
+
+	f, err := os.Create(filepath.Join(releaseDir, "gameap-daemon-v3.2.0-linux-amd64.tar.gz"))
+	require.NoError(t, err)
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	content := []byte("daemon-binary")
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "gameap-daemon", Mode: 0755, Size: int64(len(content))}))
+	_, err = tw.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	require.NoError(t, f.Close())
+}
@@ -1,20 +1,26 @@
 // Package releasesource resolves and downloads GameAP component releases from
-// the best available source: the GitHub releases API or one of the CDN mirrors
-// (cdn.gameap.com, cdn.gameap.ru). Sources are probed in parallel once per
-// process and used in latency order with fallback to the next source.
+// the best available source: the GitHub releases API, one of the CDN mirrors
+// (cdn.gameap.com, cdn.gameap.ru) or a self-hosted mirror added with
+// SetMirrors. Sources are probed in parallel once per process and used in
+// latency order with fallback to the next source.
 package releasesource
 
 import (
 	"context"
 	"log"
+	"os"
+	"strings"
 
+	"github.com/gameap/gameapctl/pkg/downloadcache"
+	"github.com/gameap/gameapctl/pkg/dryrun"
 	"github.com/gameap/gameapctl/pkg/releasefinder"
 	"github.com/gameap/gameapctl/pkg/utils"
+	"github.com/hashicorp/go-getter"
 	"github.com/pkg/errors"
 )
 
 // EnvSource forces a specific release source ("github", "cdn.gameap.com",
-// "cdn.gameap.ru") and disables probing and fallback.
+// "cdn.gameap.ru" or the name of a mirror) and disables probing and fallback.
 const EnvSource = "GAMEAP_RELEASE_SOURCE"
 
 type Release struct {
@@ -24,6 +30,14 @@ type Release struct {
 	// preference: the source that resolved the release first, then the
 	// remaining sources in probed order.
 	URLs []string
+	// SHA256 is the expected digest of the asset. When it is set, every
+	// download is verified before extraction and a location serving another
+	// file is skipped.
+	SHA256 string
+
+	// checksumURLs are the checksums files of the release published by the
+	// project, the mirrors are not among them.
+	checksumURLs []string
 }
 
 func (r *Release) PrimaryURL() string {
@@ -42,17 +56,30 @@ func FindRelease(
 }
 
 // Download fetches and extracts the release archive into the dst directory,
-// trying candidate URLs in order.
+// trying candidate URLs in order. The archive is verified against the digest
+// of the release before the extraction.
 func Download(ctx context.Context, release *Release, dst string) error {
+	loadChecksum(ctx, release)
+
 	return downloadWith(ctx, release, dst, utils.Download)
 }
 
 // DownloadFile fetches the release asset as a single file into dst, trying
-// candidate URLs in order.
+// candidate URLs in order. The asset is verified like in Download.
 func DownloadFile(ctx context.Context, release *Release, dst string) error {
+	loadChecksum(ctx, release)
+
 	return downloadWith(ctx, release, dst, utils.DownloadFile)
 }
 
+// DownloadArchive fetches the release asset into the dst file as it is,
+// without extracting it. The asset is verified like in Download.
+func DownloadArchive(ctx context.Context, release *Release, dst string) error {
+	loadChecksum(ctx, release)
+
+	return downloadWith(ctx, release, dst, utils.DownloadFileOrArchive)
+}
+
 func downloadWith(
 	ctx context.Context,
 	release *Release,
@@ -63,9 +90,13 @@ func downloadWith(
 		return errors.New("release has no download URLs")
 	}
 
+	if downloadCached(ctx, release, dst, downloadFunc) {
+		return nil
+	}
+
 	var lastErr error
 	for _, u := range release.URLs {
-		err := downloadFunc(ctx, u, dst)
+		err := downloadFunc(ctx, withChecksum(u, release.SHA256), dst)
 		if err == nil {
 			return nil
 		}
@@ -75,7 +106,55 @@ func downloadWith(
 			return lastErr
 		}
 		log.Printf("Download from %s failed: %v", u, err)
+
+		// go-getter resumes an existing file, the rejected one must not be
+		// continued from the next location.
+		var checksumErr *getter.ChecksumError
+		if errors.As(err, &checksumErr) {
+			_ = os.Remove(checksumErr.File)
+		}
 	}
 
 	return errors.WithMessage(lastErr, "failed to download from all sources")
 }
+
+// downloadCached fetches the release from the download cache, so a cached
+// copy spares the mirrors. Only a release with a digest is served from the
+// cache here, a copy cached by URL is revalidated by the download itself.
+func downloadCached(
+	ctx context.Context,
+	release *Release,
+	dst string,
+	downloadFunc func(ctx context.Context, source string, dst string) error,
+) bool {
+	if dryrun.Enabled(ctx) {
+		return false
+	}
+
+	cached, ok := downloadcache.Default().Lookup(release.SHA256)
+	if !ok {
+		return false
+	}
+
+	if err := downloadFunc(ctx, withChecksum(utils.FileURL(cached), release.SHA256), dst); err != nil {
+		log.Printf("Failed to use the cached %s: %v", release.AssetName, err)
+
+		return false
+	}
+
+	return true
+}
+
+// withChecksum makes go-getter verify the downloaded file before extracting it.
+func withChecksum(u, sha256 string) string {
+	if sha256 == "" {
+		return u
+	}
+
+	separator := "?"
+	if strings.Contains(u, "?") {
+		separator = "&"
+	}
+
+	return u + separator + "checksum=sha256:" + sha256
+}
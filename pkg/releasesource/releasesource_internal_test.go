@@ -7,10 +7,12 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/gameap/gameapctl/pkg/downloadcache"
 	"github.com/gameap/gameapctl/pkg/releasefinder"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
@@ -490,3 +492,46 @@ func Test_DownloadFile_fallbackOverHTTP(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "binary-content", string(content))
 }
+
+func Test_Download_usesDownloadCache(t *testing.T) {
+	original := downloadcache.Default()
+	t.Cleanup(func() { downloadcache.SetDefault(original) })
+	downloadcache.SetDefault(downloadcache.New(t.TempDir(), 0))
+
+	dir := t.TempDir()
+	writeMirrorRelease(t, dir)
+
+	// Without a published checksum the cached copy is revalidated, only the
+	// first request downloads the asset.
+	var assetRequests, revalidations atomic.Int32
+	files := http.FileServer(http.Dir(dir))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ".tar.gz") {
+			if r.Header.Get("If-None-Match") != "" || r.Header.Get("If-Modified-Since") != "" {
+				revalidations.Add(1)
+			} else {
+				assetRequests.Add(1)
+			}
+		}
+		files.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	release := &Release{
+		Tag:       "v3.2.0",
+		AssetName: "gameap-daemon-v3.2.0-linux-amd64.tar.gz",
+		URLs:      []string{srv.URL + "/gameap-daemon/v3.2.0/gameap-daemon-v3.2.0-linux-amd64.tar.gz"},
+	}
+
+	for range 2 {
+		dst := t.TempDir()
+		require.NoError(t, Download(context.Background(), release, dst))
+
+		content, err := os.ReadFile(filepath.Join(dst, "gameap-daemon"))
+		require.NoError(t, err)
+		assert.Equal(t, "daemon-binary", string(content))
+	}
+
+	assert.Equal(t, int32(1), assetRequests.Load())
+	assert.Equal(t, int32(1), revalidations.Load())
+}
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/gameap/gameapctl/pkg/releasefinder"
+	"github.com/gameap/gameapctl/pkg/utils"
 	"github.com/pkg/errors"
 )
 
@@ -31,7 +32,7 @@ type selector struct {
 func newSelector(sources []source) *selector {
 	return &selector{
 		sources: sources,
-		client:  &http.Client{Timeout: probeTimeout},
+		client:  &http.Client{Timeout: probeTimeout, Transport: utils.NewHTTPTransport()},
 	}
 }
 
@@ -153,7 +154,22 @@ func buildRelease(chosen source, ordered []source, component Component, rel *rel
 		urls = append(urls, src.downloadURL(component, rel))
 	}
 
-	return &Release{Tag: rel.Tag, AssetName: rel.AssetName, URLs: urls}
+	release := &Release{Tag: rel.Tag, AssetName: rel.AssetName, URLs: urls}
+
+	// A mirror could serve a digest matching its own tampered asset. A local
+	// directory, such as an offline bundle, was placed by the administrator.
+	if chosen.kind != kindMirror || strings.HasPrefix(chosen.baseURL, "file:") {
+		release.SHA256 = sha256Digest(rel.Digest)
+	}
+
+	for _, src := range append([]source{chosen}, ordered...) {
+		u := src.checksumsURL(component, rel.Tag)
+		if u != "" && !slices.Contains(release.checksumURLs, u) {
+			release.checksumURLs = append(release.checksumURLs, u)
+		}
+	}
+
+	return release
 }
 
 func isContentError(err error) bool {
@@ -2,6 +2,7 @@ package releasesource
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/gameap/gameapctl/pkg/releasefinder"
 )
@@ -19,6 +20,8 @@ type sourceKind int
 const (
 	kindGitHub sourceKind = iota
 	kindCDN
+	// kindMirror is a self-hosted mirror in the CDN layout.
+	kindMirror
 )
 
 const sourceNameGitHub = "github"
@@ -70,6 +73,22 @@ func (s source) releasesURL(component Component) string {
 	return s.baseURL + "/" + string(component) + "/releases.json"
 }
 
+// checksumsURL returns where the project publishes the checksums of the
+// release, empty for the mirrors. The GitHub URL is built from the repository,
+// not from the release list, which a mirror may have served.
+func (s source) checksumsURL(component Component, tag string) string {
+	switch s.kind {
+	case kindGitHub:
+		repo := strings.TrimSuffix(strings.TrimPrefix(githubReleasesPath[component], "/repos"), "/releases")
+
+		return "https://github.com" + repo + "/releases/download/" + tag + "/" + ChecksumsAsset
+	case kindCDN:
+		return s.baseURL + "/" + string(component) + "/" + tag + "/" + ChecksumsAsset
+	default:
+		return ""
+	}
+}
+
 func (s source) downloadURL(component Component, rel *releasefinder.Release) string {
 	if s.kind == kindGitHub {
 		// browser_download_url points at github.com even in CDN-mirrored release lists.
@@ -9,6 +9,7 @@ import (
 	"sync"
 
 	contextInternal "github.com/gameap/gameapctl/internal/context"
+	"github.com/gameap/gameapctl/pkg/dryrun"
 	"github.com/pkg/errors"
 )
 
@@ -25,6 +26,10 @@ type Service interface {
 }
 
 func Start(ctx context.Context, serviceName string) error {
+	if dryrun.Record(ctx, dryrun.KindService, "start %s service", serviceName) {
+		return nil
+	}
+
 	s, err := Load(ctx)
 	if err != nil {
 		return err
@@ -34,6 +39,10 @@ func Start(ctx context.Context, serviceName string) error {
 }
 
 func Stop(ctx context.Context, serviceName string) error {
+	if dryrun.Record(ctx, dryrun.KindService, "stop %s service", serviceName) {
+		return nil
+	}
+
 	s, err := Load(ctx)
 	if err != nil {
 		return err
@@ -43,6 +52,10 @@ func Stop(ctx context.Context, serviceName string) error {
 }
 
 func Restart(ctx context.Context, serviceName string) error {
+	if dryrun.Record(ctx, dryrun.KindService, "restart %s service", serviceName) {
+		return nil
+	}
+
 	s, err := Load(ctx)
 	if err != nil {
 		return err
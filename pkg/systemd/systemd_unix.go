@@ -12,6 +12,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/gameap/gameapctl/pkg/dryrun"
 	"github.com/gameap/gameapctl/pkg/gameap"
 	"github.com/gameap/gameapctl/pkg/oscore"
 	"github.com/gameap/gameapctl/pkg/service"
@@ -94,6 +95,12 @@ func InstallUnit(ctx context.Context, scope, unitPath string, content []byte) er
 
 	log.Println("Writing systemd service configuration to", unitPath)
 
+	unitName := strings.TrimSuffix(filepath.Base(unitPath), ".service")
+
+	if dryrun.Record(ctx, dryrun.KindService, "install unit %s to %s and enable it", unitName, unitPath) {
+		return nil
+	}
+
 	if err := os.MkdirAll(filepath.Dir(unitPath), unitDirMode); err != nil {
 		return errors.Wrap(err, "failed to create systemd unit directory")
 	}
@@ -106,7 +113,6 @@ func InstallUnit(ctx context.Context, scope, unitPath string, content []byte) er
 		return errors.WithMessage(err, "failed to reload systemctl")
 	}
 
-	unitName := strings.TrimSuffix(filepath.Base(unitPath), ".service")
 	if err := Run(ctx, scope, "enable", unitName); err != nil {
 		return errors.WithMessagef(err, "failed to enable %s service", unitName)
 	}
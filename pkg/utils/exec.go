@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"log"
 	"os/exec"
+
+	"github.com/gameap/gameapctl/internal/pkg/redact"
 )
 
 // Deprecated: use oscore.ExecCommand.
@@ -11,7 +13,7 @@ func ExecCommand(command string, args ...string) error {
 	cmd := exec.Command(command, args...)
 	cmd.Stdout = log.Writer()
 	cmd.Stderr = log.Writer()
-	log.Println('\n', cmd.String())
+	log.Println('\n', redact.String(cmd.String()))
 
 	return cmd.Run()
 }
@@ -23,7 +25,7 @@ func ExecCommandWithOutput(command string, args ...string) (string, error) {
 	buf.Grow(1024) //nolint:mnd
 	cmd.Stdout = buf
 	cmd.Stderr = log.Writer()
-	log.Println('\n', cmd.String())
+	log.Println('\n', redact.String(cmd.String()))
 	err := cmd.Run()
 	if err != nil {
 		return "", err
@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// NewHTTPTransport returns an HTTP transport that also serves file:// URLs
+// from the local file system, so a local directory can stand in for a web
+// server such as a release mirror.
+func NewHTTPTransport() http.RoundTripper {
+	t := http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert
+	t.RegisterProtocol("file", fileTransport{})
+
+	return t
+}
+
+// fileTransport answers GET and HEAD requests with the content of a local
+// file, a missing file responds with 404 Not Found.
+type fileTransport struct{}
+
+func (fileTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp := &http.Response{
+		Proto:      "HTTP/1.0",
+		ProtoMajor: 1,
+		Header:     make(http.Header),
+		Body:       http.NoBody,
+		Request:    req,
+	}
+
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		resp.StatusCode = http.StatusMethodNotAllowed
+		resp.Status = http.StatusText(resp.StatusCode)
+
+		return resp, nil
+	}
+
+	f, err := os.Open(FilePathFromURL(req.URL))
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, errors.WithStack(err)
+		}
+
+		resp.StatusCode = http.StatusNotFound
+		resp.Status = http.StatusText(resp.StatusCode)
+
+		return resp, nil
+	}
+
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		_ = f.Close()
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		resp.StatusCode = http.StatusNotFound
+		resp.Status = http.StatusText(resp.StatusCode)
+
+		return resp, nil
+	}
+
+	resp.StatusCode = http.StatusOK
+	resp.Status = http.StatusText(resp.StatusCode)
+	resp.ContentLength = info.Size()
+	resp.Header.Set("Content-Length", strconv.FormatInt(info.Size(), 10))
+	resp.Header.Set("Last-Modified", info.ModTime().UTC().Format(http.TimeFormat))
+
+	if req.Method == http.MethodHead {
+		_ = f.Close()
+
+		return resp, nil
+	}
+
+	resp.Body = f
+
+	return resp, nil
+}
+
+// FileURL returns the file:// URL of a local path, file:///C:/gameap on
+// Windows.
+func FileURL(p string) string {
+	p = filepath.ToSlash(p)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
+
+// FilePathFromURL returns the local path of a file:// URL. On Windows the
+// leading slash before the drive letter is dropped, file:///C:/gameap
+// becomes C:\gameap.
+func FilePathFromURL(u *url.URL) string {
+	p := u.Path
+	if u.Host != "" && u.Host != "localhost" {
+		p = "//" + u.Host + p
+	}
+
+	if runtime.GOOS == "windows" && len(p) > 2 && p[0] == '/' && p[2] == ':' {
+		p = p[1:]
+	}
+
+	return filepath.FromSlash(p)
+}
@@ -0,0 +1,42 @@
+package utils_test
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gameap/gameapctl/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewHTTPTransport_file(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "releases.json"), []byte("[]"), 0600))
+
+	client := &http.Client{Transport: utils.NewHTTPTransport()}
+	base := utils.FileURL(dir)
+
+	resp, err := client.Get(base + "/releases.json")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "[]", string(body))
+
+	resp, err = client.Head(base + "/releases.json")
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int64(2), resp.ContentLength)
+
+	for _, name := range []string{"/missing.json", ""} {
+		resp, err = client.Get(base + name)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode, name)
+	}
+}
@@ -2,39 +2,182 @@ package utils
 
 import (
 	"context"
+	"net/url"
+	"slices"
+	"strings"
 
+	"github.com/gameap/gameapctl/pkg/downloadcache"
+	"github.com/gameap/gameapctl/pkg/dryrun"
+	"github.com/gameap/gameapctl/pkg/progress"
 	"github.com/hashicorp/go-getter"
+	"github.com/pkg/errors"
 )
 
+// getters copies local files instead of linking them, so a download from a
+// file:// mirror never changes the mirror.
+var getters = func() map[string]getter.Getter {
+	g := make(map[string]getter.Getter, len(getter.Getters))
+	for scheme, gt := range getter.Getters {
+		g[scheme] = gt
+	}
+	g["file"] = &getter.FileGetter{Copy: true}
+
+	return g
+}()
+
+type sourceResolverKey struct{}
+
+// WithSourceResolver makes the downloads with the context fetch from the
+// location the resolver returns, such as a local copy of the file. A resolver
+// error fails the download.
+func WithSourceResolver(ctx context.Context, resolve func(source string) (string, error)) context.Context {
+	return context.WithValue(ctx, sourceResolverKey{}, resolve)
+}
+
+func resolveSource(ctx context.Context, source string) (string, error) {
+	resolve, ok := ctx.Value(sourceResolverKey{}).(func(string) (string, error))
+	if !ok {
+		return source, nil
+	}
+
+	return resolve(source)
+}
+
+// getterParams are query parameters go-getter handles itself, they are not
+// sent to the server.
+var getterParams = []string{"archive", "checksum"}
+
+// cached downloads an HTTP source into the download cache and returns the
+// cached copy as the source. The go-getter parameters are kept, so the copy
+// is still verified and extracted. Without the cache the source is returned
+// as it is.
+func cached(ctx context.Context, source string) (string, error) {
+	cache := downloadcache.Default()
+	if cache == nil {
+		return source, nil
+	}
+
+	u, err := url.Parse(source)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return source, nil //nolint:nilerr
+	}
+
+	var query, params []string
+	if u.RawQuery != "" {
+		for _, param := range strings.Split(u.RawQuery, "&") {
+			name, _, _ := strings.Cut(param, "=")
+			if slices.Contains(getterParams, name) {
+				params = append(params, param)
+			} else {
+				query = append(query, param)
+			}
+		}
+	}
+	u.RawQuery = strings.Join(query, "&")
+
+	var sha256 string
+	for _, param := range params {
+		if value, ok := strings.CutPrefix(param, "checksum=sha256:"); ok {
+			sha256 = value
+		}
+	}
+
+	p, err := cache.Fetch(ctx, u.String(), sha256, progress.NewDownloadTracker(ctx))
+	if errors.Is(err, downloadcache.ErrUnavailable) {
+		return source, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	local := FileURL(p)
+	if len(params) > 0 {
+		local += "?" + strings.Join(params, "&")
+	}
+
+	return local, nil
+}
+
 func Download(ctx context.Context, source string, dst string) error {
+	if dryrun.Record(ctx, dryrun.KindDownload, "%s to %s", source, dst) {
+		return nil
+	}
+
+	source, err := resolveSource(ctx, source)
+	if err != nil {
+		return err
+	}
+
+	source, err = cached(ctx, source)
+	if err != nil {
+		return err
+	}
+
 	c := getter.Client{
 		Ctx:  ctx,
 		Src:  source,
 		Dst:  dst,
 		Mode: getter.ClientModeAny,
+
+		Getters:          getters,
+		ProgressListener: progress.NewDownloadTracker(ctx),
 	}
 
 	return c.Get()
 }
 
 func DownloadFile(ctx context.Context, source string, dst string) error {
+	if dryrun.Record(ctx, dryrun.KindDownload, "%s to %s", source, dst) {
+		return nil
+	}
+
+	source, err := resolveSource(ctx, source)
+	if err != nil {
+		return err
+	}
+
+	source, err = cached(ctx, source)
+	if err != nil {
+		return err
+	}
+
 	c := getter.Client{
 		Ctx:  ctx,
 		Src:  source,
 		Dst:  dst,
 		Mode: getter.ClientModeFile,
+
+		Getters:          getters,
+		ProgressListener: progress.NewDownloadTracker(ctx),
 	}
 
 	return c.Get()
 }
 
 func DownloadFileOrArchive(ctx context.Context, source string, dst string) error {
+	if dryrun.Record(ctx, dryrun.KindDownload, "%s to %s", source, dst) {
+		return nil
+	}
+
+	source, err := resolveSource(ctx, source)
+	if err != nil {
+		return err
+	}
+
+	source, err = cached(ctx, source)
+	if err != nil {
+		return err
+	}
+
 	c := getter.Client{
 		Ctx:           ctx,
 		Src:           source,
 		Dst:           dst,
 		Mode:          getter.ClientModeFile,
 		Decompressors: map[string]getter.Decompressor{},
+		Getters:       getters,
+
+		ProgressListener: progress.NewDownloadTracker(ctx),
 	}
 
 	return c.Get()